@@ -0,0 +1,242 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// runner carries out the requests of a built-in action. In a dry run it
+// only works out what it would change.
+type runner interface {
+	run(ctx context.Context, params settings, dryRun bool) (*outcome, error)
+}
+
+// outcome is what a request did, or would do in a dry run
+type outcome struct {
+	data    map[string]interface{}
+	changes []plugin.PlannedChange
+}
+
+// change records a change made, or planned in a dry run
+func (o *outcome) change(action, target, detail string, args ...interface{}) {
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	o.changes = append(o.changes, plugin.PlannedChange{Action: action, Target: target, Detail: detail})
+}
+
+// Action is a built-in action plugin. The work itself is done by a runner
+// built from the action's settings.
+type Action struct {
+	def    *definition
+	info   *plugin.Info
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	status     plugin.Status
+	runner     runner
+	executions int
+	failures   int
+	lastError  string
+	lastRun    time.Time
+}
+
+func newAction(id, actionType string, def *definition, logger *zap.Logger) *Action {
+	return &Action{
+		def: def,
+		info: &plugin.Info{
+			ID:          id,
+			Name:        def.name,
+			Description: def.description,
+			Version:     "1.0.0",
+			Author:      "Stavily",
+			Tags:        []string{"builtin"},
+			Categories:  []string{actionType},
+			Type:        plugin.PluginTypeAction,
+			Metadata:    map[string]string{"supports_dry_run": "true"},
+		},
+		logger: logger.With(zap.String("plugin_id", id)),
+		status: plugin.StatusStopped,
+	}
+}
+
+// GetInfo returns plugin metadata
+func (a *Action) GetInfo() *plugin.Info {
+	return a.info
+}
+
+// GetActionConfig returns the configuration schema
+func (a *Action) GetActionConfig() *plugin.ActionConfig {
+	return &plugin.ActionConfig{
+		Schema:      a.def.schema,
+		Required:    a.def.required,
+		Examples:    a.def.examples,
+		Description: a.def.description,
+	}
+}
+
+// Parameters returns the schema of the parameters requests may pass
+func (a *Action) Parameters() map[string]*plugin.ConfigField {
+	return a.def.parameters
+}
+
+// Initialize applies the action settings on top of the schema defaults,
+// used by the next request
+func (a *Action) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := plugin.ValidateConfig(a.def.schema, a.def.required, config); err != nil {
+		return err
+	}
+
+	r, err := a.def.newRunner(withDefaults(a.def.schema, config))
+	if err != nil {
+		return fmt.Errorf("invalid %s action configuration: %w", a.def.name, err)
+	}
+
+	a.mu.Lock()
+	a.runner = r
+	a.mu.Unlock()
+	return nil
+}
+
+// Start marks the action ready to execute requests
+func (a *Action) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.runner == nil {
+		return fmt.Errorf("action %s is not configured", a.info.ID)
+	}
+	a.status = plugin.StatusRunning
+	return nil
+}
+
+// Stop marks the action stopped; requests already running are left to finish
+func (a *Action) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = plugin.StatusStopped
+	return nil
+}
+
+// GetStatus returns the current status
+func (a *Action) GetStatus() plugin.Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// GetHealth reports the outcome of recent requests
+func (a *Action) GetHealth() *plugin.Health {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	health := &plugin.Health{
+		Status:     plugin.HealthStatusHealthy,
+		Message:    "action is ready",
+		LastCheck:  time.Now(),
+		ErrorCount: a.failures,
+		LastError:  a.lastError,
+		Metrics: map[string]interface{}{
+			"executions": a.executions,
+			"failures":   a.failures,
+		},
+	}
+	if !a.lastRun.IsZero() {
+		health.Metrics["last_run"] = a.lastRun
+	}
+	if a.status != plugin.StatusRunning {
+		health.Status = plugin.HealthStatusUnknown
+		health.Message = "action is not running"
+	}
+	return health
+}
+
+// ExecuteAction carries out one request. Invalid parameters and failures
+// are reported in the result rather than as an error. A dry run, asked for
+// by the request or its dry_run parameter, lists its planned changes.
+func (a *Action) ExecuteAction(ctx context.Context, action *plugin.ActionRequest) (*plugin.ActionResult, error) {
+	if action == nil {
+		return nil, fmt.Errorf("action request is required")
+	}
+
+	a.mu.Lock()
+	status, r := a.status, a.runner
+	a.mu.Unlock()
+	if status != plugin.StatusRunning {
+		return nil, fmt.Errorf("action %s is not running", a.info.ID)
+	}
+
+	if action.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, action.Timeout)
+		defer cancel()
+	}
+
+	result := &plugin.ActionResult{
+		ID:        action.ID,
+		StartedAt: time.Now(),
+		Data:      map[string]interface{}{},
+		Metadata:  map[string]interface{}{"plugin_id": a.info.ID},
+	}
+
+	params := withDefaults(a.def.parameters, action.Parameters)
+	dryRun := action.DryRun || params.boolean("dry_run")
+
+	var out *outcome
+	err := plugin.ValidateConfig(a.def.parameters, nil, action.Parameters)
+	if err == nil {
+		out, err = r.run(ctx, params, dryRun)
+	}
+
+	result.CompletedAt = time.Now()
+	result.Duration = result.CompletedAt.Sub(result.StartedAt)
+	changes := []string{}
+	if out != nil {
+		for k, v := range out.data {
+			result.Data[k] = v
+		}
+		for _, change := range out.changes {
+			changes = append(changes, change.String())
+		}
+		if dryRun {
+			result.PlannedChanges = out.changes
+		}
+	}
+	result.Data["changes"] = changes
+	result.Data["dry_run"] = dryRun
+
+	switch {
+	case err == nil:
+		result.Status = plugin.ActionStatusCompleted
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Status = plugin.ActionStatusTimeout
+		result.Error = "action timed out"
+	case ctx.Err() != nil:
+		result.Status = plugin.ActionStatusCancelled
+		result.Error = "action was cancelled"
+	default:
+		result.Status = plugin.ActionStatusFailed
+		result.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	a.executions++
+	a.lastRun = result.CompletedAt
+	if result.Status != plugin.ActionStatusCompleted {
+		a.failures++
+		a.lastError = result.Error
+	}
+	a.mu.Unlock()
+
+	a.logger.Info("Built-in action executed",
+		zap.String("action_id", action.ID),
+		zap.String("status", string(result.Status)),
+		zap.Bool("dry_run", dryRun),
+		zap.Strings("changes", changes))
+	return result, nil
+}
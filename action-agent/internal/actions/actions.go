@@ -0,0 +1,275 @@
+// Package actions provides the action plugins built into the action agent:
+// allow-listed commands, systemd service control, templated file changes,
+// HTTP requests and temporary file cleanup.
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Built-in action types, which are also the task types they handle
+const (
+	TypeCommand     = "command"
+	TypeService     = "service"
+	TypeFile        = "file"
+	TypeHTTPRequest = "http_request"
+	TypeCleanup     = "cleanup"
+)
+
+// definition describes a built-in action type
+type definition struct {
+	name        string
+	description string
+	// Settings the action is configured with, such as its allow-lists
+	schema   map[string]*plugin.ConfigField
+	required []string
+	examples []map[string]interface{}
+	// Parameters each request may pass
+	parameters map[string]*plugin.ConfigField
+	// newRunner builds the runner for an action from its settings
+	newRunner func(s settings) (runner, error)
+}
+
+// definitions are the built-in action types by type name
+var definitions = map[string]*definition{
+	TypeCommand:     commandDefinition,
+	TypeService:     serviceDefinition,
+	TypeFile:        fileDefinition,
+	TypeHTTPRequest: httpRequestDefinition,
+	TypeCleanup:     cleanupDefinition,
+}
+
+// Types returns the names of the built-in action types
+func Types() []string {
+	types := make([]string, 0, len(definitions))
+	for name := range definitions {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates an unconfigured built-in action of the given type
+func New(actionType, id string, logger *zap.Logger) (*Action, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	def, ok := definitions[actionType]
+	if !ok {
+		return nil, fmt.Errorf("unknown built-in action type %q", actionType)
+	}
+	if id == "" {
+		id = "builtin-" + actionType
+	}
+	return newAction(id, actionType, def, logger), nil
+}
+
+// Registrar is the part of the plugin manager built-in actions are
+// registered and configured with
+type Registrar interface {
+	RegisterPlugin(p plugin.Plugin) error
+	UnregisterPlugin(id string) error
+	ConfigurePlugin(ctx context.Context, id string, config map[string]interface{}) error
+}
+
+// Register creates the configured built-in actions and registers them with
+// the plugin manager, where the executor finds them by task type
+func Register(ctx context.Context, manager Registrar, configs []config.BuiltinActionConfig, logger *zap.Logger) error {
+	for _, cfg := range configs {
+		action, err := New(cfg.Type, cfg.ID, logger)
+		if err != nil {
+			return err
+		}
+		id := action.GetInfo().ID
+
+		if err := manager.RegisterPlugin(action); err != nil {
+			return fmt.Errorf("failed to register built-in action %s: %w", id, err)
+		}
+		if err := manager.ConfigurePlugin(ctx, id, cfg.Config); err != nil {
+			manager.UnregisterPlugin(id)
+			return fmt.Errorf("failed to configure built-in action %s: %w", id, err)
+		}
+
+		logger.Info("Registered built-in action",
+			zap.String("plugin_id", id),
+			zap.String("type", cfg.Type))
+	}
+	return nil
+}
+
+// settings are an action's configuration or a request's parameters with
+// schema defaults applied
+type settings map[string]interface{}
+
+// withDefaults returns values on top of the schema defaults
+func withDefaults(schema map[string]*plugin.ConfigField, values map[string]interface{}) settings {
+	s := make(settings, len(schema)+len(values))
+	for name, field := range schema {
+		if field.Default != nil {
+			s[name] = field.Default
+		}
+	}
+	for k, v := range values {
+		s[k] = v
+	}
+	return s
+}
+
+func (s settings) string(name string) string {
+	value, _ := s[name].(string)
+	return value
+}
+
+func (s settings) number(name string) float64 {
+	switch n := s[name].(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+func (s settings) boolean(name string) bool {
+	value, _ := s[name].(bool)
+	return value
+}
+
+func (s settings) strings(name string) []string {
+	switch values := s[name].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			result = append(result, fmt.Sprint(v))
+		}
+		return result
+	}
+	return nil
+}
+
+func (s settings) object(name string) map[string]interface{} {
+	value, _ := s[name].(map[string]interface{})
+	return value
+}
+
+// duration parses a duration setting, which must be at least min
+func (s settings) duration(name string, min time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(s.string(name))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d < min {
+		return 0, fmt.Errorf("%s: must be at least %s", name, min)
+	}
+	return d, nil
+}
+
+// allowedPaths is a list of directories an action may change files in
+type allowedPaths []string
+
+// newAllowedPaths cleans the configured directories, which must be absolute
+func newAllowedPaths(name string, dirs []string) (allowedPaths, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("%s: at least one directory is required", name)
+	}
+	paths := make(allowedPaths, 0, len(dirs))
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("%s: %q is not an absolute path", name, dir)
+		}
+		paths = append(paths, filepath.Clean(dir))
+	}
+	return paths, nil
+}
+
+// check returns the cleaned path if it is inside one of the directories,
+// following symbolic links in the directories that exist
+func (a allowedPaths) check(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path %q is not absolute", path)
+	}
+	path = filepath.Clean(path)
+	resolved := resolvePath(path)
+
+	for _, dir := range a {
+		if within(dir, path) && within(resolvePath(dir), resolved) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("path %s is not in an allowed directory", path)
+}
+
+// resolvePath resolves the symbolic links in the longest existing prefix of
+// path
+func resolvePath(path string) string {
+	var rest []string
+	for current := path; ; current = filepath.Dir(current) {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			parts := append([]string{resolved}, rest...)
+			return filepath.Join(parts...)
+		}
+		if parent := filepath.Dir(current); parent == current {
+			return path
+		}
+		rest = append([]string{filepath.Base(current)}, rest...)
+	}
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Schema building blocks
+
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+// parameters returns the parameters every built-in action accepts merged
+// with its own
+func parameters(fields map[string]*plugin.ConfigField) map[string]*plugin.ConfigField {
+	fields["dry_run"] = &plugin.ConfigField{
+		Type:        "boolean",
+		Description: "Report what the action would change without changing anything",
+		Default:     false,
+	}
+	return fields
+}
+
+// pathsField is a list of directories an action is confined to
+func pathsField(description string) *plugin.ConfigField {
+	return &plugin.ConfigField{
+		Type:        "array",
+		Description: description,
+		Required:    true,
+		Examples:    []interface{}{[]interface{}{"/etc/myapp", "/var/lib/myapp"}},
+	}
+}
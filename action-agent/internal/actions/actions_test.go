@@ -0,0 +1,281 @@
+package actions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// newTestAction creates, configures and starts a built-in action
+func newTestAction(t *testing.T, actionType string, cfg map[string]interface{}) *Action {
+	t.Helper()
+	action, err := New(actionType, "", zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, action.Initialize(context.Background(), cfg))
+	require.NoError(t, action.Start(context.Background()))
+	return action
+}
+
+func execute(t *testing.T, action *Action, params map[string]interface{}) *plugin.ActionResult {
+	t.Helper()
+	result, err := action.ExecuteAction(context.Background(), &plugin.ActionRequest{ID: "task-1", Parameters: params})
+	require.NoError(t, err)
+	return result
+}
+
+func TestCommandAction(t *testing.T) {
+	action := newTestAction(t, TypeCommand, map[string]interface{}{
+		"allowed_commands": []interface{}{"echo", "false"},
+		"max_output_bytes": 5,
+	})
+
+	result := execute(t, action, map[string]interface{}{"command": "echo", "args": []interface{}{"hello", "world"}})
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, "hello", result.Data["stdout"])
+	assert.Equal(t, true, result.Data["truncated"])
+	assert.Equal(t, 0, result.Data["exit_code"])
+
+	result = execute(t, action, map[string]interface{}{"command": "false"})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Equal(t, 1, result.Data["exit_code"])
+
+	result = execute(t, action, map[string]interface{}{"command": "/bin/echo"})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "not allowed")
+
+	result = execute(t, action, map[string]interface{}{"command": "echo", "dry_run": true})
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, true, result.Data["dry_run"])
+	assert.Nil(t, result.Data["stdout"])
+	require.Len(t, result.Data["changes"], 1)
+
+	result = execute(t, action, map[string]interface{}{})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "command: is required")
+
+	unconfigured, err := New(TypeCommand, "", zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.ErrorContains(t, unconfigured.Initialize(context.Background(), nil), "allowed_commands: is required")
+	assert.Error(t, unconfigured.Start(context.Background()))
+}
+
+func TestServiceAction(t *testing.T) {
+	action := newTestAction(t, TypeService, map[string]interface{}{
+		"allowed_units": []interface{}{"myapp-*.service"},
+	})
+
+	var calls []string
+	state := "active"
+	action.runner.(*serviceRunner).systemctl = func(ctx context.Context, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "show" {
+			return []byte("LoadState=loaded\nActiveState=" + state + "\nSubState=running\n"), nil
+		}
+		state = "inactive"
+		return nil, nil
+	}
+
+	result := execute(t, action, map[string]interface{}{"unit": "myapp-web.service", "operation": "stop", "dry_run": true})
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, []string{"stop myapp-web.service (currently active)"}, result.Data["changes"])
+	assert.Equal(t, []plugin.PlannedChange{{Action: "stop", Target: "myapp-web.service", Detail: "currently active"}}, result.PlannedChanges)
+	assert.Len(t, calls, 1, "a dry run only reads the unit state")
+
+	// A dry run asked for by the request rather than its parameters
+	result, err := action.ExecuteAction(context.Background(), &plugin.ActionRequest{
+		ID:         "task-2",
+		Parameters: map[string]interface{}{"unit": "myapp-web.service", "operation": "restart"},
+		DryRun:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, result.Data["dry_run"])
+	require.Len(t, result.PlannedChanges, 1)
+	assert.Equal(t, "restart", result.PlannedChanges[0].Action)
+	assert.Len(t, calls, 2)
+
+	result = execute(t, action, map[string]interface{}{"unit": "myapp-web.service", "operation": "stop"})
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, "inactive", result.Data["state"])
+	assert.Empty(t, result.PlannedChanges, "changes that were made are not planned")
+	assert.Contains(t, calls, "stop -- myapp-web.service")
+
+	result = execute(t, action, map[string]interface{}{"unit": "sshd.service"})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "not allowed")
+
+	result = execute(t, action, map[string]interface{}{"unit": "myapp-web.service", "operation": "reload"})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "operation")
+}
+
+func TestFileAction(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	action := newTestAction(t, TypeFile, map[string]interface{}{
+		"allowed_paths": []interface{}{dir},
+	})
+	path := filepath.Join(dir, "app.conf")
+
+	template := map[string]interface{}{
+		"path":      path,
+		"template":  "port: {{ .port }}\nworkers: 4\n",
+		"variables": map[string]interface{}{"port": 8080},
+	}
+	template["dry_run"] = true
+	result := execute(t, action, template)
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, true, result.Data["created"])
+	assert.NoFileExists(t, path)
+
+	template["dry_run"] = false
+	result = execute(t, action, template)
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "port: 8080\nworkers: 4\n", string(data))
+
+	result = execute(t, action, template)
+	assert.Equal(t, false, result.Data["changed"])
+	assert.Empty(t, result.Data["changes"])
+
+	result = execute(t, action, map[string]interface{}{
+		"path":        path,
+		"operation":   "patch",
+		"pattern":     `(?m)^workers: \d+$`,
+		"replacement": "workers: 8",
+	})
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "port: 8080\nworkers: 8\n", string(data))
+	require.NotEmpty(t, result.Data["backup"])
+	backup, err := os.ReadFile(result.Data["backup"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "port: 8080\nworkers: 4\n", string(backup))
+
+	result = execute(t, action, map[string]interface{}{"path": path, "template": "{{ .missing }}"})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+
+	// Paths outside the allowed directories, directly or through a link
+	result = execute(t, action, map[string]interface{}{"path": filepath.Join(dir, "..", "escape.conf"), "template": "x"})
+	assert.Contains(t, result.Error, "not in an allowed directory")
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	result = execute(t, action, map[string]interface{}{"path": filepath.Join(dir, "link", "escape.conf"), "template": "x"})
+	assert.Contains(t, result.Error, "not in an allowed directory")
+	assert.NoFileExists(t, filepath.Join(outside, "escape.conf"))
+}
+
+func TestHTTPRequestAction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	action := newTestAction(t, TypeHTTPRequest, map[string]interface{}{
+		"allowed_hosts": []interface{}{"127.0.0.*"},
+	})
+
+	params := map[string]interface{}{
+		"url":     server.URL + "/hook",
+		"json":    map[string]interface{}{"text": "done"},
+		"headers": map[string]interface{}{"X-Token": "secret"},
+	}
+	result := execute(t, action, params)
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status, result.Error)
+	assert.Equal(t, http.StatusAccepted, result.Data["status_code"])
+	assert.Equal(t, `{"text":"done"}`, result.Data["response"])
+
+	params["expected_status"] = 200
+	result = execute(t, action, params)
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "unexpected status 202")
+
+	result = execute(t, action, map[string]interface{}{"url": "http://example.com/", "dry_run": true})
+	assert.Equal(t, plugin.ActionStatusFailed, result.Status)
+	assert.Contains(t, result.Error, "not allowed")
+}
+
+func TestCleanupAction(t *testing.T) {
+	dir := t.TempDir()
+	action := newTestAction(t, TypeCleanup, map[string]interface{}{
+		"allowed_paths": []interface{}{dir},
+	})
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"a.tmp", "b.tmp", "keep.log", "nested/c.tmp"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.tmp"), []byte("data"), 0644))
+
+	params := map[string]interface{}{"path": dir, "pattern": "*.tmp", "dry_run": true}
+	result := execute(t, action, params)
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, int64(2), result.Data["removed"])
+	assert.FileExists(t, filepath.Join(dir, "a.tmp"))
+
+	params["dry_run"] = false
+	params["recursive"] = true
+	result = execute(t, action, params)
+	assert.Equal(t, plugin.ActionStatusCompleted, result.Status)
+	assert.Equal(t, int64(3), result.Data["removed"])
+	assert.Equal(t, int64(12), result.Data["bytes"])
+	assert.NoFileExists(t, filepath.Join(dir, "a.tmp"))
+	assert.NoFileExists(t, filepath.Join(dir, "nested", "c.tmp"))
+	assert.FileExists(t, filepath.Join(dir, "keep.log"))
+	assert.FileExists(t, filepath.Join(dir, "new.tmp"))
+
+	result = execute(t, action, map[string]interface{}{"path": "/"})
+	assert.Contains(t, result.Error, "not in an allowed directory")
+}
+
+func TestRegister(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	manager, err := sharedagent.NewPluginManager(&config.PluginConfig{Directory: t.TempDir()}, logger)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = Register(ctx, manager, []config.BuiltinActionConfig{
+		{Type: TypeCommand, Config: map[string]interface{}{"allowed_commands": []interface{}{"uptime"}}},
+		{ID: "tmp-cleanup", Type: TypeCleanup, Config: map[string]interface{}{"allowed_paths": []interface{}{"/tmp"}}},
+	}, logger)
+	require.NoError(t, err)
+
+	p, err := manager.GetPlugin("builtin-command")
+	require.NoError(t, err)
+	assert.Equal(t, []string{TypeCommand}, p.GetInfo().Categories)
+	_, err = manager.GetPlugin("tmp-cleanup")
+	require.NoError(t, err)
+
+	err = Register(ctx, manager, []config.BuiltinActionConfig{
+		{ID: "bad", Type: TypeFile, Config: map[string]interface{}{"allowed_paths": []interface{}{"relative"}}},
+	}, logger)
+	assert.ErrorContains(t, err, "not an absolute path")
+	_, err = manager.GetPlugin("bad")
+	assert.Error(t, err, "an action that fails to configure is unregistered")
+
+	err = Register(ctx, manager, []config.BuiltinActionConfig{{Type: "nope"}}, logger)
+	assert.ErrorContains(t, err, "unknown built-in action type")
+
+	assert.Len(t, Types(), 5)
+}
@@ -0,0 +1,143 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// maxListedFiles caps how many removed files are listed in a result
+const maxListedFiles = 100
+
+var cleanupDefinition = &definition{
+	name:        "Cleanup",
+	description: "Removes old files matching a glob from allow-listed directories, such as temporary files and stale logs",
+	schema: map[string]*plugin.ConfigField{
+		"allowed_paths": pathsField("Directories requests may remove files from"),
+	},
+	required: []string{"allowed_paths"},
+	examples: []map[string]interface{}{{"allowed_paths": []interface{}{"/tmp", "/var/log/myapp"}}},
+	parameters: parameters(map[string]*plugin.ConfigField{
+		"path": {
+			Type:        "string",
+			Description: "Directory to clean up",
+			Required:    true,
+			Examples:    []interface{}{"/tmp"},
+		},
+		"pattern": {
+			Type:        "string",
+			Description: "Glob file names must match",
+			Default:     "*",
+			Examples:    []interface{}{"*.tmp", "*.log.[0-9]*"},
+		},
+		"older_than": {
+			Type:        "string",
+			Description: "Only remove files last modified longer ago than this",
+			Default:     "24h",
+			Pattern:     durationPattern,
+		},
+		"recursive": {
+			Type:        "boolean",
+			Description: "Also clean up the subdirectories",
+			Default:     false,
+		},
+	}),
+	newRunner: func(s settings) (runner, error) {
+		allowed, err := newAllowedPaths("allowed_paths", s.strings("allowed_paths"))
+		if err != nil {
+			return nil, err
+		}
+		return &cleanupRunner{allowed: allowed, now: time.Now}, nil
+	},
+}
+
+// cleanupRunner removes old files from allow-listed directories
+type cleanupRunner struct {
+	allowed allowedPaths
+	now     func() time.Time
+}
+
+func (r *cleanupRunner) run(ctx context.Context, params settings, dryRun bool) (*outcome, error) {
+	root, err := r.allowed.check(params.string("path"))
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to clean up %s: %w", root, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("failed to clean up %s: not a directory", root)
+	}
+	pattern := params.string("pattern")
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	olderThan, err := params.duration("older_than", 0)
+	if err != nil {
+		return nil, err
+	}
+	recursive := params.boolean("recursive")
+	cutoff := r.now().Add(-olderThan)
+
+	out := &outcome{data: map[string]interface{}{"path": root}}
+	files := []string{}
+	var removed, size int64
+	var failures []string
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			failures = append(failures, err.Error())
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if entry.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matched, _ := filepath.Match(pattern, entry.Name()); !matched {
+			return nil
+		}
+
+		// Symbolic links are removed themselves, never followed
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				failures = append(failures, err.Error())
+				return nil
+			}
+		}
+
+		removed++
+		size += info.Size()
+		if len(files) < maxListedFiles {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	out.data["files"] = files
+	out.data["removed"] = removed
+	out.data["bytes"] = size
+	if removed > 0 {
+		out.change("remove files from", root, "%d files, %d bytes", removed, size)
+	}
+	if len(failures) > 0 {
+		out.data["failures"] = failures
+		return out, fmt.Errorf("cleanup of %s finished with %d errors", root, len(failures))
+	}
+	return out, nil
+}
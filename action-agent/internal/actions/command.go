@@ -0,0 +1,181 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+var commandDefinition = &definition{
+	name:        "Command",
+	description: "Runs an allow-listed command without a shell and reports its output and exit code",
+	schema: map[string]*plugin.ConfigField{
+		"allowed_commands": {
+			Type:        "array",
+			Description: "Commands requests may run, as absolute paths or names looked up in PATH",
+			Required:    true,
+			Examples:    []interface{}{[]interface{}{"/usr/bin/journalctl", "df"}},
+		},
+		"working_dir": {
+			Type:        "string",
+			Description: "Directory commands run in, the agent's working directory when empty",
+			Examples:    []interface{}{"/tmp"},
+		},
+		"environment": {
+			Type:        "object",
+			Description: "Extra environment variables for commands",
+			Examples:    []interface{}{map[string]interface{}{"LANG": "C"}},
+		},
+		"timeout": {
+			Type:        "string",
+			Description: "Time a command may run when the request does not set a timeout",
+			Default:     "60s",
+			Pattern:     durationPattern,
+		},
+		"max_output_bytes": {
+			Type:        "integer",
+			Description: "Most output kept from each of stdout and stderr",
+			Default:     64 * 1024,
+			Minimum:     bound(1),
+		},
+	},
+	required: []string{"allowed_commands"},
+	examples: []map[string]interface{}{{"allowed_commands": []interface{}{"/usr/bin/journalctl", "df"}, "timeout": "30s"}},
+	parameters: parameters(map[string]*plugin.ConfigField{
+		"command": {
+			Type:        "string",
+			Description: "Allow-listed command to run",
+			Required:    true,
+			Examples:    []interface{}{"df"},
+		},
+		"args": {
+			Type:        "array",
+			Description: "Command arguments, passed as is without a shell",
+			Examples:    []interface{}{[]interface{}{"-h", "/"}},
+		},
+		"stdin": {
+			Type:        "string",
+			Description: "Input written to the command",
+		},
+	}),
+	newRunner: func(s settings) (runner, error) {
+		timeout, err := s.duration("timeout", time.Second)
+		if err != nil {
+			return nil, err
+		}
+		r := &commandRunner{
+			allowed:   make(map[string]bool),
+			dir:       s.string("working_dir"),
+			timeout:   timeout,
+			maxOutput: int(s.number("max_output_bytes")),
+			lookPath:  exec.LookPath,
+		}
+		if len(s.strings("allowed_commands")) == 0 {
+			return nil, fmt.Errorf("allowed_commands: at least one command is required")
+		}
+		for _, command := range s.strings("allowed_commands") {
+			r.allowed[command] = true
+		}
+		for name, value := range s.object("environment") {
+			r.env = append(r.env, name+"="+fmt.Sprint(value))
+		}
+		return r, nil
+	},
+}
+
+// commandRunner runs allow-listed commands
+type commandRunner struct {
+	allowed   map[string]bool
+	dir       string
+	env       []string
+	timeout   time.Duration
+	maxOutput int
+	lookPath  func(file string) (string, error)
+}
+
+func (r *commandRunner) run(ctx context.Context, params settings, dryRun bool) (*outcome, error) {
+	command := params.string("command")
+	args := params.strings("args")
+
+	// Only the exact names and paths on the allow-list may run; a name is
+	// not allowed because a path to the same program is, or the reverse
+	if !r.allowed[command] {
+		return nil, fmt.Errorf("command %q is not allowed", command)
+	}
+	path := command
+	if !filepath.IsAbs(command) {
+		var err error
+		if path, err = r.lookPath(command); err != nil {
+			return nil, fmt.Errorf("command %q not found: %w", command, err)
+		}
+	}
+
+	out := &outcome{data: map[string]interface{}{
+		"command": path,
+		"args":    args,
+	}}
+	out.change("run", strings.Join(append([]string{path}, args...), " "), "")
+	if dryRun {
+		return out, nil
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), r.env...)
+	cmd.WaitDelay = 5 * time.Second
+	if stdin := params.string("stdin"); stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	stdout := &limitedBuffer{max: r.maxOutput}
+	stderr := &limitedBuffer{max: r.maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	out.data["stdout"] = stdout.String()
+	out.data["stderr"] = stderr.String()
+	out.data["truncated"] = stdout.truncated || stderr.truncated
+	if cmd.ProcessState != nil {
+		out.data["exit_code"] = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		return out, fmt.Errorf("command failed: %w", err)
+	}
+	return out, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it. It does not embed
+// bytes.Buffer so that io.Copy cannot bypass Write through ReadFrom.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
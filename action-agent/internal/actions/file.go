@@ -0,0 +1,205 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+var fileDefinition = &definition{
+	name:        "File",
+	description: "Writes a file from a template or patches it with a regular expression, inside allow-listed directories",
+	schema: map[string]*plugin.ConfigField{
+		"allowed_paths": pathsField("Directories requests may write files in"),
+		"backup": {
+			Type:        "boolean",
+			Description: "Keep a copy of a changed file next to it, with a timestamp suffix",
+			Default:     true,
+		},
+	},
+	required: []string{"allowed_paths"},
+	examples: []map[string]interface{}{{"allowed_paths": []interface{}{"/etc/myapp"}, "backup": true}},
+	parameters: parameters(map[string]*plugin.ConfigField{
+		"path": {
+			Type:        "string",
+			Description: "Absolute path of the file",
+			Required:    true,
+			Examples:    []interface{}{"/etc/myapp/config.yaml"},
+		},
+		"operation": {
+			Type:        "string",
+			Description: "Write the whole file from template, or replace the matches of pattern in it",
+			Default:     "write",
+			Enum:        []string{"write", "patch"},
+		},
+		"template": {
+			Type:        "string",
+			Description: "Go text/template the file is written from",
+			Examples:    []interface{}{"listen: {{ .port }}\n"},
+		},
+		"variables": {
+			Type:        "object",
+			Description: "Values available to the template",
+			Examples:    []interface{}{map[string]interface{}{"port": 8080}},
+		},
+		"pattern": {
+			Type:        "string",
+			Description: "Regular expression to replace when patching",
+			Examples:    []interface{}{`(?m)^max_connections = \d+$`},
+		},
+		"replacement": {
+			Type:        "string",
+			Description: "Replacement for each match, where $1 refers to a group",
+			Examples:    []interface{}{"max_connections = 200"},
+		},
+		"mode": {
+			Type:        "string",
+			Description: "Permissions of a file that is created, in octal",
+			Default:     "0644",
+			Pattern:     `^0?[0-7]{3}$`,
+		},
+	}),
+	newRunner: func(s settings) (runner, error) {
+		allowed, err := newAllowedPaths("allowed_paths", s.strings("allowed_paths"))
+		if err != nil {
+			return nil, err
+		}
+		return &fileRunner{allowed: allowed, backup: s.boolean("backup")}, nil
+	},
+}
+
+// fileRunner writes and patches files in allow-listed directories
+type fileRunner struct {
+	allowed allowedPaths
+	backup  bool
+}
+
+func (r *fileRunner) run(ctx context.Context, params settings, dryRun bool) (*outcome, error) {
+	path, err := r.allowed.check(params.string("path"))
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var content []byte
+	switch operation := params.string("operation"); operation {
+	case "write":
+		content, err = render(params.string("template"), params.object("variables"))
+	case "patch":
+		if !exists {
+			return nil, fmt.Errorf("cannot patch %s: file does not exist", path)
+		}
+		content, err = patch(current, params.string("pattern"), params.string("replacement"))
+	default:
+		err = fmt.Errorf("unknown operation %q", operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed := !exists || !bytes.Equal(current, content)
+	out := &outcome{data: map[string]interface{}{
+		"path":    path,
+		"changed": changed,
+		"created": !exists,
+		"bytes":   len(content),
+	}}
+	if !changed {
+		return out, nil
+	}
+	if exists {
+		out.change("update", path, "%d bytes to %d bytes", len(current), len(content))
+	} else {
+		out.change("create", path, "%d bytes", len(content))
+	}
+	if dryRun {
+		return out, nil
+	}
+
+	mode, err := strconv.ParseUint(params.string("mode"), 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mode: %w", err)
+	}
+	if exists && r.backup {
+		backup := fmt.Sprintf("%s.%s.bak", path, time.Now().UTC().Format("20060102T150405Z"))
+		if err := writeFileAtomic(backup, current, os.FileMode(mode)); err != nil {
+			return out, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		out.data["backup"] = backup
+	}
+	if err := writeFileAtomic(path, content, os.FileMode(mode)); err != nil {
+		return out, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// render executes a template; referring to a missing variable is an error
+func render(text string, variables map[string]interface{}) ([]byte, error) {
+	if text == "" {
+		return nil, fmt.Errorf("template is required to write a file")
+	}
+	tmpl, err := template.New("file").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// patch replaces the matches of pattern in content
+func patch(content []byte, pattern, replacement string) ([]byte, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required to patch a file")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.ReplaceAll(content, []byte(replacement)), nil
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same
+// directory, keeping the permissions of an existing file
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
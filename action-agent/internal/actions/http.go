@@ -0,0 +1,180 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+var httpRequestDefinition = &definition{
+	name:        "HTTP request",
+	description: "Sends an HTTP request to an allow-listed host, for example to call a webhook or an internal API",
+	schema: map[string]*plugin.ConfigField{
+		"allowed_hosts": {
+			Type:        "array",
+			Description: "Hosts requests may be sent to, as names or globs such as *.internal",
+			Required:    true,
+			Examples:    []interface{}{[]interface{}{"hooks.slack.com", "*.internal"}},
+		},
+		"timeout": {
+			Type:        "string",
+			Description: "Time a request may take when the action request does not set a timeout",
+			Default:     "30s",
+			Pattern:     durationPattern,
+		},
+		"max_response_bytes": {
+			Type:        "integer",
+			Description: "Most of the response body kept in the result",
+			Default:     64 * 1024,
+			Minimum:     bound(0),
+		},
+	},
+	required: []string{"allowed_hosts"},
+	examples: []map[string]interface{}{{"allowed_hosts": []interface{}{"*.internal"}, "timeout": "10s"}},
+	parameters: parameters(map[string]*plugin.ConfigField{
+		"url": {
+			Type:        "string",
+			Description: "URL to send the request to",
+			Required:    true,
+			Pattern:     `^https?://`,
+			Examples:    []interface{}{"https://api.internal/v1/cache/flush"},
+		},
+		"method": {
+			Type:        "string",
+			Description: "Request method",
+			Default:     "POST",
+			Enum:        []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		},
+		"headers": {
+			Type:        "object",
+			Description: "Request headers",
+			Examples:    []interface{}{map[string]interface{}{"Authorization": "Bearer token"}},
+		},
+		"body": {
+			Type:        "string",
+			Description: "Request body",
+		},
+		"json": {
+			Type:        "object",
+			Description: "Request body sent as JSON, instead of body",
+			Examples:    []interface{}{map[string]interface{}{"text": "disk cleaned up"}},
+		},
+		"expected_status": {
+			Type:        "integer",
+			Description: "Status the response must have, any 2xx status when 0",
+			Default:     0,
+			Minimum:     bound(0),
+			Maximum:     bound(599),
+		},
+	}),
+	newRunner: func(s settings) (runner, error) {
+		timeout, err := s.duration("timeout", time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		hosts := s.strings("allowed_hosts")
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("allowed_hosts: at least one host is required")
+		}
+		for _, host := range hosts {
+			if _, err := path.Match(host, ""); err != nil {
+				return nil, fmt.Errorf("allowed_hosts: %q: %w", host, err)
+			}
+		}
+		return &httpRunner{
+			allowed:     hosts,
+			maxResponse: int64(s.number("max_response_bytes")),
+			client:      &http.Client{Timeout: timeout},
+		}, nil
+	},
+}
+
+// httpRunner sends requests to allow-listed hosts
+type httpRunner struct {
+	allowed     []string
+	maxResponse int64
+	client      *http.Client
+}
+
+func (r *httpRunner) run(ctx context.Context, params settings, dryRun bool) (*outcome, error) {
+	target, err := url.Parse(params.string("url"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if !r.allows(target.Hostname()) {
+		return nil, fmt.Errorf("host %q is not allowed", target.Hostname())
+	}
+
+	method := params.string("method")
+	body := []byte(params.string("body"))
+	contentType := ""
+	if object := params.object("json"); object != nil {
+		if body, err = json.Marshal(object); err != nil {
+			return nil, fmt.Errorf("failed to encode json body: %w", err)
+		}
+		contentType = "application/json"
+	}
+
+	// The URL may carry credentials, so only its host and path are reported
+	out := &outcome{data: map[string]interface{}{
+		"method": method,
+		"host":   target.Host,
+		"path":   target.Path,
+	}}
+	out.change(method, target.Host+target.Path, "")
+	if dryRun {
+		return out, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range params.object("headers") {
+		req.Header.Set(name, fmt.Sprint(value))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return out, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	response, err := io.ReadAll(io.LimitReader(resp.Body, r.maxResponse))
+	if err != nil {
+		return out, fmt.Errorf("failed to read response: %w", err)
+	}
+	out.data["status_code"] = resp.StatusCode
+	out.data["response"] = string(response)
+
+	expected := int(params.number("expected_status"))
+	switch {
+	case expected != 0 && resp.StatusCode != expected:
+		return out, fmt.Errorf("unexpected status %d, expected %d", resp.StatusCode, expected)
+	case expected == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		return out, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return out, nil
+}
+
+func (r *httpRunner) allows(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range r.allowed {
+		if matched, _ := path.Match(strings.ToLower(pattern), host); matched {
+			return true
+		}
+	}
+	return false
+}
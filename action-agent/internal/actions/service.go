@@ -0,0 +1,140 @@
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+var serviceDefinition = &definition{
+	name:        "Service",
+	description: "Restarts, stops or starts an allow-listed systemd unit with systemctl and reports its state afterwards",
+	schema: map[string]*plugin.ConfigField{
+		"allowed_units": {
+			Type:        "array",
+			Description: "Units requests may control, as names or globs such as myapp-*.service",
+			Required:    true,
+			Examples:    []interface{}{[]interface{}{"nginx.service", "myapp-*.service"}},
+		},
+	},
+	required: []string{"allowed_units"},
+	examples: []map[string]interface{}{{"allowed_units": []interface{}{"nginx.service"}}},
+	parameters: parameters(map[string]*plugin.ConfigField{
+		"unit": {
+			Type:        "string",
+			Description: "Unit to control",
+			Required:    true,
+			Examples:    []interface{}{"nginx.service"},
+		},
+		"operation": {
+			Type:        "string",
+			Description: "What to do with the unit",
+			Default:     "restart",
+			Enum:        []string{"restart", "stop", "start"},
+		},
+	}),
+	newRunner: func(s settings) (runner, error) {
+		units := s.strings("allowed_units")
+		if len(units) == 0 {
+			return nil, fmt.Errorf("allowed_units: at least one unit is required")
+		}
+		for _, unit := range units {
+			if _, err := path.Match(unit, ""); err != nil {
+				return nil, fmt.Errorf("allowed_units: %q: %w", unit, err)
+			}
+		}
+		return &serviceRunner{allowed: units, systemctl: systemctl}, nil
+	},
+}
+
+// serviceRunner controls allow-listed systemd units
+type serviceRunner struct {
+	allowed   []string
+	systemctl func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+func (r *serviceRunner) run(ctx context.Context, params settings, dryRun bool) (*outcome, error) {
+	unit := params.string("unit")
+	operation := params.string("operation")
+	if !r.allows(unit) {
+		return nil, fmt.Errorf("unit %q is not allowed", unit)
+	}
+
+	before, err := r.state(ctx, unit)
+	if err != nil {
+		return nil, err
+	}
+	if before["LoadState"] == "not-found" {
+		return nil, fmt.Errorf("unit %s not found", unit)
+	}
+
+	out := &outcome{data: map[string]interface{}{
+		"unit":         unit,
+		"operation":    operation,
+		"state_before": before["ActiveState"],
+	}}
+	out.change(operation, unit, "currently %s", before["ActiveState"])
+	if dryRun {
+		return out, nil
+	}
+
+	if _, err := r.systemctl(ctx, operation, "--", unit); err != nil {
+		return out, fmt.Errorf("failed to %s %s: %w", operation, unit, err)
+	}
+
+	after, err := r.state(ctx, unit)
+	if err != nil {
+		return out, err
+	}
+	out.data["state"] = after["ActiveState"]
+	out.data["sub_state"] = after["SubState"]
+	return out, nil
+}
+
+func (r *serviceRunner) allows(unit string) bool {
+	for _, pattern := range r.allowed {
+		if matched, _ := path.Match(pattern, unit); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// state returns the load and active state of a unit
+func (r *serviceRunner) state(ctx context.Context, unit string) (map[string]string, error) {
+	output, err := r.systemctl(ctx, "show", "-p", "LoadState", "-p", "ActiveState", "-p", "SubState", "--", unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state of %s: %w", unit, err)
+	}
+
+	state := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "="); ok {
+			state[key] = value
+		}
+	}
+	return state, nil
+}
+
+// systemctl runs systemctl with the given arguments
+func systemctl(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "systemctl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
@@ -7,12 +7,20 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/Stavily/01-Agents/shared/pkg/agent"
 	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/output"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
+
+	"github.com/Stavily/01-Agents/action-agent/internal/actions"
 )
 
 // ActionAgent represents the main action agent instance
@@ -26,6 +34,26 @@ type ActionAgent struct {
 	healthCheck      *HealthMonitor
 	poller           *TaskPoller
 
+	// Loads and hot-reloads plugins in the plugin directory, nil when disabled
+	pluginWatcher *agent.PluginWatcher
+	stopWatcher   context.CancelFunc
+
+	// Plugin lifecycle events, and the audit log subscribed to them when
+	// auditing is enabled
+	events   *agent.EventBus
+	auditLog *audit.Logger
+
+	// Exports spans of polls and instruction processing, nil when tracing
+	// is disabled
+	tracer *tracing.Tracer
+
+	// Records changes to the configuration file in the audit log
+	configAuditor     *agent.ConfigAuditor
+	stopConfigAuditor context.CancelFunc
+
+	// Sends execution results to the configured outputs, nil when disabled
+	outputs *output.Router
+
 	// Runtime state
 	mu        sync.RWMutex
 	running   bool
@@ -69,8 +97,42 @@ func NewActionAgent(cfg *config.Config, logger *zap.Logger) (*ActionAgent, error
 		return nil, fmt.Errorf("failed to create health checker: %w", err)
 	}
 
+	// Publish plugin lifecycle events to the audit log, metrics and orchestrator
+	events, err := agent.NewEventBus(agent.EventBusOptions{}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin event bus: %w", err)
+	}
+	pluginMgr.SetEventBus(events)
+
+	// Only run the instructions the local policy allows
+	if cfg.Security.Policy.Enabled {
+		engine, err := policy.NewEngine(cfg.Security.Policy, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policy: %w", err)
+		}
+		pluginMgr.SetPolicy(engine)
+	}
+	executor.events = events
+
+	// Register the configured built-in actions alongside installed plugins
+	if err := actions.Register(context.Background(), pluginMgr, cfg.Action.Actions, logger); err != nil {
+		return nil, fmt.Errorf("failed to register built-in actions: %w", err)
+	}
+
+	// Send execution results to the configured outputs
+	var outputs *output.Router
+	if cfg.Outputs.Enabled {
+		outputs, err = output.NewRouter(cfg.Outputs, output.ManagerResolver(pluginMgr), logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output router: %w", err)
+		}
+		executor.outputs = outputs
+	}
+
 	actionAgent := &ActionAgent{
 		cfg:         cfg,
+		events:      events,
+		outputs:     outputs,
 		logger:      logger,
 		pluginMgr:   pluginMgr,
 		executor:    executor,
@@ -80,16 +142,135 @@ func NewActionAgent(cfg *config.Config, logger *zap.Logger) (*ActionAgent, error
 		doneChan:    make(chan struct{}),
 	}
 
+	// Watch the plugin directory for action plugins under development
+	if cfg.Plugins.AutoLoad || cfg.Plugins.WatchChanges {
+		watcher, err := agent.NewPluginWatcher(pluginMgr.GetPluginBaseDir(), pluginMgr.PluginManager, agent.PluginWatcherOptions{
+			AutoLoad:     cfg.Plugins.AutoLoad,
+			WatchChanges: cfg.Plugins.WatchChanges,
+			Types:        []plugin.PluginType{plugin.PluginTypeAction},
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plugin watcher: %w", err)
+		}
+		watcher.AddHandler(plugin.EventHandlerFunc(actionAgent.handlePluginEvent))
+		actionAgent.pluginWatcher = watcher
+	}
+
 	// Create orchestrator workflow with action-specific plugin executor
 	orchestratorFlow, err := agent.NewOrchestratorWorkflow(cfg, logger, actionAgent.executeActionPlugin)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create orchestrator workflow: %w", err)
 	}
 	actionAgent.orchestratorFlow = orchestratorFlow
+	orchestratorFlow.SetMetrics(metrics)
+	if err := actionAgent.openTracer(); err != nil {
+		return nil, fmt.Errorf("failed to create tracer: %w", err)
+	}
+	actionAgent.registerHealthChecks()
+
+	// Hold high-risk instructions until the orchestrator approves them
+	if cfg.Action.Approval.Enabled {
+		gate, err := agent.NewApprovalGate(&cfg.Action.Approval, cfg.Agent.ID, actionAgent.requiresApproval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create approval gate: %w", err)
+		}
+		orchestratorFlow.SetApprovalGate(gate)
+	}
+
+	if err := actionAgent.openAuditLog(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := actionAgent.subscribePluginEvents(); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to plugin events: %w", err)
+	}
 
 	return actionAgent, nil
 }
 
+// registerHealthChecks registers the components reported by the health
+// server, and the checks the agent must pass to be ready
+func (a *ActionAgent) registerHealthChecks() {
+	a.healthCheck.RegisterComponent("executor", a.executor.GetHealth)
+	a.healthCheck.RegisterComponent("metrics", a.metrics.GetHealth)
+	a.healthCheck.RegisterComponent("orchestrator", a.orchestratorFlow.GetComponentHealth)
+
+	a.healthCheck.RegisterReadinessCheck("config", agent.ConfigCheck(a.cfg))
+	a.healthCheck.RegisterReadinessCheck("orchestrator", a.orchestratorFlow.CheckReachable)
+	a.healthCheck.RegisterReadinessCheck("plugins_dir", agent.DirWritableCheck(a.pluginMgr.GetPluginBaseDir()))
+}
+
+// openTracer creates the tracer when tracing is enabled and traces the
+// orchestrator workflow with it
+func (a *ActionAgent) openTracer() error {
+	if !a.cfg.Tracing.Enabled {
+		return nil
+	}
+
+	tracer, err := tracing.NewTracer(a.cfg.Tracing, []attribute.KeyValue{
+		attribute.String("service.name", "stavily-action-agent"),
+		attribute.String("service.instance.id", a.cfg.Agent.ID),
+		attribute.String("stavily.tenant.id", a.cfg.Agent.TenantID),
+		attribute.String("deployment.environment", a.cfg.Agent.Environment),
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+	a.tracer = tracer
+	a.orchestratorFlow.SetTracer(tracer)
+	return nil
+}
+
+// openAuditLog opens the audit log when auditing is enabled, and records
+// received instructions, authentication failures and configuration changes
+// in it
+func (a *ActionAgent) openAuditLog() error {
+	if !a.cfg.Security.Audit.Enabled || a.cfg.Security.Audit.LogFile == "" {
+		return nil
+	}
+
+	auditLog, err := audit.NewLogger(a.cfg.Security.Audit, a.cfg.Agent.ID)
+	if err != nil {
+		return err
+	}
+	a.auditLog = auditLog
+	a.orchestratorFlow.SetAuditLog(auditLog)
+
+	if a.cfg.File != "" {
+		a.configAuditor, err = agent.NewConfigAuditor(a.cfg.File, auditLog, a.logger)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribePluginEvents subscribes the audit log, metrics and orchestrator
+// status reporting to plugin lifecycle events
+func (a *ActionAgent) subscribePluginEvents() error {
+	if a.auditLog != nil {
+		eventAudit, err := agent.NewEventAuditLog(a.auditLog)
+		if err != nil {
+			return err
+		}
+		// Written by the publisher, so security records are never dropped
+		if err := a.events.SubscribeSync("audit", eventAudit); err != nil {
+			return err
+		}
+	}
+
+	if err := a.events.Subscribe("metrics", a.metrics); err != nil {
+		return err
+	}
+	return a.events.Subscribe("orchestrator", a.orchestratorFlow)
+}
+
+// SubscribePluginEvents registers a handler for plugin lifecycle events of
+// the given types, or of all types when none are given. Handlers are called
+// asynchronously, one event at a time.
+func (a *ActionAgent) SubscribePluginEvents(name string, handler plugin.EventHandler, eventTypes ...string) error {
+	return a.events.Subscribe(name, handler, eventTypes...)
+}
+
 // Start starts the action agent
 func (a *ActionAgent) Start(ctx context.Context) error {
 	a.mu.Lock()
@@ -118,11 +299,43 @@ func (a *ActionAgent) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start health checker: %w", err)
 	}
 
+	// Start output router before anything produces results
+	if a.outputs != nil {
+		if err := a.outputs.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start output router: %w", err)
+		}
+	}
+
+	// Make registered action plugins ready before tasks arrive
+	a.startActionPlugins(ctx)
+
 	// Start action executor
 	if err := a.executor.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start action executor: %w", err)
 	}
 
+	// Audit changes to the configuration file
+	if a.configAuditor != nil {
+		auditCtx, cancel := context.WithCancel(ctx)
+		a.stopConfigAuditor = cancel
+		go func() {
+			if err := a.configAuditor.Run(auditCtx); err != nil {
+				a.logger.Error("Configuration auditor stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// Load and hot-reload plugins from the plugin directory
+	if a.pluginWatcher != nil {
+		watchCtx, cancel := context.WithCancel(ctx)
+		a.stopWatcher = cancel
+		go func() {
+			if err := a.pluginWatcher.Run(watchCtx); err != nil {
+				a.logger.Error("Plugin watcher stopped", zap.Error(err))
+			}
+		}()
+	}
+
 	a.running = true
 	a.startTime = time.Now()
 
@@ -156,6 +369,13 @@ func (a *ActionAgent) Stop(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	if a.stopWatcher != nil {
+		a.stopWatcher()
+	}
+	if a.stopConfigAuditor != nil {
+		a.stopConfigAuditor()
+	}
+
 	// Stop orchestrator workflow
 	if err := a.orchestratorFlow.Stop(ctx); err != nil {
 		a.logger.Error("Error stopping orchestrator workflow", zap.Error(err))
@@ -174,11 +394,55 @@ func (a *ActionAgent) Stop(ctx context.Context) error {
 		a.logger.Error("Error stopping metrics collector", zap.Error(err))
 	}
 
+	// Send the remaining execution results
+	if a.outputs != nil {
+		if err := a.outputs.Stop(ctx); err != nil {
+			a.logger.Warn("Failed to send execution results to outputs", zap.Error(err))
+		}
+	}
+
+	// Deliver the remaining plugin events before closing the audit log
+	if err := a.events.Close(ctx); err != nil {
+		a.logger.Warn("Failed to deliver plugin events", zap.Error(err))
+	}
+	if a.auditLog != nil {
+		if err := a.auditLog.Close(); err != nil {
+			a.logger.Error("Error closing audit log", zap.Error(err))
+		}
+	}
+	if err := a.tracer.Shutdown(ctx); err != nil {
+		a.logger.Warn("Failed to export spans", zap.Error(err))
+	}
+
 	a.running = false
 	a.logger.Info("Action agent stopped successfully")
 	return nil
 }
 
+// handlePluginEvent starts action plugins once the plugin watcher loads them;
+// reloaded plugins are restarted by the plugin manager
+func (a *ActionAgent) handlePluginEvent(ctx context.Context, event *plugin.PluginEvent) error {
+	if event.Type != agent.PluginEventLoaded {
+		return nil
+	}
+	return a.pluginMgr.StartPlugin(ctx, event.PluginID)
+}
+
+// startActionPlugins starts the registered action plugins that are stopped
+func (a *ActionAgent) startActionPlugins(ctx context.Context) {
+	for _, p := range a.pluginMgr.ListPluginsByType(plugin.PluginTypeAction) {
+		if p.GetStatus() != plugin.StatusStopped {
+			continue
+		}
+		id := p.GetInfo().ID
+		if err := a.pluginMgr.StartPlugin(ctx, id); err != nil {
+			a.logger.Error("Failed to start action plugin",
+				zap.String("plugin_id", id),
+				zap.Error(err))
+		}
+	}
+}
+
 // IsRunning returns whether the agent is currently running
 func (a *ActionAgent) IsRunning() bool {
 	a.mu.RLock()
@@ -194,6 +458,12 @@ func (a *ActionAgent) executeActionPlugin(ctx context.Context, instruction *api.
 
 	// Convert api.Instruction to types.Instruction for enhanced plugin manager
 	typesInstruction := a.convertAPIInstructionToTypes(instruction)
+	if !typesInstruction.DryRun && forceDryRun(a.cfg, instruction.PluginID) {
+		a.logger.Info("Dry run forced by policy",
+			zap.String("instruction_id", instruction.ID),
+			zap.String("plugin_id", instruction.PluginID))
+		typesInstruction.DryRun = true
+	}
 	
 	// Create a poll response with the instruction
 	pollResponse := &types.PollResponse{
@@ -236,10 +506,17 @@ func (a *ActionAgent) executeActionPlugin(ctx context.Context, instruction *api.
 			"version":        result.InstallResult.Version,
 			"logs":           result.InstallResult.Logs,
 			"duration":       result.InstallResult.Duration,
+			"dry_run":        result.InstallResult.DryRun,
+		}
+		if len(result.InstallResult.PlannedChanges) > 0 {
+			resultMap["planned_changes"] = result.InstallResult.PlannedChanges
 		}
 	}
 
 	if result.ExecutionResult != nil {
+		if a.outputs != nil {
+			a.outputs.RouteExecutionResult(instruction.ID, result.ExecutionResult)
+		}
 		resultMap["execution_result"] = map[string]interface{}{
 			"plugin_id":    result.ExecutionResult.PluginID,
 			"success":      result.ExecutionResult.Success,
@@ -247,6 +524,10 @@ func (a *ActionAgent) executeActionPlugin(ctx context.Context, instruction *api.
 			"logs":         result.ExecutionResult.Logs,
 			"duration":     result.ExecutionResult.Duration,
 			"exit_code":    result.ExecutionResult.ExitCode,
+			"dry_run":      result.ExecutionResult.DryRun,
+		}
+		if len(result.ExecutionResult.PlannedChanges) > 0 {
+			resultMap["planned_changes"] = result.ExecutionResult.PlannedChanges
 		}
 	}
 
@@ -261,33 +542,43 @@ func (a *ActionAgent) executeActionPlugin(ctx context.Context, instruction *api.
 	return resultMap, nil
 }
 
-// convertAPIInstructionToTypes converts an api.Instruction to types.Instruction
-func (a *ActionAgent) convertAPIInstructionToTypes(apiInst *api.Instruction) *types.Instruction {
-	// Use the instruction type from the API, with fallback logic
-	instructionType := types.InstructionTypeExecute // Default to execute
-	
+// apiInstructionType returns the type of an instruction from the API,
+// falling back to install when it carries a plugin URL, and execute otherwise
+func apiInstructionType(apiInst *api.Instruction) types.InstructionType {
 	// First, check if instruction_type is explicitly provided
 	if apiInst.InstructionType != "" {
-		instructionType = types.InstructionType(apiInst.InstructionType)
-	} else {
-		// Fallback: determine instruction type based on plugin configuration
-		if pluginURL, hasPluginURL := apiInst.PluginConfiguration["plugin_url"]; hasPluginURL && pluginURL != "" {
-			instructionType = types.InstructionTypePluginInstall
-		} else if repoURL, hasRepoURL := apiInst.PluginConfiguration["repository_url"]; hasRepoURL && repoURL != "" {
-			instructionType = types.InstructionTypePluginInstall
-		}
+		return types.InstructionType(apiInst.InstructionType)
 	}
-	
+
+	// Fallback: determine instruction type based on plugin configuration
+	if pluginURL, hasPluginURL := apiInst.PluginConfiguration["plugin_url"]; hasPluginURL && pluginURL != "" {
+		return types.InstructionTypePluginInstall
+	} else if repoURL, hasRepoURL := apiInst.PluginConfiguration["repository_url"]; hasRepoURL && repoURL != "" {
+		return types.InstructionTypePluginInstall
+	}
+	return types.InstructionTypeExecute
+}
+
+// convertAPIInstructionToTypes converts an api.Instruction to types.Instruction
+func (a *ActionAgent) convertAPIInstructionToTypes(apiInst *api.Instruction) *types.Instruction {
+	instructionType := apiInstructionType(apiInst)
+
+	priority := types.PriorityNormal // Default priority
+	if apiInst.Priority != "" {
+		priority = types.Priority(apiInst.Priority)
+	}
+
 	return &types.Instruction{
 		ID:                  apiInst.ID,
 		AgentID:             a.cfg.Agent.ID, // Use the agent's ID
 		PluginID:            apiInst.PluginID,
 		Status:              types.InstructionStatusPending, // Default status
-		Priority:            types.PriorityNormal,           // Default priority
+		Priority:            priority,
 		Type:                instructionType,
 		Source:              types.InstructionSourceWebUI,       // Default source
 		PluginConfiguration: apiInst.PluginConfiguration,
 		InputData:           apiInst.InputData,
+		DryRun:              apiInst.DryRun,
 		Context:             make(map[string]interface{}), // Empty context
 		Variables:           make(map[string]interface{}), // Empty variables
 		TimeoutSeconds:      apiInst.TimeoutSeconds,
@@ -346,6 +637,10 @@ func (a *ActionAgent) GetStatus() *AgentStatus {
 		if a.orchestratorFlow != nil {
 			status.OrchestratorStatus = a.orchestratorFlow.GetStatus()
 		}
+
+		if a.outputs != nil {
+			status.OutputStatus = a.outputs.GetStats()
+		}
 	}
 
 	return status
@@ -408,6 +703,15 @@ func (a *ActionAgent) GetHealth() *AgentHealth {
 		Timestamp: healthCheckHealth.LastCheck,
 	}
 
+	if a.orchestratorFlow != nil {
+		orchestratorHealth := a.orchestratorFlow.GetComponentHealth()
+		health.Components["orchestrator"] = &ComponentHealth{
+			Status:    string(orchestratorHealth.Status),
+			Message:   orchestratorHealth.Message,
+			Timestamp: orchestratorHealth.LastCheck,
+		}
+	}
+
 	overallHealthy := true
 	for _, componentHealth := range health.Components {
 		if componentHealth.Status != "healthy" {
@@ -450,6 +754,17 @@ func (a *ActionAgent) run(ctx context.Context) {
 // performHealthCheck performs periodic health checks
 func (a *ActionAgent) performHealthCheck() {
 	health := a.GetHealth()
+
+	// Publish plugin crashes and health changes
+	a.pluginMgr.CheckPlugins()
+
+	// Expose whether the agent can reach its control plane
+	if a.orchestratorFlow != nil {
+		a.metrics.RecordCircuitBreaker("orchestrator", a.orchestratorFlow.CircuitBreakerStats())
+	}
+	if a.executor != nil {
+		a.metrics.SetQueueDepth("tasks", a.executor.GetStatus().QueuedTasks)
+	}
 	
 	if health.Status != "healthy" {
 		a.logger.Warn("Agent health check failed",
@@ -475,6 +790,7 @@ type AgentStatus struct {
 	OrchestratorStatus  map[string]interface{}     `json:"orchestrator_status,omitempty"`
 	HealthStatus        *HealthCheckStatus         `json:"health_status,omitempty"`
 	MetricsStatus       *MetricsStatus             `json:"metrics_status,omitempty"`
+	OutputStatus        map[string]*output.DestinationStats `json:"output_status,omitempty"`
 }
 
 // AgentHealth represents the health information of the action agent
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+// requiresApproval is the approval policy of the action agent. Plugin
+// installs and updates always wait for approval, since they replace the
+// code, and the declared risk, of the plugin. Executions wait for it when
+// the instruction has a configured priority, the plugin is configured, or
+// its manifest is unknown or declares it high-risk. Dry runs of plugins
+// declaring dry-run support never do, since they change nothing.
+func (a *ActionAgent) requiresApproval(instruction *api.Instruction) (bool, string) {
+	switch apiInstructionType(instruction) {
+	case types.InstructionTypePluginInstall, types.InstructionTypePluginUpdate:
+		return true, fmt.Sprintf("installing or updating plugin %s requires approval", instruction.PluginID)
+	}
+
+	metadata, known := a.pluginMetadata(instruction.PluginID)
+	dryRun := instruction.DryRun || forceDryRun(a.cfg, instruction.PluginID)
+	if dryRun && known && metadata["supports_dry_run"] == "true" {
+		return false, ""
+	}
+
+	policy := a.cfg.Action.Approval
+	for _, priority := range policy.Priorities {
+		if instruction.Priority == priority {
+			return true, fmt.Sprintf("instruction priority is %s", priority)
+		}
+	}
+	for _, pattern := range policy.Plugins {
+		if matched, _ := path.Match(pattern, instruction.PluginID); matched {
+			return true, fmt.Sprintf("plugin %s requires approval", instruction.PluginID)
+		}
+	}
+	if !known {
+		return true, fmt.Sprintf("plugin %s has no known manifest", instruction.PluginID)
+	}
+	if metadata["risk"] == plugin.RiskHigh {
+		return true, fmt.Sprintf("plugin %s is marked high-risk", instruction.PluginID)
+	}
+	return false, ""
+}
+
+// pluginMetadata returns the metadata of a loaded plugin, or else the one
+// declared by the manifest of an installed plugin. It reports false when
+// neither is known.
+func (a *ActionAgent) pluginMetadata(pluginID string) (map[string]string, bool) {
+	if pluginID == "" {
+		return nil, false
+	}
+	if p, err := a.pluginMgr.GetPlugin(pluginID); err == nil {
+		return p.GetInfo().Metadata, true
+	}
+	manifest, err := plugin.LoadManifest(a.pluginMgr.GetInstalledPluginPath(pluginID))
+	if err != nil {
+		return nil, false
+	}
+	return manifest.Info().Metadata, true
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func TestActionAgentRequiresApproval(t *testing.T) {
+	cfg := &config.Config{
+		Agent:   config.AgentConfig{BaseFolder: t.TempDir(), Environment: "prod"},
+		Plugins: config.PluginConfig{Directory: t.TempDir()},
+		Action: config.ActionConfig{
+			Approval: config.ApprovalConfig{
+				Priorities: []string{"urgent"},
+				Plugins:    []string{"db-*"},
+			},
+		},
+	}
+	pluginMgr, err := NewPluginManager(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	a := &ActionAgent{cfg: cfg, pluginMgr: pluginMgr}
+
+	writeManifest := func(id, risk string, supportsDryRun bool) {
+		dir := pluginMgr.GetInstalledPluginPath(id)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(fmt.Sprintf(`
+plugin:
+  id: %q
+  version: "1.0.0"
+  type: "action"
+  risk: %q
+  supports_dry_run: %t
+  runtime:
+    entry_point: "main.py"
+`, id, risk, supportsDryRun)), 0644))
+	}
+	writeManifest("restart-service", "high", false)
+	writeManifest("rotate-logs", "high", true)
+	writeManifest("cleanup", "low", false)
+
+	required, reason := a.requiresApproval(&api.Instruction{PluginID: "restart-service"})
+	assert.True(t, required)
+	assert.Equal(t, "plugin restart-service is marked high-risk", reason)
+
+	required, reason = a.requiresApproval(&api.Instruction{PluginID: "db-migrate"})
+	assert.True(t, required)
+	assert.Equal(t, "plugin db-migrate requires approval", reason)
+	required, reason = a.requiresApproval(&api.Instruction{PluginID: "cleanup", Priority: "urgent"})
+	assert.True(t, required)
+	assert.Equal(t, "instruction priority is urgent", reason)
+
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "cleanup", Priority: "normal"})
+	assert.False(t, required)
+
+	// A plugin nothing is known about may be anything
+	required, reason = a.requiresApproval(&api.Instruction{PluginID: "unknown"})
+	assert.True(t, required)
+	assert.Equal(t, "plugin unknown has no known manifest", reason)
+
+	// Installs and updates replace the plugin, and its declared risk
+	required, reason = a.requiresApproval(&api.Instruction{
+		PluginID:            "new-plugin",
+		PluginConfiguration: map[string]interface{}{"plugin_url": "https://github.com/stavily/new-plugin.git"},
+	})
+	assert.True(t, required)
+	assert.Equal(t, "installing or updating plugin new-plugin requires approval", reason)
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "cleanup", InstructionType: "plugin_update", DryRun: true})
+	assert.True(t, required)
+
+	// Dry runs change nothing, so they skip approval, but only for plugins
+	// that support them
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "rotate-logs", DryRun: true})
+	assert.False(t, required)
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "restart-service", DryRun: true})
+	assert.True(t, required)
+	cfg.Action.DryRun.Environments = []string{"prod"}
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "rotate-logs"})
+	assert.False(t, required)
+	required, _ = a.requiresApproval(&api.Instruction{PluginID: "db-migrate"})
+	assert.True(t, required)
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/action-agent/internal/actions"
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+)
+
+func TestActionAgentExecutesBuiltinActions(t *testing.T) {
+	cfg := &config.Config{
+		Agent:   config.AgentConfig{BaseFolder: t.TempDir()},
+		Plugins: config.PluginConfig{Directory: t.TempDir()},
+		Action: config.ActionConfig{
+			Approval: config.ApprovalConfig{Plugins: []string{"builtin-*"}},
+		},
+	}
+	logger := zaptest.NewLogger(t)
+	pluginMgr, err := NewPluginManager(cfg, logger)
+	require.NoError(t, err)
+	a := &ActionAgent{cfg: cfg, pluginMgr: pluginMgr, logger: logger}
+
+	ctx := context.Background()
+	require.NoError(t, actions.Register(ctx, pluginMgr, []config.BuiltinActionConfig{{
+		Type:   actions.TypeCommand,
+		Config: map[string]interface{}{"allowed_commands": []interface{}{"echo"}},
+	}}, logger))
+	require.NoError(t, pluginMgr.StartPlugin(ctx, "builtin-command"))
+	defer pluginMgr.StopPlugin(ctx, "builtin-command")
+
+	instruction := &api.Instruction{
+		ID:              "inst-1",
+		PluginID:        "builtin-command",
+		InstructionType: "execute",
+		InputData: map[string]interface{}{
+			"command": "echo",
+			"args":    []interface{}{"hello"},
+		},
+	}
+
+	// Built-in actions are gated like installed plugins
+	required, reason := a.requiresApproval(instruction)
+	assert.True(t, required)
+	assert.Equal(t, "plugin builtin-command requires approval", reason)
+
+	result, err := a.executeActionPlugin(ctx, instruction)
+	require.NoError(t, err)
+	assert.Equal(t, true, result["success"])
+	execution, ok := result["execution_result"].(map[string]interface{})
+	require.True(t, ok)
+	output, ok := execution["output_data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "hello\n", output["stdout"])
+
+	// A command outside the allow-list fails in the action
+	result, err = a.executeActionPlugin(ctx, &api.Instruction{
+		ID:              "inst-2",
+		PluginID:        "builtin-command",
+		InstructionType: "execute",
+		InputData:       map[string]interface{}{"command": "rm"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, false, result["success"])
+
+	// And the local policy applies to them
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte(`
+rules:
+  - name: file-actions
+    effect: allow
+    plugin_ids: builtin-file
+`), 0600))
+	engine, err := policy.NewEngine(config.PolicyConfig{Enabled: true, File: policyFile}, logger)
+	require.NoError(t, err)
+	pluginMgr.SetPolicy(engine)
+
+	_, err = a.executeActionPlugin(ctx, instruction)
+	assert.ErrorContains(t, err, "denied by policy")
+}
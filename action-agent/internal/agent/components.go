@@ -2,7 +2,6 @@
 package agent
 
 import (
-	"path/filepath"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,8 +15,8 @@ type PluginManager = sharedagent.EnhancedPluginManager
 
 // NewPluginManager creates a new enhanced plugin manager using the shared implementation
 func NewPluginManager(cfg *config.Config, logger *zap.Logger) (*PluginManager, error) {
-	// Create the plugin directory path based on agent base folder
-	pluginDir := filepath.Join(cfg.Agent.BaseFolder, "config", "plugins")
+	// Install plugins where they are watched and loaded from
+	pluginDir := cfg.GetPluginDir()
 	
 	// Create enhanced plugin manager configuration
 	enhancedCfg := &sharedagent.EnhancedPluginConfig{
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"path"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// forceDryRun reports whether the dry run policy turns every action of the
+// plugin into a dry run, whatever the request asks for
+func forceDryRun(cfg *config.Config, pluginID string) bool {
+	policy := cfg.Action.DryRun
+	if policy.All {
+		return true
+	}
+	for _, env := range policy.Environments {
+		if env == cfg.Agent.Environment {
+			return true
+		}
+	}
+	for _, pattern := range policy.Plugins {
+		if matched, _ := path.Match(pattern, pluginID); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func TestForceDryRun(t *testing.T) {
+	cfg := &config.Config{Agent: config.AgentConfig{Environment: "staging"}}
+	assert.False(t, forceDryRun(cfg, "builtin-service"))
+
+	cfg.Action.DryRun.Plugins = []string{"builtin-*", "restart-nginx"}
+	assert.True(t, forceDryRun(cfg, "builtin-service"))
+	assert.True(t, forceDryRun(cfg, "restart-nginx"))
+	assert.False(t, forceDryRun(cfg, "restart-apache"))
+
+	cfg.Action.DryRun.Environments = []string{"prod"}
+	assert.False(t, forceDryRun(cfg, "restart-apache"))
+	cfg.Agent.Environment = "prod"
+	assert.True(t, forceDryRun(cfg, "restart-apache"))
+
+	assert.True(t, forceDryRun(&config.Config{Action: config.ActionConfig{DryRun: config.DryRunPolicyConfig{All: true}}}, "any"))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/Stavily/01-Agents/shared/pkg/api"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/output"
 	"github.com/Stavily/01-Agents/shared/pkg/plugin"
 	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
 )
@@ -22,6 +23,11 @@ type ActionExecutor struct {
 	apiClient *api.Client
 	logger    *zap.Logger
 
+	// Plugin execution events are published here, nil when not published
+	events  *sharedagent.EventBus
+	// Action results are sent to the configured outputs, nil when disabled
+	outputs *output.Router
+
 	// Runtime state
 	mu            sync.RWMutex
 	running       bool
@@ -322,6 +328,15 @@ func (e *ActionExecutor) executeTask(ctx context.Context, task *api.Task, logger
 
 	execution.Plugin = actionPlugin
 	execution.Status = TaskStatusRunning
+	pluginID := actionPlugin.GetInfo().ID
+
+	dryRun := task.DryRun
+	if !dryRun && forceDryRun(e.cfg, pluginID) {
+		logger.Info("Dry run forced by policy",
+			zap.String("task_id", task.ID),
+			zap.String("plugin_id", pluginID))
+		dryRun = true
+	}
 
 	// Create action request
 	actionReq := &plugin.ActionRequest{
@@ -330,14 +345,28 @@ func (e *ActionExecutor) executeTask(ctx context.Context, task *api.Task, logger
 		Parameters:  task.Parameters,
 		Context:     task.Context,
 		Timeout:     task.Timeout,
+		DryRun:      dryRun,
 		Metadata:    task.Metadata,
 		RequestedAt: task.CreatedAt,
 	}
 
 	// Execute action
+	e.publishExecution(sharedagent.PluginEventExecutionStarted, pluginID, plugin.SeverityLow, map[string]interface{}{
+		"task_id":   task.ID,
+		"task_type": task.Type,
+		"dry_run":   dryRun,
+	})
 	result, err := actionPlugin.ExecuteAction(taskCtx, actionReq)
+	e.publishExecutionResult(pluginID, task, result, err, time.Since(startTime))
+	if e.outputs != nil {
+		e.outputs.RouteActionResult(pluginID, result)
+	}
+	// A plugin may report a failed action in its result instead of an error
+	if err == nil && result != nil && result.Status != plugin.ActionStatusCompleted {
+		err = fmt.Errorf("action %s: %s", result.Status, result.Error)
+	}
 	if err != nil {
-		if taskCtx.Err() == context.DeadlineExceeded {
+		if taskCtx.Err() == context.DeadlineExceeded || (result != nil && result.Status == plugin.ActionStatusTimeout) {
 			execution.Status = TaskStatusTimeout
 			e.mu.Lock()
 			e.stats.TasksTimeout++
@@ -355,7 +384,7 @@ func (e *ActionExecutor) executeTask(ctx context.Context, task *api.Task, logger
 	e.stats.TasksCompleted++
 	e.mu.Unlock()
 
-	e.handleTaskSuccess(task, result, logger)
+	e.handleTaskSuccess(task, result, dryRun, logger)
 
 	duration := time.Since(startTime)
 	logger.Info("Task execution completed",
@@ -363,42 +392,90 @@ func (e *ActionExecutor) executeTask(ctx context.Context, task *api.Task, logger
 		zap.Duration("duration", duration))
 }
 
-// findActionPlugin finds an appropriate action plugin for the given task type
+// publishExecution publishes a plugin execution event if an event bus is set
+func (e *ActionExecutor) publishExecution(eventType, pluginID string, severity plugin.Severity, data map[string]interface{}) {
+	if e.events != nil {
+		e.events.Emit(eventType, pluginID, severity, data)
+	}
+}
+
+// publishExecutionResult publishes the outcome of an action
+func (e *ActionExecutor) publishExecutionResult(pluginID string, task *api.Task, result *plugin.ActionResult, err error, duration time.Duration) {
+	data := map[string]interface{}{
+		"task_id":   task.ID,
+		"task_type": task.Type,
+		"duration":  duration.Seconds(),
+		"success":   err == nil && result != nil && result.Status == plugin.ActionStatusCompleted,
+	}
+	switch {
+	case err != nil:
+		data["error"] = err.Error()
+	case result != nil:
+		data["status"] = string(result.Status)
+		if result.Error != "" {
+			data["error"] = result.Error
+		}
+	}
+
+	severity := plugin.SeverityLow
+	if data["success"] != true {
+		severity = plugin.SeverityMedium
+	}
+	e.publishExecution(sharedagent.PluginEventExecutionFinished, pluginID, severity, data)
+}
+
+// findActionPlugin finds the action plugin for the given task type: the
+// plugin with that ID, or else the plugin with the lowest ID among those
+// declaring the task type as one of their categories
 func (e *ActionExecutor) findActionPlugin(taskType string) (plugin.ActionPlugin, error) {
 	plugins := e.pluginMgr.ListPluginsByType(plugin.PluginTypeAction)
 
+	var match plugin.ActionPlugin
 	for _, p := range plugins {
-		if actionPlugin, ok := p.(plugin.ActionPlugin); ok {
-			config := actionPlugin.GetActionConfig()
-			// Check if plugin supports this task type
-			if e.pluginSupportsTaskType(config, taskType) {
-				return actionPlugin, nil
-			}
+		actionPlugin, ok := p.(plugin.ActionPlugin)
+		if !ok {
+			continue
+		}
+		info := actionPlugin.GetInfo()
+		if info.ID == taskType {
+			return actionPlugin, nil
+		}
+		if e.pluginSupportsTaskType(info, taskType) && (match == nil || info.ID < match.GetInfo().ID) {
+			match = actionPlugin
 		}
 	}
 
-	return nil, fmt.Errorf("no action plugin found for task type: %s", taskType)
+	if match == nil {
+		return nil, fmt.Errorf("no action plugin found for task type: %s", taskType)
+	}
+	return match, nil
 }
 
-// pluginSupportsTaskType checks if a plugin supports the given task type
-func (e *ActionExecutor) pluginSupportsTaskType(config *plugin.ActionConfig, taskType string) bool {
-	// This is a simplified check - in practice, you might have more sophisticated
-	// plugin discovery and matching logic
-	return config != nil && config.Description != ""
+// pluginSupportsTaskType checks if a plugin declares the given task type as
+// one of its categories
+func (e *ActionExecutor) pluginSupportsTaskType(info *plugin.Info, taskType string) bool {
+	for _, category := range info.Categories {
+		if category == taskType {
+			return true
+		}
+	}
+	return false
 }
 
 // handleTaskSuccess handles successful task completion
-func (e *ActionExecutor) handleTaskSuccess(task *api.Task, result *plugin.ActionResult, logger *zap.Logger) {
+func (e *ActionExecutor) handleTaskSuccess(task *api.Task, result *plugin.ActionResult, dryRun bool, logger *zap.Logger) {
 	// Report success to orchestrator
 	taskResult := &api.TaskResult{
-		TaskID:      task.ID,
-		AgentID:     e.cfg.Agent.ID,
-		Status:      "completed",
-		Data:        result.Data,
-		StartedAt:   result.StartedAt,
-		CompletedAt: result.CompletedAt,
-		Duration:    result.Duration,
-		Metadata:    result.Metadata,
+		TaskID:         task.ID,
+		AgentID:        e.cfg.Agent.ID,
+		Status:         "completed",
+		Data:           result.Data,
+		StartedAt:      result.StartedAt,
+		CompletedAt:    result.CompletedAt,
+		Duration:       result.Duration,
+		Metadata:       result.Metadata,
+		DryRun:         dryRun,
+		PlannedChanges: result.PlannedChanges,
 	}
 
 	if err := e.apiClient.ReportTaskResult(context.Background(), taskResult); err != nil {
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+
+	"github.com/Stavily/01-Agents/action-agent/internal/actions"
+)
+
+func TestActionExecutor_FindActionPlugin(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	manager, err := sharedagent.NewPluginManager(&config.PluginConfig{Directory: t.TempDir()}, logger)
+	require.NoError(t, err)
+	executor, err := NewActionExecutor(&config.Config{}, manager, logger)
+	require.NoError(t, err)
+
+	_, err = executor.findActionPlugin(actions.TypeCommand)
+	assert.ErrorContains(t, err, "no action plugin found for task type: command")
+
+	for _, id := range []string{"z-command", "a-command", "cleanup"} {
+		actionType := actions.TypeCommand
+		if id == "cleanup" {
+			actionType = actions.TypeHTTPRequest
+		}
+		action, err := actions.New(actionType, id, logger)
+		require.NoError(t, err)
+		require.NoError(t, manager.RegisterPlugin(action))
+	}
+
+	// The lowest plugin ID among those handling the task type
+	p, err := executor.findActionPlugin(actions.TypeCommand)
+	require.NoError(t, err)
+	assert.Equal(t, "a-command", p.GetInfo().ID)
+
+	// A plugin whose ID is the task type takes precedence
+	p, err = executor.findActionPlugin(actions.TypeCleanup)
+	require.NoError(t, err)
+	assert.Equal(t, "cleanup", p.GetInfo().ID)
+
+	_, err = executor.findActionPlugin("unknown")
+	assert.Error(t, err)
+}
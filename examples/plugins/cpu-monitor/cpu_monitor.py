@@ -7,11 +7,12 @@ This plugin monitors CPU usage and triggers alerts when threshold is exceeded.
 
 import json
 import os
+import signal
 import sys
 import time
 import psutil
 import logging
-from datetime import datetime
+from datetime import datetime, timezone
 from typing import Dict, Any, Optional
 
 class CPUMonitorPlugin:
@@ -209,9 +210,30 @@ class CPUMonitorPlugin:
         return psutil.cpu_percent(interval=1)
 
 
+def run_stream(plugin: CPUMonitorPlugin):
+    """Run as a long-lived trigger plugin, writing one JSON event per line to stdout."""
+    config = json.loads(os.environ.get("STAVILY_PLUGIN_CONFIG") or "{}")
+    if not plugin.initialize(config) or not plugin.start():
+        sys.exit(1)
+
+    signal.signal(signal.SIGTERM, lambda signum, frame: sys.exit(0))
+
+    while plugin.running:
+        event = plugin.detect_triggers()
+        if event:
+            # The agent expects RFC 3339 timestamps
+            event["timestamp"] = datetime.now(timezone.utc).isoformat()
+            print(json.dumps(event), flush=True)
+        time.sleep(plugin.interval)
+
+
 def main():
     """Main plugin entry point."""
     plugin = CPUMonitorPlugin()
+
+    if os.environ.get("STAVILY_PLUGIN_MODE") == "stream":
+        run_stream(plugin)
+        return
     
     # Plugin communication protocol
     while True:
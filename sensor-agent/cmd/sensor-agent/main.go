@@ -14,6 +14,8 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/Stavily/01-Agents/sensor-agent/internal/agent"
+	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
 )
 
@@ -56,6 +58,7 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(auditCmd)
 }
 
 // initConfig reads in config file and ENV variables
@@ -272,9 +275,85 @@ var validateCmd = &cobra.Command{
 var healthCmd = &cobra.Command{
 	Use:   "health",
 	Short: "Check agent health",
+	Long: `Health queries the health server of the running agent, as configured
+under health, and prints its report. It exits with an error when the check
+fails, for use by container health checks and service watchdogs.
+
+By default the detailed health of every component is checked; --live checks
+only that the agent is responsive and --ready that it can do its work.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// This would implement a health check against a running agent
-		fmt.Println("Health check not implemented for standalone execution")
-		return nil
+		cfg, err := config.LoadConfig(viper.ConfigFileUsed())
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		path := cfg.Health.Path
+		if live, _ := cmd.Flags().GetBool("live"); live {
+			path = sharedagent.LivenessPath
+		} else if ready, _ := cmd.Flags().GetBool("ready"); ready {
+			path = sharedagent.ReadinessPath
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		report, err := sharedagent.QueryHealth(ctx, &cfg.Health, path)
+		if len(report) > 0 {
+			fmt.Print(string(report))
+		}
+		return err
 	},
 }
+
+func init() {
+	healthCmd.Flags().Bool("live", false, "only check that the agent is responsive")
+	healthCmd.Flags().Bool("ready", false, "check that the agent is ready to do its work")
+	healthCmd.Flags().Duration("timeout", 15*time.Second, "time to wait for the agent to answer")
+	healthCmd.MarkFlagsMutuallyExclusive("live", "ready")
+}
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit log commands",
+}
+
+func init() {
+	auditCmd.AddCommand(&cobra.Command{
+		Use:   "verify [log-file]",
+		Short: "Verify the hash chain of the audit log",
+		Long: `Verify checks that no record of the audit log, or of its rotated files,
+has been modified, removed or reordered. The log file defaults to the one
+in the configuration.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var logFile string
+			if len(args) > 0 {
+				logFile = args[0]
+			} else {
+				cfg, err := config.LoadConfig(viper.ConfigFileUsed())
+				if err != nil {
+					return fmt.Errorf("failed to load configuration: %w", err)
+				}
+				logFile = cfg.Security.Audit.LogFile
+			}
+			if logFile == "" {
+				return fmt.Errorf("no audit log file configured")
+			}
+
+			report, err := audit.Verify(logFile)
+			if err != nil {
+				return fmt.Errorf("audit log verification failed: %w", err)
+			}
+
+			fmt.Printf("Audit log is intact: %d records in %d files\n", report.Records, len(report.Files))
+			if report.Records > 0 {
+				fmt.Printf("Records %d to %d\n", report.First, report.Last)
+				if !report.Complete {
+					fmt.Printf("Records before %d were rotated out\n", report.First)
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -3,17 +3,28 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/Stavily/01-Agents/shared/pkg/agent"
 	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/output"
 	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
+
+	"github.com/Stavily/01-Agents/sensor-agent/internal/rules"
+	"github.com/Stavily/01-Agents/sensor-agent/internal/triggers"
 )
 
 // SensorAgent represents the main sensor agent
@@ -31,11 +42,62 @@ type SensorAgent struct {
 	mu      sync.RWMutex
 
 	// Trigger detection
-	triggerPlugins []plugin.TriggerPlugin
-	eventChannel   chan *plugin.TriggerEvent
+	triggerMu      sync.Mutex
+	triggerPlugins map[string]*runningTrigger
+	eventQueue     *EventQueue
+
+	// Loads and hot-reloads plugins in the plugin directory, nil when disabled
+	pluginWatcher *agent.PluginWatcher
+
+	// Plugin lifecycle events, and the audit log subscribed to them when
+	// auditing is enabled
+	events   *agent.EventBus
+	auditLog *audit.Logger
+
+	// Exports spans of polls and instruction processing, nil when tracing
+	// is disabled
+	tracer *tracing.Tracer
+
+	// Records changes to the configuration file in the audit log
+	configAuditor *agent.ConfigAuditor
+
+	// Local rules filtering and enriching trigger events, nil when disabled
+	ruleEngine *rules.Engine
+
+	// Deduplication, throttling and aggregation of trigger events
+	processor *EventProcessor
+
+	// Delivery of trigger events to the orchestrator
+	reporter *TriggerReporter
+
+	// Sends trigger events to the configured outputs, nil when disabled
+	outputs *output.Router
+
+	// Restarts trigger plugins that stop or crash
+	supervisor *TriggerSupervisor
 
 	// Metrics and monitoring
 	metrics *Metrics
+
+	// Serves liveness, readiness and component health over HTTP
+	healthChecker *HealthChecker
+}
+
+// runningTrigger is a started trigger plugin and the monitor reading its events
+type runningTrigger struct {
+	plugin plugin.TriggerPlugin
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the monitor returns
+}
+
+// monitoring returns whether the monitor is still reading the plugin's events
+func (t *runningTrigger) monitoring() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
 }
 
 // NewSensorAgent creates a new sensor agent instance
@@ -59,12 +121,89 @@ func NewSensorAgent(cfg *config.Config, logger *zap.Logger) (*SensorAgent, error
 		return nil, fmt.Errorf("failed to initialize metrics: %w", err)
 	}
 
+	// Create health checker
+	healthChecker, err := NewHealthChecker(&cfg.Health, pluginManager, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health checker: %w", err)
+	}
+
+	// Create event processor
+	processor, err := NewEventProcessor(cfg.Sensor.Processing, metrics, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event processor: %w", err)
+	}
+
+	// Create the queue between trigger plugins and event processing
+	eventQueue, err := NewEventQueue(cfg.Sensor.Queue, cfg.GetStateDir(), metrics, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event queue: %w", err)
+	}
+
+	// Load local event rules
+	var ruleEngine *rules.Engine
+	if cfg.Sensor.Rules.Enabled {
+		ruleEngine, err = rules.NewEngine(cfg.Sensor.Rules, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load event rules: %w", err)
+		}
+	}
+
+	// Publish plugin lifecycle events to the audit log, metrics and orchestrator
+	events, err := agent.NewEventBus(agent.EventBusOptions{}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin event bus: %w", err)
+	}
+	pluginManager.SetEventBus(events)
+
+	// Only run the instructions the local policy allows
+	if cfg.Security.Policy.Enabled {
+		engine, err := policy.NewEngine(cfg.Security.Policy, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policy: %w", err)
+		}
+		pluginManager.SetPolicy(engine)
+	}
+
+	// Register the configured built-in triggers alongside installed plugins
+	if err := triggers.Register(context.Background(), pluginManager, cfg.Sensor.Triggers, logger); err != nil {
+		return nil, fmt.Errorf("failed to register built-in triggers: %w", err)
+	}
+
+	// Send trigger events to the configured outputs
+	var outputs *output.Router
+	if cfg.Outputs.Enabled {
+		outputs, err = output.NewRouter(cfg.Outputs, output.ManagerResolver(pluginManager), logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output router: %w", err)
+		}
+	}
+
 	sensorAgent := &SensorAgent{
-		config:        cfg,
-		logger:        logger,
-		pluginManager: pluginManager,
-		metrics:       metrics,
-		eventChannel:  make(chan *plugin.TriggerEvent, 100), // Buffered channel
+		config:         cfg,
+		logger:         logger,
+		pluginManager:  pluginManager,
+		events:         events,
+		outputs:        outputs,
+		triggerPlugins: make(map[string]*runningTrigger),
+		metrics:        metrics,
+		healthChecker:  healthChecker,
+		processor:      processor,
+		eventQueue:     eventQueue,
+		ruleEngine:     ruleEngine,
+	}
+
+	// Watch the plugin directory for trigger plugins under development
+	if cfg.Plugins.AutoLoad || cfg.Plugins.WatchChanges {
+		watcher, err := agent.NewPluginWatcher(pluginManager.GetPluginBaseDir(), pluginManager.PluginManager, agent.PluginWatcherOptions{
+			AutoLoad:     cfg.Plugins.AutoLoad,
+			WatchChanges: cfg.Plugins.WatchChanges,
+			Types:        []plugin.PluginType{plugin.PluginTypeTrigger},
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plugin watcher: %w", err)
+		}
+		watcher.AddHandler(plugin.EventHandlerFunc(sensorAgent.handlePluginEvent))
+		sensorAgent.pluginWatcher = watcher
 	}
 
 	// Create orchestrator workflow with sensor-specific plugin executor
@@ -73,10 +212,124 @@ func NewSensorAgent(cfg *config.Config, logger *zap.Logger) (*SensorAgent, error
 		return nil, fmt.Errorf("failed to create orchestrator workflow: %w", err)
 	}
 	sensorAgent.orchestratorFlow = orchestratorFlow
+	orchestratorFlow.SetMetrics(metrics.MetricsCollector)
+	if err := sensorAgent.openTracer(); err != nil {
+		return nil, fmt.Errorf("failed to create tracer: %w", err)
+	}
+	sensorAgent.registerHealthChecks()
+
+	// Create trigger reporter sharing the workflow's orchestrator client
+	reporter, err := NewTriggerReporter(cfg, orchestratorFlow.Client(), metrics, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trigger reporter: %w", err)
+	}
+	sensorAgent.reporter = reporter
+
+	// Supervise trigger plugins, reporting restarts and quarantines as
+	// plugin events
+	supervisor, err := NewTriggerSupervisor(cfg.Sensor.Supervisor,
+		sensorAgent.restartTriggerPlugin, sensorAgent.quarantineTriggerPlugin, events, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trigger supervisor: %w", err)
+	}
+	sensorAgent.supervisor = supervisor
+	// The supervisor alone restarts trigger plugins, so their processes must
+	// not restart themselves behind its back
+	pluginManager.SuperviseTriggers()
+
+	if err := sensorAgent.openAuditLog(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := sensorAgent.subscribePluginEvents(); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to plugin events: %w", err)
+	}
 
 	return sensorAgent, nil
 }
 
+// registerHealthChecks registers the components reported by the health
+// server, and the checks the agent must pass to be ready
+func (s *SensorAgent) registerHealthChecks() {
+	s.healthChecker.RegisterComponent("metrics", s.metrics.GetHealth)
+	s.healthChecker.RegisterComponent("orchestrator", s.orchestratorFlow.GetComponentHealth)
+
+	s.healthChecker.RegisterReadinessCheck("config", agent.ConfigCheck(s.config))
+	s.healthChecker.RegisterReadinessCheck("orchestrator", s.orchestratorFlow.CheckReachable)
+	s.healthChecker.RegisterReadinessCheck("plugins_dir", agent.DirWritableCheck(s.pluginManager.GetPluginBaseDir()))
+}
+
+// openTracer creates the tracer when tracing is enabled and traces the
+// orchestrator workflow with it
+func (s *SensorAgent) openTracer() error {
+	if !s.config.Tracing.Enabled {
+		return nil
+	}
+
+	tracer, err := tracing.NewTracer(s.config.Tracing, []attribute.KeyValue{
+		attribute.String("service.name", "stavily-sensor-agent"),
+		attribute.String("service.instance.id", s.config.Agent.ID),
+		attribute.String("stavily.tenant.id", s.config.Agent.TenantID),
+		attribute.String("deployment.environment", s.config.Agent.Environment),
+	}, s.logger)
+	if err != nil {
+		return err
+	}
+	s.tracer = tracer
+	s.orchestratorFlow.SetTracer(tracer)
+	return nil
+}
+
+// openAuditLog opens the audit log when auditing is enabled, and records
+// received instructions, authentication failures and configuration changes
+// in it
+func (s *SensorAgent) openAuditLog() error {
+	if !s.config.Security.Audit.Enabled || s.config.Security.Audit.LogFile == "" {
+		return nil
+	}
+
+	auditLog, err := audit.NewLogger(s.config.Security.Audit, s.config.Agent.ID)
+	if err != nil {
+		return err
+	}
+	s.auditLog = auditLog
+	s.orchestratorFlow.SetAuditLog(auditLog)
+
+	if s.config.File != "" {
+		s.configAuditor, err = agent.NewConfigAuditor(s.config.File, auditLog, s.logger)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribePluginEvents subscribes the audit log, metrics and orchestrator
+// status reporting to plugin lifecycle events
+func (s *SensorAgent) subscribePluginEvents() error {
+	if s.auditLog != nil {
+		eventAudit, err := agent.NewEventAuditLog(s.auditLog)
+		if err != nil {
+			return err
+		}
+		// Written by the publisher, so security records are never dropped
+		if err := s.events.SubscribeSync("audit", eventAudit); err != nil {
+			return err
+		}
+	}
+
+	if err := s.events.Subscribe("metrics", s.metrics.MetricsCollector); err != nil {
+		return err
+	}
+	return s.events.Subscribe("orchestrator", s.orchestratorFlow)
+}
+
+// SubscribePluginEvents registers a handler for plugin lifecycle events of
+// the given types, or of all types when none are given. Handlers are called
+// asynchronously, one event at a time.
+func (s *SensorAgent) SubscribePluginEvents(name string, handler plugin.EventHandler, eventTypes ...string) error {
+	return s.events.Subscribe(name, handler, eventTypes...)
+}
+
 // Start starts the sensor agent
 func (s *SensorAgent) Start(ctx context.Context) error {
 	s.mu.Lock()
@@ -89,6 +342,13 @@ func (s *SensorAgent) Start(ctx context.Context) error {
 	s.ctx, s.cancel = context.WithCancel(ctx)
 	s.logger.Info("Starting sensor agent")
 
+	// Start output router before any trigger events arrive
+	if s.outputs != nil {
+		if err := s.outputs.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start output router: %w", err)
+		}
+	}
+
 	// Load and start trigger plugins
 	if err := s.loadTriggerPlugins(); err != nil {
 		return fmt.Errorf("failed to load trigger plugins: %w", err)
@@ -100,11 +360,48 @@ func (s *SensorAgent) Start(ctx context.Context) error {
 	}
 
 	// Start core services
-	s.wg.Add(3)
+	s.wg.Add(4)
 	go s.eventProcessingLoop()
+	go s.triggerReportingLoop()
 	go s.pluginMonitoringLoop()
 	go s.metricsCollectionLoop()
 
+	// Load and hot-reload plugins from the plugin directory
+	if s.pluginWatcher != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.pluginWatcher.Run(s.ctx); err != nil {
+				s.logger.Error("Plugin watcher stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// Audit changes to the configuration file
+	if s.configAuditor != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.configAuditor.Run(s.ctx); err != nil {
+				s.logger.Error("Configuration auditor stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// Hot reload of the event rules
+	if s.ruleEngine != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.ruleEngine.Run(s.ctx)
+		}()
+	}
+
+	// Serve health checks
+	if err := s.healthChecker.Start(s.ctx); err != nil {
+		return fmt.Errorf("failed to start health checker: %w", err)
+	}
+
 	// Start metrics server if enabled
 	if s.config.Metrics.Enabled {
 		if err := s.metrics.Start(ctx); err != nil {
@@ -133,13 +430,20 @@ func (s *SensorAgent) Stop(ctx context.Context) error {
 	// Cancel context to signal shutdown
 	s.cancel()
 
+	// No more restarts once trigger plugins are being stopped
+	s.supervisor.Stop()
+
 	// Stop orchestrator workflow
 	if err := s.orchestratorFlow.Stop(ctx); err != nil {
 		s.logger.Error("Failed to stop orchestrator workflow", zap.Error(err))
 	}
 
 	// Stop trigger plugins
-	s.stopTriggerPlugins()
+	s.stopTriggerPlugins(ctx)
+
+	if err := s.healthChecker.Stop(ctx); err != nil {
+		s.logger.Error("Failed to stop health checker", zap.Error(err))
+	}
 
 	// Stop metrics server
 	if s.metrics != nil {
@@ -162,7 +466,29 @@ func (s *SensorAgent) Stop(ctx context.Context) error {
 		s.logger.Warn("Shutdown timeout, some goroutines may not have stopped cleanly")
 	}
 
-	close(s.eventChannel)
+	if err := s.eventQueue.Close(); err != nil {
+		s.logger.Warn("Failed to close event queue", zap.Error(err))
+	}
+
+	// Send the remaining trigger events
+	if s.outputs != nil {
+		if err := s.outputs.Stop(ctx); err != nil {
+			s.logger.Warn("Failed to send trigger events to outputs", zap.Error(err))
+		}
+	}
+
+	// Deliver the remaining plugin events before closing the audit log
+	if err := s.events.Close(ctx); err != nil {
+		s.logger.Warn("Failed to deliver plugin events", zap.Error(err))
+	}
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			s.logger.Error("Failed to close audit log", zap.Error(err))
+		}
+	}
+	if err := s.tracer.Shutdown(ctx); err != nil {
+		s.logger.Warn("Failed to export spans", zap.Error(err))
+	}
 	s.started = false
 
 	s.logger.Info("Sensor agent stopped")
@@ -271,6 +597,7 @@ func (s *SensorAgent) convertAPIInstructionToTypes(apiInst *api.Instruction) *ty
 		Source:              types.InstructionSourceWebUI,       // Default source
 		PluginConfiguration: apiInst.PluginConfiguration,
 		InputData:           apiInst.InputData,
+		DryRun:              apiInst.DryRun,
 		Context:             make(map[string]interface{}), // Empty context
 		Variables:           make(map[string]interface{}), // Empty variables
 		TimeoutSeconds:      apiInst.TimeoutSeconds,
@@ -288,6 +615,9 @@ func (s *SensorAgent) convertAPIInstructionToTypes(apiInst *api.Instruction) *ty
 func (s *SensorAgent) loadTriggerPlugins() error {
 	s.logger.Info("Loading trigger plugins")
 
+	// Run installed subprocess plugins as trigger plugins
+	s.registerInstalledTriggerPlugins()
+
 	// Get all trigger plugins from plugin manager
 	plugins := s.pluginManager.ListPluginsByType(plugin.PluginTypeTrigger)
 
@@ -298,82 +628,261 @@ func (s *SensorAgent) loadTriggerPlugins() error {
 			continue
 		}
 
-		// Start the plugin
-		if err := triggerPlugin.Start(s.ctx); err != nil {
+		// Start the plugin and monitor its triggers
+		if err := s.startTriggerPlugin(triggerPlugin); err != nil {
 			s.logger.Error("Failed to start trigger plugin",
 				zap.String("plugin_id", p.GetInfo().ID),
 				zap.Error(err))
-			continue
 		}
+	}
 
-		s.triggerPlugins = append(s.triggerPlugins, triggerPlugin)
+	s.logger.Info("Loaded trigger plugins", zap.Int("count", len(s.activeTriggerPlugins())))
+	return nil
+}
 
-		// Start monitoring this plugin's triggers
-		s.wg.Add(1)
-		go s.monitorTriggerPlugin(triggerPlugin)
+// startTriggerPlugin starts a trigger plugin if needed and monitors its events
+func (s *SensorAgent) startTriggerPlugin(triggerPlugin plugin.TriggerPlugin) error {
+	info := triggerPlugin.GetInfo()
+	if err := s.ctx.Err(); err != nil {
+		return fmt.Errorf("sensor agent is stopping: %w", err)
+	}
+
+	// Plugins reloaded by the plugin manager are already restarted
+	if status := triggerPlugin.GetStatus(); status == plugin.StatusStopped || status == plugin.StatusError {
+		if err := s.pluginManager.StartPlugin(s.ctx, info.ID); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	running := &runningTrigger{plugin: triggerPlugin, cancel: cancel, done: make(chan struct{})}
+	s.triggerMu.Lock()
+	if previous, ok := s.triggerPlugins[info.ID]; ok {
+		previous.cancel()
+	}
+	s.triggerPlugins[info.ID] = running
+	s.triggerMu.Unlock()
+
+	s.supervisor.Running(info.ID)
+	s.wg.Add(1)
+	go s.monitorTriggerPlugin(ctx, running)
+
+	s.logger.Info("Started trigger plugin",
+		zap.String("plugin_id", info.ID),
+		zap.String("plugin_name", info.Name))
+	return nil
+}
+
+// forgetTriggerPlugin stops monitoring a trigger plugin; stopping the plugin
+// itself is left to the caller
+func (s *SensorAgent) forgetTriggerPlugin(id string) {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	if running, ok := s.triggerPlugins[id]; ok {
+		running.cancel()
+		delete(s.triggerPlugins, id)
+	}
+}
+
+// restartTriggerPlugin restarts a trigger plugin for the supervisor. A plugin
+// that recovered by itself while still being monitored is left running.
+func (s *SensorAgent) restartTriggerPlugin(ctx context.Context, id string) error {
+	p, err := s.pluginManager.GetPlugin(id)
+	if err != nil {
+		return err
+	}
+	triggerPlugin, ok := p.(plugin.TriggerPlugin)
+	if !ok {
+		return fmt.Errorf("plugin %s is not a trigger plugin", id)
+	}
+
+	s.triggerMu.Lock()
+	running, ok := s.triggerPlugins[id]
+	s.triggerMu.Unlock()
+
+	status := triggerPlugin.GetStatus()
+	if ok && running.plugin == triggerPlugin && running.monitoring() && status == plugin.StatusRunning {
+		s.supervisor.Running(id)
+		return nil
+	}
 
-		s.logger.Info("Started trigger plugin",
-			zap.String("plugin_id", p.GetInfo().ID),
-			zap.String("plugin_name", p.GetInfo().Name))
+	if status != plugin.StatusStopped && status != plugin.StatusError {
+		if err := s.pluginManager.StopPlugin(ctx, id); err != nil {
+			return fmt.Errorf("failed to stop plugin: %w", err)
+		}
+	}
+	return s.startTriggerPlugin(triggerPlugin)
+}
+
+// quarantineTriggerPlugin stops monitoring and running a trigger plugin the
+// supervisor gave up on
+func (s *SensorAgent) quarantineTriggerPlugin(ctx context.Context, id string) error {
+	s.forgetTriggerPlugin(id)
+
+	status, err := s.pluginManager.GetPluginStatus(id)
+	if err != nil || status == plugin.StatusStopped {
+		return err
+	}
+	return s.pluginManager.StopPlugin(ctx, id)
+}
+
+// activeTriggerPlugins returns the trigger plugins being monitored
+func (s *SensorAgent) activeTriggerPlugins() []plugin.TriggerPlugin {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	plugins := make([]plugin.TriggerPlugin, 0, len(s.triggerPlugins))
+	for _, running := range s.triggerPlugins {
+		plugins = append(plugins, running.plugin)
+	}
+	return plugins
+}
+
+// handlePluginEvent keeps trigger monitoring in step with the plugins the
+// plugin watcher loads, reloads and unloads
+func (s *SensorAgent) handlePluginEvent(ctx context.Context, event *plugin.PluginEvent) error {
+	switch event.Type {
+	case agent.PluginEventLoaded, agent.PluginEventReloaded:
+		p, err := s.pluginManager.GetPlugin(event.PluginID)
+		if err != nil {
+			s.forgetTriggerPlugin(event.PluginID)
+			return err
+		}
+		triggerPlugin, ok := p.(plugin.TriggerPlugin)
+		if !ok {
+			return fmt.Errorf("plugin %s is not a trigger plugin", event.PluginID)
+		}
+		// A new version gets a fresh start, even when quarantined
+		s.supervisor.Reset(event.PluginID)
+		return s.startTriggerPlugin(triggerPlugin)
+
+	case agent.PluginEventUnloaded:
+		s.forgetTriggerPlugin(event.PluginID)
+		s.supervisor.Forget(event.PluginID)
 	}
 
-	s.logger.Info("Loaded trigger plugins", zap.Int("count", len(s.triggerPlugins)))
 	return nil
 }
 
+// registerInstalledTriggerPlugins loads the installed trigger plugins that
+// are not yet known to the plugin manager
+func (s *SensorAgent) registerInstalledTriggerPlugins() {
+	baseDir := s.pluginManager.GetPluginBaseDir()
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Warn("Failed to read plugin directory", zap.String("dir", baseDir), zap.Error(err))
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(baseDir, entry.Name())
+		manifest, err := plugin.LoadManifest(dir)
+		if err != nil {
+			s.logger.Debug("Skipping plugin directory", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		if manifest.Type != plugin.PluginTypeTrigger {
+			continue
+		}
+		if _, err := s.pluginManager.GetPlugin(manifest.ID); err == nil {
+			continue
+		}
+
+		if _, err := s.pluginManager.LoadPlugin(s.ctx, dir); err != nil {
+			s.logger.Error("Failed to load installed trigger plugin",
+				zap.String("plugin_id", manifest.ID),
+				zap.Error(err))
+		}
+	}
+}
+
 // stopTriggerPlugins stops all trigger plugins
-func (s *SensorAgent) stopTriggerPlugins() {
+func (s *SensorAgent) stopTriggerPlugins(ctx context.Context) {
 	s.logger.Info("Stopping trigger plugins")
 
-	for _, triggerPlugin := range s.triggerPlugins {
-		if err := triggerPlugin.Stop(s.ctx); err != nil {
+	s.triggerMu.Lock()
+	running := s.triggerPlugins
+	s.triggerPlugins = make(map[string]*runningTrigger)
+	s.triggerMu.Unlock()
+
+	for id, trigger := range running {
+		trigger.cancel()
+		if err := s.pluginManager.StopPlugin(ctx, id); err != nil {
 			s.logger.Error("Failed to stop trigger plugin",
-				zap.String("plugin_id", triggerPlugin.GetInfo().ID),
+				zap.String("plugin_id", id),
 				zap.Error(err))
 		}
 	}
-
-	s.triggerPlugins = nil
 }
 
-// monitorTriggerPlugin monitors a trigger plugin for events
-func (s *SensorAgent) monitorTriggerPlugin(triggerPlugin plugin.TriggerPlugin) {
+// monitorTriggerPlugin monitors a trigger plugin for events. When the plugin
+// stops producing events the supervisor decides whether to restart it.
+func (s *SensorAgent) monitorTriggerPlugin(ctx context.Context, running *runningTrigger) {
 	defer s.wg.Done()
+	defer close(running.done)
 
+	triggerPlugin := running.plugin
 	pluginID := triggerPlugin.GetInfo().ID
 	s.logger.Debug("Starting trigger monitoring", zap.String("plugin_id", pluginID))
 
 	// Get the trigger event channel from the plugin
-	eventChan, err := triggerPlugin.DetectTriggers(s.ctx)
+	eventChan, err := triggerPlugin.DetectTriggers(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get trigger event channel",
 			zap.String("plugin_id", pluginID),
 			zap.Error(err))
+		if ctx.Err() == nil {
+			s.supervisor.Exited(pluginID, err)
+		}
 		return
 	}
 
 	for {
 		select {
-		case <-s.ctx.Done():
+		case <-ctx.Done():
 			s.logger.Debug("Trigger monitoring stopping", zap.String("plugin_id", pluginID))
 			return
 		case event, ok := <-eventChan:
 			if !ok {
 				s.logger.Info("Trigger event channel closed", zap.String("plugin_id", pluginID))
+				// Monitoring that was cancelled is not an exit
+				if ctx.Err() == nil {
+					s.supervisor.Exited(pluginID, triggerFailure(triggerPlugin))
+				}
 				return
 			}
 
-			// Forward event to main event channel
-			select {
-			case s.eventChannel <- event:
-				s.metrics.IncrementTriggersDetected()
-			case <-s.ctx.Done():
+			// Record which plugin produced the event
+			if event.Metadata == nil {
+				event.Metadata = make(map[string]interface{})
+			}
+			if _, ok := event.Metadata[MetadataPluginID]; !ok {
+				event.Metadata[MetadataPluginID] = pluginID
+			}
+
+			s.metrics.RecordTriggerDetected(event)
+
+			// Apply local rules before the event takes up queue space
+			if s.ruleEngine != nil {
+				result := s.ruleEngine.Apply(event)
+				if result.Dropped {
+					s.metrics.RecordEventsDropped(dropReasonRules, 1)
+					continue
+				}
+				event = result.Event
+			}
+
+			// Forward event to the event queue; drops are logged and
+			// counted by the queue
+			if err := s.eventQueue.Push(ctx, event); err != nil && ctx.Err() != nil {
 				return
-			default:
-				s.logger.Warn("Event channel full, dropping event",
-					zap.String("plugin_id", pluginID),
-					zap.String("event_id", event.ID))
-				s.metrics.IncrementEventsDropped()
 			}
 		}
 	}
@@ -385,20 +894,39 @@ func (s *SensorAgent) eventProcessingLoop() {
 
 	s.logger.Debug("Starting event processing loop")
 
+	// Periodically resolve events that have stopped firing
+	sweepTicker := time.NewTicker(s.processor.SweepInterval())
+	defer sweepTicker.Stop()
+
 	for {
 		select {
 		case <-s.ctx.Done():
 			s.logger.Debug("Event processing loop stopping")
 			return
-		case event := <-s.eventChannel:
-			if err := s.processTriggerEvent(event); err != nil {
-				s.logger.Error("Failed to process trigger event",
-					zap.String("event_id", event.ID),
-					zap.Error(err))
-				s.metrics.IncrementEventProcessingErrors()
-			} else {
-				s.metrics.IncrementEventsProcessed()
+		case <-s.eventQueue.Notify():
+			for s.ctx.Err() == nil {
+				event, ok := s.eventQueue.TryPop()
+				if !ok {
+					break
+				}
+				s.handleTriggerEvents(s.processor.Process(event))
 			}
+		case <-sweepTicker.C:
+			s.handleTriggerEvents(s.processor.Sweep())
+		}
+	}
+}
+
+// handleTriggerEvents processes the events emitted by the event processor
+func (s *SensorAgent) handleTriggerEvents(events []*plugin.TriggerEvent) {
+	for _, event := range events {
+		if err := s.processTriggerEvent(event); err != nil {
+			s.logger.Error("Failed to process trigger event",
+				zap.String("event_id", event.ID),
+				zap.Error(err))
+			s.metrics.IncrementEventProcessingErrors()
+		} else {
+			s.metrics.IncrementEventsProcessed()
 		}
 	}
 }
@@ -410,39 +938,31 @@ func (s *SensorAgent) processTriggerEvent(event *plugin.TriggerEvent) error {
 		zap.String("event_type", event.Type),
 		zap.String("source", event.Source))
 
-	// Prepare event data for orchestrator
-	eventData := map[string]interface{}{
-		"event_type":   "trigger_event",
-		"agent_id":     s.config.Agent.ID,
-		"tenant_id":    s.config.Agent.TenantID,
-		"timestamp":    event.Timestamp,
-		"trigger_type": event.Type,
-		"payload": map[string]interface{}{
-			"id":       event.ID,
-			"source":   event.Source,
-			"data":     event.Data,
-			"metadata": event.Metadata,
-			"tags":     event.Tags,
-			"severity": event.Severity,
-		},
-	}
-
-	// Send to orchestrator via workflow (this is a placeholder - in the current architecture,
-	// sensor agents don't directly send events to orchestrator, they respond to instructions)
 	s.logger.Info("Trigger event detected",
 		zap.String("event_id", event.ID),
 		zap.String("event_type", event.Type),
-		zap.Any("event_data", eventData))
+		zap.String("severity", string(event.Severity)))
 
-	// For now, we'll just log the event. In a complete implementation, this would
-	// either queue the event for later processing or send it through a different channel
+	// Queue the event for batched delivery to the orchestrator
+	s.reporter.Enqueue(event)
 
-	s.logger.Debug("Trigger event processed successfully",
+	// Outputs receive the event independently of orchestrator delivery
+	if s.outputs != nil {
+		s.outputs.RouteTriggerEvent(event)
+	}
+
+	s.logger.Debug("Trigger event queued for reporting",
 		zap.String("event_id", event.ID))
 
 	return nil
 }
 
+// triggerReportingLoop delivers queued trigger events to the orchestrator
+func (s *SensorAgent) triggerReportingLoop() {
+	defer s.wg.Done()
+	s.reporter.Run(s.ctx)
+}
+
 // pluginMonitoringLoop monitors plugin health and status
 func (s *SensorAgent) pluginMonitoringLoop() {
 	defer s.wg.Done()
@@ -465,7 +985,10 @@ func (s *SensorAgent) pluginMonitoringLoop() {
 
 // checkPluginHealth checks the health of all plugins
 func (s *SensorAgent) checkPluginHealth() {
-	for _, triggerPlugin := range s.triggerPlugins {
+	// Publish plugin crashes and health changes
+	s.pluginManager.CheckPlugins()
+
+	for _, triggerPlugin := range s.activeTriggerPlugins() {
 		health := triggerPlugin.GetHealth()
 		pluginID := triggerPlugin.GetInfo().ID
 
@@ -481,11 +1004,28 @@ func (s *SensorAgent) checkPluginHealth() {
 				zap.String("plugin_id", pluginID),
 				zap.String("message", health.Message),
 				zap.String("last_error", health.LastError))
-			// Could implement plugin restart logic here
 		}
 
 		s.metrics.UpdatePluginHealth(pluginID, health)
+
+		// Plugins that crashed without closing their event channel
+		if triggerPlugin.GetStatus() == plugin.StatusError {
+			s.supervisor.Exited(pluginID, triggerFailure(triggerPlugin))
+		}
+	}
+}
+
+// triggerFailure returns why a trigger plugin stopped, or nil when it
+// stopped cleanly
+func triggerFailure(triggerPlugin plugin.TriggerPlugin) error {
+	health := triggerPlugin.GetHealth()
+	if triggerPlugin.GetStatus() != plugin.StatusError && (health == nil || health.Status != plugin.HealthStatusUnhealthy) {
+		return nil
 	}
+	if health != nil && health.LastError != "" {
+		return errors.New(health.LastError)
+	}
+	return fmt.Errorf("plugin failed")
 }
 
 // metricsCollectionLoop collects and updates metrics
@@ -511,10 +1051,14 @@ func (s *SensorAgent) metricsCollectionLoop() {
 // collectMetrics collects current metrics
 func (s *SensorAgent) collectMetrics() {
 	// Update agent-level metrics
-	s.metrics.SetActivePlugins(len(s.triggerPlugins))
-	s.metrics.SetEventChannelSize(len(s.eventChannel))
+	s.metrics.SetActivePlugins(len(s.activeTriggerPlugins()))
+	s.metrics.SetEventQueueStats(s.eventQueue.GetStats())
+	s.metrics.SetTriggerReporterStats(s.reporter.GetStats())
 
-	// Could add more metrics collection here
+	// Expose whether the agent can reach its control plane
+	if s.orchestratorFlow != nil {
+		s.metrics.RecordCircuitBreaker("orchestrator", s.orchestratorFlow.CircuitBreakerStats())
+	}
 }
 
 // getAgentCapabilities returns the capabilities of this agent
@@ -533,13 +1077,26 @@ func (s *SensorAgent) GetStatus() map[string]interface{} {
 	defer s.mu.RUnlock()
 
 	status := map[string]interface{}{
-		"agent_id":         s.config.Agent.ID,
-		"tenant_id":        s.config.Agent.TenantID,
-		"type":             "sensor",
-		"running":          s.started,
-		"plugin_count":     len(s.triggerPlugins),
-		"event_queue_size": len(s.eventChannel),
-		"metrics":          s.metrics.GetCurrentMetrics(),
+		"agent_id":          s.config.Agent.ID,
+		"tenant_id":         s.config.Agent.TenantID,
+		"type":              "sensor",
+		"running":           s.started,
+		"plugin_count":      len(s.activeTriggerPlugins()),
+		"event_queue_size":  s.eventQueue.Len(),
+		"event_queue":       s.eventQueue.GetStats(),
+		"metrics":           s.metrics.GetCurrentMetrics(),
+		"event_processing":  s.processor.GetStats(),
+		"trigger_reporting": s.reporter.GetStats(),
+		"plugin_events":     s.events.GetStats(),
+		"plugin_supervisor": s.supervisor.GetStatus(),
+	}
+
+	if s.ruleEngine != nil {
+		status["event_rules"] = s.ruleEngine.GetStats()
+	}
+
+	if s.outputs != nil {
+		status["outputs"] = s.outputs.GetStats()
 	}
 
 	// Add orchestrator workflow status
@@ -558,7 +1115,7 @@ func (s *SensorAgent) GetHealth() map[string]interface{} {
 	components := make(map[string]interface{})
 	
 	// Add plugin health
-	for _, triggerPlugin := range s.triggerPlugins {
+	for _, triggerPlugin := range s.activeTriggerPlugins() {
 		pluginID := triggerPlugin.GetInfo().ID
 		health := triggerPlugin.GetHealth()
 		components[pluginID] = map[string]interface{}{
@@ -568,9 +1125,37 @@ func (s *SensorAgent) GetHealth() map[string]interface{} {
 		}
 	}
 
+	status := "healthy"
+
+	// Quarantined plugins are no longer detecting anything
+	for _, pluginID := range s.supervisor.Quarantined() {
+		components[pluginID] = map[string]interface{}{
+			"status":  string(plugin.HealthStatusUnhealthy),
+			"message": "quarantined after repeated crashes",
+		}
+		status = "degraded"
+	}
+
+	// Add orchestrator connection health
+	if s.orchestratorFlow != nil {
+		orchestratorHealth := s.orchestratorFlow.GetComponentHealth()
+		orchestratorComponent := map[string]interface{}{
+			"status":          string(orchestratorHealth.Status),
+			"message":         orchestratorHealth.Message,
+			"circuit_breaker": s.orchestratorFlow.CircuitBreakerStats(),
+		}
+		if certificate := s.orchestratorFlow.CertificateStatus(); certificate != nil {
+			orchestratorComponent["client_certificate"] = certificate
+		}
+		components["orchestrator"] = orchestratorComponent
+		if orchestratorHealth.Status != agent.HealthStatusHealthy {
+			status = "degraded"
+		}
+	}
+
 	return map[string]interface{}{
 		"agent_id":   s.config.Agent.ID,
-		"status":     "healthy", // Could be more sophisticated
+		"status":     status,
 		"components": components,
 	}
 }
@@ -3,13 +3,13 @@ package agent
 
 import (
 	"context"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/metrics"
 	"github.com/Stavily/01-Agents/shared/pkg/plugin"
 	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
 )
@@ -19,8 +19,8 @@ type PluginManager = sharedagent.EnhancedPluginManager
 
 // NewPluginManager creates a new enhanced plugin manager using the shared implementation
 func NewPluginManager(cfg *config.Config, logger *zap.Logger) (*PluginManager, error) {
-	// Create the plugin directory path based on agent base folder
-	pluginDir := filepath.Join(cfg.Agent.BaseFolder, "config", "plugins")
+	// Install plugins where they are watched and loaded from
+	pluginDir := cfg.GetPluginDir()
 	
 	// Create enhanced plugin manager configuration
 	enhancedCfg := &sharedagent.EnhancedPluginConfig{
@@ -37,9 +37,19 @@ func NewPluginManager(cfg *config.Config, logger *zap.Logger) (*PluginManager, e
 
 
 
-// Metrics wraps the shared MetricsCollector with sensor-specific functionality
+// Metrics wraps the shared MetricsCollector with sensor-specific metrics
 type Metrics struct {
 	*sharedagent.MetricsCollector
+
+	eventsProcessed       *metrics.Counter
+	eventProcessingErrors *metrics.Counter
+	eventsDropped         *metrics.CounterVec
+	eventQueueDropped     *metrics.CounterVec
+	eventsSuppressed      *metrics.CounterVec
+	eventQueueSpilled     *metrics.Gauge
+	triggerReports        *metrics.GaugeVec
+	activePlugins         *metrics.Gauge
+	pluginHealthy         *metrics.GaugeVec
 }
 
 // NewMetrics creates a new metrics collector for the sensor agent
@@ -49,66 +59,103 @@ func NewMetrics(cfg config.MetricsConfig, logger *zap.Logger) (*Metrics, error)
 		return nil, err
 	}
 	
+	registry := collector.Registry()
 	return &Metrics{
 		MetricsCollector: collector,
+
+		eventsProcessed: registry.NewCounter("events_processed_total",
+			"Trigger events reported or queued for reporting."),
+		eventProcessingErrors: registry.NewCounter("event_processing_errors_total",
+			"Trigger events that failed to be processed or reported."),
+		eventsDropped: registry.NewCounterVec("events_dropped_total",
+			"Trigger events dropped, by reason.", "reason"),
+		eventQueueDropped: registry.NewCounterVec("event_queue_dropped_total",
+			"Trigger events dropped because the event queue was full, by severity.", "severity"),
+		eventsSuppressed: registry.NewCounterVec("events_suppressed_total",
+			"Trigger events suppressed by deduplication or throttling.", "reason"),
+		eventQueueSpilled: registry.NewGauge("event_queue_spilled",
+			"Events spilled from the event queue to disk."),
+		triggerReports: registry.NewGaugeVec("trigger_reports",
+			"Trigger reports sent to the orchestrator since start, by outcome.", "outcome"),
+		activePlugins: registry.NewGauge("active_plugins",
+			"Trigger plugins currently running."),
+		pluginHealthy: registry.NewGaugeVec("plugin_healthy",
+			"Whether a trigger plugin reports itself healthy.", "plugin"),
 	}, nil
 }
 
-// Sensor-specific metrics methods that extend the shared MetricsCollector
+// Reasons events are dropped or suppressed
+const (
+	dropReasonQueueFull       = "queue_full"
+	dropReasonRules           = "rules"
+	dropReasonReportQueueFull = "report_queue_full"
+	dropReasonShutdown        = "shutdown"
 
-// RecordTriggerDetected records a trigger detection event
-func (m *Metrics) RecordTriggerDetected() {
-	m.IncrementCounter("triggers_detected")
-}
-
-// RecordEventProcessed records an event processing event
-func (m *Metrics) RecordEventProcessed() {
-	m.IncrementCounter("events_processed")
-}
+	suppressReasonDeduplicated = "deduplicated"
+	suppressReasonThrottled    = "throttled"
+)
 
-// IncrementHeartbeats increments the heartbeat counter
-func (m *Metrics) IncrementHeartbeats() {
-	m.IncrementCounter("heartbeats")
+// RecordTriggerDetected counts a trigger event detected by a plugin
+func (m *Metrics) RecordTriggerDetected(event *plugin.TriggerEvent) {
+	m.RecordTriggerEvent(string(event.Severity))
 }
 
-// IncrementHeartbeatErrors increments the heartbeat error counter
-func (m *Metrics) IncrementHeartbeatErrors() {
-	m.IncrementCounter("heartbeat_errors")
+// RecordEventsDropped counts dropped events
+func (m *Metrics) RecordEventsDropped(reason string, count int) {
+	m.eventsDropped.With(reason).Add(float64(count))
 }
 
-// IncrementTriggersDetected increments the triggers detected counter
-func (m *Metrics) IncrementTriggersDetected() {
-	m.IncrementCounter("triggers_detected")
+// RecordEventQueueDropped counts an event dropped because the event queue
+// was full
+func (m *Metrics) RecordEventQueueDropped(severity string) {
+	m.RecordEventsDropped(dropReasonQueueFull, 1)
+	m.eventQueueDropped.With(severity).Inc()
 }
 
-// IncrementEventsDropped increments the events dropped counter
-func (m *Metrics) IncrementEventsDropped() {
-	m.IncrementCounter("events_dropped")
+// IncrementEventsSuppressed counts an event that was deduplicated or throttled
+func (m *Metrics) IncrementEventsSuppressed(reason string) {
+	m.eventsSuppressed.With(reason).Inc()
 }
 
 // IncrementEventProcessingErrors increments the event processing errors counter
 func (m *Metrics) IncrementEventProcessingErrors() {
-	m.IncrementCounter("event_processing_errors")
+	m.eventProcessingErrors.Inc()
 }
 
 // IncrementEventsProcessed increments the events processed counter
 func (m *Metrics) IncrementEventsProcessed() {
-	m.IncrementCounter("events_processed")
+	m.eventsProcessed.Inc()
 }
 
-// UpdatePluginHealth updates plugin health metrics
+// UpdatePluginHealth records whether a plugin is healthy
 func (m *Metrics) UpdatePluginHealth(pluginID string, health *plugin.Health) {
-	m.RecordMetric("plugin_health_"+pluginID, health)
+	healthy := 0.0
+	if health.Status == plugin.HealthStatusHealthy {
+		healthy = 1
+	}
+	m.pluginHealthy.With(pluginID).Set(healthy)
 }
 
 // SetActivePlugins sets the number of active plugins
 func (m *Metrics) SetActivePlugins(count int) {
-	m.SetGauge("active_plugins", float64(count))
+	m.activePlugins.Set(float64(count))
+}
+
+// SetEventQueueStats records event queue metrics, with the queue depth of
+// each severity
+func (m *Metrics) SetEventQueueStats(stats EventQueueStats) {
+	m.eventQueueSpilled.Set(float64(stats.Spilled))
+	for severity, queued := range stats.Queued {
+		m.SetQueueDepth("events_"+severity, queued)
+	}
 }
 
-// SetEventChannelSize sets the event channel size metric
-func (m *Metrics) SetEventChannelSize(size int) {
-	m.SetGauge("event_channel_size", float64(size))
+// SetTriggerReporterStats records trigger delivery metrics
+func (m *Metrics) SetTriggerReporterStats(stats TriggerReporterStats) {
+	m.SetQueueDepth("trigger_reports", stats.Pending)
+	m.triggerReports.With("delivered").Set(float64(stats.Reported))
+	m.triggerReports.With("failed").Set(float64(stats.Failed))
+	m.triggerReports.With("dropped").Set(float64(stats.Dropped))
 }
 
 // HealthChecker is an alias to the shared health checker
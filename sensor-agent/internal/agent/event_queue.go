@@ -0,0 +1,556 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Overflow policies for the sensor event queue
+const (
+	OverflowDropOldestLow = "drop_oldest_low"
+	OverflowSpill         = "spill"
+	OverflowBlock         = "block"
+)
+
+var (
+	// ErrEventDropped is returned when an event could not be queued
+	ErrEventDropped = errors.New("event dropped: queue full")
+	// ErrQueueClosed is returned when pushing to a closed queue
+	ErrQueueClosed = errors.New("event queue is closed")
+)
+
+// queueSeverities lists severities in dequeue order. Events with an unknown
+// severity are queued as low.
+var queueSeverities = []plugin.Severity{
+	plugin.SeverityCritical,
+	plugin.SeverityHigh,
+	plugin.SeverityMedium,
+	plugin.SeverityLow,
+}
+
+// severityRank returns the queue level of a severity, 0 being the highest
+func severityRank(severity plugin.Severity) int {
+	for i, s := range queueSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(queueSeverities) - 1
+}
+
+// EventQueue is a bounded queue of trigger events that dequeues by severity,
+// so critical events are never starved behind low ones. Events of the same
+// severity are dequeued in arrival order. What happens when the queue is full
+// depends on the configured overflow policy.
+type EventQueue struct {
+	capacity       int
+	policy         string
+	spillDir       string
+	spillMaxEvents int
+	metrics        *Metrics
+	logger         *zap.Logger
+
+	mu      sync.Mutex
+	levels  [][]*plugin.TriggerEvent
+	size    int
+	spills  []*spillFile
+	spilled int
+	dropped []int
+	blocked int
+	closed  bool
+
+	// notify is signalled when events are available
+	notify chan struct{}
+	// space is closed and replaced whenever an event is dequeued, waking
+	// producers blocked on a full queue
+	space chan struct{}
+}
+
+// spillFile holds the overflow of one severity level on disk, in arrival order
+type spillFile struct {
+	path       string
+	file       *os.File
+	readOffset int64
+	count      int
+}
+
+// EventQueueStats tracks event queue statistics
+type EventQueueStats struct {
+	Size     int            `json:"size"`
+	Capacity int            `json:"capacity"`
+	Policy   string         `json:"policy"`
+	Queued   map[string]int `json:"queued"`
+	Spilled  int            `json:"spilled"`
+	Dropped  map[string]int `json:"dropped"`
+	Blocked  int            `json:"blocked"`
+}
+
+// NewEventQueue creates a new event queue. With the spill policy, events
+// left on disk by a previous run are picked up again.
+func NewEventQueue(cfg config.EventQueueConfig, stateDir string, metrics *Metrics, logger *zap.Logger) (*EventQueue, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	q := &EventQueue{
+		capacity:       cfg.Capacity,
+		policy:         cfg.OverflowPolicy,
+		spillDir:       cfg.SpillDir,
+		spillMaxEvents: cfg.SpillMaxEvents,
+		metrics:        metrics,
+		logger:         logger,
+		levels:         make([][]*plugin.TriggerEvent, len(queueSeverities)),
+		dropped:        make([]int, len(queueSeverities)),
+		notify:         make(chan struct{}, 1),
+		space:          make(chan struct{}),
+	}
+
+	if q.capacity <= 0 {
+		q.capacity = 1000
+	}
+	if q.policy == "" {
+		q.policy = OverflowDropOldestLow
+	}
+	if q.spillMaxEvents <= 0 {
+		q.spillMaxEvents = 100000
+	}
+
+	switch q.policy {
+	case OverflowDropOldestLow, OverflowBlock:
+	case OverflowSpill:
+		if q.spillDir == "" {
+			q.spillDir = filepath.Join(stateDir, "event-spill")
+		}
+		if err := q.openSpillFiles(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported overflow policy: %s", q.policy)
+	}
+
+	return q, nil
+}
+
+// Push queues an event, applying the overflow policy if the queue is full.
+// It returns ErrEventDropped if the event itself was dropped. With the block
+// policy it waits for space until the context is cancelled.
+func (q *EventQueue) Push(ctx context.Context, event *plugin.TriggerEvent) error {
+	rank := severityRank(event.Severity)
+
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return ErrQueueClosed
+		}
+
+		// Keep arrival order within a severity that already has events on disk
+		if q.policy == OverflowSpill && q.spills[rank].count > 0 {
+			err := q.spillLocked(rank, event)
+			q.refillLocked()
+			q.mu.Unlock()
+			q.signal()
+			return err
+		}
+
+		if q.size < q.capacity {
+			q.levels[rank] = append(q.levels[rank], event)
+			q.size++
+			q.mu.Unlock()
+			q.signal()
+			return nil
+		}
+
+		switch q.policy {
+		case OverflowSpill:
+			err := q.spillLocked(rank, event)
+			q.mu.Unlock()
+			return err
+
+		case OverflowBlock:
+			q.blocked++
+			space := q.space
+			q.mu.Unlock()
+
+			select {
+			case <-space:
+				continue
+			case <-ctx.Done():
+				q.mu.Lock()
+				q.dropLocked(rank, event)
+				q.mu.Unlock()
+				return ctx.Err()
+			}
+
+		default:
+			// Make room by dropping the oldest event of the lowest severity,
+			// unless the new event is itself the lowest
+			victim := -1
+			for i := len(q.levels) - 1; i >= rank; i-- {
+				if len(q.levels[i]) > 0 {
+					victim = i
+					break
+				}
+			}
+			if victim < 0 {
+				q.dropLocked(rank, event)
+				q.mu.Unlock()
+				return ErrEventDropped
+			}
+
+			oldest := q.levels[victim][0]
+			q.levels[victim] = q.levels[victim][1:]
+			q.dropLocked(victim, oldest)
+			q.levels[rank] = append(q.levels[rank], event)
+			q.mu.Unlock()
+			q.signal()
+			return nil
+		}
+	}
+}
+
+// TryPop removes and returns the highest severity event, if any. Events
+// still in memory can be drained after the queue is closed, unless the spill
+// policy moved them to disk.
+func (q *EventQueue) TryPop() (*plugin.TriggerEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for rank := range q.levels {
+		if len(q.levels[rank]) == 0 {
+			continue
+		}
+
+		event := q.levels[rank][0]
+		q.levels[rank][0] = nil
+		q.levels[rank] = q.levels[rank][1:]
+		q.size--
+
+		// Close already woke the producers and closed the spill files
+		if q.closed {
+			return event, true
+		}
+
+		if q.policy == OverflowSpill {
+			q.refillLocked()
+		}
+
+		// Wake producers waiting for space
+		close(q.space)
+		q.space = make(chan struct{})
+
+		return event, true
+	}
+
+	return nil, false
+}
+
+// Notify returns a channel that receives a value when events may be available
+func (q *EventQueue) Notify() <-chan struct{} {
+	return q.notify
+}
+
+// Len returns the number of events queued in memory
+func (q *EventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// GetStats returns event queue statistics
+func (q *EventQueue) GetStats() EventQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := EventQueueStats{
+		Size:     q.size,
+		Capacity: q.capacity,
+		Policy:   q.policy,
+		Queued:   make(map[string]int, len(queueSeverities)),
+		Spilled:  q.spilled,
+		Dropped:  make(map[string]int, len(queueSeverities)),
+		Blocked:  q.blocked,
+	}
+
+	for rank, severity := range queueSeverities {
+		stats.Queued[string(severity)] = len(q.levels[rank])
+		stats.Dropped[string(severity)] = q.dropped[rank]
+	}
+
+	return stats
+}
+
+// Close closes the queue. With the spill policy, the events still in memory
+// are written back to disk ahead of the unread spilled ones, and the events
+// already read are removed from disk, so the next run picks up exactly the
+// events that were not dequeued.
+func (q *EventQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.space)
+
+	var firstErr error
+	for rank := range q.spills {
+		if err := q.persistSpillLocked(rank); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// signal wakes the consumer
+func (q *EventQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dropLocked records a dropped event; the caller must hold the lock
+func (q *EventQueue) dropLocked(rank int, event *plugin.TriggerEvent) {
+	q.dropped[rank]++
+	severity := string(queueSeverities[rank])
+
+	q.logger.Warn("Event queue full, dropping event",
+		zap.String("event_id", event.ID),
+		zap.String("severity", severity),
+		zap.String("policy", q.policy))
+
+	if q.metrics != nil {
+		q.metrics.RecordEventQueueDropped(severity)
+	}
+}
+
+// openSpillFiles opens one spill file per severity and counts the events
+// left in them by a previous run
+func (q *EventQueue) openSpillFiles() error {
+	if err := os.MkdirAll(q.spillDir, 0750); err != nil {
+		return fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	q.spills = make([]*spillFile, len(queueSeverities))
+	for rank, severity := range queueSeverities {
+		path := filepath.Join(q.spillDir, fmt.Sprintf("events-%s.ndjson", severity))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open spill file: %w", err)
+		}
+
+		count, err := countLines(file)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to read spill file: %w", err)
+		}
+
+		q.spills[rank] = &spillFile{path: path, file: file, count: count}
+		q.spilled += count
+	}
+
+	if q.spilled > 0 {
+		q.logger.Info("Recovered spilled events from disk",
+			zap.Int("events", q.spilled),
+			zap.String("spill_dir", q.spillDir))
+		q.refillLocked()
+	}
+
+	return nil
+}
+
+// spillLocked appends an event to the spill file of its severity; the caller must hold the lock
+func (q *EventQueue) spillLocked(rank int, event *plugin.TriggerEvent) error {
+	if q.spilled >= q.spillMaxEvents {
+		q.dropLocked(rank, event)
+		return ErrEventDropped
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		q.dropLocked(rank, event)
+		return fmt.Errorf("failed to encode event for spilling: %w", err)
+	}
+
+	spill := q.spills[rank]
+	if _, err := spill.file.Write(append(data, '\n')); err != nil {
+		q.dropLocked(rank, event)
+		return fmt.Errorf("failed to spill event to disk: %w", err)
+	}
+
+	spill.count++
+	q.spilled++
+	return nil
+}
+
+// refillLocked moves spilled events back into memory, highest severity
+// first, while there is space; the caller must hold the lock
+func (q *EventQueue) refillLocked() {
+	for rank, spill := range q.spills {
+		for spill.count > 0 && q.size < q.capacity {
+			n, err := q.readSpilled(rank, q.capacity-q.size)
+			if err != nil {
+				q.logger.Error("Failed to read spilled events, discarding spill file",
+					zap.String("path", spill.path),
+					zap.Int("events", spill.count),
+					zap.Error(err))
+				q.dropped[rank] += spill.count
+				q.spilled -= spill.count
+				spill.count = 0
+			}
+			if n == 0 || err != nil {
+				break
+			}
+		}
+
+		// Reclaim disk space once a spill file has been fully read
+		if spill.count == 0 && spill.readOffset > 0 {
+			if err := spill.file.Truncate(0); err != nil {
+				q.logger.Warn("Failed to truncate spill file", zap.String("path", spill.path), zap.Error(err))
+			}
+			spill.readOffset = 0
+		}
+	}
+}
+
+// readSpilled reads up to max events from a spill file into memory
+func (q *EventQueue) readSpilled(rank, max int) (int, error) {
+	spill := q.spills[rank]
+
+	reader := io.NewSectionReader(spill.file, spill.readOffset, 1<<62)
+	buffered := bufio.NewReader(reader)
+
+	read := 0
+	for read < max && spill.count > 0 {
+		line, err := buffered.ReadBytes('\n')
+		if err != nil {
+			return read, err
+		}
+		spill.readOffset += int64(len(line))
+		spill.count--
+		q.spilled--
+
+		var event plugin.TriggerEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			q.logger.Warn("Discarding corrupt spilled event", zap.String("path", spill.path), zap.Error(err))
+			q.dropped[rank]++
+			continue
+		}
+
+		q.levels[rank] = append(q.levels[rank], &event)
+		q.size++
+		read++
+	}
+
+	return read, nil
+}
+
+// persistSpillLocked closes the spill file of a severity, replacing it with
+// the events of the severity still in memory followed by its unread spilled
+// events; the caller must hold the lock. If it cannot be replaced, the file
+// is left as it was and the events in memory are dropped.
+func (q *EventQueue) persistSpillLocked(rank int) error {
+	spill := q.spills[rank]
+	events := q.levels[rank]
+	q.levels[rank] = nil
+	q.size -= len(events)
+
+	if len(events) == 0 && spill.readOffset == 0 {
+		if err := spill.file.Close(); err != nil {
+			return fmt.Errorf("failed to close spill file: %w", err)
+		}
+		return nil
+	}
+
+	var kept []*plugin.TriggerEvent
+	var lines [][]byte
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			q.dropLocked(rank, event)
+			continue
+		}
+		kept = append(kept, event)
+		lines = append(lines, append(data, '\n'))
+	}
+
+	tmpPath := spill.path + ".tmp"
+	err := writeSpillFile(tmpPath, lines, io.NewSectionReader(spill.file, spill.readOffset, 1<<62))
+	if closeErr := spill.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, spill.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		for _, event := range kept {
+			q.dropLocked(rank, event)
+		}
+		return fmt.Errorf("failed to persist queued events: %w", err)
+	}
+
+	spill.count += len(kept)
+	spill.readOffset = 0
+	q.spilled += len(kept)
+	return nil
+}
+
+// writeSpillFile writes encoded events followed by the rest of an existing
+// spill file to a new file
+func writeSpillFile(path string, lines [][]byte, rest io.Reader) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(writer, rest); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// countLines counts the newline-terminated lines in a file
+func countLines(file *os.File) (int, error) {
+	reader := bufio.NewReader(io.NewSectionReader(file, 0, 1<<62))
+
+	count := 0
+	for {
+		_, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
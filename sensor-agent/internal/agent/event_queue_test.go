@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+func queuedEvent(id string, severity plugin.Severity) *plugin.TriggerEvent {
+	return &plugin.TriggerEvent{
+		ID:        id,
+		Type:      "test",
+		Source:    "test-plugin",
+		Timestamp: time.Now(),
+		Severity:  severity,
+	}
+}
+
+func popIDs(q *EventQueue) []string {
+	var ids []string
+	for {
+		event, ok := q.TryPop()
+		if !ok {
+			return ids
+		}
+		ids = append(ids, event.ID)
+	}
+}
+
+func TestEventQueueOrdersBySeverity(t *testing.T) {
+	q, err := NewEventQueue(config.EventQueueConfig{Capacity: 10}, t.TempDir(), nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+	require.NoError(t, q.Push(ctx, queuedEvent("low-1", plugin.SeverityLow)))
+	require.NoError(t, q.Push(ctx, queuedEvent("high-1", plugin.SeverityHigh)))
+	require.NoError(t, q.Push(ctx, queuedEvent("unknown-1", plugin.Severity("unknown"))))
+	require.NoError(t, q.Push(ctx, queuedEvent("critical-1", plugin.SeverityCritical)))
+	require.NoError(t, q.Push(ctx, queuedEvent("high-2", plugin.SeverityHigh)))
+
+	assert.Equal(t, []string{"critical-1", "high-1", "high-2", "low-1", "unknown-1"}, popIDs(q))
+}
+
+func TestEventQueueDropsOldestLowSeverity(t *testing.T) {
+	q, err := NewEventQueue(config.EventQueueConfig{Capacity: 2, OverflowPolicy: OverflowDropOldestLow}, t.TempDir(), nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+	require.NoError(t, q.Push(ctx, queuedEvent("low-1", plugin.SeverityLow)))
+	require.NoError(t, q.Push(ctx, queuedEvent("low-2", plugin.SeverityLow)))
+
+	// A critical event evicts the oldest low one
+	require.NoError(t, q.Push(ctx, queuedEvent("critical-1", plugin.SeverityCritical)))
+
+	// Newer events of the same severity replace older ones
+	require.NoError(t, q.Push(ctx, queuedEvent("low-3", plugin.SeverityLow)))
+	require.NoError(t, q.Push(ctx, queuedEvent("medium-1", plugin.SeverityMedium)))
+
+	// A low event cannot evict anything more important and is dropped itself
+	assert.ErrorIs(t, q.Push(ctx, queuedEvent("low-4", plugin.SeverityLow)), ErrEventDropped)
+
+	stats := q.GetStats()
+	assert.Equal(t, 4, stats.Dropped["low"])
+	assert.Equal(t, 0, stats.Dropped["critical"])
+
+	assert.Equal(t, []string{"critical-1", "medium-1"}, popIDs(q))
+}
+
+func TestEventQueueSpillsToDisk(t *testing.T) {
+	spillDir := t.TempDir()
+	cfg := config.EventQueueConfig{Capacity: 2, OverflowPolicy: OverflowSpill, SpillDir: spillDir}
+
+	q, err := NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, id := range []string{"low-1", "low-2", "low-3", "low-4"} {
+		require.NoError(t, q.Push(ctx, queuedEvent(id, plugin.SeverityLow)))
+	}
+	require.NoError(t, q.Push(ctx, queuedEvent("critical-1", plugin.SeverityCritical)))
+
+	stats := q.GetStats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 3, stats.Spilled)
+
+	// The spilled critical event is refilled first once there is room
+	event, ok := q.TryPop()
+	require.True(t, ok)
+	assert.Equal(t, "low-1", event.ID)
+	event, ok = q.TryPop()
+	require.True(t, ok)
+	assert.Equal(t, "critical-1", event.ID)
+
+	// Events not dequeued survive a restart, whether they were in memory or
+	// on disk
+	require.NoError(t, q.Close())
+	q, err = NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	assert.Equal(t, []string{"low-2", "low-3", "low-4"}, popIDs(q))
+	assert.Equal(t, 0, q.GetStats().Spilled)
+}
+
+func TestEventQueueRestartDeliversEventsOnce(t *testing.T) {
+	cfg := config.EventQueueConfig{Capacity: 2, OverflowPolicy: OverflowSpill, SpillDir: t.TempDir()}
+	q, err := NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(t, q.Push(ctx, queuedEvent(id, plugin.SeverityLow)))
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		event, ok := q.TryPop()
+		require.True(t, ok)
+		assert.Equal(t, id, event.ID)
+	}
+	require.NoError(t, q.Close())
+
+	// Consumed events are not delivered again after a restart
+	q, err = NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	event, ok := q.TryPop()
+	require.True(t, ok)
+	assert.Equal(t, "d", event.ID)
+	require.NoError(t, q.Close())
+	assert.Equal(t, 2, q.GetStats().Spilled)
+
+	q, err = NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+	assert.Equal(t, []string{"e", "f"}, popIDs(q))
+}
+
+func TestEventQueueBlocksProducer(t *testing.T) {
+	q, err := NewEventQueue(config.EventQueueConfig{Capacity: 1, OverflowPolicy: OverflowBlock}, t.TempDir(), nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+	require.NoError(t, q.Push(ctx, queuedEvent("medium-1", plugin.SeverityMedium)))
+
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- q.Push(ctx, queuedEvent("medium-2", plugin.SeverityMedium))
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	event, ok := q.TryPop()
+	require.True(t, ok)
+	assert.Equal(t, "medium-1", event.ID)
+
+	select {
+	case err := <-pushed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("push did not resume after space was freed")
+	}
+
+	// A cancelled producer gives up and the event counts as dropped
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, q.Push(cancelled, queuedEvent("medium-3", plugin.SeverityMedium)), context.Canceled)
+	assert.Equal(t, 1, q.GetStats().Dropped["medium"])
+}
+
+func TestEventQueueTryPopAfterClose(t *testing.T) {
+	q, err := NewEventQueue(config.EventQueueConfig{Capacity: 2, OverflowPolicy: OverflowBlock}, t.TempDir(), nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, q.Push(ctx, queuedEvent("medium-1", plugin.SeverityMedium)))
+	require.NoError(t, q.Push(ctx, queuedEvent("high-1", plugin.SeverityHigh)))
+	require.NoError(t, q.Close())
+
+	// Events still in memory drain after close
+	assert.Equal(t, []string{"high-1", "medium-1"}, popIDs(q))
+	assert.ErrorIs(t, q.Push(ctx, queuedEvent("medium-2", plugin.SeverityMedium)), ErrQueueClosed)
+
+	// With the spill policy, events in memory are moved to disk instead
+	spillDir := t.TempDir()
+	cfg := config.EventQueueConfig{Capacity: 1, OverflowPolicy: OverflowSpill, SpillDir: spillDir}
+	q, err = NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, q.Push(ctx, queuedEvent("low-1", plugin.SeverityLow)))
+	require.NoError(t, q.Push(ctx, queuedEvent("low-2", plugin.SeverityLow)))
+	require.NoError(t, q.Close())
+	assert.Empty(t, popIDs(q))
+
+	q, err = NewEventQueue(cfg, "", nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer q.Close()
+	assert.Equal(t, []string{"low-1", "low-2"}, popIDs(q))
+}
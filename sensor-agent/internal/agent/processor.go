@@ -0,0 +1,352 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// EventState is the lifecycle state of an aggregated trigger event
+type EventState string
+
+const (
+	EventStateFiring   EventState = "firing"
+	EventStateResolved EventState = "resolved"
+)
+
+// Metadata keys set on events emitted by the event processor
+const (
+	MetadataPluginID    = "plugin_id"
+	MetadataFingerprint = "fingerprint"
+	MetadataState       = "state"
+	MetadataCount       = "count"
+	MetadataFirstSeen   = "first_seen"
+	MetadataLastSeen    = "last_seen"
+)
+
+// EventProcessor sits between the trigger plugins and the reporter. Repeats
+// of the same event, identified by a fingerprint of type, source and key data
+// fields, are folded into a single firing event carrying a count and first
+// and last seen times. An aggregated update is emitted once per dedupe window
+// while the event keeps firing, straight away when it escalates to a higher
+// severity, and a resolved event once it stops or is no longer tracked. New
+// events are rate limited per plugin.
+type EventProcessor struct {
+	cfg     config.EventProcessingConfig
+	metrics *Metrics
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	active   map[string]*trackedEvent
+	limiters map[string]*pluginLimiter
+	stats    EventProcessorStats
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// trackedEvent is an event that is currently firing
+type trackedEvent struct {
+	fingerprint string
+	pluginID    string
+	firstID     string
+	latest      *plugin.TriggerEvent
+	count       int
+	firstSeen   time.Time
+	lastSeen    time.Time
+	lastEmitted time.Time
+	severity    plugin.Severity // Of the last emitted update
+}
+
+// pluginLimiter is a token bucket refilled at the per-minute rate limit
+type pluginLimiter struct {
+	tokens float64
+	last   time.Time
+}
+
+// EventProcessorStats tracks event processing statistics
+type EventProcessorStats struct {
+	Received     int `json:"received"`
+	Emitted      int `json:"emitted"`
+	Deduplicated int `json:"deduplicated"`
+	Throttled    int `json:"throttled"`
+	Resolved     int `json:"resolved"`
+	Active       int `json:"active"`
+}
+
+// NewEventProcessor creates a new event processor
+func NewEventProcessor(cfg config.EventProcessingConfig, metrics *Metrics, logger *zap.Logger) (*EventProcessor, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if cfg.DedupeWindow <= 0 {
+		cfg.DedupeWindow = 5 * time.Minute
+	}
+	if cfg.ResolveAfter <= 0 {
+		cfg.ResolveAfter = 2 * time.Minute
+	}
+	if cfg.MaxTracked <= 0 {
+		cfg.MaxTracked = 10000
+	}
+
+	return &EventProcessor{
+		cfg:      cfg,
+		metrics:  metrics,
+		logger:   logger,
+		active:   make(map[string]*trackedEvent),
+		limiters: make(map[string]*pluginLimiter),
+		now:      time.Now,
+	}, nil
+}
+
+// Process handles an event from a trigger plugin and returns the events to
+// report, which may be none if the event was folded into one already firing
+func (p *EventProcessor) Process(event *plugin.TriggerEvent) []*plugin.TriggerEvent {
+	if !p.cfg.Enabled {
+		return []*plugin.TriggerEvent{event}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.stats.Received++
+	fingerprint := p.fingerprint(event)
+
+	// Plugins may signal resolution explicitly
+	if state, _ := event.Metadata[MetadataState].(string); state == string(EventStateResolved) {
+		tracked, ok := p.active[fingerprint]
+		if !ok {
+			return p.emit(event)
+		}
+		tracked.latest = event
+		tracked.lastSeen = now
+		delete(p.active, fingerprint)
+		p.stats.Resolved++
+		return p.emit(p.aggregate(tracked, EventStateResolved, now))
+	}
+
+	if tracked, ok := p.active[fingerprint]; ok {
+		tracked.latest = event
+		tracked.count++
+		tracked.lastSeen = now
+
+		escalated := severityRank(event.Severity) < severityRank(tracked.severity)
+		if !escalated && now.Sub(tracked.lastEmitted) < p.cfg.DedupeWindow {
+			p.stats.Deduplicated++
+			p.recordSuppressed(suppressReasonDeduplicated)
+			return nil
+		}
+
+		tracked.lastEmitted = now
+		tracked.severity = event.Severity
+		return p.emit(p.aggregate(tracked, EventStateFiring, now))
+	}
+
+	pluginID := pluginIDOf(event)
+	if !p.allow(pluginID, now) {
+		p.stats.Throttled++
+		p.recordSuppressed(suppressReasonThrottled)
+		p.logger.Debug("Trigger event throttled",
+			zap.String("plugin_id", pluginID),
+			zap.String("event_id", event.ID))
+		return nil
+	}
+
+	var events []*plugin.TriggerEvent
+	if len(p.active) >= p.cfg.MaxTracked {
+		events = p.evictOldest(now)
+	}
+
+	tracked := &trackedEvent{
+		fingerprint: fingerprint,
+		pluginID:    pluginID,
+		firstID:     event.ID,
+		latest:      event,
+		count:       1,
+		firstSeen:   now,
+		lastSeen:    now,
+		lastEmitted: now,
+		severity:    event.Severity,
+	}
+	p.active[fingerprint] = tracked
+
+	return append(events, p.emit(p.aggregate(tracked, EventStateFiring, now))...)
+}
+
+// Sweep resolves events that have stopped firing and returns the resolved events
+func (p *EventProcessor) Sweep() []*plugin.TriggerEvent {
+	if !p.cfg.Enabled {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var resolved []*plugin.TriggerEvent
+	for fingerprint, tracked := range p.active {
+		if now.Sub(tracked.lastSeen) < p.cfg.ResolveAfter {
+			continue
+		}
+		delete(p.active, fingerprint)
+		p.stats.Resolved++
+		resolved = append(resolved, p.emit(p.aggregate(tracked, EventStateResolved, now))...)
+	}
+
+	return resolved
+}
+
+// SweepInterval returns how often Sweep should be called
+func (p *EventProcessor) SweepInterval() time.Duration {
+	interval := p.cfg.ResolveAfter / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	return interval
+}
+
+// GetStats returns event processing statistics
+func (p *EventProcessor) GetStats() EventProcessorStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.Active = len(p.active)
+	return stats
+}
+
+// emit counts an outgoing event; the caller must hold the lock
+func (p *EventProcessor) emit(event *plugin.TriggerEvent) []*plugin.TriggerEvent {
+	p.stats.Emitted++
+	return []*plugin.TriggerEvent{event}
+}
+
+// aggregate builds the event reported for a tracked event in the given state
+func (p *EventProcessor) aggregate(tracked *trackedEvent, state EventState, now time.Time) *plugin.TriggerEvent {
+	latest := tracked.latest
+
+	metadata := make(map[string]interface{}, len(latest.Metadata)+5)
+	for k, v := range latest.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataFingerprint] = tracked.fingerprint
+	metadata[MetadataState] = string(state)
+	metadata[MetadataCount] = tracked.count
+	metadata[MetadataFirstSeen] = tracked.firstSeen
+	metadata[MetadataLastSeen] = tracked.lastSeen
+
+	// The first report keeps the plugin's event ID; later ones derive from it
+	id := tracked.firstID
+	switch {
+	case state == EventStateResolved:
+		id = fmt.Sprintf("%s-resolved", tracked.firstID)
+	case tracked.count > 1:
+		id = fmt.Sprintf("%s-%d", tracked.firstID, tracked.count)
+	}
+
+	timestamp := latest.Timestamp
+	if tracked.count > 1 || state == EventStateResolved {
+		timestamp = now
+	}
+
+	return &plugin.TriggerEvent{
+		ID:        id,
+		Type:      latest.Type,
+		Source:    latest.Source,
+		Timestamp: timestamp,
+		Data:      latest.Data,
+		Metadata:  metadata,
+		Tags:      latest.Tags,
+		Severity:  latest.Severity,
+	}
+}
+
+// fingerprint identifies repeats of the same event
+func (p *EventProcessor) fingerprint(event *plugin.TriggerEvent) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", event.Type, event.Source)
+
+	fields := append([]string(nil), p.cfg.FingerprintFields...)
+	sort.Strings(fields)
+	for _, field := range fields {
+		fmt.Fprintf(h, "\x00%s=%v", field, event.Data[field])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// allow applies the per-plugin rate limit; the caller must hold the lock
+func (p *EventProcessor) allow(pluginID string, now time.Time) bool {
+	if p.cfg.PluginRateLimit <= 0 {
+		return true
+	}
+
+	limit := float64(p.cfg.PluginRateLimit)
+	limiter, ok := p.limiters[pluginID]
+	if !ok {
+		limiter = &pluginLimiter{tokens: limit, last: now}
+		p.limiters[pluginID] = limiter
+	}
+
+	limiter.tokens += now.Sub(limiter.last).Minutes() * limit
+	if limiter.tokens > limit {
+		limiter.tokens = limit
+	}
+	limiter.last = now
+
+	if limiter.tokens < 1 {
+		return false
+	}
+	limiter.tokens--
+	return true
+}
+
+// evictOldest stops tracking the least recently seen event and returns its
+// resolved event, since a later repeat would fire anew; the caller must hold
+// the lock
+func (p *EventProcessor) evictOldest(now time.Time) []*plugin.TriggerEvent {
+	var oldest *trackedEvent
+	for _, tracked := range p.active {
+		if oldest == nil || tracked.lastSeen.Before(oldest.lastSeen) {
+			oldest = tracked
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+
+	delete(p.active, oldest.fingerprint)
+	p.stats.Resolved++
+	p.logger.Warn("Too many active trigger events, resolving the oldest",
+		zap.String("fingerprint", oldest.fingerprint),
+		zap.Int("max_tracked", p.cfg.MaxTracked))
+	return p.emit(p.aggregate(oldest, EventStateResolved, now))
+}
+
+// recordSuppressed counts a suppressed event if metrics are available
+func (p *EventProcessor) recordSuppressed(reason string) {
+	if p.metrics != nil {
+		p.metrics.IncrementEventsSuppressed(reason)
+	}
+}
+
+// pluginIDOf returns the plugin that produced an event
+func pluginIDOf(event *plugin.TriggerEvent) string {
+	if id, ok := event.Metadata[MetadataPluginID].(string); ok && id != "" {
+		return id
+	}
+	return event.Source
+}
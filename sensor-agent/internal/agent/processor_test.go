@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+func newTestProcessor(t *testing.T, cfg config.EventProcessingConfig) (*EventProcessor, *time.Time) {
+	cfg.Enabled = true
+	processor, err := NewEventProcessor(cfg, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	now := time.Now()
+	processor.now = func() time.Time { return now }
+	return processor, &now
+}
+
+func cpuEvent(id string, host string) *plugin.TriggerEvent {
+	return &plugin.TriggerEvent{
+		ID:        id,
+		Type:      "cpu_high",
+		Source:    "cpu-monitor",
+		Timestamp: time.Now(),
+		Data:      map[string]interface{}{"host": host, "usage": 95.0},
+		Severity:  plugin.SeverityHigh,
+	}
+}
+
+func TestEventProcessorDeduplicatesAndResolves(t *testing.T) {
+	processor, now := newTestProcessor(t, config.EventProcessingConfig{
+		DedupeWindow:      time.Minute,
+		ResolveAfter:      30 * time.Second,
+		FingerprintFields: []string{"host"},
+	})
+
+	events := processor.Process(cpuEvent("e1", "web-1"))
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].ID)
+	assert.Equal(t, "firing", events[0].Metadata[MetadataState])
+
+	// Repeats within the window are folded in
+	*now = now.Add(10 * time.Second)
+	assert.Empty(t, processor.Process(cpuEvent("e2", "web-1")))
+	*now = now.Add(10 * time.Second)
+	assert.Empty(t, processor.Process(cpuEvent("e3", "web-1")))
+
+	// A different host is a different event
+	assert.Len(t, processor.Process(cpuEvent("e4", "web-2")), 1)
+
+	// Nothing resolves while the event keeps repeating
+	assert.Empty(t, processor.Sweep())
+
+	// Once it stops, both events resolve with their aggregated counts
+	*now = now.Add(time.Minute)
+	resolved := processor.Sweep()
+	require.Len(t, resolved, 2)
+
+	counts := map[string]interface{}{}
+	for _, event := range resolved {
+		assert.Equal(t, "resolved", event.Metadata[MetadataState])
+		counts[event.ID] = event.Metadata[MetadataCount]
+	}
+	assert.Equal(t, map[string]interface{}{"e1-resolved": 3, "e4-resolved": 1}, counts)
+
+	stats := processor.GetStats()
+	assert.Equal(t, 4, stats.Received)
+	assert.Equal(t, 2, stats.Deduplicated)
+	assert.Equal(t, 2, stats.Resolved)
+	assert.Equal(t, 0, stats.Active)
+}
+
+func TestEventProcessorEmitsAggregatedUpdateAfterWindow(t *testing.T) {
+	processor, now := newTestProcessor(t, config.EventProcessingConfig{
+		DedupeWindow: time.Minute,
+		ResolveAfter: 10 * time.Minute,
+	})
+
+	first := processor.Process(cpuEvent("e1", "web-1"))[0]
+
+	for i := 0; i < 3; i++ {
+		*now = now.Add(25 * time.Second)
+		processor.Process(cpuEvent("repeat", "web-1"))
+	}
+
+	// The third repeat falls outside the dedupe window
+	stats := processor.GetStats()
+	assert.Equal(t, 2, stats.Deduplicated)
+	assert.Equal(t, 2, stats.Emitted)
+
+	*now = now.Add(25 * time.Second)
+	assert.Empty(t, processor.Process(cpuEvent("repeat", "web-1")))
+
+	firstSeen := first.Metadata[MetadataFirstSeen]
+	resolved := processor.Process(&plugin.TriggerEvent{
+		ID:       "clear",
+		Type:     "cpu_high",
+		Source:   "cpu-monitor",
+		Metadata: map[string]interface{}{MetadataState: "resolved"},
+	})
+	require.Len(t, resolved, 1)
+	assert.Equal(t, "resolved", resolved[0].Metadata[MetadataState])
+	assert.Equal(t, firstSeen, resolved[0].Metadata[MetadataFirstSeen])
+	assert.Equal(t, 5, resolved[0].Metadata[MetadataCount])
+}
+
+func TestEventProcessorEmitsEscalationImmediately(t *testing.T) {
+	processor, now := newTestProcessor(t, config.EventProcessingConfig{
+		DedupeWindow:      time.Minute,
+		ResolveAfter:      10 * time.Minute,
+		FingerprintFields: []string{"host"},
+	})
+
+	require.Len(t, processor.Process(cpuEvent("e1", "web-1")), 1)
+
+	// The same or a lower severity is folded in
+	*now = now.Add(5 * time.Second)
+	assert.Empty(t, processor.Process(cpuEvent("e2", "web-1")))
+	lower := cpuEvent("e3", "web-1")
+	lower.Severity = plugin.SeverityMedium
+	assert.Empty(t, processor.Process(lower))
+
+	// A higher severity is reported within the window
+	*now = now.Add(5 * time.Second)
+	critical := cpuEvent("e4", "web-1")
+	critical.Severity = plugin.SeverityCritical
+	events := processor.Process(critical)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1-4", events[0].ID)
+	assert.Equal(t, plugin.SeverityCritical, events[0].Severity)
+	assert.Equal(t, "firing", events[0].Metadata[MetadataState])
+	assert.Equal(t, 4, events[0].Metadata[MetadataCount])
+
+	// Once reported, it is deduplicated again
+	*now = now.Add(5 * time.Second)
+	critical = cpuEvent("e5", "web-1")
+	critical.Severity = plugin.SeverityCritical
+	assert.Empty(t, processor.Process(critical))
+	assert.Equal(t, 3, processor.GetStats().Deduplicated)
+}
+
+func TestEventProcessorResolvesEvictedEvents(t *testing.T) {
+	processor, now := newTestProcessor(t, config.EventProcessingConfig{
+		DedupeWindow:      time.Minute,
+		ResolveAfter:      10 * time.Minute,
+		MaxTracked:        2,
+		FingerprintFields: []string{"host"},
+	})
+
+	processor.Process(cpuEvent("e1", "web-1"))
+	*now = now.Add(time.Second)
+	processor.Process(cpuEvent("e2", "web-2"))
+	*now = now.Add(time.Second)
+	processor.Process(cpuEvent("e3", "web-1"))
+
+	// Tracking a third event resolves the least recently seen one
+	*now = now.Add(time.Second)
+	events := processor.Process(cpuEvent("e4", "web-3"))
+	require.Len(t, events, 2)
+	assert.Equal(t, "e2-resolved", events[0].ID)
+	assert.Equal(t, "resolved", events[0].Metadata[MetadataState])
+	assert.Equal(t, "e4", events[1].ID)
+	assert.Equal(t, "firing", events[1].Metadata[MetadataState])
+
+	stats := processor.GetStats()
+	assert.Equal(t, 1, stats.Resolved)
+	assert.Equal(t, 2, stats.Active)
+
+	// The evicted event fires anew when it repeats
+	*now = now.Add(time.Second)
+	events = processor.Process(cpuEvent("e5", "web-2"))
+	require.Len(t, events, 2)
+	assert.Equal(t, "e1-resolved", events[0].ID)
+	assert.Equal(t, "e5", events[1].ID)
+}
+
+func TestEventProcessorRateLimitsPerPlugin(t *testing.T) {
+	processor, now := newTestProcessor(t, config.EventProcessingConfig{
+		PluginRateLimit:   2,
+		FingerprintFields: []string{"host"},
+	})
+
+	assert.Len(t, processor.Process(cpuEvent("e1", "a")), 1)
+	assert.Len(t, processor.Process(cpuEvent("e2", "b")), 1)
+	assert.Empty(t, processor.Process(cpuEvent("e3", "c")))
+
+	// Tokens refill over time
+	*now = now.Add(30 * time.Second)
+	assert.Len(t, processor.Process(cpuEvent("e4", "d")), 1)
+
+	assert.Equal(t, 1, processor.GetStats().Throttled)
+}
+
+func TestEventProcessorDisabledPassesThrough(t *testing.T) {
+	processor, err := NewEventProcessor(config.EventProcessingConfig{}, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	event := cpuEvent("e1", "web-1")
+	assert.Equal(t, []*plugin.TriggerEvent{event}, processor.Process(event))
+	assert.Equal(t, []*plugin.TriggerEvent{event}, processor.Process(event))
+}
@@ -0,0 +1,337 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// TriggerReportClient is the orchestrator API used to deliver trigger events
+type TriggerReportClient interface {
+	ReportTriggers(ctx context.Context, req *api.ReportTriggerRequest) (*api.ReportTriggerResponse, error)
+}
+
+// TriggerReporter batches trigger events and delivers them to the orchestrator.
+// A batch is sent when it is full or when the flush interval elapses. Events
+// the orchestrator rejects, or that could not be sent at all, are re-queued
+// until they exceed the maximum number of delivery attempts.
+type TriggerReporter struct {
+	cfg     config.TriggerReportingConfig
+	agent   config.AgentConfig
+	client  TriggerReportClient
+	metrics *Metrics
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	pending  []*pendingTrigger
+	flushNow chan struct{}
+	stats    TriggerReporterStats
+}
+
+// pendingTrigger is a trigger event waiting for delivery
+type pendingTrigger struct {
+	event    *api.TriggerEvent
+	attempts int
+}
+
+// TriggerReporterStats tracks trigger delivery statistics
+type TriggerReporterStats struct {
+	Pending     int       `json:"pending"`
+	Reported    int       `json:"reported"`
+	Failed      int       `json:"failed"`
+	Dropped     int       `json:"dropped"`
+	LastReport  time.Time `json:"last_report"`
+	LastError   string    `json:"last_error,omitempty"`
+	BatchesSent int       `json:"batches_sent"`
+	BatchErrors int       `json:"batch_errors"`
+}
+
+// NewTriggerReporter creates a new trigger reporter
+func NewTriggerReporter(cfg *config.Config, client TriggerReportClient, metrics *Metrics, logger *zap.Logger) (*TriggerReporter, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if client == nil {
+		return nil, fmt.Errorf("orchestrator client is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	reportingCfg := cfg.Sensor.Reporting
+	if reportingCfg.BatchSize <= 0 {
+		reportingCfg.BatchSize = 50
+	}
+	if reportingCfg.FlushInterval <= 0 {
+		reportingCfg.FlushInterval = 5 * time.Second
+	}
+	if reportingCfg.MaxPending <= 0 {
+		reportingCfg.MaxPending = 10000
+	}
+	if reportingCfg.MaxAttempts <= 0 {
+		reportingCfg.MaxAttempts = 5
+	}
+
+	return &TriggerReporter{
+		cfg:      reportingCfg,
+		agent:    cfg.Agent,
+		client:   client,
+		metrics:  metrics,
+		logger:   logger,
+		flushNow: make(chan struct{}, 1),
+	}, nil
+}
+
+// Enqueue queues a trigger event for delivery
+func (r *TriggerReporter) Enqueue(event *plugin.TriggerEvent) {
+	apiEvent := &api.TriggerEvent{
+		ID:        event.ID,
+		Type:      event.Type,
+		Source:    event.Source,
+		Timestamp: event.Timestamp,
+		Data:      event.Data,
+		Metadata:  event.Metadata,
+		Tags:      event.Tags,
+		Severity:  string(event.Severity),
+		AgentID:   r.agent.ID,
+		TenantID:  r.agent.TenantID,
+	}
+
+	r.mu.Lock()
+	r.pending = append(r.pending, &pendingTrigger{event: apiEvent})
+	dropped := r.trimLocked()
+	full := len(r.pending) >= r.cfg.BatchSize
+	r.mu.Unlock()
+
+	r.recordDropped(dropped)
+
+	if full {
+		select {
+		case r.flushNow <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// trimLocked drops the oldest events beyond MaxPending; the caller must hold the lock
+func (r *TriggerReporter) trimLocked() int {
+	excess := len(r.pending) - r.cfg.MaxPending
+	if excess <= 0 {
+		return 0
+	}
+
+	r.pending = r.pending[excess:]
+	r.stats.Dropped += excess
+	return excess
+}
+
+// recordDropped logs and counts events dropped because the queue overflowed
+func (r *TriggerReporter) recordDropped(count int) {
+	if count == 0 {
+		return
+	}
+
+	r.logger.Warn("Trigger report queue full, dropping oldest events",
+		zap.Int("dropped", count),
+		zap.Int("max_pending", r.cfg.MaxPending))
+
+	if r.metrics != nil {
+		r.metrics.RecordEventsDropped(dropReasonReportQueueFull, count)
+	}
+}
+
+// Run delivers batches until the context is cancelled, then delivers what is
+// still pending for up to one more flush interval
+func (r *TriggerReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	r.logger.Debug("Starting trigger reporter",
+		zap.Int("batch_size", r.cfg.BatchSize),
+		zap.Duration("flush_interval", r.cfg.FlushInterval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain()
+			return
+		case <-ticker.C:
+			r.Flush(ctx)
+		case <-r.flushNow:
+			r.Flush(ctx)
+		}
+	}
+}
+
+// drain delivers pending events in batches on shutdown, until the queue is
+// empty or the flush interval has passed, and drops what is left
+func (r *TriggerReporter) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.FlushInterval)
+	defer cancel()
+
+	for r.PendingCount() > 0 && ctx.Err() == nil {
+		if _, err := r.sendBatch(ctx); err != nil {
+			r.logger.Warn("Failed to deliver pending trigger events on shutdown",
+				zap.Int("pending", r.PendingCount()),
+				zap.Error(err))
+		}
+	}
+
+	r.mu.Lock()
+	left := len(r.pending)
+	r.pending = nil
+	r.stats.Dropped += left
+	r.mu.Unlock()
+
+	if left == 0 {
+		return
+	}
+	r.logger.Warn("Dropping trigger events left undelivered on shutdown",
+		zap.Int("dropped", left))
+	if r.metrics != nil {
+		r.metrics.RecordEventsDropped(dropReasonShutdown, left)
+	}
+}
+
+// Flush sends pending events in batches until the queue is empty or a batch
+// has failures; re-queued events wait for the next flush
+func (r *TriggerReporter) Flush(ctx context.Context) {
+	for r.PendingCount() > 0 {
+		requeued, err := r.sendBatch(ctx)
+		if err != nil {
+			r.logger.Warn("Failed to report trigger events, will retry",
+				zap.Int("pending", r.PendingCount()),
+				zap.Error(err))
+			return
+		}
+		if requeued > 0 {
+			return
+		}
+	}
+}
+
+// sendBatch delivers up to BatchSize pending events and re-queues failures,
+// returning the number of events that were re-queued
+func (r *TriggerReporter) sendBatch(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	n := len(r.pending)
+	if n > r.cfg.BatchSize {
+		n = r.cfg.BatchSize
+	}
+	batch := make([]*pendingTrigger, n)
+	copy(batch, r.pending[:n])
+	r.pending = r.pending[n:]
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	events := make([]*api.TriggerEvent, len(batch))
+	for i, p := range batch {
+		p.attempts++
+		events[i] = p.event
+	}
+
+	resp, err := r.client.ReportTriggers(ctx, &api.ReportTriggerRequest{
+		AgentID: r.agent.ID,
+		Events:  events,
+	})
+	if err != nil {
+		r.requeue(batch, err.Error())
+		r.mu.Lock()
+		r.stats.BatchErrors++
+		r.stats.LastError = err.Error()
+		r.mu.Unlock()
+		return len(batch), err
+	}
+
+	// Re-queue only the events the orchestrator rejected
+	failedIDs := make(map[string]bool, len(resp.FailedEvents))
+	for _, id := range resp.FailedEvents {
+		failedIDs[id] = true
+	}
+
+	var failed []*pendingTrigger
+	for _, p := range batch {
+		if failedIDs[p.event.ID] {
+			failed = append(failed, p)
+		}
+	}
+	delivered := len(batch) - len(failed)
+
+	r.mu.Lock()
+	r.stats.BatchesSent++
+	r.stats.Reported += delivered
+	r.stats.LastReport = time.Now()
+	r.mu.Unlock()
+
+	if len(failed) > 0 {
+		r.logger.Warn("Orchestrator rejected some trigger events",
+			zap.Int("failed", len(failed)),
+			zap.Int("processed", resp.ProcessedEvents))
+		r.requeue(failed, "rejected by orchestrator")
+	}
+
+	r.logger.Debug("Trigger events reported",
+		zap.Int("delivered", delivered),
+		zap.Int("failed", len(failed)))
+
+	return len(failed), nil
+}
+
+// requeue puts events back at the front of the queue, dropping those that
+// have used up their delivery attempts
+func (r *TriggerReporter) requeue(batch []*pendingTrigger, reason string) {
+	retry := make([]*pendingTrigger, 0, len(batch))
+	exhausted := 0
+	for _, p := range batch {
+		if p.attempts >= r.cfg.MaxAttempts {
+			exhausted++
+			r.logger.Error("Giving up on trigger event after repeated delivery failures",
+				zap.String("event_id", p.event.ID),
+				zap.Int("attempts", p.attempts),
+				zap.String("reason", reason))
+			continue
+		}
+		retry = append(retry, p)
+	}
+
+	r.mu.Lock()
+	r.pending = append(retry, r.pending...)
+	r.stats.Failed += exhausted
+	dropped := r.trimLocked()
+	r.mu.Unlock()
+
+	r.recordDropped(dropped)
+
+	if r.metrics != nil {
+		for i := 0; i < exhausted; i++ {
+			r.metrics.IncrementEventProcessingErrors()
+		}
+	}
+}
+
+// PendingCount returns the number of events waiting for delivery
+func (r *TriggerReporter) PendingCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// GetStats returns trigger delivery statistics
+func (r *TriggerReporter) GetStats() TriggerReporterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Pending = len(r.pending)
+	return stats
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// fakeReportClient records reported batches and rejects configured event IDs
+type fakeReportClient struct {
+	mu      sync.Mutex
+	batches [][]string
+	reject  map[string]bool
+	err     error
+}
+
+func (f *fakeReportClient) ReportTriggers(ctx context.Context, req *api.ReportTriggerRequest) (*api.ReportTriggerResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var ids, failed []string
+	for _, event := range req.Events {
+		ids = append(ids, event.ID)
+		if f.reject[event.ID] {
+			failed = append(failed, event.ID)
+		}
+	}
+	f.batches = append(f.batches, ids)
+
+	return &api.ReportTriggerResponse{
+		ProcessedEvents: len(ids) - len(failed),
+		FailedEvents:    failed,
+		ServerTime:      time.Now(),
+	}, nil
+}
+
+func newTestReporter(t *testing.T, client TriggerReportClient, reporting config.TriggerReportingConfig) *TriggerReporter {
+	cfg := &config.Config{
+		Agent:  config.AgentConfig{ID: "test-sensor", TenantID: "test-tenant"},
+		Sensor: config.SensorConfig{Reporting: reporting},
+	}
+	reporter, err := NewTriggerReporter(cfg, client, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return reporter
+}
+
+func testTriggerEvent(id string) *plugin.TriggerEvent {
+	return &plugin.TriggerEvent{
+		ID:        id,
+		Type:      "cpu_high",
+		Source:    "cpu-monitor",
+		Timestamp: time.Now(),
+		Severity:  plugin.SeverityHigh,
+	}
+}
+
+func TestTriggerReporterBatchesByCount(t *testing.T) {
+	client := &fakeReportClient{}
+	reporter := newTestReporter(t, client, config.TriggerReportingConfig{BatchSize: 2})
+
+	for i := 0; i < 5; i++ {
+		reporter.Enqueue(testTriggerEvent(fmt.Sprintf("event-%d", i)))
+	}
+	reporter.Flush(context.Background())
+
+	assert.Equal(t, [][]string{{"event-0", "event-1"}, {"event-2", "event-3"}, {"event-4"}}, client.batches)
+
+	stats := reporter.GetStats()
+	assert.Equal(t, 5, stats.Reported)
+	assert.Equal(t, 0, stats.Pending)
+}
+
+func TestTriggerReporterRequeuesFailedEvents(t *testing.T) {
+	client := &fakeReportClient{reject: map[string]bool{"event-1": true}}
+	reporter := newTestReporter(t, client, config.TriggerReportingConfig{BatchSize: 10, MaxAttempts: 2})
+
+	reporter.Enqueue(testTriggerEvent("event-0"))
+	reporter.Enqueue(testTriggerEvent("event-1"))
+
+	reporter.Flush(context.Background())
+	assert.Equal(t, 1, reporter.PendingCount())
+
+	// The rejected event is retried once more and then given up on
+	reporter.Flush(context.Background())
+	assert.Equal(t, [][]string{{"event-0", "event-1"}, {"event-1"}}, client.batches)
+
+	stats := reporter.GetStats()
+	assert.Equal(t, 1, stats.Reported)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, 0, stats.Pending)
+}
+
+func TestTriggerReporterKeepsEventsWhenOrchestratorUnavailable(t *testing.T) {
+	client := &fakeReportClient{err: errors.New("connection refused")}
+	reporter := newTestReporter(t, client, config.TriggerReportingConfig{BatchSize: 10, MaxPending: 2})
+
+	reporter.Enqueue(testTriggerEvent("event-0"))
+	reporter.Enqueue(testTriggerEvent("event-1"))
+	reporter.Enqueue(testTriggerEvent("event-2"))
+
+	reporter.Flush(context.Background())
+
+	stats := reporter.GetStats()
+	assert.Equal(t, 2, stats.Pending)
+	assert.Equal(t, 1, stats.Dropped)
+	assert.Equal(t, 1, stats.BatchErrors)
+
+	// Delivery resumes once the orchestrator is back
+	client.mu.Lock()
+	client.err = nil
+	client.mu.Unlock()
+
+	reporter.Flush(context.Background())
+	assert.Equal(t, []string{"event-1", "event-2"}, client.batches[0])
+	assert.Equal(t, 0, reporter.PendingCount())
+}
+
+func TestTriggerReporterFlushesOnInterval(t *testing.T) {
+	client := &fakeReportClient{}
+	reporter := newTestReporter(t, client, config.TriggerReportingConfig{BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reporter.Run(ctx)
+
+	reporter.Enqueue(testTriggerEvent("event-0"))
+
+	assert.Eventually(t, func() bool {
+		return reporter.GetStats().Reported == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+// blockingReportClient never answers before the request is cancelled
+type blockingReportClient struct{}
+
+func (blockingReportClient) ReportTriggers(ctx context.Context, req *api.ReportTriggerRequest) (*api.ReportTriggerResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTriggerReporterDrainsOnShutdown(t *testing.T) {
+	client := &fakeReportClient{}
+	reporter := newTestReporter(t, client, config.TriggerReportingConfig{BatchSize: 2})
+
+	for i := 0; i < 5; i++ {
+		reporter.Enqueue(testTriggerEvent(fmt.Sprintf("event-%d", i)))
+	}
+	reporter.drain()
+
+	assert.Equal(t, [][]string{{"event-0", "event-1"}, {"event-2", "event-3"}, {"event-4"}}, client.batches)
+	stats := reporter.GetStats()
+	assert.Equal(t, 5, stats.Reported)
+	assert.Equal(t, 0, stats.Dropped)
+}
+
+func TestTriggerReporterCountsEventsLeftOnShutdown(t *testing.T) {
+	reporter := newTestReporter(t, blockingReportClient{}, config.TriggerReportingConfig{
+		BatchSize:     2,
+		FlushInterval: 20 * time.Millisecond,
+	})
+
+	for i := 0; i < 5; i++ {
+		reporter.Enqueue(testTriggerEvent(fmt.Sprintf("event-%d", i)))
+	}
+	reporter.drain()
+
+	stats := reporter.GetStats()
+	assert.Equal(t, 0, stats.Pending)
+	assert.Equal(t, 5, stats.Dropped)
+	assert.Equal(t, 1, stats.BatchErrors)
+}
@@ -0,0 +1,436 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Restart policies for trigger plugins
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+)
+
+// States of a supervised trigger plugin
+const (
+	SupervisorStateRunning     = "running"
+	SupervisorStateRestarting  = "restarting"
+	SupervisorStateStopped     = "stopped"
+	SupervisorStateQuarantined = "quarantined"
+)
+
+// TriggerFunc acts on the trigger plugin with the given ID
+type TriggerFunc func(ctx context.Context, pluginID string) error
+
+// TriggerSupervisor restarts trigger plugins that stop or crash according to
+// their restart policy. Restarts back off exponentially, and a plugin that
+// keeps crashing is quarantined rather than restarted forever. Every state
+// change is published on the event bus, from where it reaches the
+// orchestrator with the next heartbeat.
+type TriggerSupervisor struct {
+	cfg     config.TriggerSupervisorConfig
+	restart TriggerFunc
+	stop    TriggerFunc
+	events  *agent.EventBus
+	logger  *zap.Logger
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	plugins map[string]*supervisedTrigger
+	closed  bool
+}
+
+// supervisedTrigger is the supervision state of a trigger plugin
+type supervisedTrigger struct {
+	state    string
+	restarts []time.Time // restarts within the restart window
+	total    int
+	timer    *time.Timer
+
+	lastError        string
+	lastExit         time.Time
+	nextRestart      time.Time
+	quarantinedUntil time.Time
+}
+
+// SupervisedTriggerStatus reports the supervision state of a trigger plugin
+type SupervisedTriggerStatus struct {
+	State            string     `json:"state"`
+	Policy           string     `json:"policy"`
+	Restarts         int        `json:"restarts"`
+	RecentRestarts   int        `json:"recent_restarts"`
+	LastError        string     `json:"last_error,omitempty"`
+	LastExit         *time.Time `json:"last_exit,omitempty"`
+	NextRestart      *time.Time `json:"next_restart,omitempty"`
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// NewTriggerSupervisor creates a supervisor that restarts trigger plugins
+// with restart and stops quarantined ones with stop. State changes are
+// published on events when it is not nil.
+func NewTriggerSupervisor(cfg config.TriggerSupervisorConfig, restart, stop TriggerFunc, events *agent.EventBus, logger *zap.Logger) (*TriggerSupervisor, error) {
+	if restart == nil || stop == nil {
+		return nil, fmt.Errorf("restart and stop functions are required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if cfg.RestartPolicy == "" {
+		cfg.RestartPolicy = RestartOnFailure
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+	if cfg.MaxRestarts <= 0 {
+		cfg.MaxRestarts = 5
+	}
+	if cfg.RestartWindow <= 0 {
+		cfg.RestartWindow = 10 * time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TriggerSupervisor{
+		cfg:     cfg,
+		restart: restart,
+		stop:    stop,
+		events:  events,
+		logger:  logger,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+		plugins: make(map[string]*supervisedTrigger),
+	}, nil
+}
+
+// Running records that a trigger plugin has been started and is monitored
+func (s *TriggerSupervisor) Running(pluginID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.plugins[pluginID]
+	if !ok {
+		p = &supervisedTrigger{}
+		s.plugins[pluginID] = p
+	}
+	s.cancelTimerLocked(p)
+	p.state = SupervisorStateRunning
+	p.nextRestart = time.Time{}
+	p.quarantinedUntil = time.Time{}
+}
+
+// Exited records that a running trigger plugin stopped, with the error it
+// failed with or nil when it stopped cleanly, and restarts or quarantines it
+// as its policy requires. Exits of plugins that are not running are ignored,
+// so the same crash may safely be reported more than once.
+func (s *TriggerSupervisor) Exited(pluginID string, exitErr error) {
+	s.mu.Lock()
+	p, ok := s.plugins[pluginID]
+	if !ok || s.closed || p.state != SupervisorStateRunning {
+		s.mu.Unlock()
+		return
+	}
+
+	now := s.now()
+	p.lastExit = now
+	p.lastError = ""
+	severity := plugin.SeverityLow
+	if exitErr != nil {
+		p.lastError = exitErr.Error()
+		severity = plugin.SeverityHigh
+	}
+
+	policy := s.policy(pluginID)
+	if policy == RestartNever || (policy == RestartOnFailure && exitErr == nil) {
+		p.state = SupervisorStateStopped
+		data := map[string]interface{}{
+			"error":    p.lastError,
+			"policy":   policy,
+			"restarts": p.total,
+		}
+		s.mu.Unlock()
+
+		s.logger.Warn("Trigger plugin exited and will not be restarted",
+			zap.String("plugin_id", pluginID),
+			zap.String("policy", policy),
+			zap.Error(exitErr))
+		s.publish(agent.PluginEventExited, pluginID, severity, data)
+		return
+	}
+
+	// Only restarts within the window count towards quarantine
+	cutoff := now.Add(-s.cfg.RestartWindow)
+	recent := p.restarts[:0]
+	for _, restartedAt := range p.restarts {
+		if restartedAt.After(cutoff) {
+			recent = append(recent, restartedAt)
+		}
+	}
+	p.restarts = recent
+
+	if len(p.restarts) >= s.cfg.MaxRestarts {
+		p.state = SupervisorStateQuarantined
+		data := map[string]interface{}{
+			"error":           p.lastError,
+			"restarts":        p.total,
+			"recent_restarts": len(p.restarts),
+			"restart_window":  s.cfg.RestartWindow.String(),
+		}
+		if s.cfg.QuarantineDuration > 0 {
+			p.quarantinedUntil = now.Add(s.cfg.QuarantineDuration)
+			data["quarantined_until"] = p.quarantinedUntil
+			s.scheduleLocked(pluginID, p, s.cfg.QuarantineDuration, s.release)
+		}
+		s.mu.Unlock()
+
+		s.logger.Error("Trigger plugin keeps crashing, quarantining it",
+			zap.String("plugin_id", pluginID),
+			zap.Int("recent_restarts", len(recent)),
+			zap.Duration("restart_window", s.cfg.RestartWindow),
+			zap.Error(exitErr))
+		if err := s.stop(s.ctx, pluginID); err != nil {
+			s.logger.Warn("Failed to stop quarantined trigger plugin",
+				zap.String("plugin_id", pluginID),
+				zap.Error(err))
+		}
+		s.publish(agent.PluginEventQuarantined, pluginID, plugin.SeverityCritical, data)
+		return
+	}
+
+	delay := s.backoff(len(p.restarts))
+	p.state = SupervisorStateRestarting
+	p.nextRestart = now.Add(delay)
+	s.scheduleLocked(pluginID, p, delay, s.restartPlugin)
+	data := map[string]interface{}{
+		"error":    p.lastError,
+		"policy":   policy,
+		"attempt":  len(p.restarts) + 1,
+		"delay":    delay.String(),
+		"restarts": p.total,
+	}
+	s.mu.Unlock()
+
+	s.logger.Warn("Trigger plugin exited, restarting",
+		zap.String("plugin_id", pluginID),
+		zap.Duration("restart_in", delay),
+		zap.Error(exitErr))
+	s.publish(agent.PluginEventRestarting, pluginID, severity, data)
+}
+
+// Reset clears the restart history of a trigger plugin, lifting its
+// quarantine, e.g. when a new version of it is loaded
+func (s *TriggerSupervisor) Reset(pluginID string) {
+	s.mu.Lock()
+	p, ok := s.plugins[pluginID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	s.cancelTimerLocked(p)
+	quarantined := p.state == SupervisorStateQuarantined
+	p.restarts = nil
+	p.state = SupervisorStateStopped
+	p.nextRestart = time.Time{}
+	p.quarantinedUntil = time.Time{}
+	s.mu.Unlock()
+
+	if quarantined {
+		s.logger.Info("Trigger plugin released from quarantine", zap.String("plugin_id", pluginID))
+		s.publish(agent.PluginEventReleased, pluginID, plugin.SeverityLow, nil)
+	}
+}
+
+// Forget stops supervising a trigger plugin
+func (s *TriggerSupervisor) Forget(pluginID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.plugins[pluginID]; ok {
+		s.cancelTimerLocked(p)
+		delete(s.plugins, pluginID)
+	}
+}
+
+// Stop cancels pending restarts and waits for restarts in progress
+func (s *TriggerSupervisor) Stop() {
+	s.mu.Lock()
+	s.closed = true
+	for _, p := range s.plugins {
+		s.cancelTimerLocked(p)
+	}
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+}
+
+// GetStatus returns the supervision state of every trigger plugin
+func (s *TriggerSupervisor) GetStatus() map[string]*SupervisedTriggerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-s.cfg.RestartWindow)
+	status := make(map[string]*SupervisedTriggerStatus, len(s.plugins))
+	for id, p := range s.plugins {
+		recent := 0
+		for _, restartedAt := range p.restarts {
+			if restartedAt.After(cutoff) {
+				recent++
+			}
+		}
+		status[id] = &SupervisedTriggerStatus{
+			State:            p.state,
+			Policy:           s.policy(id),
+			Restarts:         p.total,
+			RecentRestarts:   recent,
+			LastError:        p.lastError,
+			LastExit:         optionalTime(p.lastExit),
+			NextRestart:      optionalTime(p.nextRestart),
+			QuarantinedUntil: optionalTime(p.quarantinedUntil),
+		}
+	}
+	return status
+}
+
+// Quarantined returns the IDs of the quarantined trigger plugins
+func (s *TriggerSupervisor) Quarantined() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id, p := range s.plugins {
+		if p.state == SupervisorStateQuarantined {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// restartPlugin restarts a plugin whose backoff has elapsed
+func (s *TriggerSupervisor) restartPlugin(pluginID string, p *supervisedTrigger) {
+	s.mu.Lock()
+	if s.closed || s.plugins[pluginID] != p || p.state != SupervisorStateRestarting {
+		s.mu.Unlock()
+		return
+	}
+	// Running before the restart returns, so an immediate crash is seen
+	p.state = SupervisorStateRunning
+	p.nextRestart = time.Time{}
+	p.restarts = append(p.restarts, s.now())
+	p.total++
+	total := p.total
+	s.mu.Unlock()
+
+	s.start(pluginID, total)
+}
+
+// release restarts a plugin whose quarantine has elapsed
+func (s *TriggerSupervisor) release(pluginID string, p *supervisedTrigger) {
+	s.mu.Lock()
+	if s.closed || s.plugins[pluginID] != p || p.state != SupervisorStateQuarantined {
+		s.mu.Unlock()
+		return
+	}
+	p.state = SupervisorStateRunning
+	p.restarts = nil
+	p.quarantinedUntil = time.Time{}
+	total := p.total
+	s.mu.Unlock()
+
+	s.logger.Info("Trigger plugin quarantine elapsed, restarting it", zap.String("plugin_id", pluginID))
+	s.publish(agent.PluginEventReleased, pluginID, plugin.SeverityLow, nil)
+	s.start(pluginID, total)
+}
+
+// start restarts a plugin and publishes the restart once it is running. A
+// failed restart counts as a crash.
+func (s *TriggerSupervisor) start(pluginID string, restarts int) {
+	if err := s.restart(s.ctx, pluginID); err != nil {
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.Exited(pluginID, fmt.Errorf("restart failed: %w", err))
+		return
+	}
+
+	s.logger.Info("Trigger plugin restarted",
+		zap.String("plugin_id", pluginID),
+		zap.Int("restarts", restarts))
+	s.publish(agent.PluginEventRestarted, pluginID, plugin.SeverityLow, map[string]interface{}{"restarts": restarts})
+}
+
+// scheduleLocked runs fn for a plugin after delay; the caller holds s.mu
+func (s *TriggerSupervisor) scheduleLocked(pluginID string, p *supervisedTrigger, delay time.Duration, fn func(string, *supervisedTrigger)) {
+	s.cancelTimerLocked(p)
+	s.wg.Add(1)
+	p.timer = time.AfterFunc(delay, func() {
+		defer s.wg.Done()
+		fn(pluginID, p)
+	})
+}
+
+// cancelTimerLocked cancels a plugin's pending restart; the caller holds s.mu
+func (s *TriggerSupervisor) cancelTimerLocked(p *supervisedTrigger) {
+	if p.timer != nil && p.timer.Stop() {
+		s.wg.Done()
+	}
+	p.timer = nil
+}
+
+// backoff returns the delay before a plugin's next restart
+func (s *TriggerSupervisor) backoff(recentRestarts int) time.Duration {
+	delay := s.cfg.InitialBackoff
+	for i := 0; i < recentRestarts && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.MaxBackoff {
+		delay = s.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// policy returns the restart policy of a plugin
+func (s *TriggerSupervisor) policy(pluginID string) string {
+	if policy, ok := s.cfg.Policies[pluginID]; ok && policy != "" {
+		return policy
+	}
+	return s.cfg.RestartPolicy
+}
+
+// publish publishes a supervision event when an event bus is set
+func (s *TriggerSupervisor) publish(eventType, pluginID string, severity plugin.Severity, data map[string]interface{}) {
+	if s.events != nil {
+		s.events.Emit(eventType, pluginID, severity, data)
+	}
+}
+
+// optionalTime returns nil for the zero time so it is omitted from JSON
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,325 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// fakeTriggers records the restarts and stops requested by a supervisor
+type fakeTriggers struct {
+	mu         sync.Mutex
+	restarts   []string
+	stops      []string
+	restartErr error
+}
+
+func (f *fakeTriggers) restart(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restarts = append(f.restarts, id)
+	return f.restartErr
+}
+
+func (f *fakeTriggers) stop(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stops = append(f.stops, id)
+	return nil
+}
+
+func (f *fakeTriggers) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.restarts), len(f.stops)
+}
+
+func newTestSupervisor(t *testing.T, cfg config.TriggerSupervisorConfig, triggers *fakeTriggers) (*TriggerSupervisor, <-chan *plugin.PluginEvent) {
+	bus, err := sharedagent.NewEventBus(sharedagent.EventBusOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	events := make(chan *plugin.PluginEvent, 100)
+	require.NoError(t, bus.Subscribe("test", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	})))
+
+	supervisor, err := NewTriggerSupervisor(cfg, triggers.restart, triggers.stop, bus, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		supervisor.Stop()
+		_ = bus.Close(context.Background())
+	})
+	return supervisor, events
+}
+
+func nextSupervisorEvent(t *testing.T, events <-chan *plugin.PluginEvent, eventType string) *plugin.PluginEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		require.Equal(t, eventType, event.Type)
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s event", eventType)
+		return nil
+	}
+}
+
+func TestTriggerSupervisor_RestartsAndQuarantines(t *testing.T) {
+	triggers := &fakeTriggers{}
+	supervisor, events := newTestSupervisor(t, config.TriggerSupervisorConfig{
+		RestartPolicy:      RestartOnFailure,
+		InitialBackoff:     5 * time.Millisecond,
+		MaxBackoff:         10 * time.Millisecond,
+		MaxRestarts:        2,
+		RestartWindow:      time.Minute,
+		QuarantineDuration: 500 * time.Millisecond,
+	}, triggers)
+
+	supervisor.Running("cpu")
+	crash := errors.New("exit status 1")
+
+	for i := 1; i <= 2; i++ {
+		supervisor.Exited("cpu", crash)
+		// Reported twice, e.g. by the monitor and the health check
+		supervisor.Exited("cpu", crash)
+
+		restarting := nextSupervisorEvent(t, events, sharedagent.PluginEventRestarting)
+		assert.Equal(t, "exit status 1", restarting.Data["error"])
+		assert.Equal(t, i, restarting.Data["attempt"])
+		restarted := nextSupervisorEvent(t, events, sharedagent.PluginEventRestarted)
+		assert.Equal(t, i, restarted.Data["restarts"])
+	}
+
+	supervisor.Exited("cpu", crash)
+	quarantined := nextSupervisorEvent(t, events, sharedagent.PluginEventQuarantined)
+	assert.Equal(t, plugin.SeverityCritical, quarantined.Severity)
+	assert.Equal(t, 2, quarantined.Data["recent_restarts"])
+	restarts, stops := triggers.counts()
+	assert.Equal(t, 2, restarts)
+	assert.Equal(t, 1, stops)
+
+	status := supervisor.GetStatus()["cpu"]
+	assert.Equal(t, SupervisorStateQuarantined, status.State)
+	assert.NotNil(t, status.QuarantinedUntil)
+	assert.Equal(t, []string{"cpu"}, supervisor.Quarantined())
+
+	// The quarantine elapses and the plugin gets a fresh start
+	nextSupervisorEvent(t, events, sharedagent.PluginEventReleased)
+	nextSupervisorEvent(t, events, sharedagent.PluginEventRestarted)
+	status = supervisor.GetStatus()["cpu"]
+	assert.Equal(t, SupervisorStateRunning, status.State)
+	assert.Equal(t, 0, status.RecentRestarts)
+	assert.Equal(t, 2, status.Restarts)
+	assert.Empty(t, supervisor.Quarantined())
+}
+
+func TestTriggerSupervisor_Policies(t *testing.T) {
+	triggers := &fakeTriggers{}
+	supervisor, events := newTestSupervisor(t, config.TriggerSupervisorConfig{
+		RestartPolicy:  RestartOnFailure,
+		Policies:       map[string]string{"disk": RestartNever, "net": RestartAlways},
+		InitialBackoff: 5 * time.Millisecond,
+	}, triggers)
+
+	// A clean exit is only restarted under the always policy
+	supervisor.Running("cpu")
+	supervisor.Exited("cpu", nil)
+	exited := nextSupervisorEvent(t, events, sharedagent.PluginEventExited)
+	assert.Equal(t, "cpu", exited.PluginID)
+	assert.Equal(t, plugin.SeverityLow, exited.Severity)
+	assert.Equal(t, SupervisorStateStopped, supervisor.GetStatus()["cpu"].State)
+
+	supervisor.Running("net")
+	supervisor.Exited("net", nil)
+	nextSupervisorEvent(t, events, sharedagent.PluginEventRestarting)
+	nextSupervisorEvent(t, events, sharedagent.PluginEventRestarted)
+
+	supervisor.Running("disk")
+	supervisor.Exited("disk", errors.New("segfault"))
+	exited = nextSupervisorEvent(t, events, sharedagent.PluginEventExited)
+	assert.Equal(t, "segfault", exited.Data["error"])
+	assert.Equal(t, RestartNever, supervisor.GetStatus()["disk"].Policy)
+
+	restarts, _ := triggers.counts()
+	assert.Equal(t, 1, restarts)
+}
+
+func TestTriggerSupervisor_FailedRestartsLeadToQuarantine(t *testing.T) {
+	triggers := &fakeTriggers{restartErr: errors.New("binary missing")}
+	supervisor, events := newTestSupervisor(t, config.TriggerSupervisorConfig{
+		InitialBackoff: 5 * time.Millisecond,
+		MaxRestarts:    3,
+	}, triggers)
+
+	supervisor.Running("cpu")
+	supervisor.Exited("cpu", errors.New("exit status 2"))
+
+	for i := 0; i < 3; i++ {
+		nextSupervisorEvent(t, events, sharedagent.PluginEventRestarting)
+	}
+	quarantined := nextSupervisorEvent(t, events, sharedagent.PluginEventQuarantined)
+	assert.Equal(t, "restart failed: binary missing", quarantined.Data["error"])
+	assert.Nil(t, supervisor.GetStatus()["cpu"].QuarantinedUntil)
+
+	// Loading a new version lifts the quarantine
+	supervisor.Reset("cpu")
+	nextSupervisorEvent(t, events, sharedagent.PluginEventReleased)
+	supervisor.Running("cpu")
+	assert.Equal(t, SupervisorStateRunning, supervisor.GetStatus()["cpu"].State)
+}
+
+func TestTriggerSupervisor_Backoff(t *testing.T) {
+	supervisor, err := NewTriggerSupervisor(config.TriggerSupervisorConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}, (&fakeTriggers{}).restart, (&fakeTriggers{}).stop, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, supervisor.backoff(0))
+	assert.Equal(t, 2*time.Second, supervisor.backoff(1))
+	assert.Equal(t, 4*time.Second, supervisor.backoff(2))
+	assert.Equal(t, 5*time.Second, supervisor.backoff(3))
+	assert.Equal(t, 5*time.Second, supervisor.backoff(50))
+
+	// Pending restarts are cancelled on stop
+	supervisor.Running("cpu")
+	supervisor.Exited("cpu", errors.New("crash"))
+	assert.Equal(t, SupervisorStateRestarting, supervisor.GetStatus()["cpu"].State)
+	supervisor.Stop()
+	supervisor.Exited("cpu", errors.New("crash"))
+}
+
+const crashingTriggerManifest = `
+plugin:
+  id: "crashing-trigger"
+  name: "Crashing Trigger"
+  version: "1.0.0"
+  type: "trigger"
+  runtime:
+    type: "bash"
+    entry_point: "run.sh"
+`
+
+// superviseCrashingTrigger runs a real trigger process that crashes shortly
+// after every start under a supervisor, reporting crashes the way the
+// sensor agent's health check does. It returns the plugin and a function
+// counting how often the process was started.
+func superviseCrashingTrigger(t *testing.T, cfg config.TriggerSupervisorConfig) (*plugin.SubprocessTriggerPlugin, *TriggerSupervisor, <-chan *plugin.PluginEvent, func() int) {
+	dir := t.TempDir()
+	runs := filepath.Join(t.TempDir(), "runs")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(crashingTriggerManifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo run >> \"$RUNS_FILE\"\nsleep 0.1\nexit 1\n"), 0755))
+	manifest, err := plugin.LoadManifest(dir)
+	require.NoError(t, err)
+
+	p, err := plugin.NewSubprocessTriggerPlugin(dir, manifest, plugin.SubprocessTriggerOptions{
+		RestartDelay:   5 * time.Millisecond,
+		DisableRestart: true,
+		Environment:    map[string]string{"RUNS_FILE": runs},
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, p.Initialize(context.Background(), nil))
+
+	bus, err := sharedagent.NewEventBus(sharedagent.EventBusOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	events := make(chan *plugin.PluginEvent, 100)
+	require.NoError(t, bus.Subscribe("test", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	})))
+
+	supervisor, err := NewTriggerSupervisor(cfg,
+		func(ctx context.Context, id string) error { return p.Start(ctx) },
+		func(ctx context.Context, id string) error { return p.Stop(ctx) },
+		bus, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if p.GetStatus() == plugin.StatusError {
+					supervisor.Exited(manifest.ID, triggerFailure(p))
+				}
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		supervisor.Stop()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer stopCancel()
+		_ = p.Stop(stopCtx)
+		_ = bus.Close(context.Background())
+	})
+
+	supervisor.Running(manifest.ID)
+	require.NoError(t, p.Start(context.Background()))
+
+	countRuns := func() int {
+		data, err := os.ReadFile(runs)
+		if err != nil {
+			return 0
+		}
+		return strings.Count(string(data), "run\n")
+	}
+	return p, supervisor, events, countRuns
+}
+
+func TestTriggerSupervisor_OwnsSubprocessRestarts(t *testing.T) {
+	p, supervisor, events, runs := superviseCrashingTrigger(t, config.TriggerSupervisorConfig{
+		RestartPolicy:  RestartOnFailure,
+		InitialBackoff: 5 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		MaxRestarts:    2,
+		RestartWindow:  time.Minute,
+	})
+
+	for i := 1; i <= 2; i++ {
+		restarting := nextSupervisorEvent(t, events, sharedagent.PluginEventRestarting)
+		assert.Equal(t, "exit status 1", restarting.Data["error"])
+		nextSupervisorEvent(t, events, sharedagent.PluginEventRestarted)
+	}
+	nextSupervisorEvent(t, events, sharedagent.PluginEventQuarantined)
+
+	// The process is not restarted behind the supervisor's back
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 3, runs())
+	assert.Equal(t, plugin.StatusStopped, p.GetStatus())
+	assert.Equal(t, SupervisorStateQuarantined, supervisor.GetStatus()["crashing-trigger"].State)
+}
+
+func TestTriggerSupervisor_NeverRestartsSubprocess(t *testing.T) {
+	p, supervisor, events, runs := superviseCrashingTrigger(t, config.TriggerSupervisorConfig{
+		RestartPolicy: RestartNever,
+	})
+
+	exited := nextSupervisorEvent(t, events, sharedagent.PluginEventExited)
+	assert.Equal(t, "exit status 1", exited.Data["error"])
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, runs())
+	assert.Equal(t, plugin.StatusError, p.GetStatus())
+	assert.Equal(t, SupervisorStateStopped, supervisor.GetStatus()["crashing-trigger"].State)
+}
@@ -0,0 +1,294 @@
+// Package rules filters and enriches trigger events on the sensor using a
+// declarative rules file, so noise can be tuned without redeploying plugins.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/output"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Metadata keys set on events by the rule engine
+const (
+	MetadataRoutes       = output.MetadataRoutes
+	MetadataMatchedRules = "matched_rules"
+)
+
+// Decision records what a rule did, or would have done in dry-run mode
+type Decision struct {
+	Rule    string `json:"rule"`
+	Actions string `json:"actions"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// Result is the outcome of applying the rules to an event
+type Result struct {
+	// Event is the event after the rules were applied; nil if it was dropped
+	Event     *plugin.TriggerEvent
+	Dropped   bool
+	Decisions []Decision
+}
+
+// Stats tracks rule engine statistics
+type Stats struct {
+	Rules      int            `json:"rules"`
+	DryRun     bool           `json:"dry_run"`
+	Evaluated  int            `json:"evaluated"`
+	Dropped    int            `json:"dropped"`
+	Modified   int            `json:"modified"`
+	Matches    map[string]int `json:"matches"`
+	LastReload time.Time      `json:"last_reload"`
+	LastError  string         `json:"last_error,omitempty"`
+}
+
+// Engine applies the rules from a rules file to trigger events. The file is
+// reloaded when it changes; if a new version is invalid the previous rules
+// stay in effect.
+type Engine struct {
+	cfg    config.EventRulesConfig
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	rules   []*compiledRule
+	modTime time.Time
+
+	statsMu sync.Mutex
+	stats   Stats
+}
+
+// NewEngine creates a new rule engine and loads the rules file
+func NewEngine(cfg config.EventRulesConfig, logger *zap.Logger) (*Engine, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if cfg.File == "" {
+		return nil, fmt.Errorf("rules file is required")
+	}
+
+	e := &Engine{
+		cfg:    cfg,
+		logger: logger,
+		stats:  Stats{DryRun: cfg.DryRun, Matches: make(map[string]int)},
+	}
+
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Reload loads the rules file if it changed since the last load and reports
+// whether new rules were loaded
+func (e *Engine) Reload() (bool, error) {
+	info, err := os.Stat(e.cfg.File)
+	if err != nil {
+		return false, e.reloadFailed(fmt.Errorf("failed to stat rules file: %w", err))
+	}
+
+	e.mu.RLock()
+	unchanged := info.ModTime().Equal(e.modTime)
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(e.cfg.File)
+	if err != nil {
+		return false, e.reloadFailed(fmt.Errorf("failed to read rules file: %w", err))
+	}
+
+	rules, err := parseRules(content)
+	if err != nil {
+		return false, e.reloadFailed(err)
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+
+	e.statsMu.Lock()
+	e.stats.Rules = len(rules)
+	e.stats.LastReload = time.Now()
+	e.stats.LastError = ""
+	e.statsMu.Unlock()
+
+	e.logger.Info("Loaded event rules",
+		zap.String("file", e.cfg.File),
+		zap.Int("rules", len(rules)),
+		zap.Bool("dry_run", e.cfg.DryRun))
+
+	return true, nil
+}
+
+// reloadFailed records a failed load and returns the error
+func (e *Engine) reloadFailed(err error) error {
+	e.statsMu.Lock()
+	e.stats.LastError = err.Error()
+	e.statsMu.Unlock()
+	return err
+}
+
+// Run reloads the rules file when it changes until the context is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	if e.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(e.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.Reload(); err != nil {
+				e.logger.Error("Failed to reload event rules, keeping previous rules",
+					zap.String("file", e.cfg.File),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// Apply evaluates the rules against an event. The event passed in is not
+// modified. In dry-run mode, globally or for a single rule, matching rules
+// are logged and reported but not applied.
+func (e *Engine) Apply(event *plugin.TriggerEvent) *Result {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	result := &Result{Event: event}
+	var current *plugin.TriggerEvent
+
+	for _, rule := range rules {
+		target := event
+		if current != nil {
+			target = current
+		}
+		if !rule.matches(target) {
+			continue
+		}
+
+		dryRun := e.cfg.DryRun || rule.DryRun
+		decision := Decision{Rule: rule.Name, Actions: rule.describe(), DryRun: dryRun}
+		result.Decisions = append(result.Decisions, decision)
+
+		if dryRun {
+			e.logger.Info("Event rule matched (dry run)",
+				zap.String("rule", rule.Name),
+				zap.String("event_id", event.ID),
+				zap.String("would_apply", decision.Actions))
+			continue
+		}
+
+		e.logger.Debug("Event rule matched",
+			zap.String("rule", rule.Name),
+			zap.String("event_id", event.ID),
+			zap.String("actions", decision.Actions))
+
+		if rule.Actions.Drop {
+			result.Event = nil
+			result.Dropped = true
+			break
+		}
+
+		if current == nil {
+			current = copyEvent(event)
+		}
+		applyActions(current, rule)
+
+		if rule.Stop {
+			break
+		}
+	}
+
+	if current != nil && !result.Dropped {
+		result.Event = current
+	}
+
+	e.record(result, current != nil)
+	return result
+}
+
+// record updates statistics for an evaluated event
+func (e *Engine) record(result *Result, modified bool) {
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	e.stats.Evaluated++
+	if result.Dropped {
+		e.stats.Dropped++
+	} else if modified {
+		e.stats.Modified++
+	}
+	for _, decision := range result.Decisions {
+		e.stats.Matches[decision.Rule]++
+	}
+}
+
+// GetStats returns rule engine statistics
+func (e *Engine) GetStats() Stats {
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	stats := e.stats
+	stats.Matches = make(map[string]int, len(e.stats.Matches))
+	for rule, count := range e.stats.Matches {
+		stats.Matches[rule] = count
+	}
+	return stats
+}
+
+// applyActions applies a rule's actions to an event
+func applyActions(event *plugin.TriggerEvent, rule *compiledRule) {
+	if rule.Actions.Severity != "" {
+		event.Severity = plugin.Severity(rule.Actions.Severity)
+	}
+
+	for _, tag := range rule.Actions.AddTags {
+		if !hasTag(event.Tags, tag) {
+			event.Tags = append(event.Tags, tag)
+		}
+	}
+
+	for key, value := range rule.Actions.Metadata {
+		event.Metadata[key] = value
+	}
+
+	if len(rule.Actions.Route) > 0 {
+		routes, _ := event.Metadata[MetadataRoutes].([]string)
+		routes = append([]string(nil), routes...)
+		for _, route := range rule.Actions.Route {
+			if !hasTag(routes, route) {
+				routes = append(routes, route)
+			}
+		}
+		event.Metadata[MetadataRoutes] = routes
+	}
+
+	matched, _ := event.Metadata[MetadataMatchedRules].([]string)
+	event.Metadata[MetadataMatchedRules] = append(append([]string(nil), matched...), rule.Name)
+}
+
+// copyEvent copies the parts of an event the rules may change
+func copyEvent(event *plugin.TriggerEvent) *plugin.TriggerEvent {
+	copied := *event
+	copied.Tags = append([]string(nil), event.Tags...)
+	copied.Metadata = make(map[string]interface{}, len(event.Metadata)+2)
+	for key, value := range event.Metadata {
+		copied.Metadata[key] = value
+	}
+	return &copied
+}
@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+const testRules = `
+rules:
+  - name: drop-staging-noise
+    match:
+      source: "*-staging"
+      severity: [low, medium]
+    actions:
+      drop: true
+  - name: escalate-hot-cpu
+    match:
+      type: cpu_*
+      data:
+        - usage >= 95
+        - host =~ ^db-
+    actions:
+      severity: critical
+      add_tags: [database]
+      metadata:
+        team: dba
+      route: pagerduty
+  - name: route-critical
+    match:
+      severity: critical
+    actions:
+      route: [slack, pagerduty]
+    stop: true
+  - name: never-reached
+    match:
+      severity: critical
+    actions:
+      drop: true
+`
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func testEvent(source string, severity plugin.Severity, data map[string]interface{}) *plugin.TriggerEvent {
+	return &plugin.TriggerEvent{
+		ID:       "event-1",
+		Type:     "cpu_high",
+		Source:   source,
+		Data:     data,
+		Metadata: map[string]interface{}{"plugin_id": "cpu-monitor"},
+		Severity: severity,
+	}
+}
+
+func TestEngineAppliesRules(t *testing.T) {
+	engine, err := NewEngine(config.EventRulesConfig{File: writeRules(t, testRules)}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	// Dropped
+	result := engine.Apply(testEvent("cpu-staging", plugin.SeverityLow, nil))
+	assert.True(t, result.Dropped)
+	assert.Nil(t, result.Event)
+
+	// Escalated, enriched and routed; the last rule is never evaluated
+	event := testEvent("cpu-prod", plugin.SeverityHigh, map[string]interface{}{"usage": 97, "host": "db-01"})
+	result = engine.Apply(event)
+	require.False(t, result.Dropped)
+	require.NotNil(t, result.Event)
+	assert.Equal(t, plugin.SeverityCritical, result.Event.Severity)
+	assert.Equal(t, []string{"database"}, result.Event.Tags)
+	assert.Equal(t, "dba", result.Event.Metadata["team"])
+	assert.Equal(t, []string{"pagerduty", "slack"}, result.Event.Metadata[MetadataRoutes])
+	assert.Equal(t, []string{"escalate-hot-cpu", "route-critical"}, result.Event.Metadata[MetadataMatchedRules])
+
+	// The original event is left untouched
+	assert.Equal(t, plugin.SeverityHigh, event.Severity)
+	assert.Empty(t, event.Tags)
+
+	// Unmatched events pass through as they are
+	event = testEvent("cpu-prod", plugin.SeverityHigh, map[string]interface{}{"usage": 50, "host": "db-01"})
+	result = engine.Apply(event)
+	assert.Same(t, event, result.Event)
+	assert.Empty(t, result.Decisions)
+
+	stats := engine.GetStats()
+	assert.Equal(t, 4, stats.Rules)
+	assert.Equal(t, 3, stats.Evaluated)
+	assert.Equal(t, 1, stats.Dropped)
+	assert.Equal(t, 1, stats.Modified)
+	assert.Equal(t, 0, stats.Matches["never-reached"])
+}
+
+func TestEngineDryRun(t *testing.T) {
+	engine, err := NewEngine(config.EventRulesConfig{File: writeRules(t, testRules), DryRun: true}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	event := testEvent("cpu-staging", plugin.SeverityLow, nil)
+	result := engine.Apply(event)
+
+	assert.False(t, result.Dropped)
+	assert.Same(t, event, result.Event)
+	require.Len(t, result.Decisions, 1)
+	assert.Equal(t, Decision{Rule: "drop-staging-noise", Actions: "drop", DryRun: true}, result.Decisions[0])
+}
+
+func TestEngineReload(t *testing.T) {
+	path := writeRules(t, testRules)
+	engine, err := NewEngine(config.EventRulesConfig{File: path}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	// Unchanged files are not reloaded
+	reloaded, err := engine.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// Invalid rules are rejected and the previous rules stay in effect
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: bad\n    match:\n      data: [\"usage >> 1\"]\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	_, err = engine.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 4, engine.GetStats().Rules)
+	assert.NotEmpty(t, engine.GetStats().LastError)
+
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: drop-all\n    actions:\n      drop: true\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	reloaded, err = engine.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.True(t, engine.Apply(testEvent("cpu-prod", plugin.SeverityCritical, nil)).Dropped)
+	assert.Empty(t, engine.GetStats().LastError)
+}
+
+func TestExpressions(t *testing.T) {
+	data := map[string]interface{}{
+		"usage": 92.5,
+		"host":  "web-01",
+		"disk":  map[string]interface{}{"mount": "/var", "free": "10"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"usage > 90", true},
+		{"usage <= 90", false},
+		{"host == web-01", true},
+		{"host == 'web 01'", false},
+		{"host != db-01", true},
+		{"host =~ ^web-", true},
+		{"host !~ ^web-", false},
+		{"disk.mount == /var", true},
+		{"disk.free < 20", true},
+		{"disk.missing exists", false},
+		{"disk.missing missing", true},
+		{"missing == x", false},
+		{"missing != x", true},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseExpression(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, expr.Evaluate(data), tt.expr)
+	}
+
+	for _, invalid := range []string{"usage", "usage >> 1", "usage >", "usage > high", "host =~ ("} {
+		_, err := ParseExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expression is a condition on an event data field, written as
+// "<field> <operator> <value>" or "<field> exists" / "<field> missing".
+// Fields are dotted paths into the event data. Supported operators are
+// ==, !=, >, >=, <, <=, =~ (regular expression match) and !~. Values may be
+// quoted; numbers are compared numerically.
+type Expression struct {
+	source   string
+	field    []string
+	operator string
+	value    string
+	number   float64
+	numeric  bool
+	pattern  *regexp.Regexp
+}
+
+// Operators in the order they are tried, longest first
+var operators = []string{"==", "!=", ">=", "<=", "=~", "!~", ">", "<"}
+
+// ParseExpression parses a data expression
+func ParseExpression(source string) (*Expression, error) {
+	source = strings.TrimSpace(source)
+	parts := strings.Fields(source)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid expression %q: expected \"<field> <operator> <value>\"", source)
+	}
+
+	expr := &Expression{source: source, field: strings.Split(parts[0], ".")}
+
+	if len(parts) == 2 && (parts[1] == "exists" || parts[1] == "missing") {
+		expr.operator = parts[1]
+		return expr, nil
+	}
+
+	for _, op := range operators {
+		if parts[1] == op {
+			expr.operator = op
+			break
+		}
+	}
+	if expr.operator == "" {
+		return nil, fmt.Errorf("invalid expression %q: unknown operator %q", source, parts[1])
+	}
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("invalid expression %q: missing value", source)
+	}
+
+	// The value is everything after the operator, so it may contain spaces
+	rest := strings.TrimSpace(source[len(parts[0]):])
+	expr.value = unquote(strings.TrimSpace(rest[len(expr.operator):]))
+
+	switch expr.operator {
+	case "=~", "!~":
+		pattern, err := regexp.Compile(expr.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+		}
+		expr.pattern = pattern
+	case ">", ">=", "<", "<=":
+		number, err := strconv.ParseFloat(expr.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %s requires a number", source, expr.operator)
+		}
+		expr.number = number
+		expr.numeric = true
+	default:
+		if number, err := strconv.ParseFloat(expr.value, 64); err == nil {
+			expr.number = number
+			expr.numeric = true
+		}
+	}
+
+	return expr, nil
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate reports whether the expression holds for the given event data
+func (e *Expression) Evaluate(data map[string]interface{}) bool {
+	value, found := lookup(data, e.field)
+
+	switch e.operator {
+	case "exists":
+		return found
+	case "missing":
+		return !found
+	case "!=":
+		return !found || !e.equals(value)
+	case "!~":
+		return !found || !e.pattern.MatchString(fmt.Sprint(value))
+	}
+
+	if !found {
+		return false
+	}
+
+	switch e.operator {
+	case "==":
+		return e.equals(value)
+	case "=~":
+		return e.pattern.MatchString(fmt.Sprint(value))
+	}
+
+	number, ok := toNumber(value)
+	if !ok {
+		return false
+	}
+
+	switch e.operator {
+	case ">":
+		return number > e.number
+	case ">=":
+		return number >= e.number
+	case "<":
+		return number < e.number
+	case "<=":
+		return number <= e.number
+	}
+
+	return false
+}
+
+// equals compares numerically when both sides are numbers, otherwise as strings
+func (e *Expression) equals(value interface{}) bool {
+	if e.numeric {
+		if number, ok := toNumber(value); ok {
+			return number == e.number
+		}
+	}
+	return fmt.Sprint(value) == e.value
+}
+
+// lookup resolves a dotted path into nested maps
+func lookup(data map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// toNumber converts numeric values, including numeric strings, to float64
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	case fmt.Stringer:
+		number, err := strconv.ParseFloat(v.String(), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// unquote strips matching single or double quotes
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
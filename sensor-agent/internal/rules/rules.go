@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// File is the layout of a rules file
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule matches trigger events and applies actions to them. Rules are
+// evaluated in file order and later rules see the changes made by earlier ones.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Disabled    bool   `yaml:"disabled"`
+	// Log what the rule would do without applying it
+	DryRun  bool    `yaml:"dry_run"`
+	Match   Match   `yaml:"match"`
+	Actions Actions `yaml:"actions"`
+	// Stop evaluating further rules once this rule has been applied
+	Stop bool `yaml:"stop"`
+}
+
+// Match selects events. All given conditions must hold; an empty match
+// selects every event.
+type Match struct {
+	// Glob patterns matched against the event type and source
+	Type   StringList `yaml:"type"`
+	Source StringList `yaml:"source"`
+	// Any of the listed severities
+	Severity StringList `yaml:"severity"`
+	// All of the listed tags
+	Tags StringList `yaml:"tags"`
+	// Expressions on event data fields, see Expression
+	Data StringList `yaml:"data"`
+}
+
+// Actions are applied to matching events
+type Actions struct {
+	Drop     bool                   `yaml:"drop"`
+	Severity string                 `yaml:"severity"`
+	AddTags  StringList             `yaml:"add_tags"`
+	Metadata map[string]interface{} `yaml:"metadata"`
+	// Output destinations the event is sent to, instead of every
+	// destination taking trigger events
+	Route StringList `yaml:"route"`
+}
+
+// StringList accepts either a single string or a list of strings
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+// compiledRule is a validated rule with its expressions parsed
+type compiledRule struct {
+	Rule
+	data []*Expression
+}
+
+// parseRules parses and validates a rules file, skipping disabled rules
+func parseRules(content []byte) ([]*compiledRule, error) {
+	var file File
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	compiled := make([]*compiledRule, 0, len(file.Rules))
+	for i, rule := range file.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		c, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", rule.Name, err)
+		}
+		if !rule.Disabled {
+			compiled = append(compiled, c)
+		}
+	}
+
+	return compiled, nil
+}
+
+// compile validates a rule and parses its expressions
+func compile(rule Rule) (*compiledRule, error) {
+	for _, pattern := range append(append([]string{}, rule.Match.Type...), rule.Match.Source...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, severity := range rule.Match.Severity {
+		if !validSeverity(severity) {
+			return nil, fmt.Errorf("unknown severity %q", severity)
+		}
+	}
+	if rule.Actions.Severity != "" && !validSeverity(rule.Actions.Severity) {
+		return nil, fmt.Errorf("unknown severity %q", rule.Actions.Severity)
+	}
+
+	c := &compiledRule{Rule: rule}
+	for _, source := range rule.Match.Data {
+		expr, err := ParseExpression(source)
+		if err != nil {
+			return nil, err
+		}
+		c.data = append(c.data, expr)
+	}
+
+	return c, nil
+}
+
+// matches reports whether the rule selects the event
+func (r *compiledRule) matches(event *plugin.TriggerEvent) bool {
+	if len(r.Match.Type) > 0 && !matchAny(r.Match.Type, event.Type) {
+		return false
+	}
+	if len(r.Match.Source) > 0 && !matchAny(r.Match.Source, event.Source) {
+		return false
+	}
+
+	if len(r.Match.Severity) > 0 {
+		found := false
+		for _, severity := range r.Match.Severity {
+			if plugin.Severity(severity) == event.Severity {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, tag := range r.Match.Tags {
+		if !hasTag(event.Tags, tag) {
+			return false
+		}
+	}
+
+	for _, expr := range r.data {
+		if !expr.Evaluate(event.Data) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describe summarizes the rule's actions for logs and dry-run reports
+func (r *compiledRule) describe() string {
+	var actions []string
+	if r.Actions.Drop {
+		actions = append(actions, "drop")
+	}
+	if r.Actions.Severity != "" {
+		actions = append(actions, "severity="+r.Actions.Severity)
+	}
+	if len(r.Actions.AddTags) > 0 {
+		actions = append(actions, "add_tags="+strings.Join(r.Actions.AddTags, ","))
+	}
+	if len(r.Actions.Metadata) > 0 {
+		keys := make([]string, 0, len(r.Actions.Metadata))
+		for key := range r.Actions.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		actions = append(actions, "metadata="+strings.Join(keys, ","))
+	}
+	if len(r.Actions.Route) > 0 {
+		actions = append(actions, "route="+strings.Join(r.Actions.Route, ","))
+	}
+	if r.Stop {
+		actions = append(actions, "stop")
+	}
+	if len(actions) == 0 {
+		return "none"
+	}
+	return strings.Join(actions, " ")
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func validSeverity(severity string) bool {
+	switch plugin.Severity(severity) {
+	case plugin.SeverityLow, plugin.SeverityMedium, plugin.SeverityHigh, plugin.SeverityCritical:
+		return true
+	}
+	return false
+}
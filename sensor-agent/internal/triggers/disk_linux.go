@@ -0,0 +1,25 @@
+//go:build linux
+
+package triggers
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// diskUsage returns the usage of the filesystem containing path
+func diskUsage(path string) (*diskStats, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return nil, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+
+	size := uint64(fs.Bsize)
+	return &diskStats{
+		total:      fs.Blocks * size,
+		free:       fs.Bfree * size,
+		available:  fs.Bavail * size,
+		inodes:     fs.Files,
+		inodesFree: fs.Ffree,
+	}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package triggers
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// diskUsage is only implemented on Linux
+func diskUsage(path string) (*diskStats, error) {
+	return nil, fmt.Errorf("disk usage is not supported on %s", runtime.GOOS)
+}
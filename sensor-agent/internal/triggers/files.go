@@ -0,0 +1,346 @@
+package triggers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// fileOperations maps operation names to fsnotify operations
+var fileOperations = map[string]fsnotify.Op{
+	"create": fsnotify.Create,
+	"write":  fsnotify.Write,
+	"remove": fsnotify.Remove,
+	"rename": fsnotify.Rename,
+	"chmod":  fsnotify.Chmod,
+}
+
+var fileWatchDefinition = &definition{
+	name:        "File watch",
+	description: "Fires when watched files or directories are created, written, removed, renamed or have their permissions changed",
+	schema: schema("", plugin.SeverityMedium, map[string]*plugin.ConfigField{
+		"paths": {
+			Type:        "array",
+			Description: "Files and directories to watch",
+			Required:    true,
+			Examples:    []interface{}{[]interface{}{"/etc/nginx", "/etc/passwd"}},
+		},
+		"recursive": {
+			Type:        "boolean",
+			Description: "Also watch the subdirectories of watched directories, including ones created later",
+			Default:     false,
+		},
+		"operations": {
+			Type:        "array",
+			Description: "Operations that fire the trigger: create, write, remove, rename and chmod",
+			Default:     []interface{}{"create", "write", "remove", "rename", "chmod"},
+			Examples:    []interface{}{[]interface{}{"write", "remove"}},
+		},
+		"pattern": {
+			Type:        "string",
+			Description: "Glob the file name must match, all files when empty",
+			Examples:    []interface{}{"*.conf"},
+		},
+	}),
+	required: []string{"paths"},
+	examples: []map[string]interface{}{{"paths": []interface{}{"/etc/nginx"}, "recursive": true, "pattern": "*.conf"}},
+	newDetector: func(id string, s settings) (detector, error) {
+		w := &fileWatcher{
+			id:        id,
+			paths:     s.strings("paths"),
+			recursive: s.boolean("recursive"),
+			pattern:   s.string("pattern"),
+		}
+		if len(w.paths) == 0 {
+			return nil, fmt.Errorf("paths: at least one path is required")
+		}
+		for _, name := range s.strings("operations") {
+			op, ok := fileOperations[name]
+			if !ok {
+				return nil, fmt.Errorf("operations: unknown operation %q", name)
+			}
+			w.operations |= op
+		}
+		if _, err := filepath.Match(w.pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+		return w, nil
+	},
+}
+
+// fileWatcher emits an event for every change to the watched paths
+type fileWatcher struct {
+	id         string
+	paths      []string
+	recursive  bool
+	operations fsnotify.Op
+	pattern    string
+}
+
+func (w *fileWatcher) run(ctx context.Context, emit func(*plugin.TriggerEvent), record func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, path := range w.paths {
+		if err := w.add(watcher, path); err != nil {
+			return err
+		}
+	}
+	record(nil)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("file watcher closed")
+			}
+			record(fmt.Errorf("file watcher error: %w", err))
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("file watcher closed")
+			}
+
+			// Watch directories created inside recursively watched ones
+			if w.recursive && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.add(watcher, event.Name); err != nil {
+						record(err)
+					}
+				}
+			}
+
+			if event.Op&w.operations == 0 || !w.matches(event.Name) {
+				continue
+			}
+			emit(&plugin.TriggerEvent{
+				Type:   "file_changed",
+				Source: w.id + ":" + event.Name,
+				Data: map[string]interface{}{
+					"path":       event.Name,
+					"operations": operationNames(event.Op),
+				},
+				Tags: []string{"file"},
+			})
+		}
+	}
+}
+
+// add watches path and, when recursive, the directories below it
+func (w *fileWatcher) add(watcher *fsnotify.Watcher, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+	if !w.recursive || !info.IsDir() {
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	}
+
+	return filepath.WalkDir(path, func(dir string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		return nil
+	})
+}
+
+func (w *fileWatcher) matches(path string) bool {
+	if w.pattern == "" {
+		return true
+	}
+	matched, _ := filepath.Match(w.pattern, filepath.Base(path))
+	return matched
+}
+
+// operationNames returns the names of the operations in op
+func operationNames(op fsnotify.Op) []string {
+	var names []string
+	for _, name := range []string{"create", "write", "remove", "rename", "chmod"} {
+		if op.Has(fileOperations[name]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// maxLogRead caps how much of a log file is read in one check
+const maxLogRead = 1 << 20
+
+var logTailDefinition = &definition{
+	name:        "Log tail",
+	description: "Fires for every line appended to a log file that matches a regular expression; rotated files are followed",
+	schema: schema("2s", plugin.SeverityMedium, map[string]*plugin.ConfigField{
+		"path": {
+			Type:        "string",
+			Description: "Log file to follow",
+			Required:    true,
+			Examples:    []interface{}{"/var/log/auth.log"},
+		},
+		"pattern": {
+			Type:        "string",
+			Description: "Regular expression lines must match; named groups are added to the event",
+			Required:    true,
+			Examples:    []interface{}{`Failed password for (?P<user>\S+) from (?P<ip>\S+)`},
+		},
+		"from_start": {
+			Type:        "boolean",
+			Description: "Read the lines already in the file when the trigger starts instead of only new ones",
+			Default:     false,
+		},
+		"event_type": {
+			Type:        "string",
+			Description: "Type of the events the trigger emits",
+			Default:     "log_match",
+			Examples:    []interface{}{"ssh_login_failed"},
+		},
+		"max_events_per_check": {
+			Type:        "integer",
+			Description: "Most events emitted per check; further matching lines are counted but not emitted",
+			Default:     100,
+			Minimum:     bound(1),
+		},
+	}),
+	required: []string{"path", "pattern"},
+	examples: []map[string]interface{}{{
+		"path":       "/var/log/auth.log",
+		"pattern":    `Failed password for (?P<user>\S+) from (?P<ip>\S+)`,
+		"event_type": "ssh_login_failed",
+		"severity":   "high",
+	}},
+	newDetector: func(id string, s settings) (detector, error) {
+		pattern, err := regexp.Compile(s.string("pattern"))
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+		return s.poll(&logTailer{
+			id:        id,
+			path:      s.string("path"),
+			pattern:   pattern,
+			fromStart: s.boolean("from_start"),
+			eventType: s.string("event_type"),
+			maxEvents: int(s.number("max_events_per_check")),
+		})
+	},
+}
+
+// logTailer reads the lines appended to a log file since the previous check
+type logTailer struct {
+	id        string
+	path      string
+	pattern   *regexp.Regexp
+	fromStart bool
+	eventType string
+	maxEvents int
+
+	started bool
+	file    os.FileInfo
+	offset  int64
+}
+
+func (t *logTailer) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	switch {
+	case !t.started:
+		// Only lines written after the trigger starts, unless asked otherwise
+		t.started = true
+		if !t.fromStart {
+			t.offset = info.Size()
+		}
+	case !os.SameFile(t.file, info) || info.Size() < t.offset:
+		// The file was rotated or truncated
+		t.offset = 0
+	}
+	t.file = info
+
+	if info.Size() <= t.offset {
+		return nil, nil
+	}
+
+	file, err := os.Open(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxLogRead))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	// A line still being written is read once it is complete, unless it does
+	// not fit in a single read
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end == 0 {
+		if len(data) < maxLogRead {
+			return nil, nil
+		}
+		end = len(data)
+	}
+	t.offset += int64(end)
+
+	var events []*plugin.TriggerEvent
+	suppressed := 0
+	for _, line := range strings.Split(strings.TrimSuffix(string(data[:end]), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		match := t.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if len(events) >= t.maxEvents {
+			suppressed++
+			continue
+		}
+
+		eventData := map[string]interface{}{
+			"path": t.path,
+			"line": line,
+		}
+		for i, name := range t.pattern.SubexpNames() {
+			if name != "" && i < len(match) {
+				eventData[name] = match[i]
+			}
+		}
+		events = append(events, &plugin.TriggerEvent{
+			Type:   t.eventType,
+			Source: t.id + ":" + t.path,
+			Data:   eventData,
+			Tags:   []string{"log"},
+		})
+	}
+	if suppressed > 0 {
+		events[len(events)-1].Data["suppressed_matches"] = suppressed
+	}
+	return events, nil
+}
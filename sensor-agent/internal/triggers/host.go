@@ -0,0 +1,384 @@
+package triggers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// procRoot is where the proc filesystem is mounted
+const procRoot = "/proc"
+
+var cpuDefinition = &definition{
+	name:        "CPU usage",
+	description: "Fires while CPU usage across all CPUs, read from /proc/stat, is at or above the threshold",
+	schema: schema("30s", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"threshold": percentField("CPU usage in percent at which the trigger fires", 90),
+	}),
+	examples: []map[string]interface{}{{"threshold": 85, "interval": "15s"}},
+	newDetector: func(id string, s settings) (detector, error) {
+		return s.poll(&cpuChecker{id: id, threshold: s.number("threshold"), proc: procRoot, alerts: conditions{}})
+	},
+}
+
+var memoryDefinition = &definition{
+	name:        "Memory usage",
+	description: "Fires while memory in use, read from /proc/meminfo, is at or above the threshold",
+	schema: schema("30s", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"threshold": percentField("Memory in use, excluding reclaimable caches, in percent at which the trigger fires", 90),
+	}),
+	examples: []map[string]interface{}{{"threshold": 95}},
+	newDetector: func(id string, s settings) (detector, error) {
+		return s.poll(&memoryChecker{id: id, threshold: s.number("threshold"), proc: procRoot, alerts: conditions{}})
+	},
+}
+
+var loadDefinition = &definition{
+	name:        "Load average",
+	description: "Fires while the load average, read from /proc/loadavg, is at or above the threshold",
+	schema: schema("30s", plugin.SeverityMedium, map[string]*plugin.ConfigField{
+		"threshold": {
+			Type:        "number",
+			Description: "Load average at which the trigger fires",
+			Default:     1.0,
+			Minimum:     bound(0),
+			Examples:    []interface{}{1.5, 8},
+		},
+		"period": {
+			Type:        "integer",
+			Description: "Load average period in minutes",
+			Default:     5,
+			Enum:        []string{"1", "5", "15"},
+		},
+		"per_cpu": {
+			Type:        "boolean",
+			Description: "Divide the load average by the number of CPUs before comparing it",
+			Default:     true,
+		},
+	}),
+	examples: []map[string]interface{}{{"threshold": 2, "period": 1, "per_cpu": true}},
+	newDetector: func(id string, s settings) (detector, error) {
+		c := &loadChecker{
+			id:        id,
+			threshold: s.number("threshold"),
+			period:    int(s.number("period")),
+			cpus:      1,
+			proc:      procRoot,
+			alerts:    conditions{},
+		}
+		if s.boolean("per_cpu") {
+			c.cpus = runtime.NumCPU()
+		}
+		return s.poll(c)
+	},
+}
+
+var diskDefinition = &definition{
+	name:        "Disk usage",
+	description: "Fires for each filesystem whose space or inode usage is at or above the threshold",
+	schema: schema("1m", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"paths": {
+			Type:        "array",
+			Description: "Paths on the filesystems to check",
+			Default:     []interface{}{"/"},
+			Examples:    []interface{}{[]interface{}{"/", "/var"}},
+		},
+		"threshold":       percentField("Space in use in percent at which the trigger fires", 90),
+		"inode_threshold": percentField("Inodes in use in percent at which the trigger fires", 90),
+	}),
+	examples: []map[string]interface{}{{"paths": []interface{}{"/", "/var/lib/docker"}, "threshold": 85}},
+	newDetector: func(id string, s settings) (detector, error) {
+		paths := s.strings("paths")
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("paths: at least one path is required")
+		}
+		return s.poll(&diskChecker{
+			id:             id,
+			paths:          paths,
+			threshold:      s.number("threshold"),
+			inodeThreshold: s.number("inode_threshold"),
+			usage:          diskUsage,
+			alerts:         conditions{},
+		})
+	},
+}
+
+// cpuChecker compares CPU usage since the previous check with the threshold
+type cpuChecker struct {
+	id        string
+	threshold float64
+	proc      string
+	alerts    conditions
+
+	previous *cpuTimes
+}
+
+// cpuTimes are the aggregate CPU times from /proc/stat
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+func (c *cpuChecker) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	times, err := readCPUTimes(filepath.Join(c.proc, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	previous := c.previous
+	c.previous = times
+
+	// Usage is measured between two checks
+	if previous == nil || times.total <= previous.total {
+		return nil, nil
+	}
+	idle := float64(times.idle - previous.idle)
+	total := float64(times.total - previous.total)
+	usage := round((1 - idle/total) * 100)
+
+	return c.alerts.update("cpu", usage >= c.threshold, &plugin.TriggerEvent{
+		Type: "cpu_high",
+		Data: map[string]interface{}{
+			"usage_percent": usage,
+			"threshold":     c.threshold,
+			"cpus":          runtime.NumCPU(),
+		},
+		Tags: []string{"cpu"},
+	}), nil
+}
+
+// readCPUTimes reads the aggregate "cpu" line of /proc/stat
+func readCPUTimes(path string) (*cpuTimes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CPU times: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		// user nice system idle iowait irq softirq steal; guest time is
+		// already included in user time
+		times := &cpuTimes{}
+		for i, field := range fields[1:] {
+			if i >= 8 {
+				break
+			}
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU times in %s: %w", path, err)
+			}
+			times.total += v
+			if i == 3 || i == 4 {
+				times.idle += v
+			}
+		}
+		return times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CPU times: %w", err)
+	}
+	return nil, fmt.Errorf("no CPU times in %s", path)
+}
+
+// memoryChecker compares memory in use with the threshold
+type memoryChecker struct {
+	id        string
+	threshold float64
+	proc      string
+	alerts    conditions
+}
+
+func (c *memoryChecker) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	info, err := readMemInfo(filepath.Join(c.proc, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	total, ok := info["MemTotal"]
+	if !ok || total == 0 {
+		return nil, fmt.Errorf("no MemTotal in meminfo")
+	}
+	available, ok := info["MemAvailable"]
+	if !ok {
+		// Kernels before 3.14 do not estimate available memory
+		available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	used := round(float64(total-min(available, total)) / float64(total) * 100)
+
+	return c.alerts.update("memory", used >= c.threshold, &plugin.TriggerEvent{
+		Type: "memory_high",
+		Data: map[string]interface{}{
+			"used_percent":    used,
+			"threshold":       c.threshold,
+			"total_bytes":     total,
+			"available_bytes": available,
+		},
+		Tags: []string{"memory"},
+	}), nil
+}
+
+// readMemInfo reads /proc/meminfo in bytes
+func readMemInfo(path string) (map[string]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory usage: %w", err)
+	}
+	defer file.Close()
+
+	info := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		info[name] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read memory usage: %w", err)
+	}
+	return info, nil
+}
+
+// loadChecker compares the load average with the threshold
+type loadChecker struct {
+	id        string
+	threshold float64
+	period    int
+	cpus      int
+	proc      string
+	alerts    conditions
+}
+
+func (c *loadChecker) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	data, err := os.ReadFile(filepath.Join(c.proc, "loadavg"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read load average: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid load average %q", strings.TrimSpace(string(data)))
+	}
+
+	index := map[int]int{1: 0, 5: 1, 15: 2}[c.period]
+	load, err := strconv.ParseFloat(fields[index], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid load average: %w", err)
+	}
+	normalized := round(load / float64(c.cpus))
+
+	return c.alerts.update("load", normalized >= c.threshold, &plugin.TriggerEvent{
+		Type: "load_high",
+		Data: map[string]interface{}{
+			"load":           load,
+			"normalized":     normalized,
+			"period_minutes": c.period,
+			"cpus":           c.cpus,
+			"threshold":      c.threshold,
+		},
+		Tags: []string{"load"},
+	}), nil
+}
+
+// diskStats is the usage of a filesystem
+type diskStats struct {
+	total      uint64
+	free       uint64
+	available  uint64
+	inodes     uint64
+	inodesFree uint64
+}
+
+// diskChecker compares filesystem usage with the thresholds
+type diskChecker struct {
+	id             string
+	paths          []string
+	threshold      float64
+	inodeThreshold float64
+	usage          func(path string) (*diskStats, error)
+	alerts         conditions
+}
+
+func (c *diskChecker) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	var events []*plugin.TriggerEvent
+	var errs []string
+
+	for _, path := range c.paths {
+		stats, err := c.usage(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		// Space reserved for root is not available to others, as with df
+		used := stats.total - stats.free
+		usedPercent := 0.0
+		if used+stats.available > 0 {
+			usedPercent = round(float64(used) / float64(used+stats.available) * 100)
+		}
+		events = append(events, c.alerts.update(path, usedPercent >= c.threshold, &plugin.TriggerEvent{
+			Type:   "disk_usage_high",
+			Source: c.id + ":" + path,
+			Data: map[string]interface{}{
+				"path":            path,
+				"used_percent":    usedPercent,
+				"threshold":       c.threshold,
+				"total_bytes":     stats.total,
+				"available_bytes": stats.available,
+			},
+			Tags: []string{"disk"},
+		})...)
+
+		// Some filesystems do not have a fixed number of inodes
+		if stats.inodes == 0 {
+			continue
+		}
+		inodesUsed := round(float64(stats.inodes-stats.inodesFree) / float64(stats.inodes) * 100)
+		events = append(events, c.alerts.update(path+"#inodes", inodesUsed >= c.inodeThreshold, &plugin.TriggerEvent{
+			Type:   "disk_inodes_high",
+			Source: c.id + ":" + path,
+			Data: map[string]interface{}{
+				"path":                path,
+				"inodes_used_percent": inodesUsed,
+				"threshold":           c.inodeThreshold,
+				"inodes":              stats.inodes,
+				"inodes_free":         stats.inodesFree,
+			},
+			Tags: []string{"disk"},
+		})...)
+	}
+
+	if len(errs) > 0 {
+		return events, fmt.Errorf("failed to read disk usage: %s", strings.Join(errs, "; "))
+	}
+	return events, nil
+}
+
+// round rounds a percentage or load to two decimals
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
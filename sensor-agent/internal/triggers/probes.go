@@ -0,0 +1,235 @@
+package triggers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// maxProbeBody caps how much of a response body is matched
+const maxProbeBody = 1 << 20
+
+var tcpProbeDefinition = &definition{
+	name:        "TCP probe",
+	description: "Fires while a TCP port does not accept connections, and once more when it recovers",
+	schema: schema("30s", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"address": {
+			Type:        "string",
+			Description: "Address to connect to as host:port",
+			Required:    true,
+			Pattern:     `^.+:[0-9]+$`,
+			Examples:    []interface{}{"localhost:5432", "db.internal:3306"},
+		},
+		"timeout": {
+			Type:        "string",
+			Description: "Connection timeout",
+			Default:     "5s",
+			Pattern:     durationPattern,
+		},
+	}),
+	required: []string{"address"},
+	examples: []map[string]interface{}{{"address": "localhost:5432", "timeout": "2s"}},
+	newDetector: func(id string, s settings) (detector, error) {
+		timeout, err := s.duration("timeout", time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return s.poll(&tcpProbe{
+			address: s.string("address"),
+			timeout: timeout,
+			alerts:  conditions{},
+		})
+	},
+}
+
+// tcpProbe checks that a TCP port accepts connections
+type tcpProbe struct {
+	address string
+	timeout time.Duration
+	alerts  conditions
+}
+
+func (p *tcpProbe) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	started := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	elapsed := time.Since(started)
+	if err == nil {
+		conn.Close()
+	}
+
+	data := map[string]interface{}{
+		"address":     p.address,
+		"duration_ms": elapsed.Milliseconds(),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+
+	// A failed probe is what the trigger reports, not a failed check
+	return p.alerts.update(p.address, err != nil, &plugin.TriggerEvent{
+		Type: "tcp_probe_failed",
+		Data: data,
+		Tags: []string{"probe", "tcp"},
+	}), nil
+}
+
+var httpProbeDefinition = &definition{
+	name:        "HTTP probe",
+	description: "Fires while an HTTP endpoint does not answer with the expected status or body, and once more when it recovers",
+	schema: schema("30s", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"url": {
+			Type:        "string",
+			Description: "URL to request",
+			Required:    true,
+			Pattern:     `^https?://`,
+			Examples:    []interface{}{"http://localhost:8080/healthz"},
+		},
+		"method": {
+			Type:        "string",
+			Description: "Request method",
+			Default:     "GET",
+			Enum:        []string{"GET", "HEAD"},
+		},
+		"expected_status": {
+			Type:        "integer",
+			Description: "Status the endpoint must answer with, any 2xx status when 0",
+			Default:     0,
+			Minimum:     bound(0),
+			Maximum:     bound(599),
+			Examples:    []interface{}{200, 204},
+		},
+		"body_pattern": {
+			Type:        "string",
+			Description: "Regular expression the response body must match",
+			Examples:    []interface{}{`"status":\s*"ok"`},
+		},
+		"headers": {
+			Type:        "object",
+			Description: "Request headers",
+			Examples:    []interface{}{map[string]interface{}{"Authorization": "Bearer token"}},
+		},
+		"timeout": {
+			Type:        "string",
+			Description: "Request timeout",
+			Default:     "10s",
+			Pattern:     durationPattern,
+		},
+		"insecure_skip_verify": {
+			Type:        "boolean",
+			Description: "Accept any TLS certificate",
+			Default:     false,
+		},
+	}),
+	required: []string{"url"},
+	examples: []map[string]interface{}{{"url": "https://localhost:8443/healthz", "expected_status": 200, "body_pattern": "ok"}},
+	newDetector: func(id string, s settings) (detector, error) {
+		timeout, err := s.duration("timeout", time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		p := &httpProbe{
+			url:      s.string("url"),
+			method:   s.string("method"),
+			expected: int(s.number("expected_status")),
+			headers:  make(map[string]string),
+			alerts:   conditions{},
+			client: &http.Client{
+				Timeout: timeout,
+				Transport: &http.Transport{
+					Proxy: http.ProxyFromEnvironment,
+					TLSClientConfig: &tls.Config{
+						InsecureSkipVerify: s.boolean("insecure_skip_verify"),
+					},
+				},
+			},
+		}
+		if pattern := s.string("body_pattern"); pattern != "" {
+			if p.body, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("body_pattern: %w", err)
+			}
+		}
+		if headers, ok := s["headers"].(map[string]interface{}); ok {
+			for name, value := range headers {
+				p.headers[name] = fmt.Sprint(value)
+			}
+		}
+		return s.poll(p)
+	},
+}
+
+// httpProbe checks that an HTTP endpoint answers as expected
+type httpProbe struct {
+	url      string
+	method   string
+	expected int
+	body     *regexp.Regexp
+	headers  map[string]string
+	client   *http.Client
+	alerts   conditions
+}
+
+func (p *httpProbe) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create probe request: %w", err)
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	data := map[string]interface{}{
+		"url":    p.url,
+		"method": p.method,
+	}
+	started := time.Now()
+	problem := p.probe(req, data)
+	data["duration_ms"] = time.Since(started).Milliseconds()
+	if problem != "" {
+		data["error"] = problem
+	}
+
+	return p.alerts.update(p.url, problem != "", &plugin.TriggerEvent{
+		Type: "http_probe_failed",
+		Data: data,
+		Tags: []string{"probe", "http"},
+	}), nil
+}
+
+// probe sends the request and returns what is wrong with the response, if
+// anything
+func (p *httpProbe) probe(req *http.Request, data map[string]interface{}) string {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+	data["status_code"] = resp.StatusCode
+
+	switch {
+	case p.expected != 0 && resp.StatusCode != p.expected:
+		return fmt.Sprintf("unexpected status %d, expected %d", resp.StatusCode, p.expected)
+	case p.expected == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		return "unexpected status " + strconv.Itoa(resp.StatusCode)
+	}
+
+	if p.body == nil || p.method == http.MethodHead {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return fmt.Sprintf("failed to read response body: %v", err)
+	}
+	if !p.body.Match(body) {
+		return "response body does not match " + p.body.String()
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package triggers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+var systemdDefinition = &definition{
+	name:        "Systemd unit state",
+	description: "Fires while systemd units are not in the expected state, and once more when they return to it",
+	schema: schema("30s", plugin.SeverityHigh, map[string]*plugin.ConfigField{
+		"units": {
+			Type:        "array",
+			Description: "Units to check",
+			Required:    true,
+			Examples:    []interface{}{[]interface{}{"nginx.service", "postgresql.service"}},
+		},
+		"expected_state": {
+			Type:        "string",
+			Description: "Active state the units are expected to be in",
+			Default:     "active",
+			Enum:        []string{"active", "inactive"},
+		},
+	}),
+	required: []string{"units"},
+	examples: []map[string]interface{}{{"units": []interface{}{"nginx.service"}, "interval": "15s"}},
+	newDetector: func(id string, s settings) (detector, error) {
+		units := s.strings("units")
+		if len(units) == 0 {
+			return nil, fmt.Errorf("units: at least one unit is required")
+		}
+		return s.poll(&systemdChecker{
+			id:       id,
+			units:    units,
+			expected: s.string("expected_state"),
+			run:      systemctl,
+			alerts:   conditions{},
+		})
+	},
+}
+
+// systemdChecker compares the active state of units with the expected one
+type systemdChecker struct {
+	id       string
+	units    []string
+	expected string
+	run      func(ctx context.Context, args ...string) ([]byte, error)
+	alerts   conditions
+}
+
+// unitState is the state of a unit as reported by systemctl show
+type unitState map[string]string
+
+func (c *systemdChecker) check(ctx context.Context) ([]*plugin.TriggerEvent, error) {
+	args := append([]string{"show", "-p", "Id", "-p", "LoadState", "-p", "ActiveState", "-p", "SubState", "--"}, c.units...)
+	output, err := c.run(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unit state: %w", err)
+	}
+
+	states := parseUnitStates(output)
+	if len(states) != len(c.units) {
+		return nil, fmt.Errorf("failed to read unit state: expected %d units, got %d", len(c.units), len(states))
+	}
+
+	var events []*plugin.TriggerEvent
+	for i, unit := range c.units {
+		state := states[i]
+		events = append(events, c.alerts.update(unit, state["ActiveState"] != c.expected, &plugin.TriggerEvent{
+			Type:   "systemd_unit_state",
+			Source: c.id + ":" + unit,
+			Data: map[string]interface{}{
+				"unit":           unit,
+				"load_state":     state["LoadState"],
+				"active_state":   state["ActiveState"],
+				"sub_state":      state["SubState"],
+				"expected_state": c.expected,
+			},
+			Tags: []string{"systemd"},
+		})...)
+	}
+	return events, nil
+}
+
+// parseUnitStates parses systemctl show output, one blank line separated
+// block of properties per unit in the order they were asked for
+func parseUnitStates(output []byte) []unitState {
+	var states []unitState
+	var current unitState
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			current = nil
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = make(unitState)
+			states = append(states, current)
+		}
+		current[key] = value
+	}
+	return states
+}
+
+// systemctl runs systemctl with the given arguments
+func systemctl(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "systemctl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
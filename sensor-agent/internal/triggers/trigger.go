@@ -0,0 +1,355 @@
+package triggers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Metadata marking an event whose condition has cleared, as understood by
+// the sensor agent's event processor
+const (
+	metadataState = "state"
+	stateResolved = "resolved"
+)
+
+// A trigger whose checks fail this many times in a row is unhealthy
+const unhealthyAfter = 3
+
+// detector produces the events of a built-in trigger until ctx is cancelled.
+// It passes events to emit and the outcome of every check to record, and
+// returns an error only when it cannot continue.
+type detector interface {
+	run(ctx context.Context, emit func(*plugin.TriggerEvent), record func(error)) error
+}
+
+// checker is a detector that checks for events at a fixed interval
+type checker interface {
+	check(ctx context.Context) ([]*plugin.TriggerEvent, error)
+}
+
+// poller runs a checker at an interval, starting immediately
+type poller struct {
+	interval time.Duration
+	checker  checker
+}
+
+func (p *poller) run(ctx context.Context, emit func(*plugin.TriggerEvent), record func(error)) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := p.checker.check(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		record(err)
+		for _, event := range events {
+			emit(event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Trigger is a built-in trigger plugin. The detection itself is done by a
+// detector built from the trigger's settings.
+type Trigger struct {
+	def    *definition
+	info   *plugin.Info
+	logger *zap.Logger
+	events chan *plugin.TriggerEvent
+
+	mu        sync.Mutex
+	status    plugin.Status
+	settings  settings
+	detector  detector
+	cancel    context.CancelFunc
+	done      chan struct{}
+	startedAt time.Time
+
+	checks              int
+	failures            int
+	consecutiveFailures int
+	emitted             int
+	lastError           string
+	lastCheck           time.Time
+	lastEvent           time.Time
+}
+
+func newTrigger(id string, def *definition, logger *zap.Logger) *Trigger {
+	return &Trigger{
+		def: def,
+		info: &plugin.Info{
+			ID:          id,
+			Name:        def.name,
+			Description: def.description,
+			Version:     "1.0.0",
+			Author:      "Stavily",
+			Tags:        []string{"builtin"},
+			Type:        plugin.PluginTypeTrigger,
+		},
+		logger: logger.With(zap.String("plugin_id", id)),
+		events: make(chan *plugin.TriggerEvent, 100),
+		status: plugin.StatusStopped,
+	}
+}
+
+// GetInfo returns plugin metadata
+func (t *Trigger) GetInfo() *plugin.Info {
+	return t.info
+}
+
+// GetTriggerConfig returns the configuration schema
+func (t *Trigger) GetTriggerConfig() *plugin.TriggerConfig {
+	return &plugin.TriggerConfig{
+		Schema:      t.def.schema,
+		Required:    t.def.required,
+		Examples:    t.def.examples,
+		Description: t.def.description,
+	}
+}
+
+// Initialize applies the trigger settings on top of the schema defaults. A
+// running trigger is restarted to pick them up.
+func (t *Trigger) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := plugin.ValidateConfig(t.def.schema, t.def.required, config); err != nil {
+		return err
+	}
+
+	s := make(settings, len(t.def.schema)+len(config))
+	for name, field := range t.def.schema {
+		if field.Default != nil {
+			s[name] = field.Default
+		}
+	}
+	for k, v := range config {
+		s[k] = v
+	}
+
+	d, err := t.def.newDetector(t.info.ID, s)
+	if err != nil {
+		return fmt.Errorf("invalid %s trigger configuration: %w", t.def.name, err)
+	}
+
+	t.mu.Lock()
+	t.settings = s
+	t.detector = d
+	running := t.cancel != nil
+	t.mu.Unlock()
+
+	if running {
+		if err := t.Stop(ctx); err != nil {
+			return err
+		}
+		return t.Start(ctx)
+	}
+	return nil
+}
+
+// Start starts detection; an unconfigured trigger uses the schema defaults
+func (t *Trigger) Start(ctx context.Context) error {
+	t.mu.Lock()
+	configured := t.detector != nil
+	t.mu.Unlock()
+	if !configured {
+		if err := t.Initialize(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancel != nil {
+		return fmt.Errorf("trigger %s is already running", t.info.ID)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	t.status = plugin.StatusRunning
+	t.startedAt = time.Now()
+	t.consecutiveFailures = 0
+
+	go t.run(runCtx, t.detector, t.done)
+	return nil
+}
+
+// Stop stops detection
+func (t *Trigger) Stop(ctx context.Context) error {
+	t.mu.Lock()
+	cancel, done := t.cancel, t.done
+	t.cancel = nil
+	t.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for trigger %s to stop: %w", t.info.ID, ctx.Err())
+	}
+
+	t.mu.Lock()
+	t.status = plugin.StatusStopped
+	t.mu.Unlock()
+	return nil
+}
+
+// GetStatus returns the current status. A trigger whose detector failed
+// reports StatusError until it is restarted.
+func (t *Trigger) GetStatus() plugin.Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// GetHealth reports the outcome of recent checks
+func (t *Trigger) GetHealth() *plugin.Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := &plugin.Health{
+		LastCheck:  t.lastCheck,
+		ErrorCount: t.failures,
+		LastError:  t.lastError,
+		Metrics: map[string]interface{}{
+			"checks":         t.checks,
+			"failures":       t.failures,
+			"events_emitted": t.emitted,
+		},
+	}
+	if !t.lastEvent.IsZero() {
+		health.Metrics["last_event"] = t.lastEvent
+	}
+
+	switch {
+	case t.status == plugin.StatusError:
+		health.Status = plugin.HealthStatusUnhealthy
+		health.Message = "trigger stopped after an error"
+	case t.status != plugin.StatusRunning:
+		health.Status = plugin.HealthStatusUnknown
+		health.Message = "trigger is not running"
+	case t.consecutiveFailures >= unhealthyAfter:
+		health.Status = plugin.HealthStatusUnhealthy
+		health.Message = fmt.Sprintf("last %d checks failed", t.consecutiveFailures)
+	case t.consecutiveFailures > 0:
+		health.Status = plugin.HealthStatusDegraded
+		health.Message = "last check failed"
+	default:
+		health.Status = plugin.HealthStatusHealthy
+		health.Message = "trigger is running"
+	}
+	if t.status == plugin.StatusRunning || t.status == plugin.StatusError {
+		health.Uptime = time.Since(t.startedAt)
+	}
+	return health
+}
+
+// DetectTriggers returns the channel of detected events
+func (t *Trigger) DetectTriggers(ctx context.Context) (<-chan *plugin.TriggerEvent, error) {
+	return t.events, nil
+}
+
+// run runs the detector until it fails or ctx is cancelled
+func (t *Trigger) run(ctx context.Context, d detector, done chan struct{}) {
+	defer close(done)
+
+	err := d.run(ctx, func(event *plugin.TriggerEvent) {
+		t.emit(ctx, event)
+	}, t.record)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	t.logger.Error("Built-in trigger stopped", zap.Error(err))
+	t.mu.Lock()
+	t.status = plugin.StatusError
+	t.failures++
+	t.lastError = err.Error()
+	t.mu.Unlock()
+}
+
+// emit fills in the fields detectors leave empty and sends an event, waiting
+// while the channel is full
+func (t *Trigger) emit(ctx context.Context, event *plugin.TriggerEvent) {
+	now := time.Now()
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("%s-%d", t.info.ID, now.UnixNano())
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = now
+	}
+	if event.Source == "" {
+		event.Source = t.info.ID
+	}
+	if event.Severity == "" {
+		t.mu.Lock()
+		event.Severity = plugin.Severity(t.settings.string("severity"))
+		t.mu.Unlock()
+	}
+
+	select {
+	case t.events <- event:
+	case <-ctx.Done():
+		return
+	}
+
+	t.mu.Lock()
+	t.emitted++
+	t.lastEvent = now
+	t.mu.Unlock()
+}
+
+// record records the outcome of a check
+func (t *Trigger) record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.checks++
+	t.lastCheck = time.Now()
+	if err != nil {
+		t.failures++
+		t.consecutiveFailures++
+		t.lastError = err.Error()
+		t.logger.Warn("Built-in trigger check failed", zap.Error(err))
+		return
+	}
+	t.consecutiveFailures = 0
+}
+
+// conditions tracks the conditions a trigger is alerting on, so it can
+// report once they clear
+type conditions map[string]bool
+
+// update returns the events for the current state of a condition: event
+// while it holds, and event marked resolved the first time it does not
+func (c conditions) update(key string, holds bool, event *plugin.TriggerEvent) []*plugin.TriggerEvent {
+	if holds {
+		c[key] = true
+		return []*plugin.TriggerEvent{event}
+	}
+	if !c[key] {
+		return nil
+	}
+	delete(c, key)
+
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]interface{})
+	}
+	event.Metadata[metadataState] = stateResolved
+	return []*plugin.TriggerEvent{event}
+}
@@ -0,0 +1,227 @@
+// Package triggers provides the trigger plugins built into the sensor agent:
+// host resource thresholds read from /proc, file and log watching, network
+// probes and systemd unit state.
+package triggers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Built-in trigger types
+const (
+	TypeCPU       = "cpu"
+	TypeMemory    = "memory"
+	TypeDisk      = "disk"
+	TypeLoad      = "load"
+	TypeFileWatch = "file_watch"
+	TypeLogTail   = "log_tail"
+	TypeTCPProbe  = "tcp_probe"
+	TypeHTTPProbe = "http_probe"
+	TypeSystemd   = "systemd"
+)
+
+// definition describes a built-in trigger type
+type definition struct {
+	name        string
+	description string
+	schema      map[string]*plugin.ConfigField
+	required    []string
+	examples    []map[string]interface{}
+	// newDetector builds the detector for a trigger from its settings
+	newDetector func(id string, s settings) (detector, error)
+}
+
+// definitions are the built-in trigger types by type name
+var definitions = map[string]*definition{
+	TypeCPU:       cpuDefinition,
+	TypeMemory:    memoryDefinition,
+	TypeDisk:      diskDefinition,
+	TypeLoad:      loadDefinition,
+	TypeFileWatch: fileWatchDefinition,
+	TypeLogTail:   logTailDefinition,
+	TypeTCPProbe:  tcpProbeDefinition,
+	TypeHTTPProbe: httpProbeDefinition,
+	TypeSystemd:   systemdDefinition,
+}
+
+// Types returns the names of the built-in trigger types
+func Types() []string {
+	types := make([]string, 0, len(definitions))
+	for name := range definitions {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates an unconfigured built-in trigger of the given type
+func New(triggerType, id string, logger *zap.Logger) (*Trigger, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	def, ok := definitions[triggerType]
+	if !ok {
+		return nil, fmt.Errorf("unknown built-in trigger type %q", triggerType)
+	}
+	if id == "" {
+		id = "builtin-" + triggerType
+	}
+	return newTrigger(id, def, logger), nil
+}
+
+// Registrar is the part of the plugin manager built-in triggers are
+// registered and configured with
+type Registrar interface {
+	RegisterPlugin(p plugin.Plugin) error
+	UnregisterPlugin(id string) error
+	ConfigurePlugin(ctx context.Context, id string, config map[string]interface{}) error
+}
+
+// Register creates the configured built-in triggers and registers them with
+// the plugin manager, which starts them with the other trigger plugins
+func Register(ctx context.Context, manager Registrar, configs []config.BuiltinTriggerConfig, logger *zap.Logger) error {
+	for _, cfg := range configs {
+		trigger, err := New(cfg.Type, cfg.ID, logger)
+		if err != nil {
+			return err
+		}
+		id := trigger.GetInfo().ID
+
+		if err := manager.RegisterPlugin(trigger); err != nil {
+			return fmt.Errorf("failed to register built-in trigger %s: %w", id, err)
+		}
+		if err := manager.ConfigurePlugin(ctx, id, cfg.Config); err != nil {
+			manager.UnregisterPlugin(id)
+			return fmt.Errorf("failed to configure built-in trigger %s: %w", id, err)
+		}
+
+		logger.Info("Registered built-in trigger",
+			zap.String("plugin_id", id),
+			zap.String("type", cfg.Type))
+	}
+	return nil
+}
+
+// settings are a trigger's configuration with schema defaults applied
+type settings map[string]interface{}
+
+func (s settings) string(name string) string {
+	value, _ := s[name].(string)
+	return value
+}
+
+func (s settings) number(name string) float64 {
+	switch n := s[name].(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+func (s settings) boolean(name string) bool {
+	value, _ := s[name].(bool)
+	return value
+}
+
+func (s settings) strings(name string) []string {
+	switch values := s[name].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			result = append(result, fmt.Sprint(v))
+		}
+		return result
+	}
+	return nil
+}
+
+// duration parses a duration setting, which must be at least min
+func (s settings) duration(name string, min time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(s.string(name))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d < min {
+		return 0, fmt.Errorf("%s: must be at least %s", name, min)
+	}
+	return d, nil
+}
+
+// poll returns a poller running c at the trigger's interval
+func (s settings) poll(c checker) (detector, error) {
+	interval, err := s.duration("interval", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &poller{interval: interval, checker: c}, nil
+}
+
+// Schema building blocks
+
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+
+var severities = []string{
+	string(plugin.SeverityLow),
+	string(plugin.SeverityMedium),
+	string(plugin.SeverityHigh),
+	string(plugin.SeverityCritical),
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+// schema returns the settings every built-in trigger accepts merged with its
+// own. Triggers that do not poll have no interval.
+func schema(interval string, severity plugin.Severity, fields map[string]*plugin.ConfigField) map[string]*plugin.ConfigField {
+	fields["severity"] = &plugin.ConfigField{
+		Type:        "string",
+		Description: "Severity of the events the trigger emits",
+		Default:     string(severity),
+		Enum:        severities,
+	}
+	if interval != "" {
+		fields["interval"] = &plugin.ConfigField{
+			Type:        "string",
+			Description: "How often the trigger checks, as a duration of at least 1s",
+			Default:     interval,
+			Pattern:     durationPattern,
+			Examples:    []interface{}{"10s", "1m"},
+		}
+	}
+	return fields
+}
+
+// percentField is a usage threshold in percent
+func percentField(description string, def float64) *plugin.ConfigField {
+	return &plugin.ConfigField{
+		Type:        "number",
+		Description: description,
+		Default:     def,
+		Minimum:     bound(0),
+		Maximum:     bound(100),
+		Examples:    []interface{}{80, 95},
+	}
+}
@@ -0,0 +1,335 @@
+package triggers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	sharedagent "github.com/Stavily/01-Agents/shared/pkg/agent"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString(content)
+	require.NoError(t, err)
+}
+
+func resolved(event *plugin.TriggerEvent) bool {
+	return event.Metadata[metadataState] == stateResolved
+}
+
+func TestHostCheckers(t *testing.T) {
+	ctx := context.Background()
+	proc := t.TempDir()
+
+	t.Run("cpu", func(t *testing.T) {
+		c := &cpuChecker{threshold: 80, proc: proc, alerts: conditions{}}
+
+		writeFile(t, filepath.Join(proc, "stat"), "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 100 0 100 700 100 0 0 0 0 0\n")
+		events, err := c.check(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, events, "the first sample only primes the checker")
+
+		// 90 busy out of 100
+		writeFile(t, filepath.Join(proc, "stat"), "cpu  190 0 100 710 100 0 0 0 0 0\n")
+		events, err = c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "cpu_high", events[0].Type)
+		assert.Equal(t, 90.0, events[0].Data["usage_percent"])
+		assert.False(t, resolved(events[0]))
+
+		// 10 busy out of 100
+		writeFile(t, filepath.Join(proc, "stat"), "cpu  200 0 100 800 100 0 0 0 0 0\n")
+		events, err = c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.True(t, resolved(events[0]))
+
+		events, err = c.check(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		c := &memoryChecker{threshold: 90, proc: proc, alerts: conditions{}}
+
+		writeFile(t, filepath.Join(proc, "meminfo"), "MemTotal:       1000 kB\nMemFree:          20 kB\nMemAvailable:     50 kB\n")
+		events, err := c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "memory_high", events[0].Type)
+		assert.Equal(t, 95.0, events[0].Data["used_percent"])
+		assert.Equal(t, uint64(1000*1024), events[0].Data["total_bytes"])
+
+		writeFile(t, filepath.Join(proc, "meminfo"), "MemTotal: 1000 kB\nMemAvailable: 500 kB\n")
+		events, err = c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.True(t, resolved(events[0]))
+	})
+
+	t.Run("load", func(t *testing.T) {
+		c := &loadChecker{threshold: 2, period: 5, cpus: 2, proc: proc, alerts: conditions{}}
+
+		writeFile(t, filepath.Join(proc, "loadavg"), "9.00 5.00 1.00 3/512 4242\n")
+		events, err := c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "load_high", events[0].Type)
+		assert.Equal(t, 2.5, events[0].Data["normalized"])
+
+		c.period = 15
+		events, err = c.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.True(t, resolved(events[0]))
+	})
+
+	t.Run("disk", func(t *testing.T) {
+		stats := map[string]*diskStats{
+			"/":    {total: 100, free: 5, available: 5, inodes: 100, inodesFree: 50},
+			"/var": {total: 100, free: 50, available: 50, inodes: 100, inodesFree: 1},
+		}
+		c := &diskChecker{
+			id:             "disk",
+			paths:          []string{"/", "/var", "/missing"},
+			threshold:      90,
+			inodeThreshold: 90,
+			usage: func(path string) (*diskStats, error) {
+				if s, ok := stats[path]; ok {
+					return s, nil
+				}
+				return nil, os.ErrNotExist
+			},
+			alerts: conditions{},
+		}
+
+		events, err := c.check(ctx)
+		assert.ErrorContains(t, err, "/missing")
+		require.Len(t, events, 2)
+		assert.Equal(t, "disk_usage_high", events[0].Type)
+		assert.Equal(t, "disk:/", events[0].Source)
+		assert.Equal(t, "disk_inodes_high", events[1].Type)
+		assert.Equal(t, "disk:/var", events[1].Source)
+	})
+}
+
+func TestLogTailer(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "auth.log")
+	writeFile(t, path, "Failed password for old from 10.0.0.1\n")
+
+	tail, err := logTailDefinition.newDetector("auth", settings{
+		"interval":             "1s",
+		"path":                 path,
+		"pattern":              `Failed password for (?P<user>\S+) from (?P<ip>\S+)`,
+		"event_type":           "ssh_login_failed",
+		"max_events_per_check": 2,
+	})
+	require.NoError(t, err)
+	tailer := tail.(*poller).checker
+
+	events, err := tailer.check(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, events, "lines written before the trigger started are skipped")
+
+	appendFile(t, path, "Accepted password for alice\nFailed password for bob from 10.0.0.2\nFailed password for eve")
+	events, err = tailer.check(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "the incomplete line is read once it is complete")
+	assert.Equal(t, "ssh_login_failed", events[0].Type)
+	assert.Equal(t, "bob", events[0].Data["user"])
+	assert.Equal(t, "10.0.0.2", events[0].Data["ip"])
+
+	appendFile(t, path, " from 10.0.0.3\nFailed password for a from b\nFailed password for c from d\n")
+	events, err = tailer.check(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "eve", events[0].Data["user"])
+	assert.Equal(t, 1, events[1].Data["suppressed_matches"])
+
+	// Rotation starts over at the beginning of the new file
+	require.NoError(t, os.Rename(path, path+".1"))
+	writeFile(t, path, "Failed password for root from 10.0.0.4\n")
+	events, err = tailer.check(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "root", events[0].Data["user"])
+}
+
+func TestFileWatchTrigger(t *testing.T) {
+	dir := t.TempDir()
+	trigger, err := New(TypeFileWatch, "", zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.Equal(t, "builtin-file_watch", trigger.GetInfo().ID)
+
+	ctx := context.Background()
+	require.NoError(t, trigger.Initialize(ctx, map[string]interface{}{
+		"paths":      []interface{}{dir},
+		"recursive":  true,
+		"operations": []interface{}{"create", "write"},
+		"pattern":    "*.conf",
+	}))
+	require.NoError(t, trigger.Start(ctx))
+	defer trigger.Stop(ctx)
+
+	events, err := trigger.DetectTriggers(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sites"), 0755))
+	writeFile(t, filepath.Join(dir, "ignored.txt"), "x")
+	// Give the watcher time to watch the new directory
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, filepath.Join(dir, "sites", "app.conf"), "x")
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "file_changed", event.Type)
+		assert.Equal(t, filepath.Join(dir, "sites", "app.conf"), event.Data["path"])
+		assert.Equal(t, "builtin-file_watch:"+filepath.Join(dir, "sites", "app.conf"), event.Source)
+		assert.Equal(t, plugin.SeverityMedium, event.Severity)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no file change event")
+	}
+	assert.Equal(t, plugin.HealthStatusHealthy, trigger.GetHealth().Status)
+}
+
+func TestProbes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+
+		probe := &tcpProbe{address: address, timeout: time.Second, alerts: conditions{}}
+		events, err := probe.check(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		listener.Close()
+		events, err = probe.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "tcp_probe_failed", events[0].Type)
+		assert.NotEmpty(t, events[0].Data["error"])
+	})
+
+	t.Run("http", func(t *testing.T) {
+		var healthy atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "secret", r.Header.Get("X-Token"))
+			if healthy.Load() {
+				w.Write([]byte(`{"status": "ok"}`))
+				return
+			}
+			w.Write([]byte(`{"status": "starting"}`))
+		}))
+		defer server.Close()
+
+		d, err := httpProbeDefinition.newDetector("web", settings{
+			"interval":     "1s",
+			"url":          server.URL,
+			"method":       "GET",
+			"timeout":      "1s",
+			"body_pattern": `"status":\s*"ok"`,
+			"headers":      map[string]interface{}{"X-Token": "secret"},
+		})
+		require.NoError(t, err)
+		probe := d.(*poller).checker
+
+		events, err := probe.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "http_probe_failed", events[0].Type)
+		assert.Equal(t, 200, events[0].Data["status_code"])
+		assert.Contains(t, events[0].Data["error"], "does not match")
+
+		healthy.Store(true)
+		events, err = probe.check(ctx)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.True(t, resolved(events[0]))
+	})
+}
+
+func TestSystemdChecker(t *testing.T) {
+	var args []string
+	c := &systemdChecker{
+		id:       "units",
+		units:    []string{"nginx.service", "missing.service"},
+		expected: "active",
+		alerts:   conditions{},
+		run: func(ctx context.Context, a ...string) ([]byte, error) {
+			args = a
+			return []byte("Id=nginx.service\nLoadState=loaded\nActiveState=active\nSubState=running\n\n" +
+				"Id=missing.service\nLoadState=not-found\nActiveState=inactive\nSubState=dead\n"), nil
+		},
+	}
+
+	events, err := c.check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nginx.service", "missing.service"}, args[len(args)-2:])
+	require.Len(t, events, 1)
+	assert.Equal(t, "systemd_unit_state", events[0].Type)
+	assert.Equal(t, "units:missing.service", events[0].Source)
+	assert.Equal(t, "not-found", events[0].Data["load_state"])
+
+	c.units = c.units[:1]
+	_, err = c.check(context.Background())
+	assert.ErrorContains(t, err, "expected 1 units, got 2")
+}
+
+func TestRegister(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	manager, err := sharedagent.NewPluginManager(&config.PluginConfig{Directory: t.TempDir()}, logger)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = Register(ctx, manager, []config.BuiltinTriggerConfig{
+		{Type: TypeCPU, Config: map[string]interface{}{"threshold": 75, "interval": "10s"}},
+		{ID: "api", Type: TypeHTTPProbe, Config: map[string]interface{}{"url": "http://localhost:8080/healthz"}},
+	}, logger)
+	require.NoError(t, err)
+
+	p, err := manager.GetPlugin("builtin-cpu")
+	require.NoError(t, err)
+	cpu := p.(*Trigger)
+	assert.Equal(t, 75, cpu.settings["threshold"])
+	assert.Equal(t, "high", cpu.settings.string("severity"))
+	_, err = manager.GetPlugin("api")
+	require.NoError(t, err)
+
+	err = Register(ctx, manager, []config.BuiltinTriggerConfig{
+		{ID: "bad", Type: TypeTCPProbe, Config: map[string]interface{}{"address": "no-port"}},
+	}, logger)
+	assert.ErrorContains(t, err, "address")
+	_, err = manager.GetPlugin("bad")
+	assert.Error(t, err, "a trigger that fails to configure is unregistered")
+
+	err = Register(ctx, manager, []config.BuiltinTriggerConfig{{Type: "nope"}}, logger)
+	assert.ErrorContains(t, err, "unknown built-in trigger type")
+
+	assert.Len(t, Types(), 9)
+}
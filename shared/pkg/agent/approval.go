@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// ApprovalPolicy reports whether an instruction needs approval before it
+// executes, and why
+type ApprovalPolicy func(instruction *api.Instruction) (bool, string)
+
+// ApprovalGate decides which instructions wait for approval and checks the
+// approval tokens signed by the orchestrator
+type ApprovalGate struct {
+	key     ed25519.PublicKey
+	agentID string
+	timeout time.Duration
+	policy  ApprovalPolicy
+	now     func() time.Time
+}
+
+// NewApprovalGate creates an approval gate from its configuration
+func NewApprovalGate(cfg *config.ApprovalConfig, agentID string, policy ApprovalPolicy) (*ApprovalGate, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("approval configuration is required")
+	}
+	if policy == nil {
+		return nil, fmt.Errorf("approval policy is required")
+	}
+
+	encoded := cfg.PublicKey
+	if encoded == "" && cfg.PublicKeyFile != "" {
+		content, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read approval public key: %w", err)
+		}
+		encoded = string(content)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("approval public key is required")
+	}
+	key, err := parseApprovalKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid approval public key: %w", err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = time.Hour
+	}
+
+	return &ApprovalGate{
+		key:     key,
+		agentID: agentID,
+		timeout: timeout,
+		policy:  policy,
+		now:     time.Now,
+	}, nil
+}
+
+// parseApprovalKey parses an Ed25519 public key, PEM encoded or as the
+// base64 encoding of its 32 bytes
+func parseApprovalKey(encoded string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("not an Ed25519 key")
+		}
+		return key, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Requires reports whether an instruction must be approved before it executes
+func (g *ApprovalGate) Requires(instruction *api.Instruction) (bool, string) {
+	return g.policy(instruction)
+}
+
+// Timeout returns how long an instruction waits for approval
+func (g *ApprovalGate) Timeout() time.Duration {
+	return g.timeout
+}
+
+// Verify checks that an approval was signed by the orchestrator for this
+// agent and has not expired
+func (g *ApprovalGate) Verify(approval *api.InstructionApproval) error {
+	if approval == nil || approval.InstructionID == "" {
+		return fmt.Errorf("approval has no instruction ID")
+	}
+	if approval.AgentID != g.agentID {
+		return fmt.Errorf("approval is for agent %q", approval.AgentID)
+	}
+	if !ed25519.Verify(g.key, approval.SigningPayload(), approval.Signature) {
+		return fmt.Errorf("approval signature is invalid")
+	}
+	if !g.now().Before(approval.ExpiresAt) {
+		return fmt.Errorf("approval expired at %s", approval.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
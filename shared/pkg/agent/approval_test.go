@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func signApproval(key ed25519.PrivateKey, instruction *api.Instruction, expiresAt time.Time) *api.InstructionApproval {
+	approval := &api.InstructionApproval{
+		InstructionID:     instruction.ID,
+		InstructionDigest: instruction.ApprovalDigest(),
+		AgentID:           "agent-1",
+		ApprovedBy:        "alice@example.com",
+		ExpiresAt:         expiresAt,
+	}
+	approval.Signature = ed25519.Sign(key, approval.SigningPayload())
+	return approval
+}
+
+func TestApprovalGateVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	policy := func(*api.Instruction) (bool, string) { return true, "always" }
+
+	_, err = NewApprovalGate(&config.ApprovalConfig{}, "agent-1", policy)
+	assert.ErrorContains(t, err, "public key is required")
+	_, err = NewApprovalGate(&config.ApprovalConfig{PublicKey: "c2hvcnQ="}, "agent-1", policy)
+	assert.ErrorContains(t, err, "expected 32 bytes")
+
+	// The key may also be read from a PEM file
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "approval.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	gate, err := NewApprovalGate(&config.ApprovalConfig{PublicKeyFile: keyFile}, "agent-1", policy)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, gate.Timeout())
+
+	gate, err = NewApprovalGate(&config.ApprovalConfig{PublicKey: base64.StdEncoding.EncodeToString(public)}, "agent-1", policy)
+	require.NoError(t, err)
+
+	instruction := &api.Instruction{ID: "inst-1", PluginID: "restart"}
+	approval := signApproval(private, instruction, time.Now().Add(time.Minute))
+	assert.NoError(t, gate.Verify(approval))
+	assert.True(t, approval.Approves(instruction))
+
+	tampered := *approval
+	tampered.InstructionID = "inst-2"
+	assert.ErrorContains(t, gate.Verify(&tampered), "signature is invalid")
+	tampered = *approval
+	tampered.InstructionDigest = (&api.Instruction{ID: "inst-1", PluginID: "wipe"}).ApprovalDigest()
+	assert.ErrorContains(t, gate.Verify(&tampered), "signature is invalid")
+
+	other := signApproval(private, instruction, time.Now().Add(time.Minute))
+	other.AgentID = "agent-2"
+	assert.ErrorContains(t, gate.Verify(other), "for agent")
+
+	expired := signApproval(private, instruction, time.Now().Add(-time.Second))
+	assert.ErrorContains(t, gate.Verify(expired), "expired")
+
+	_, forged, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	assert.ErrorContains(t, gate.Verify(signApproval(forged, instruction, time.Now().Add(time.Minute))), "signature is invalid")
+}
+
+// recordingOrchestrator records the status updates and results it receives
+type recordingOrchestrator struct {
+	mu       sync.Mutex
+	statuses []string
+	results  []api.InstructionResultRequest
+}
+
+func (o *recordingOrchestrator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPut:
+		var update api.InstructionUpdateRequest
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		o.statuses = append(o.statuses, update.Status)
+		_ = json.NewEncoder(w).Encode(api.InstructionUpdateResponse{Success: true})
+	case strings.HasSuffix(r.URL.Path, "/result"):
+		var result api.InstructionResultRequest
+		_ = json.NewDecoder(r.Body).Decode(&result)
+		o.results = append(o.results, result)
+		_ = json.NewEncoder(w).Encode(api.InstructionResultResponse{Acknowledged: true})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOrchestratorWorkflowApproval(t *testing.T) {
+	orchestrator := &recordingOrchestrator{}
+	server := httptest.NewServer(orchestrator)
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:       server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			RetryDelay:    time.Millisecond,
+			RateLimitRPS:  100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer test-key"},
+		},
+	}
+
+	var executed []string
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		executed = append(executed, instruction.ID)
+		return map[string]interface{}{"ok": true}, nil
+	})
+	require.NoError(t, err)
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	gate, err := NewApprovalGate(&config.ApprovalConfig{
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+		Timeout:   time.Minute,
+	}, "agent-1", func(instruction *api.Instruction) (bool, string) {
+		return instruction.Priority == "urgent", "instruction priority is urgent"
+	})
+	require.NoError(t, err)
+	workflow.SetApprovalGate(gate)
+	ctx := context.Background()
+
+	// Instructions the policy does not select run straight away
+	workflow.processInstruction(ctx, &api.Instruction{ID: "inst-0", PluginID: "cleanup"})
+	assert.Equal(t, []string{"inst-0"}, executed)
+
+	// A high-risk instruction waits, and runs once approved
+	inst1 := &api.Instruction{ID: "inst-1", PluginID: "restart", Priority: "urgent"}
+	workflow.processInstruction(ctx, inst1)
+	assert.Equal(t, []string{"inst-0"}, executed)
+	assert.Equal(t, 1, workflow.GetStatus()["awaiting_approval"])
+
+	workflow.approveInstruction(signApproval(private, inst1, time.Now().Add(time.Minute)))
+	workflow.processInstruction(ctx, <-workflow.instructionChan)
+	assert.Equal(t, []string{"inst-0", "inst-1"}, executed)
+
+	// An approval may arrive before its instruction; forged ones are ignored
+	inst2 := &api.Instruction{ID: "inst-2", Priority: "urgent"}
+	workflow.approveInstruction(signApproval(private, inst2, time.Now().Add(time.Minute)))
+	workflow.processInstruction(ctx, inst2)
+	inst3 := &api.Instruction{ID: "inst-3", Priority: "urgent"}
+	workflow.processInstruction(ctx, inst3)
+	_, forged, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	workflow.approveInstruction(signApproval(forged, inst3, time.Now().Add(time.Minute)))
+	assert.Equal(t, []string{"inst-0", "inst-1", "inst-2"}, executed)
+	assert.Empty(t, workflow.instructionChan)
+
+	// Instructions not approved in time expire
+	gate.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	workflow.checkAwaitingApproval(ctx)
+	assert.Equal(t, 0, workflow.GetStatus()["awaiting_approval"])
+
+	orchestrator.mu.Lock()
+	defer orchestrator.mu.Unlock()
+	assert.Equal(t, []string{"executing", "awaiting_approval", "executing", "executing", "awaiting_approval"}, orchestrator.statuses)
+	require.Len(t, orchestrator.results, 4)
+	assert.Equal(t, "timeout", orchestrator.results[3].Status)
+	assert.Contains(t, orchestrator.results[3].ErrorMessage, "not approved within 1m0s")
+}
+
+func TestOrchestratorWorkflowApprovalBindsInstructionContent(t *testing.T) {
+	orchestrator := &recordingOrchestrator{}
+	server := httptest.NewServer(orchestrator)
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:       server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			RetryDelay:    time.Millisecond,
+			RateLimitRPS:  100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer test-key"},
+		},
+	}
+
+	var executed []string
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		executed = append(executed, instruction.ID)
+		return map[string]interface{}{"ok": true}, nil
+	})
+	require.NoError(t, err)
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	gate, err := NewApprovalGate(&config.ApprovalConfig{
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+		Timeout:   time.Minute,
+	}, "agent-1", func(*api.Instruction) (bool, string) { return true, "always" })
+	require.NoError(t, err)
+	workflow.SetApprovalGate(gate)
+	ctx := context.Background()
+
+	// An approval does not carry over to different input
+	approved := &api.Instruction{
+		ID:                  "inst-1",
+		PluginID:            "restart",
+		PluginConfiguration: map[string]interface{}{"service": "web"},
+		InputData:           map[string]interface{}{"force": false},
+	}
+	workflow.approveInstruction(signApproval(private, approved, time.Now().Add(time.Minute)))
+	changed := *approved
+	changed.InputData = map[string]interface{}{"force": true}
+	workflow.processInstruction(ctx, &changed)
+	assert.Empty(t, executed)
+	assert.Equal(t, 1, workflow.GetStatus()["awaiting_approval"])
+
+	// An approved instruction is held rather than dropped while the
+	// instruction queue is full
+	for len(workflow.instructionChan) < cap(workflow.instructionChan) {
+		workflow.instructionChan <- &api.Instruction{ID: "filler"}
+	}
+	workflow.approveInstruction(signApproval(private, &changed, time.Now().Add(time.Minute)))
+	assert.Equal(t, 1, workflow.GetStatus()["awaiting_approval"])
+
+	for len(workflow.instructionChan) > 0 {
+		<-workflow.instructionChan
+	}
+	workflow.checkAwaitingApproval(ctx)
+	assert.Equal(t, 0, workflow.GetStatus()["awaiting_approval"])
+	workflow.processInstruction(ctx, <-workflow.instructionChan)
+	assert.Equal(t, []string{"inst-1"}, executed)
+}
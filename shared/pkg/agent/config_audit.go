@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
+)
+
+// ConfigAuditor records the configuration file the agent loaded, and every
+// later change to it, in the audit log
+type ConfigAuditor struct {
+	path     string
+	log      *audit.Logger
+	logger   *zap.Logger
+	debounce time.Duration
+
+	digest string
+}
+
+// NewConfigAuditor creates an auditor for the configuration file at path
+func NewConfigAuditor(path string, log *audit.Logger, logger *zap.Logger) (*ConfigAuditor, error) {
+	if path == "" {
+		return nil, fmt.Errorf("configuration file is required")
+	}
+	if log == nil {
+		return nil, fmt.Errorf("audit log is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve configuration file: %w", err)
+	}
+	return &ConfigAuditor{
+		path:     absPath,
+		log:      log,
+		logger:   logger,
+		debounce: 500 * time.Millisecond,
+	}, nil
+}
+
+// Run records the loaded configuration, then records changes to the file
+// until ctx is cancelled. The running agent keeps the configuration it
+// loaded; changes take effect on restart.
+func (a *ConfigAuditor) Run(ctx context.Context) error {
+	digest, err := fileDigest(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	a.digest = digest
+	a.record(audit.EventConfigLoaded, map[string]interface{}{"file": a.path, "digest": digest})
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create configuration watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory, as editors often replace the file rather than
+	// write to it
+	if err := watcher.Add(filepath.Dir(a.path)); err != nil {
+		return fmt.Errorf("failed to watch configuration directory: %w", err)
+	}
+
+	timer := time.NewTimer(a.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == a.path {
+				timer.Reset(a.debounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			a.logger.Warn("Configuration watcher error", zap.Error(err))
+
+		case <-timer.C:
+			a.check()
+		}
+	}
+}
+
+// check records a change if the file's content differs from the last
+// recorded content
+func (a *ConfigAuditor) check() {
+	digest, err := fileDigest(a.path)
+	if err != nil && !os.IsNotExist(err) {
+		a.logger.Warn("Failed to read changed configuration file", zap.Error(err))
+		return
+	}
+	if digest == a.digest {
+		return
+	}
+
+	data := map[string]interface{}{"file": a.path, "previous_digest": a.digest}
+	if digest == "" {
+		data["removed"] = true
+	} else {
+		data["digest"] = digest
+	}
+	a.digest = digest
+
+	a.logger.Warn("Configuration file changed, restart the agent to apply it",
+		zap.String("file", a.path))
+	a.record(audit.EventConfigChanged, data)
+}
+
+func (a *ConfigAuditor) record(event string, data map[string]interface{}) {
+	if err := a.log.Log(&audit.Record{
+		Actor:   audit.ActorLocal,
+		Event:   event,
+		Outcome: audit.OutcomeSuccess,
+		Data:    data,
+	}); err != nil {
+		a.logger.Error("Failed to write audit record", zap.String("event", event), zap.Error(err))
+	}
+}
+
+// fileDigest returns the SHA-256 digest of a file
+func fileDigest(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
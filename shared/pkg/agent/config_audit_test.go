@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// auditEvents returns the events recorded in an audit log, after checking
+// its chain
+func auditEvents(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	_, err := audit.Verify(path)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var records []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(content), []byte("\n")) {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// auditLines returns the number of records written to an audit log so far
+func auditLines(path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return bytes.Count(content, []byte("\n"))
+}
+
+func TestConfigAuditor(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "agent.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("agent:\n  id: agent-1\n"), 0600))
+	auditFile := filepath.Join(dir, "audit.log")
+	log, err := audit.NewLogger(config.AuditConfig{Enabled: true, LogFile: auditFile}, "agent-1")
+	require.NoError(t, err)
+	defer log.Close()
+
+	auditor, err := NewConfigAuditor(configFile, log, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	auditor.debounce = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- auditor.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return auditLines(auditFile) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Rewriting the same content is not a change
+	require.NoError(t, os.WriteFile(configFile, []byte("agent:\n  id: agent-1\n"), 0600))
+	require.NoError(t, os.WriteFile(configFile, []byte("agent:\n  id: agent-2\n"), 0600))
+	require.Eventually(t, func() bool {
+		return auditLines(auditFile) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	records := auditEvents(t, auditFile)
+	assert.Equal(t, audit.EventConfigLoaded, records[0]["event"])
+	changed := records[1]
+	assert.Equal(t, audit.EventConfigChanged, changed["event"])
+	assert.Equal(t, audit.ActorLocal, changed["actor"])
+	data := changed["data"].(map[string]interface{})
+	assert.Equal(t, records[0]["data"].(map[string]interface{})["digest"], data["previous_digest"])
+	assert.NotEqual(t, data["previous_digest"], data["digest"])
+}
+
+func TestOrchestratorWorkflowAuditsAuthFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:       server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 1,
+			RetryDelay:    time.Millisecond,
+			RateLimitRPS:  100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer revoked-key"},
+		},
+	}
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.NewLogger(config.AuditConfig{Enabled: true, LogFile: auditFile}, "agent-1")
+	require.NoError(t, err)
+	workflow.SetAuditLog(log)
+
+	workflow.sendHeartbeat(context.Background())
+	workflow.auditReceived(&api.Instruction{ID: "inst-1", PluginID: "cleanup", InstructionType: "execute", Priority: "high"})
+	require.NoError(t, log.Close())
+
+	records := auditEvents(t, auditFile)
+	require.Len(t, records, 2)
+	failed := records[0]
+	assert.Equal(t, audit.EventAuthFailed, failed["event"])
+	assert.Equal(t, audit.OutcomeFailure, failed["outcome"])
+	assert.Equal(t, float64(http.StatusUnauthorized), failed["data"].(map[string]interface{})["status_code"])
+
+	received := records[1]
+	assert.Equal(t, audit.EventInstructionReceived, received["event"])
+	assert.Equal(t, audit.ActorOrchestrator, received["actor"])
+	assert.Equal(t, "inst-1", received["instruction_id"])
+	assert.Equal(t, "high", received["data"].(map[string]interface{})["priority"])
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/Stavily/01-Agents/shared/pkg/config"
 	"github.com/Stavily/01-Agents/shared/pkg/instruction"
 	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
 	"go.uber.org/zap"
 )
@@ -22,6 +24,7 @@ type EnhancedPluginManager struct {
 	instructionHandler *instruction.Handler
 	factory           *plugin.Factory
 	pendingInstructions sync.Map // map[string]*types.Instruction
+	digests sync.Map // map[string]string, plugin ID to digest of its installed files
 }
 
 // EnhancedPluginConfig contains configuration for the enhanced plugin manager
@@ -52,6 +55,7 @@ func NewEnhancedPluginManager(cfg *EnhancedPluginConfig, logger *zap.Logger) (*E
 	if baseDir == "" {
 		baseDir = filepath.Join(".", "plugins")
 	}
+	basePM.baseDir = baseDir
 
 	// Create plugin factory
 	factoryConfig := &plugin.FactoryConfig{
@@ -68,6 +72,14 @@ func NewEnhancedPluginManager(cfg *EnhancedPluginConfig, logger *zap.Logger) (*E
 		ExecTimeout:   cfg.ExecTimeout,
 	}
 	instructionHandler := instruction.NewHandler(logger, handlerConfig)
+	instructionHandler.SetActionLookup(func(pluginID string) (plugin.ActionPlugin, bool) {
+		p, err := basePM.GetPlugin(pluginID)
+		if err != nil {
+			return nil, false
+		}
+		action, ok := p.(plugin.ActionPlugin)
+		return action, ok
+	})
 
 	return &EnhancedPluginManager{
 		PluginManager:      basePM,
@@ -88,19 +100,40 @@ func (epm *EnhancedPluginManager) ProcessInstruction(ctx context.Context, respon
 		zap.String("instruction_type", string(inst.Type)))
 
 	// Validate instruction
-	if err := epm.instructionHandler.ValidateInstruction(inst); err != nil {
+	_, span := tracing.Start(ctx, "instruction.validate")
+	err := epm.instructionHandler.ValidateInstruction(inst)
+	tracing.RecordError(span, err)
+	span.End()
+	if err != nil {
 		epm.logger.Error("Instruction validation failed",
 			zap.String("instruction_id", inst.ID),
 			zap.Error(err))
+		epm.publishValidation(inst, err)
 		return nil, fmt.Errorf("instruction validation failed: %w", err)
 	}
+	epm.publishValidation(inst, nil)
 
 	// Store pending instruction
 	epm.pendingInstructions.Store(inst.ID, inst)
 	defer epm.pendingInstructions.Delete(inst.ID)
 
 	// Process the instruction
-	return epm.instructionHandler.ProcessPollResponse(ctx, response)
+	if inst.Type == types.InstructionTypeExecute {
+		epm.publishExecutionStarted(inst)
+	}
+	result, err := epm.instructionHandler.ProcessPollResponse(ctx, response)
+	if err == nil && result != nil {
+		switch {
+		case result.InstallResult != nil:
+			epm.publishInstallResult(inst, result.InstallResult)
+		case result.ExecutionResult != nil:
+			epm.publishExecutionResult(inst, result.ExecutionResult)
+		}
+	} else if inst.Type == types.InstructionTypeExecute {
+		epm.publishExecutionResult(inst, &types.ExecutionResult{PluginID: inst.PluginID, Error: errorText(err, result)})
+	}
+
+	return result, err
 }
 
 // InstallPlugin installs a plugin from a repository URL
@@ -128,7 +161,11 @@ func (epm *EnhancedPluginManager) InstallPlugin(ctx context.Context, pluginID, r
 
 	// Use the factory to create downloader
 	downloader := epm.factory.CreateDownloader()
-	return downloader.DownloadPlugin(ctx, inst)
+	result, err := downloader.DownloadPlugin(ctx, inst)
+	if err == nil && result != nil {
+		epm.publishInstallResult(inst, result)
+	}
+	return result, err
 }
 
 // ExecutePlugin executes an installed plugin
@@ -155,7 +192,143 @@ func (epm *EnhancedPluginManager) ExecutePlugin(ctx context.Context, pluginID, e
 
 	// Use the factory to create executor
 	executor := epm.factory.CreateExecutor()
-	return executor.ExecutePlugin(ctx, inst)
+	epm.publishExecutionStarted(inst)
+	result, err := executor.ExecutePlugin(ctx, inst)
+	finished := result
+	if finished == nil {
+		finished = &types.ExecutionResult{PluginID: pluginID, Error: errorText(err, nil)}
+	}
+	epm.publishExecutionResult(inst, finished)
+	return result, err
+}
+
+// SetPolicy makes the manager reject instructions the local policy denies.
+// Every decision is published on the event bus, and so audited.
+func (epm *EnhancedPluginManager) SetPolicy(engine *policy.Engine) {
+	epm.instructionHandler.SetPolicy(engine, epm.publishPolicyDecision)
+}
+
+// publishPolicyDecision publishes a policy decision on an instruction
+func (epm *EnhancedPluginManager) publishPolicyDecision(inst *types.Instruction, decision *policy.Decision) {
+	req := policy.NewRequest(inst)
+	data := map[string]interface{}{
+		"instruction_id":   inst.ID,
+		"instruction_type": req.InstructionType,
+		"allowed":          decision.Allowed,
+		"rule":             decision.Rule,
+		"reason":           decision.Reason,
+	}
+	if req.Source != "" {
+		data["source"] = req.Source
+	}
+	if req.Entrypoint != "" {
+		data["entrypoint"] = req.Entrypoint
+	}
+
+	severity := plugin.SeverityLow
+	if !decision.Allowed {
+		severity = plugin.SeverityHigh
+	}
+	epm.publish(PluginEventPolicyDecision, inst.PluginID, severity, data)
+}
+
+// publishValidation publishes whether an instruction passed validation
+func (epm *EnhancedPluginManager) publishValidation(inst *types.Instruction, err error) {
+	data := map[string]interface{}{
+		"instruction_id":   inst.ID,
+		"instruction_type": string(inst.Type),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+		epm.publish(PluginEventInstructionDenied, inst.PluginID, plugin.SeverityMedium, data)
+		return
+	}
+	epm.publish(PluginEventInstructionValidated, inst.PluginID, plugin.SeverityLow, data)
+}
+
+// publishInstallResult publishes a successful plugin install or update
+func (epm *EnhancedPluginManager) publishInstallResult(inst *types.Instruction, result *types.InstallationResult) {
+	if !result.Success || result.DryRun {
+		return
+	}
+
+	eventType := PluginEventInstalled
+	if inst.Type == types.InstructionTypePluginUpdate {
+		eventType = PluginEventUpdated
+	}
+	data := map[string]interface{}{
+		"instruction_id": inst.ID,
+		"version":        result.Version,
+		"path":           result.InstalledPath,
+	}
+	if result.Digest != "" {
+		epm.digests.Store(result.PluginID, result.Digest)
+		data["digest"] = result.Digest
+	} else {
+		epm.digests.Delete(result.PluginID)
+	}
+	epm.publish(eventType, result.PluginID, plugin.SeverityLow, data)
+}
+
+// publishExecutionStarted publishes the start of a plugin execution
+func (epm *EnhancedPluginManager) publishExecutionStarted(inst *types.Instruction) {
+	data := map[string]interface{}{
+		"instruction_id": inst.ID,
+	}
+	if digest := epm.pluginDigest(inst.PluginID); digest != "" {
+		data["digest"] = digest
+	}
+	epm.publish(PluginEventExecutionStarted, inst.PluginID, plugin.SeverityLow, data)
+}
+
+// publishExecutionResult publishes the outcome of a plugin execution
+func (epm *EnhancedPluginManager) publishExecutionResult(inst *types.Instruction, result *types.ExecutionResult) {
+	severity := plugin.SeverityLow
+	data := map[string]interface{}{
+		"instruction_id": inst.ID,
+		"success":        result.Success,
+		"duration":       result.Duration,
+		"exit_code":      result.ExitCode,
+	}
+	if digest := epm.pluginDigest(inst.PluginID); digest != "" {
+		data["digest"] = digest
+	}
+	if !result.Success {
+		severity = plugin.SeverityMedium
+		data["error"] = result.Error
+	}
+	epm.publish(PluginEventExecutionFinished, inst.PluginID, severity, data)
+}
+
+// pluginDigest returns the digest of an installed plugin's files, computed
+// once and then cached until the plugin is reinstalled or removed
+func (epm *EnhancedPluginManager) pluginDigest(pluginID string) string {
+	if digest, ok := epm.digests.Load(pluginID); ok {
+		return digest.(string)
+	}
+
+	dir := filepath.Join(epm.GetPluginBaseDir(), pluginID)
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	digest, err := plugin.Digest(dir)
+	if err != nil {
+		epm.logger.Warn("Failed to compute plugin digest", zap.String("plugin_id", pluginID), zap.Error(err))
+		return ""
+	}
+	epm.digests.Store(pluginID, digest)
+	return digest
+}
+
+// errorText describes why an instruction failed
+func errorText(err error, result *types.InstructionResult) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case result != nil:
+		return result.Error
+	}
+	return "no result"
 }
 
 // IsPluginInstalled checks if a plugin is installed
@@ -169,6 +342,11 @@ func (epm *EnhancedPluginManager) GetInstalledPluginPath(pluginID string) string
 	return filepath.Join(epm.factory.GetBaseDir(), pluginID)
 }
 
+// GetPluginBaseDir returns the directory plugins are installed in
+func (epm *EnhancedPluginManager) GetPluginBaseDir() string {
+	return epm.factory.GetBaseDir()
+}
+
 // UninstallPlugin removes an installed plugin
 func (epm *EnhancedPluginManager) UninstallPlugin(pluginID string) error {
 	epm.logger.Info("Uninstalling plugin", zap.String("plugin_id", pluginID))
@@ -181,6 +359,8 @@ func (epm *EnhancedPluginManager) UninstallPlugin(pluginID string) error {
 	// Also remove from registered plugins if it was registered
 	epm.UnregisterPlugin(pluginID)
 
+	epm.digests.Delete(pluginID)
+	epm.publish(PluginEventRemoved, pluginID, plugin.SeverityLow, nil)
 	return nil
 }
 
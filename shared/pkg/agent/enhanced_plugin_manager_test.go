@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+func TestEnhancedPluginManager_DryRunInstall(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewEnhancedPluginManager(&EnhancedPluginConfig{
+		PluginConfig:  &config.PluginConfig{Directory: dir},
+		PluginBaseDir: dir,
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	installed := filepath.Join(dir, "disk-check", "plugin.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(installed), 0755))
+	require.NoError(t, os.WriteFile(installed, []byte("id: disk-check\n"), 0644))
+
+	t.Run("install", func(t *testing.T) {
+		result, err := manager.ProcessInstruction(context.Background(), &types.PollResponse{Instruction: &types.Instruction{
+			ID:       "inst-1",
+			PluginID: "net-check",
+			Type:     types.InstructionTypePluginInstall,
+			DryRun:   true,
+			PluginConfiguration: map[string]interface{}{
+				"plugin_url":     "https://github.com/stavily/net-check.git",
+				"plugin_version": "v1.2.0",
+			},
+		}})
+		require.NoError(t, err)
+		require.True(t, result.Success)
+		require.NotNil(t, result.InstallResult)
+		assert.True(t, result.InstallResult.DryRun)
+		assert.Equal(t, []types.PlannedChange{
+			{Action: "clone", Target: "https://github.com/stavily/net-check.git", Detail: "branch v1.2.0"},
+			{Action: "install", Target: filepath.Join(dir, "net-check"), Detail: "install plugin version v1.2.0"},
+		}, result.InstallResult.PlannedChanges)
+		assert.NoDirExists(t, filepath.Join(dir, "net-check"))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		result, err := manager.ProcessInstruction(context.Background(), &types.PollResponse{Instruction: &types.Instruction{
+			ID:       "inst-2",
+			PluginID: "disk-check",
+			Type:     types.InstructionTypePluginUpdate,
+			DryRun:   true,
+			PluginConfiguration: map[string]interface{}{
+				"plugin_url": "https://github.com/stavily/disk-check.git",
+				"tag":        "v2.0.0",
+			},
+		}})
+		require.NoError(t, err)
+		require.True(t, result.Success)
+		assert.Equal(t, []types.PlannedChange{
+			{Action: "remove", Target: filepath.Join(dir, "disk-check"), Detail: "existing installation"},
+			{Action: "clone", Target: "https://github.com/stavily/disk-check.git", Detail: "tag v2.0.0"},
+			{Action: "install", Target: filepath.Join(dir, "disk-check"), Detail: "install plugin"},
+		}, result.InstallResult.PlannedChanges)
+		assert.FileExists(t, installed)
+	})
+}
+
+func TestEnhancedPluginManager_WatcherSeesInstalledPlugins(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// A plugin repository to install from
+	repo := t.TempDir()
+	writeTestActionPlugin(t, repo, "disk-check", "1.0.0")
+	for _, args := range [][]string{
+		{"init", "--initial-branch", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-m", "disk-check"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// Configured the way the agents configure it
+	cfg := &config.Config{Agent: config.AgentConfig{BaseFolder: t.TempDir()}}
+	cfg.Plugins.Timeout = time.Second
+	manager, err := NewEnhancedPluginManager(&EnhancedPluginConfig{
+		PluginConfig:  &cfg.Plugins,
+		PluginBaseDir: cfg.GetPluginDir(),
+		GitTimeout:    time.Minute,
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.Equal(t, cfg.GetPluginDir(), manager.GetPluginBaseDir())
+	require.NoError(t, os.MkdirAll(manager.GetPluginBaseDir(), 0755))
+
+	watcher, err := NewPluginWatcher(manager.GetPluginBaseDir(), manager.PluginManager, PluginWatcherOptions{
+		AutoLoad:     true,
+		WatchChanges: true,
+		Debounce:     50 * time.Millisecond,
+		Types:        []plugin.PluginType{plugin.PluginTypeAction},
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	events := make(chan *plugin.PluginEvent, 10)
+	watcher.AddHandler(plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx)
+	// Give the watcher time to register its watches
+	time.Sleep(100 * time.Millisecond)
+
+	result, err := manager.InstallPlugin(ctx, "disk-check", "file://"+repo, "")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, PluginEventLoaded, event.Type)
+		assert.Equal(t, "disk-check", event.PluginID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the watcher did not see the installed plugin")
+	}
+
+	// Plugins are resolved by ID in the same directory
+	installed, err := manager.GetPlugin("disk-check")
+	require.NoError(t, err)
+	require.NoError(t, manager.UnloadPlugin(ctx, installed))
+	_, err = manager.LoadPlugin(ctx, "disk-check")
+	assert.NoError(t, err)
+}
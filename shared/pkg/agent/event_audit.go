@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// EventAuditLog records plugin lifecycle and instruction events in the
+// audit log. Subscribe it with EventBus.SubscribeSync so that no record is
+// lost to a full queue.
+type EventAuditLog struct {
+	log *audit.Logger
+}
+
+// NewEventAuditLog creates an event bus subscriber writing to an audit log
+func NewEventAuditLog(log *audit.Logger) (*EventAuditLog, error) {
+	if log == nil {
+		return nil, fmt.Errorf("audit log is required")
+	}
+	return &EventAuditLog{log: log}, nil
+}
+
+// HandleEvent writes an event to the audit log
+func (l *EventAuditLog) HandleEvent(ctx context.Context, event *plugin.PluginEvent) error {
+	record := &audit.Record{
+		Timestamp: event.Timestamp,
+		Actor:     audit.ActorAgent,
+		Event:     event.Type,
+		PluginID:  event.PluginID,
+		Severity:  string(event.Severity),
+		Outcome:   eventOutcome(event),
+		Data:      event.Data,
+	}
+	// Events about an instruction were caused by the orchestrator sending it
+	if id, ok := event.Data["instruction_id"].(string); ok && id != "" {
+		record.Actor = audit.ActorOrchestrator
+		record.InstructionID = id
+	}
+	if digest, ok := event.Data["digest"].(string); ok {
+		record.PluginDigest = digest
+	}
+
+	return l.log.Log(record)
+}
+
+// eventOutcome derives the outcome an event records
+func eventOutcome(event *plugin.PluginEvent) string {
+	switch event.Type {
+	case PluginEventInstructionDenied:
+		return audit.OutcomeDenied
+	case PluginEventCrashed, PluginEventQuarantined:
+		return audit.OutcomeFailure
+	}
+	if allowed, ok := event.Data["allowed"].(bool); ok && !allowed {
+		return audit.OutcomeDenied
+	}
+	if success, ok := event.Data["success"].(bool); ok && !success {
+		return audit.OutcomeFailure
+	}
+	if msg, ok := event.Data["error"].(string); ok && msg != "" {
+		return audit.OutcomeFailure
+	}
+	return audit.OutcomeSuccess
+}
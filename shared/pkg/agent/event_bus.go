@@ -0,0 +1,346 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Plugin lifecycle events published on the event bus, in addition to the
+// plugin watcher events
+const (
+	PluginEventInstalled         = "plugin_installed"
+	PluginEventUpdated           = "plugin_updated"
+	PluginEventStarted           = "plugin_started"
+	PluginEventStopped           = "plugin_stopped"
+	PluginEventCrashed           = "plugin_crashed"
+	PluginEventHealthChanged     = "plugin_health_changed"
+	PluginEventExecutionStarted  = "plugin_execution_started"
+	PluginEventExecutionFinished = "plugin_execution_finished"
+	PluginEventPolicyDecision    = "policy_decision"
+	PluginEventRemoved           = "plugin_removed"
+
+	// Instruction events published by the enhanced plugin manager
+	PluginEventInstructionValidated = "instruction_validated"
+	PluginEventInstructionDenied    = "instruction_denied"
+
+	// Published by the sensor agent's trigger plugin supervisor
+	PluginEventExited      = "plugin_exited"
+	PluginEventRestarting  = "plugin_restarting"
+	PluginEventRestarted   = "plugin_restarted"
+	PluginEventQuarantined = "plugin_quarantined"
+	PluginEventReleased    = "plugin_released"
+)
+
+// EventBusOptions bounds event delivery
+type EventBusOptions struct {
+	// Events queued per subscriber before new events are dropped for it
+	QueueSize int
+	// Time a handler is given for a single event
+	HandlerTimeout time.Duration
+}
+
+// EventBus delivers plugin lifecycle events to subscribers. Each subscriber
+// has its own bounded queue and goroutine, so a slow or failing subscriber
+// only loses its own events and never blocks publishers or other subscribers.
+// Synchronous subscribers, such as the audit log, are instead called by the
+// publisher and never lose events.
+type EventBus struct {
+	opts   EventBusOptions
+	logger *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu          sync.RWMutex
+	subscribers map[string]*subscriber
+	closed      bool
+	published   uint64
+}
+
+// subscriber is a handler registered on the event bus. Synchronous
+// subscribers have no queue.
+type subscriber struct {
+	name    string
+	handler plugin.EventHandler
+	types   map[string]bool
+	queue   chan *plugin.PluginEvent
+
+	delivered uint64
+	dropped   uint64
+	failed    uint64
+}
+
+// EventBusStats reports event delivery
+type EventBusStats struct {
+	Published   uint64                      `json:"published"`
+	Subscribers map[string]*SubscriberStats `json:"subscribers"`
+}
+
+// SubscriberStats reports event delivery to a single subscriber
+type SubscriberStats struct {
+	Queued    int    `json:"queued"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	Failed    uint64 `json:"failed"`
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus(opts EventBusOptions, logger *zap.Logger) (*EventBus, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+	if opts.HandlerTimeout <= 0 {
+		opts.HandlerTimeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EventBus{
+		opts:        opts,
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+		subscribers: make(map[string]*subscriber),
+	}, nil
+}
+
+// Subscribe registers a handler under a unique name for the given event
+// types, or for all events when none are given
+func (b *EventBus) Subscribe(name string, handler plugin.EventHandler, eventTypes ...string) error {
+	return b.subscribe(name, handler, false, eventTypes)
+}
+
+// SubscribeSync registers a handler that is called by Publish itself, so it
+// receives every event published before Publish returns. The handler must
+// be fast and must not publish events.
+func (b *EventBus) SubscribeSync(name string, handler plugin.EventHandler, eventTypes ...string) error {
+	return b.subscribe(name, handler, true, eventTypes)
+}
+
+// subscribe registers a queued or synchronous subscriber
+func (b *EventBus) subscribe(name string, handler plugin.EventHandler, sync bool, eventTypes []string) error {
+	if name == "" {
+		return fmt.Errorf("subscriber name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("event handler is required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("event bus is closed")
+	}
+	if _, exists := b.subscribers[name]; exists {
+		return fmt.Errorf("subscriber %s already registered", name)
+	}
+
+	sub := &subscriber{
+		name:    name,
+		handler: handler,
+	}
+	if len(eventTypes) > 0 {
+		sub.types = make(map[string]bool, len(eventTypes))
+		for _, t := range eventTypes {
+			sub.types[t] = true
+		}
+	}
+	b.subscribers[name] = sub
+
+	if !sync {
+		sub.queue = make(chan *plugin.PluginEvent, b.opts.QueueSize)
+		b.wg.Add(1)
+		go b.deliver(sub)
+	}
+
+	b.logger.Debug("Event subscriber registered", zap.String("subscriber", name))
+	return nil
+}
+
+// Unsubscribe removes a subscriber once the events already queued for it
+// have been delivered
+func (b *EventBus) Unsubscribe(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if sub, ok := b.subscribers[name]; ok {
+		delete(b.subscribers, name)
+		if sub.queue != nil {
+			close(sub.queue)
+		}
+	}
+}
+
+// Publish hands an event to every interested synchronous subscriber and
+// queues it for the others without blocking. Subscribers whose queue is full
+// miss the event.
+func (b *EventBus) Publish(event *plugin.PluginEvent) {
+	if event == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	var synchronous []*subscriber
+	defer func() {
+		for _, sub := range synchronous {
+			b.record(sub, event, b.handle(sub, event))
+		}
+	}()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return
+	}
+	atomic.AddUint64(&b.published, 1)
+
+	for _, sub := range b.subscribers {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		if sub.queue == nil {
+			synchronous = append(synchronous, sub)
+			continue
+		}
+
+		select {
+		case sub.queue <- event:
+		default:
+			if dropped := atomic.AddUint64(&sub.dropped, 1); dropped == 1 || dropped%100 == 0 {
+				b.logger.Warn("Event subscriber queue full, dropping events",
+					zap.String("subscriber", sub.name),
+					zap.String("event", event.Type),
+					zap.Uint64("dropped", dropped))
+			}
+		}
+	}
+}
+
+// Emit publishes a lifecycle event for a plugin
+func (b *EventBus) Emit(eventType, pluginID string, severity plugin.Severity, data map[string]interface{}) {
+	b.Publish(&plugin.PluginEvent{
+		PluginID:  pluginID,
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+		Severity:  severity,
+	})
+}
+
+// Close stops accepting events and waits until queued events are delivered
+// or ctx is done, after which in-flight handlers are cancelled
+func (b *EventBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for _, sub := range b.subscribers {
+		if sub.queue != nil {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return fmt.Errorf("event bus did not drain: %w", ctx.Err())
+	}
+}
+
+// GetStats returns delivery statistics per subscriber
+func (b *EventBus) GetStats() *EventBusStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := &EventBusStats{
+		Published:   atomic.LoadUint64(&b.published),
+		Subscribers: make(map[string]*SubscriberStats, len(b.subscribers)),
+	}
+	for name, sub := range b.subscribers {
+		stats.Subscribers[name] = &SubscriberStats{
+			Queued:    len(sub.queue),
+			Delivered: atomic.LoadUint64(&sub.delivered),
+			Dropped:   atomic.LoadUint64(&sub.dropped),
+			Failed:    atomic.LoadUint64(&sub.failed),
+		}
+	}
+	return stats
+}
+
+// Subscribers returns the names of the registered subscribers
+func (b *EventBus) Subscribers() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, 0, len(b.subscribers))
+	for name := range b.subscribers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// deliver hands a subscriber its events in order until its queue is closed
+func (b *EventBus) deliver(sub *subscriber) {
+	defer b.wg.Done()
+
+	for event := range sub.queue {
+		b.record(sub, event, b.handle(sub, event))
+	}
+}
+
+// record counts the outcome of handing an event to a subscriber
+func (b *EventBus) record(sub *subscriber, event *plugin.PluginEvent, err error) {
+	if err != nil {
+		atomic.AddUint64(&sub.failed, 1)
+		b.logger.Warn("Event subscriber failed",
+			zap.String("subscriber", sub.name),
+			zap.String("event", event.Type),
+			zap.String("plugin_id", event.PluginID),
+			zap.Error(err))
+		return
+	}
+	atomic.AddUint64(&sub.delivered, 1)
+}
+
+// handle calls a subscriber's handler for one event, turning a panic into an error
+func (b *EventBus) handle(sub *subscriber, event *plugin.PluginEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(b.ctx, b.opts.HandlerTimeout)
+	defer cancel()
+
+	return sub.handler.HandleEvent(ctx, event)
+}
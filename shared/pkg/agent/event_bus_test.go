@@ -0,0 +1,324 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+// flakyPlugin is a plugin whose status and health are set by the test
+type flakyPlugin struct {
+	mu     sync.Mutex
+	status plugin.Status
+	health plugin.HealthStatus
+}
+
+func (f *flakyPlugin) GetInfo() *plugin.Info {
+	return &plugin.Info{ID: "flaky", Version: "1.0.0", Type: plugin.PluginTypeAction}
+}
+
+func (f *flakyPlugin) Initialize(ctx context.Context, config map[string]interface{}) error {
+	return nil
+}
+
+func (f *flakyPlugin) Start(ctx context.Context) error {
+	f.set(plugin.StatusRunning, plugin.HealthStatusHealthy)
+	return nil
+}
+
+func (f *flakyPlugin) Stop(ctx context.Context) error {
+	f.set(plugin.StatusStopped, plugin.HealthStatusUnknown)
+	return nil
+}
+
+func (f *flakyPlugin) GetStatus() plugin.Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.status
+}
+
+func (f *flakyPlugin) GetHealth() *plugin.Health {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &plugin.Health{Status: f.health, LastError: "exit status 1"}
+}
+
+func (f *flakyPlugin) set(status plugin.Status, health plugin.HealthStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+	f.health = health
+}
+
+func TestEventBus(t *testing.T) {
+	bus, err := NewEventBus(EventBusOptions{QueueSize: 2, HandlerTimeout: time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var received []string
+	require.NoError(t, bus.Subscribe("all", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event.Type)
+		return nil
+	})))
+
+	crashes := make(chan *plugin.PluginEvent, 10)
+	require.NoError(t, bus.Subscribe("crashes", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		crashes <- event
+		return nil
+	}), PluginEventCrashed))
+
+	// Failing and panicking subscribers do not affect the others
+	require.NoError(t, bus.Subscribe("failing", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		if event.Type == PluginEventStarted {
+			panic("boom")
+		}
+		return fmt.Errorf("unavailable")
+	})))
+
+	// A stuck subscriber loses events once its queue is full, without
+	// blocking publishers
+	release := make(chan struct{})
+	require.NoError(t, bus.Subscribe("stuck", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		<-release
+		return nil
+	})))
+
+	assert.Error(t, bus.Subscribe("all", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error { return nil })))
+
+	receivedCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+
+	bus.Emit(PluginEventStarted, "p1", plugin.SeverityLow, nil)
+	require.Eventually(t, func() bool { return receivedCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		bus.Emit(PluginEventCrashed, "p1", plugin.SeverityHigh, map[string]interface{}{"attempt": i})
+		select {
+		case event := <-crashes:
+			assert.Equal(t, i, event.Data["attempt"])
+			assert.False(t, event.Timestamp.IsZero())
+		case <-time.After(5 * time.Second):
+			t.Fatal("crash event was not delivered")
+		}
+		require.Eventually(t, func() bool { return receivedCount() == i+2 }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	stats := bus.GetStats()
+	assert.Equal(t, uint64(5), stats.Published)
+	assert.Equal(t, uint64(2), stats.Subscribers["stuck"].Dropped)
+	assert.Equal(t, []string{"all", "crashes", "failing", "stuck"}, bus.Subscribers())
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, bus.Close(ctx))
+
+	mu.Lock()
+	assert.Equal(t, []string{PluginEventStarted, PluginEventCrashed, PluginEventCrashed, PluginEventCrashed, PluginEventCrashed}, received)
+	mu.Unlock()
+	stats = bus.GetStats()
+	assert.Equal(t, uint64(5), stats.Subscribers["failing"].Failed)
+	assert.Equal(t, uint64(4), stats.Subscribers["crashes"].Delivered)
+	assert.Equal(t, uint64(3), stats.Subscribers["stuck"].Delivered)
+
+	assert.Error(t, bus.Subscribe("late", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error { return nil })))
+	bus.Emit(PluginEventStopped, "p1", plugin.SeverityLow, nil)
+}
+
+func TestPluginManager_PublishesLifecycleEvents(t *testing.T) {
+	manager, err := NewPluginManager(&config.PluginConfig{Directory: t.TempDir()}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	bus, err := NewEventBus(EventBusOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.SetEventBus(bus)
+
+	auditFile := filepath.Join(t.TempDir(), "audit", "audit.log")
+	log, err := audit.NewLogger(config.AuditConfig{Enabled: true, LogFile: auditFile}, "agent-1")
+	require.NoError(t, err)
+	auditLog, err := NewEventAuditLog(log)
+	require.NoError(t, err)
+	require.NoError(t, bus.Subscribe("audit", auditLog))
+
+	events := make(chan *plugin.PluginEvent, 10)
+	require.NoError(t, bus.Subscribe("test", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	})))
+
+	next := func(eventType string) *plugin.PluginEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			require.Equal(t, eventType, event.Type)
+			require.Equal(t, "flaky", event.PluginID)
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event", eventType)
+			return nil
+		}
+	}
+
+	p := &flakyPlugin{status: plugin.StatusStopped}
+	require.NoError(t, manager.RegisterPlugin(p))
+
+	ctx := context.Background()
+	require.NoError(t, manager.StartPlugin(ctx, "flaky"))
+	assert.Equal(t, "1.0.0", next(PluginEventStarted).Data["version"])
+
+	manager.CheckPlugins()
+	p.set(plugin.StatusError, plugin.HealthStatusUnhealthy)
+	manager.CheckPlugins()
+
+	crashed := next(PluginEventCrashed)
+	assert.Equal(t, plugin.SeverityHigh, crashed.Severity)
+	assert.Equal(t, "exit status 1", crashed.Data["error"])
+	changed := next(PluginEventHealthChanged)
+	assert.Equal(t, "healthy", changed.Data["previous_health"])
+	assert.Equal(t, "unhealthy", changed.Data["health"])
+
+	// Nothing changed since the last check
+	manager.CheckPlugins()
+
+	require.NoError(t, manager.StopPlugin(ctx, "flaky"))
+	next(PluginEventStopped)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, bus.Close(ctx))
+	require.NoError(t, log.Close())
+	assert.Empty(t, events)
+
+	data, err := os.ReadFile(auditFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "agent-1", record["agent_id"])
+	assert.Equal(t, PluginEventCrashed, record["event"])
+	assert.Equal(t, "flaky", record["plugin_id"])
+	assert.Equal(t, "high", record["severity"])
+	assert.Equal(t, "failure", record["outcome"])
+
+	report, err := audit.Verify(auditFile)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Records)
+}
+
+func TestEnhancedPluginManager_PolicyDecisions(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte(`
+rules:
+  - name: trusted-sources
+    effect: allow
+    sources: https://github.com/stavily/*
+`), 0600))
+	engine, err := policy.NewEngine(config.PolicyConfig{Enabled: true, File: policyFile}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	manager, err := NewEnhancedPluginManager(&EnhancedPluginConfig{
+		PluginConfig:  &config.PluginConfig{Directory: dir},
+		PluginBaseDir: dir,
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	bus, err := NewEventBus(EventBusOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	manager.SetEventBus(bus)
+	manager.SetPolicy(engine)
+
+	events := make(chan *plugin.PluginEvent, 10)
+	require.NoError(t, bus.Subscribe("test", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	})))
+
+	_, err = manager.ProcessInstruction(context.Background(), &types.PollResponse{Instruction: &types.Instruction{
+		ID:                  "inst-1",
+		PluginID:            "disk-check",
+		Type:                types.InstructionTypePluginInstall,
+		PluginConfiguration: map[string]interface{}{"plugin_url": "https://evil.example.com/disk-check.git"},
+	}})
+	assert.ErrorContains(t, err, "denied by policy: denied by default, no rule matched")
+
+	select {
+	case event := <-events:
+		assert.Equal(t, PluginEventPolicyDecision, event.Type)
+		assert.Equal(t, "disk-check", event.PluginID)
+		assert.Equal(t, plugin.SeverityHigh, event.Severity)
+		assert.Equal(t, false, event.Data["allowed"])
+		assert.Equal(t, "https://evil.example.com/disk-check.git", event.Data["source"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no policy decision event")
+	}
+	select {
+	case event := <-events:
+		assert.Equal(t, PluginEventInstructionDenied, event.Type)
+		assert.Contains(t, event.Data["error"], "denied by policy")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no instruction denied event")
+	}
+}
+
+func TestEventBus_SyncAuditSubscriberLosesNothing(t *testing.T) {
+	bus, err := NewEventBus(EventBusOptions{QueueSize: 1, HandlerTimeout: time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.NewLogger(config.AuditConfig{Enabled: true, LogFile: auditFile}, "agent-1")
+	require.NoError(t, err)
+	defer log.Close()
+	eventAudit, err := NewEventAuditLog(log)
+	require.NoError(t, err)
+	require.NoError(t, bus.SubscribeSync("audit", eventAudit))
+
+	// A stuck subscriber drops events but the audit log does not
+	release := make(chan struct{})
+	require.NoError(t, bus.Subscribe("stuck", plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		<-release
+		return nil
+	})))
+
+	for i := 0; i < 50; i++ {
+		bus.Emit(PluginEventPolicyDecision, "backup", plugin.SeverityLow, map[string]interface{}{"allowed": i%2 == 0})
+	}
+
+	// Records are written before Publish returns
+	records := auditEvents(t, auditFile)
+	require.Len(t, records, 50)
+	assert.Equal(t, audit.OutcomeSuccess, records[0]["outcome"])
+	assert.Equal(t, audit.OutcomeDenied, records[1]["outcome"])
+
+	stats := bus.GetStats()
+	assert.Equal(t, uint64(50), stats.Subscribers["audit"].Delivered)
+	assert.Zero(t, stats.Subscribers["audit"].Dropped)
+	assert.Greater(t, stats.Subscribers["stuck"].Dropped, uint64(0))
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, bus.Close(ctx))
+}
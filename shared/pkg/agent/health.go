@@ -3,6 +3,9 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -52,6 +55,18 @@ type HealthChecker struct {
 	
 	// Component-specific health checkers
 	checkers map[string]func() *ComponentHealth
+
+	// Checks of the dependencies the agent needs to do its work, served at
+	// /readyz
+	readiness map[string]ReadinessCheck
+
+	// HTTP server for health checks, nil when not serving
+	server  *http.Server
+	addr    net.Addr
+	started time.Time
+
+	// now is replaceable for tests
+	now func() time.Time
 }
 
 // NewHealthChecker creates a new health checker
@@ -60,7 +75,9 @@ func NewHealthChecker(cfg *config.HealthConfig, logger *zap.Logger) (*HealthChec
 		cfg:      cfg,
 		logger:   logger,
 		stats:    &HealthStats{},
-		checkers: make(map[string]func() *ComponentHealth),
+		checkers:  make(map[string]func() *ComponentHealth),
+		readiness: make(map[string]ReadinessCheck),
+		now:       time.Now,
 	}, nil
 }
 
@@ -74,6 +91,16 @@ func (hc *HealthChecker) RegisterComponent(name string, checker func() *Componen
 // Start starts the health checker
 func (hc *HealthChecker) Start(ctx context.Context) error {
 	hc.logger.Info("Starting health checker")
+
+	hc.mu.Lock()
+	hc.started = hc.now()
+	hc.mu.Unlock()
+
+	if hc.cfg.Enabled {
+		if err := hc.serve(); err != nil {
+			return err
+		}
+	}
 	
 	// Start periodic health checks
 	go hc.healthCheckLoop(ctx)
@@ -84,6 +111,17 @@ func (hc *HealthChecker) Start(ctx context.Context) error {
 // Stop stops the health checker
 func (hc *HealthChecker) Stop(ctx context.Context) error {
 	hc.logger.Info("Stopping health checker")
+
+	hc.mu.Lock()
+	server := hc.server
+	hc.server = nil
+	hc.mu.Unlock()
+
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to stop health server: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -94,7 +132,7 @@ func (hc *HealthChecker) GetStatus() *HealthCheckStatus {
 
 	return &HealthCheckStatus{
 		LastCheck:     hc.stats.LastCheck,
-		CheckInterval: 30 * time.Second, // Default interval, should come from config
+		CheckInterval: hc.interval(),
 		ChecksPassed:  hc.stats.ChecksPassed,
 		ChecksFailed:  hc.stats.ChecksFailed,
 	}
@@ -125,7 +163,7 @@ func (hc *HealthChecker) CheckAllComponents() map[string]*ComponentHealth {
 
 // healthCheckLoop runs periodic health checks
 func (hc *HealthChecker) healthCheckLoop(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second) // Should come from config
+	ticker := time.NewTicker(hc.interval())
 	defer ticker.Stop()
 	
 	for {
@@ -141,7 +179,7 @@ func (hc *HealthChecker) healthCheckLoop(ctx context.Context) {
 // performHealthCheck performs a single health check cycle
 func (hc *HealthChecker) performHealthCheck() {
 	hc.mu.Lock()
-	hc.stats.LastCheck = time.Now()
+	hc.stats.LastCheck = hc.now()
 	hc.mu.Unlock()
 	
 	results := hc.CheckAllComponents()
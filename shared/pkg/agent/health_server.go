@@ -0,0 +1,299 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// Paths of the liveness and readiness endpoints, served alongside the
+// detailed health at the configured path
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Defaults used when the health configuration leaves them unset
+const (
+	defaultHealthPath     = "/health"
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthTimeout  = 10 * time.Second
+)
+
+// livenessIntervals is how many check intervals may pass without a health
+// check cycle before the agent is considered stuck
+const livenessIntervals = 3
+
+// ReadinessCheck returns an error while a dependency the agent needs to do
+// its work is unavailable
+type ReadinessCheck func(ctx context.Context) error
+
+// HealthReport is the detailed health of the agent and each of its components
+type HealthReport struct {
+	Status     HealthStatus                `json:"status"`
+	Timestamp  time.Time                   `json:"timestamp"`
+	Components map[string]*ComponentHealth `json:"components"`
+}
+
+// ReadinessReport is the outcome of each readiness check, "ok" or the error
+type ReadinessReport struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// LivenessReport tells whether health check cycles are still running
+type LivenessReport struct {
+	Alive     bool      `json:"alive"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// RegisterReadinessCheck registers a check the agent must pass to be ready
+func (hc *HealthChecker) RegisterReadinessCheck(name string, check ReadinessCheck) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.readiness[name] = check
+}
+
+// Addr returns the address health checks are served on, or nil when not
+// serving
+func (hc *HealthChecker) Addr() net.Addr {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.addr
+}
+
+// Health checks every registered component. The agent is unhealthy if any
+// component is, and degraded if any is degraded or unknown.
+func (hc *HealthChecker) Health() *HealthReport {
+	report := &HealthReport{
+		Status:     HealthStatusHealthy,
+		Timestamp:  hc.now(),
+		Components: hc.CheckAllComponents(),
+	}
+
+	for name, health := range report.Components {
+		if health == nil {
+			health = &ComponentHealth{Status: HealthStatusUnknown}
+			report.Components[name] = health
+		}
+		switch health.Status {
+		case HealthStatusHealthy:
+		case HealthStatusUnhealthy:
+			report.Status = HealthStatusUnhealthy
+		default:
+			if report.Status == HealthStatusHealthy {
+				report.Status = HealthStatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+// Ready runs every readiness check, bounded by the configured timeout
+func (hc *HealthChecker) Ready(ctx context.Context) *ReadinessReport {
+	timeout := hc.cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	hc.mu.RLock()
+	names := make([]string, 0, len(hc.readiness))
+	checks := make(map[string]ReadinessCheck, len(hc.readiness))
+	for name, check := range hc.readiness {
+		names = append(names, name)
+		checks[name] = check
+	}
+	hc.mu.RUnlock()
+	sort.Strings(names)
+
+	report := &ReadinessReport{Ready: true, Checks: make(map[string]string, len(names))}
+	for _, name := range names {
+		if err := checks[name](ctx); err != nil {
+			report.Ready = false
+			report.Checks[name] = err.Error()
+			continue
+		}
+		report.Checks[name] = "ok"
+	}
+	return report
+}
+
+// Live reports whether health check cycles are still running. A cycle that
+// never finishes, such as one blocked on a deadlocked component, makes the
+// agent not live, so a supervisor restarts it.
+func (hc *HealthChecker) Live() *LivenessReport {
+	hc.mu.RLock()
+	last := hc.stats.LastCheck
+	started := hc.started
+	hc.mu.RUnlock()
+
+	report := &LivenessReport{Alive: true, LastCheck: last}
+	if started.IsZero() {
+		return report
+	}
+	if last.Before(started) {
+		last = started
+	}
+	if stale := hc.now().Sub(last); stale > livenessIntervals*hc.interval() {
+		report.Alive = false
+		report.Message = fmt.Sprintf("no health check cycle for %s", stale.Round(time.Second))
+	}
+	return report
+}
+
+// interval returns the configured health check interval
+func (hc *HealthChecker) interval() time.Duration {
+	if hc.cfg.Interval > 0 {
+		return hc.cfg.Interval
+	}
+	return defaultHealthInterval
+}
+
+// Handler returns an HTTP handler serving liveness at /healthz, readiness at
+// /readyz and the detailed health at the configured path. Failing checks are
+// served with status 503.
+func (hc *HealthChecker) Handler() http.Handler {
+	path := hc.cfg.Path
+	if path == "" {
+		path = defaultHealthPath
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		report := hc.Live()
+		writeHealthResponse(w, r, report.Alive, report)
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		report := hc.Ready(r.Context())
+		writeHealthResponse(w, r, report.Ready, report)
+	})
+	if path != LivenessPath && path != ReadinessPath {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			report := hc.Health()
+			writeHealthResponse(w, r, report.Status != HealthStatusUnhealthy, report)
+		})
+	}
+	return mux
+}
+
+// serve starts the health server on the configured address
+func (hc *HealthChecker) serve() error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(hc.cfg.Bind, strconv.Itoa(hc.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen for health checks: %w", err)
+	}
+	server := &http.Server{
+		Handler:           hc.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	hc.mu.Lock()
+	hc.server = server
+	hc.addr = listener.Addr()
+	hc.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			hc.logger.Error("Health server failed", zap.Error(err))
+		}
+	}()
+
+	hc.logger.Info("Serving health checks", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+func writeHealthResponse(w http.ResponseWriter, r *http.Request, ok bool, report interface{}) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+}
+
+// DirWritableCheck checks that files can be created in dir
+func DirWritableCheck(dir string) ReadinessCheck {
+	return func(ctx context.Context) error {
+		file, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return fmt.Errorf("directory %s is not writable: %w", dir, err)
+		}
+		name := file.Name()
+		file.Close()
+		return os.Remove(name)
+	}
+}
+
+// ConfigCheck checks that the configuration is loaded and valid
+func ConfigCheck(cfg *config.Config) ReadinessCheck {
+	return func(ctx context.Context) error {
+		if cfg == nil {
+			return fmt.Errorf("configuration is not loaded")
+		}
+		if err := config.ValidateAgentConfig(cfg); err != nil {
+			return fmt.Errorf("configuration is invalid: %w", err)
+		}
+		return nil
+	}
+}
+
+// QueryHealth requests a health endpoint of a running agent, such as
+// LivenessPath, and returns the response body. The error is non-nil when
+// the check fails or the agent cannot be reached.
+func QueryHealth(ctx context.Context, cfg *config.HealthConfig, path string) ([]byte, error) {
+	host := cfg.Bind
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+		if ip != nil && ip.To4() == nil {
+			host = "::1"
+		}
+	}
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port)) + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach agent at %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("health check failed: %s", resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func TestHealthServer(t *testing.T) {
+	cfg := &config.HealthConfig{Enabled: true, Bind: "127.0.0.1", Path: "/health", Interval: time.Minute, Timeout: time.Second}
+	hc, err := NewHealthChecker(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	status := HealthStatusDegraded
+	hc.RegisterComponent("plugin_manager", func() *ComponentHealth {
+		return &ComponentHealth{Status: HealthStatusHealthy}
+	})
+	hc.RegisterComponent("orchestrator", func() *ComponentHealth {
+		return &ComponentHealth{Status: status, Message: "circuit breaker half-open"}
+	})
+	hc.RegisterReadinessCheck("config", func(ctx context.Context) error { return nil })
+	hc.RegisterReadinessCheck("orchestrator", func(ctx context.Context) error {
+		return errors.New("orchestrator not contacted yet")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, hc.Start(ctx))
+	defer hc.Stop(context.Background())
+
+	// Clients reach the agent through the configured port
+	_, port, err := net.SplitHostPort(hc.Addr().String())
+	require.NoError(t, err)
+	cfg.Port, err = strconv.Atoi(port)
+	require.NoError(t, err)
+
+	body, err := QueryHealth(ctx, cfg, LivenessPath)
+	require.NoError(t, err)
+	var live LivenessReport
+	require.NoError(t, json.Unmarshal(body, &live))
+	assert.True(t, live.Alive)
+
+	body, err = QueryHealth(ctx, cfg, ReadinessPath)
+	assert.EqualError(t, err, "health check failed: 503 Service Unavailable")
+	var ready ReadinessReport
+	require.NoError(t, json.Unmarshal(body, &ready))
+	assert.False(t, ready.Ready)
+	assert.Equal(t, map[string]string{"config": "ok", "orchestrator": "orchestrator not contacted yet"}, ready.Checks)
+
+	// Degraded components leave the agent serving
+	body, err = QueryHealth(ctx, cfg, cfg.Path)
+	require.NoError(t, err)
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, HealthStatusDegraded, report.Status)
+	assert.Equal(t, "circuit breaker half-open", report.Components["orchestrator"].Message)
+	assert.Equal(t, HealthStatusHealthy, report.Components["plugin_manager"].Status)
+
+	status = HealthStatusUnhealthy
+	_, err = QueryHealth(ctx, cfg, cfg.Path)
+	assert.Error(t, err)
+
+	resp, err := http.Post("http://"+hc.Addr().String()+LivenessPath, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHealthCheckerLive(t *testing.T) {
+	hc, err := NewHealthChecker(&config.HealthConfig{Interval: 10 * time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	// Not started yet
+	assert.True(t, hc.Live().Alive)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hc.now = func() time.Time { return now }
+	hc.started = now
+	assert.True(t, hc.Live().Alive)
+
+	now = now.Add(31 * time.Second)
+	live := hc.Live()
+	assert.False(t, live.Alive)
+	assert.Equal(t, "no health check cycle for 31s", live.Message)
+
+	hc.performHealthCheck()
+	assert.True(t, hc.Live().Alive)
+}
+
+func TestDirWritableCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, DirWritableCheck(dir)(context.Background()))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Error(t, DirWritableCheck(filepath.Join(dir, "missing"))(context.Background()))
+}
+
+func TestQueryHealthUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	_, err = QueryHealth(context.Background(), &config.HealthConfig{Bind: "0.0.0.0", Port: port}, LivenessPath)
+	assert.ErrorContains(t, err, "failed to reach agent at http://127.0.0.1:"+strconv.Itoa(port)+"/healthz")
+}
@@ -2,25 +2,55 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/Stavily/01-Agents/shared/pkg/api"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/metrics"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
 	"go.uber.org/zap"
 )
 
-// MetricsCollector collects and exports metrics for agents
+// MetricsCollector collects agent metrics and serves them in the Prometheus
+// text format on the configured port and path
 type MetricsCollector struct {
 	cfg    *config.MetricsConfig
 	logger *zap.Logger
 	stats  *MetricsStats
 	mu     sync.RWMutex
 	
-	// Custom metrics storage
-	customMetrics map[string]interface{}
+	registry *metrics.Registry
+	server   *http.Server
+	addr     net.Addr
+
+	// Metrics shared by both agents
+	instructions       *metrics.CounterVec
+	executionDuration  *metrics.HistogramVec
+	executionFailures  *metrics.CounterVec
+	pollDuration       *metrics.Histogram
+	orchestratorErrors *metrics.CounterVec
+	queueDepth         *metrics.GaugeVec
+	triggerEvents      *metrics.CounterVec
+	pluginEvents       *metrics.CounterVec
+	circuitState       *metrics.GaugeVec
+	circuitTrips       *metrics.GaugeVec
+	circuitFailures    *metrics.GaugeVec
 }
 
+// executionBuckets are histogram buckets, in seconds, for plugin executions,
+// which run much longer than requests
+var executionBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// pollBuckets are histogram buckets, in seconds, for polls, which include
+// the time a long-poll is held open by the orchestrator
+var pollBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
 // MetricsStats tracks metrics collection statistics
 type MetricsStats struct {
 	MetricsExported int       `json:"metrics_exported"`
@@ -33,7 +63,7 @@ type MetricsStatus struct {
 	MetricsExported int                    `json:"metrics_exported"`
 	LastExport      time.Time              `json:"last_export"`
 	ExportErrors    int                    `json:"export_errors"`
-	CustomMetrics   map[string]interface{} `json:"custom_metrics,omitempty"`
+	Metrics         map[string]interface{} `json:"metrics,omitempty"`
 }
 
 // NewMetricsCollector creates a new metrics collector
@@ -45,46 +75,144 @@ func NewMetricsCollector(cfg *config.MetricsConfig, logger *zap.Logger) (*Metric
 		return nil, fmt.Errorf("logger is required")
 	}
 	
+	registry := metrics.NewRegistry(cfg.Namespace)
 	return &MetricsCollector{
-		cfg:           cfg,
-		logger:        logger,
-		stats:         &MetricsStats{},
-		customMetrics: make(map[string]interface{}),
+		cfg:      cfg,
+		logger:   logger,
+		stats:    &MetricsStats{},
+		registry: registry,
+
+		instructions: registry.NewCounterVec("instructions_total",
+			"Instructions processed, by instruction type and final status.", "type", "status"),
+		executionDuration: registry.NewHistogramVec("plugin_execution_duration_seconds",
+			"Duration of plugin executions.", executionBuckets, "plugin"),
+		executionFailures: registry.NewCounterVec("plugin_execution_failures_total",
+			"Plugin executions that failed.", "plugin"),
+		pollDuration: registry.NewHistogram("poll_duration_seconds",
+			"Duration of polls for instructions.", pollBuckets),
+		orchestratorErrors: registry.NewCounterVec("orchestrator_errors_total",
+			"Failed orchestrator requests, by response status code.", "status_code"),
+		queueDepth: registry.NewGaugeVec("queue_depth",
+			"Items waiting in an agent queue.", "queue"),
+		triggerEvents: registry.NewCounterVec("trigger_events_total",
+			"Trigger events detected, by severity.", "severity"),
+		pluginEvents: registry.NewCounterVec("plugin_events_total",
+			"Plugin lifecycle events, by event type.", "event"),
+		circuitState: registry.NewGaugeVec("circuit_breaker_state",
+			"Circuit breaker state: 0 closed, 1 half-open, 2 open.", "name"),
+		circuitTrips: registry.NewGaugeVec("circuit_breaker_trips",
+			"Times the circuit breaker has opened.", "name"),
+		circuitFailures: registry.NewGaugeVec("circuit_breaker_consecutive_failures",
+			"Consecutive failures counted by the circuit breaker.", "name"),
 	}, nil
 }
 
-// Start starts the metrics collector
+// Start starts serving metrics when metrics are enabled. The port is bound
+// before Start returns, so a port in use fails the agent's start.
 func (mc *MetricsCollector) Start(ctx context.Context) error {
+	if !mc.cfg.Enabled {
+		return nil
+	}
 	mc.logger.Info("Starting metrics collector")
-	
-	// Start periodic metrics export
-	go mc.metricsExportLoop(ctx)
-	
+
+	path := mc.cfg.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, mc.serveMetrics)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(mc.cfg.Bind, strconv.Itoa(mc.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics requests: %w", err)
+	}
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	mc.mu.Lock()
+	mc.server = server
+	mc.addr = listener.Addr()
+	mc.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			mc.logger.Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+
+	mc.logger.Info("Serving metrics",
+		zap.String("address", listener.Addr().String()),
+		zap.String("path", path))
 	return nil
 }
 
-// Stop stops the metrics collector
+// Stop stops serving metrics
 func (mc *MetricsCollector) Stop(ctx context.Context) error {
+	mc.mu.Lock()
+	server := mc.server
+	mc.server = nil
+	mc.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
 	mc.logger.Info("Stopping metrics collector")
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop metrics server: %w", err)
+	}
 	return nil
 }
 
-// GetStatus returns the metrics collector status
-func (mc *MetricsCollector) GetStatus() *MetricsStatus {
+// Addr returns the address metrics are served on, or nil when not serving
+func (mc *MetricsCollector) Addr() net.Addr {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
+	return mc.addr
+}
+
+// Registry returns the registry holding the collected metrics, so agents can
+// register metrics of their own
+func (mc *MetricsCollector) Registry() *metrics.Registry {
+	return mc.registry
+}
+
+// serveMetrics writes the metrics for a scrape and counts it as an export
+func (mc *MetricsCollector) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	err := mc.registry.WriteText(w)
 
-	// Create a copy of custom metrics
-	customMetricsCopy := make(map[string]interface{})
-	for k, v := range mc.customMetrics {
-		customMetricsCopy[k] = v
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if err != nil {
+		mc.stats.ExportErrors++
+		mc.logger.Debug("Failed to write metrics", zap.Error(err))
+		return
 	}
+	mc.stats.MetricsExported++
+	mc.stats.LastExport = time.Now()
+}
+
+// GetStatus returns the metrics collector status
+func (mc *MetricsCollector) GetStatus() *MetricsStatus {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
 
 	return &MetricsStatus{
 		MetricsExported: mc.stats.MetricsExported,
 		LastExport:      mc.stats.LastExport,
 		ExportErrors:    mc.stats.ExportErrors,
-		CustomMetrics:   customMetricsCopy,
+		Metrics:         mc.registry.Snapshot(),
 	}
 }
 
@@ -110,76 +238,79 @@ func (mc *MetricsCollector) GetHealth() *ComponentHealth {
 	}
 }
 
-// RecordMetric records a custom metric
-func (mc *MetricsCollector) RecordMetric(name string, value interface{}) {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-	mc.customMetrics[name] = value
+// RecordInstruction counts an instruction that reached a final status
+func (mc *MetricsCollector) RecordInstruction(instructionType, status string) {
+	mc.instructions.With(instructionType, status).Inc()
 }
 
-// IncrementCounter increments a counter metric
-func (mc *MetricsCollector) IncrementCounter(name string) {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-	
-	if current, exists := mc.customMetrics[name]; exists {
-		if count, ok := current.(int); ok {
-			mc.customMetrics[name] = count + 1
-		} else {
-			mc.customMetrics[name] = 1
-		}
-	} else {
-		mc.customMetrics[name] = 1
+// ObserveExecution records the duration and outcome of a plugin execution
+func (mc *MetricsCollector) ObserveExecution(pluginID string, duration time.Duration, success bool) {
+	mc.executionDuration.With(pluginID).Observe(duration.Seconds())
+	if !success {
+		mc.executionFailures.With(pluginID).Inc()
 	}
 }
 
-// SetGauge sets a gauge metric value
-func (mc *MetricsCollector) SetGauge(name string, value float64) {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-	mc.customMetrics[name] = value
+// ObservePoll records the duration of a poll for instructions
+func (mc *MetricsCollector) ObservePoll(duration time.Duration) {
+	mc.pollDuration.Observe(duration.Seconds())
 }
 
-// GetCurrentMetrics returns all current metrics
-func (mc *MetricsCollector) GetCurrentMetrics() map[string]interface{} {
-	mc.mu.RLock()
-	defer mc.mu.RUnlock()
-	
-	metrics := make(map[string]interface{})
-	for k, v := range mc.customMetrics {
-		metrics[k] = v
+// RecordOrchestratorError counts a failed orchestrator request by its
+// response status code; requests that got no response count as transport
+// errors. Its signature matches api.ErrorHandler.
+func (mc *MetricsCollector) RecordOrchestratorError(method, path string, statusCode int, err error) {
+	code := "transport"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
 	}
-	
-	return metrics
+	mc.orchestratorErrors.With(code).Inc()
 }
 
-// metricsExportLoop runs periodic metrics export
-func (mc *MetricsCollector) metricsExportLoop(ctx context.Context) {
-	ticker := time.NewTicker(60 * time.Second) // Should come from config
-	defer ticker.Stop()
-	
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			mc.exportMetrics()
-		}
+// SetQueueDepth records the number of items waiting in a queue
+func (mc *MetricsCollector) SetQueueDepth(queue string, depth int) {
+	mc.queueDepth.With(queue).Set(float64(depth))
+}
+
+// RecordTriggerEvent counts a detected trigger event by severity
+func (mc *MetricsCollector) RecordTriggerEvent(severity string) {
+	if severity == "" {
+		severity = "unknown"
 	}
+	mc.triggerEvents.With(severity).Inc()
 }
 
-// exportMetrics exports metrics to configured destination
-func (mc *MetricsCollector) exportMetrics() {
-	mc.mu.Lock()
-	mc.stats.LastExport = time.Now()
-	mc.mu.Unlock()
-	
-	// TODO: Implement actual metrics export based on configuration
-	// This could export to Prometheus, InfluxDB, CloudWatch, etc.
-	
-	mc.mu.Lock()
-	mc.stats.MetricsExported++
-	mc.mu.Unlock()
-	
-	mc.logger.Debug("Metrics exported successfully")
-} 
\ No newline at end of file
+// RecordCircuitBreaker records the state of a circuit breaker as gauges.
+// The state gauge is 0 when closed, 1 when half-open and 2 when open.
+func (mc *MetricsCollector) RecordCircuitBreaker(name string, stats api.CircuitBreakerStats) {
+	var state float64
+	switch stats.State {
+	case api.CircuitHalfOpen:
+		state = 1
+	case api.CircuitOpen:
+		state = 2
+	}
+
+	mc.circuitState.With(name).Set(state)
+	mc.circuitTrips.With(name).Set(float64(stats.Trips))
+	mc.circuitFailures.With(name).Set(float64(stats.ConsecutiveFailures))
+}
+
+// HandleEvent counts plugin lifecycle events and records plugin executions,
+// so the collector can subscribe to the plugin event bus
+func (mc *MetricsCollector) HandleEvent(ctx context.Context, event *plugin.PluginEvent) error {
+	mc.pluginEvents.With(event.Type).Inc()
+
+	if event.Type == PluginEventExecutionFinished {
+		// Durations are published in seconds
+		seconds, _ := event.Data["duration"].(float64)
+		success, ok := event.Data["success"].(bool)
+		mc.ObserveExecution(event.PluginID, time.Duration(seconds*float64(time.Second)), success || !ok)
+	}
+	return nil
+}
+
+// GetCurrentMetrics returns the current value of every metric
+func (mc *MetricsCollector) GetCurrentMetrics() map[string]interface{} {
+	return mc.registry.Snapshot()
+}
@@ -2,6 +2,10 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -9,7 +13,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 
+	"github.com/Stavily/01-Agents/shared/pkg/api"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
 )
 
 func TestNewMetricsCollector(t *testing.T) {
@@ -136,4 +142,104 @@ func TestMetricsCollector_DisabledMetrics(t *testing.T) {
 	// Stop should succeed
 	err = collector.Stop(ctx)
 	assert.NoError(t, err)
-} 
\ No newline at end of file
+} 
+
+func TestMetricsCollector_ServesMetrics(t *testing.T) {
+	cfg := &config.MetricsConfig{
+		Enabled:   true,
+		Bind:      "127.0.0.1",
+		Path:      "/metrics",
+		Namespace: "stavily_action",
+	}
+	collector, err := NewMetricsCollector(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, collector.Start(ctx))
+	defer collector.Stop(ctx)
+
+	collector.RecordInstruction("execute", "completed")
+	collector.RecordTriggerEvent("high")
+	collector.RecordTriggerEvent("")
+	collector.SetQueueDepth("instructions", 2)
+	collector.ObservePoll(30 * time.Millisecond)
+	collector.RecordOrchestratorError("GET", "/instructions", http.StatusBadGateway, nil)
+	collector.RecordOrchestratorError("GET", "/instructions", 0, nil)
+	require.NoError(t, collector.HandleEvent(ctx, &plugin.PluginEvent{
+		Type:     PluginEventExecutionFinished,
+		PluginID: "cleanup",
+		Data:     map[string]interface{}{"success": false, "duration": 1.5},
+	}))
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", collector.Addr()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, line := range []string{
+		`stavily_action_instructions_total{type="execute",status="completed"} 1`,
+		`stavily_action_trigger_events_total{severity="high"} 1`,
+		`stavily_action_trigger_events_total{severity="unknown"} 1`,
+		`stavily_action_queue_depth{queue="instructions"} 2`,
+		`stavily_action_poll_duration_seconds_count 1`,
+		`stavily_action_orchestrator_errors_total{status_code="502"} 1`,
+		`stavily_action_orchestrator_errors_total{status_code="transport"} 1`,
+		`stavily_action_plugin_events_total{event="plugin_execution_finished"} 1`,
+		`stavily_action_plugin_execution_duration_seconds_bucket{plugin="cleanup",le="1"} 0`,
+		`stavily_action_plugin_execution_duration_seconds_bucket{plugin="cleanup",le="2.5"} 1`,
+		`stavily_action_plugin_execution_duration_seconds_sum{plugin="cleanup"} 1.5`,
+		`stavily_action_plugin_execution_failures_total{plugin="cleanup"} 1`,
+	} {
+		assert.Contains(t, string(body), line+"\n")
+	}
+
+	status := collector.GetStatus()
+	assert.Equal(t, 1, status.MetricsExported)
+	assert.False(t, status.LastExport.IsZero())
+	assert.Equal(t, float64(1), status.Metrics[`stavily_action_instructions_total{type="execute",status="completed"}`])
+}
+
+func TestOrchestratorWorkflowMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:       server.URL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 2,
+			RetryDelay:    time.Millisecond,
+			RateLimitRPS:  100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer key"},
+		},
+	}
+	executed := false
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		executed = true
+		return nil, fmt.Errorf("plugin failed")
+	})
+	require.NoError(t, err)
+
+	collector, err := NewMetricsCollector(&config.MetricsConfig{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	workflow.SetMetrics(collector)
+
+	ctx := context.Background()
+	workflow.pollAndProcessInstructions(ctx)
+	workflow.processInstruction(ctx, &api.Instruction{ID: "inst-1", PluginID: "cleanup", InstructionType: "execute"})
+	require.True(t, executed)
+
+	metrics := collector.GetCurrentMetrics()
+	require.Contains(t, metrics, "poll_duration_seconds")
+	assert.Equal(t, uint64(1), metrics["poll_duration_seconds"].(map[string]interface{})["count"])
+	assert.Equal(t, float64(1), metrics[`instructions_total{type="execute",status="failed"}`])
+	// Every attempt counts, including retries and result submission
+	assert.GreaterOrEqual(t, metrics[`orchestrator_errors_total{status_code="503"}`], float64(3))
+}
@@ -3,12 +3,19 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/audit"
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -30,11 +37,68 @@ type OrchestratorWorkflow struct {
 	// Current instruction being processed
 	currentInstruction *api.Instruction
 	executionLog       []string
+	cancelCurrent      context.CancelFunc
+
+	// Instruction delivery over push transports (long-poll, SSE)
+	transport       string
+	pushConnected   bool
+	instructionChan chan *api.Instruction
+	cancellations   map[string]*instructionCancellation
+
+	// Plugin status reported with heartbeats, kept current from plugin
+	// lifecycle events; statusChanged requests an early heartbeat
+	pluginReports map[string]*api.PluginStatusReport
+	statusChanged chan struct{}
+
+	// High-risk instructions held until the orchestrator approves them, and
+	// verified approvals not yet matched to an instruction; the gate is nil
+	// when approval is not required
+	approvalGate *ApprovalGate
+	awaiting     map[string]*awaitingInstruction
+	approvals    map[string]*api.InstructionApproval
+
+	// Audit log recording received instructions and approvals, nil when
+	// auditing is disabled
+	auditLog *audit.Logger
+
+	// Metrics for instructions, polls and orchestrator errors, nil when
+	// metrics are not collected
+	metrics *MetricsCollector
+
+	// Tracer for polls and instruction processing, nil when tracing is
+	// disabled
+	tracer *tracing.Tracer
+
+	// Outcome of the last poll, heartbeat or push connection, for readiness
+	// checks
+	contacted  bool
+	contactErr error
 
 	// Plugin executor function (provided by the specific agent)
 	pluginExecutor PluginExecutor
 }
 
+// instructionCancellation records a cancellation requested by the orchestrator
+type instructionCancellation struct {
+	reason      string
+	requestedAt time.Time
+}
+
+// cancellationRetention bounds how long a cancellation for an instruction that
+// was never seen by this agent is remembered
+const cancellationRetention = time.Hour
+
+// awaitingInstruction is an instruction held until it is approved
+type awaitingInstruction struct {
+	instruction *api.Instruction
+	reason      string
+	deadline    time.Time
+}
+
+// approvalCheckInterval is how often instructions awaiting approval are
+// checked for expiry and cancellation
+const approvalCheckInterval = 5 * time.Second
+
 // PluginExecutor is a function type that specific agents implement to execute plugins
 type PluginExecutor func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error)
 
@@ -56,6 +120,11 @@ func NewOrchestratorWorkflow(cfg *config.Config, logger *zap.Logger, pluginExecu
 		return nil, fmt.Errorf("failed to create orchestrator client: %w", err)
 	}
 
+	transport := cfg.API.Transport
+	if transport == "" {
+		transport = api.TransportPoll
+	}
+
 	return &OrchestratorWorkflow{
 		cfg:                cfg,
 		logger:             logger,
@@ -64,9 +133,141 @@ func NewOrchestratorWorkflow(cfg *config.Config, logger *zap.Logger, pluginExecu
 		stopChan:           make(chan struct{}),
 		doneChan:           make(chan struct{}),
 		executionLog:       make([]string, 0),
+		transport:          transport,
+		instructionChan:    make(chan *api.Instruction, 16),
+		cancellations:      make(map[string]*instructionCancellation),
+		pluginReports:      make(map[string]*api.PluginStatusReport),
+		statusChanged:      make(chan struct{}, 1),
+		awaiting:           make(map[string]*awaitingInstruction),
+		approvals:          make(map[string]*api.InstructionApproval),
 	}, nil
 }
 
+// SetApprovalGate makes instructions the gate selects wait for approval by
+// the orchestrator before they execute. It must be called before Start.
+func (w *OrchestratorWorkflow) SetApprovalGate(gate *ApprovalGate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.approvalGate = gate
+}
+
+// SetAuditLog records received instructions, approvals and failures to
+// authenticate with the orchestrator in the audit log. It must be called
+// before Start.
+func (w *OrchestratorWorkflow) SetAuditLog(log *audit.Logger) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.auditLog = log
+	w.orchestratorClient.SetAuthFailureHandler(w.auditAuthFailure)
+}
+
+// SetMetrics records instructions, poll latency, instruction queue depth and
+// failed orchestrator requests in a metrics collector. It must be called
+// before Start.
+func (w *OrchestratorWorkflow) SetMetrics(metrics *MetricsCollector) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.metrics = metrics
+	w.orchestratorClient.SetErrorHandler(metrics.RecordOrchestratorError)
+}
+
+// SetTracer traces polls and the processing of each instruction, continuing
+// the orchestrator's trace when an instruction carries one. It must be
+// called before Start.
+func (w *OrchestratorWorkflow) SetTracer(tracer *tracing.Tracer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tracer = tracer
+}
+
+// recordInstruction counts an instruction that reached a final status
+func (w *OrchestratorWorkflow) recordInstruction(instruction *api.Instruction, status string) {
+	if w.metrics != nil {
+		w.metrics.RecordInstruction(instruction.InstructionType, status)
+	}
+}
+
+// observePoll records the duration of a poll that started at start
+func (w *OrchestratorWorkflow) observePoll(start time.Time) {
+	if w.metrics != nil {
+		w.metrics.ObservePoll(time.Since(start))
+	}
+}
+
+// recordQueueDepth records the number of pushed instructions waiting to be
+// processed and of instructions awaiting approval
+func (w *OrchestratorWorkflow) recordQueueDepth() {
+	if w.metrics == nil {
+		return
+	}
+	w.mu.RLock()
+	awaiting := len(w.awaiting)
+	w.mu.RUnlock()
+
+	w.metrics.SetQueueDepth("instructions", len(w.instructionChan))
+	w.metrics.SetQueueDepth("awaiting_approval", awaiting)
+}
+
+// audit records an action in the audit log, if auditing is enabled
+func (w *OrchestratorWorkflow) audit(record *audit.Record) {
+	if w.auditLog == nil {
+		return
+	}
+	if err := w.auditLog.Log(record); err != nil {
+		w.logger.Error("Failed to write audit record",
+			zap.String("event", record.Event),
+			zap.String("instruction_id", record.InstructionID),
+			zap.Error(err))
+	}
+}
+
+// auditAuthFailure records a request the orchestrator did not authenticate
+func (w *OrchestratorWorkflow) auditAuthFailure(method, path string, statusCode int, err error) {
+	data := map[string]interface{}{
+		"method":      method,
+		"path":        path,
+		"auth_method": w.cfg.Security.Auth.Method,
+		"error":       err.Error(),
+	}
+	if statusCode != 0 {
+		data["status_code"] = statusCode
+	}
+
+	w.audit(&audit.Record{
+		Actor:    audit.ActorAgent,
+		Event:    audit.EventAuthFailed,
+		Severity: string(plugin.SeverityHigh),
+		Outcome:  audit.OutcomeFailure,
+		Data:     data,
+	})
+}
+
+// auditReceived records an instruction received from the orchestrator
+func (w *OrchestratorWorkflow) auditReceived(instruction *api.Instruction) {
+	data := map[string]interface{}{
+		"instruction_type": instruction.InstructionType,
+		"transport":        w.transport,
+	}
+	if instruction.Priority != "" {
+		data["priority"] = instruction.Priority
+	}
+	if instruction.DryRun {
+		data["dry_run"] = true
+	}
+	if instruction.CorrelationID != "" {
+		data["correlation_id"] = instruction.CorrelationID
+	}
+
+	w.audit(&audit.Record{
+		Actor:         audit.ActorOrchestrator,
+		Event:         audit.EventInstructionReceived,
+		InstructionID: instruction.ID,
+		PluginID:      instruction.PluginID,
+		Outcome:       audit.OutcomeSuccess,
+		Data:          data,
+	})
+}
+
 // Start starts the orchestrator workflow
 func (w *OrchestratorWorkflow) Start(ctx context.Context) error {
 	w.mu.Lock()
@@ -93,12 +294,14 @@ func (w *OrchestratorWorkflow) Start(ctx context.Context) error {
 
 // Stop stops the orchestrator workflow gracefully
 func (w *OrchestratorWorkflow) Stop(ctx context.Context) error {
+	// The main loop takes the lock, so it is not held while waiting for it
 	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	if !w.running {
+		w.mu.Unlock()
 		return nil
 	}
+	w.running = false
+	w.mu.Unlock()
 
 	w.logger.Info("Stopping orchestrator workflow")
 
@@ -124,7 +327,6 @@ func (w *OrchestratorWorkflow) Stop(ctx context.Context) error {
 		w.logger.Error("Error closing orchestrator client", zap.Error(err))
 	}
 
-	w.running = false
 	w.logger.Info("Orchestrator workflow stopped successfully")
 	return nil
 }
@@ -155,9 +357,25 @@ func (w *OrchestratorWorkflow) run(ctx context.Context) {
 	pollTicker := time.NewTicker(pollInterval)
 	defer pollTicker.Stop()
 
+	var approvalCheck <-chan time.Time
+	if w.approvalGate != nil {
+		approvalTicker := time.NewTicker(approvalCheckInterval)
+		defer approvalTicker.Stop()
+		approvalCheck = approvalTicker.C
+	}
+
 	w.logger.Info("Orchestrator workflow main loop started",
 		zap.Duration("heartbeat_interval", heartbeatInterval),
-		zap.Duration("poll_interval", pollInterval))
+		zap.Duration("poll_interval", pollInterval),
+		zap.String("transport", w.transport))
+
+	// Push transports run alongside the loop; interval polling only
+	// happens while the push channel is disconnected
+	if w.transport != api.TransportPoll {
+		receiverCtx, cancelReceiver := context.WithCancel(ctx)
+		defer cancelReceiver()
+		go w.receiveInstructions(receiverCtx)
+	}
 
 	for {
 		select {
@@ -169,24 +387,533 @@ func (w *OrchestratorWorkflow) run(ctx context.Context) {
 			return
 		case <-heartbeatTicker.C:
 			w.sendHeartbeat(ctx)
+		case <-w.statusChanged:
+			w.sendHeartbeat(ctx)
 		case <-pollTicker.C:
+			if w.isPushConnected() {
+				continue
+			}
 			w.pollAndProcessInstructions(ctx)
+		case instruction := <-w.instructionChan:
+			w.recordQueueDepth()
+			w.processInstruction(ctx, instruction)
+		case <-approvalCheck:
+			w.checkAwaitingApproval(ctx)
+		}
+	}
+}
+
+// receiveInstructions keeps a long-poll or event-stream channel to the
+// orchestrator open, reconnecting after a delay whenever it drops
+func (w *OrchestratorWorkflow) receiveInstructions(ctx context.Context) {
+	reconnectDelay := w.cfg.API.StreamReconnectDelay
+	if reconnectDelay <= 0 {
+		reconnectDelay = 5 * time.Second
+	}
+
+	for {
+		var err error
+		switch w.transport {
+		case api.TransportLongPoll:
+			err = w.longPollLoop(ctx)
+		case api.TransportSSE:
+			err = w.orchestratorClient.StreamInstructions(ctx, func() {
+				w.setPushConnected(true)
+			}, func(event *api.InstructionEvent) {
+				w.handleInstructionEvent(ctx, event)
+			})
+		default:
+			w.logger.Error("Unsupported instruction transport, using interval polling",
+				zap.String("transport", w.transport))
+			return
+		}
+
+		w.setPushConnected(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		w.logger.Warn("Instruction channel dropped, falling back to interval polling",
+			zap.String("transport", w.transport),
+			zap.Duration("reconnect_delay", reconnectDelay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// longPollLoop issues back-to-back long-poll requests until one fails
+func (w *OrchestratorWorkflow) longPollLoop(ctx context.Context) error {
+	for {
+		start := time.Now()
+		pollCtx, span := w.tracer.Start(ctx, "instruction.poll", attribute.String("transport", "long_poll"))
+		response, err := w.orchestratorClient.LongPollInstructions(pollCtx)
+		w.observePoll(start)
+		tracing.RecordError(span, err)
+		span.End()
+		w.recordContact(err)
+		if err != nil {
+			return err
+		}
+		w.setPushConnected(true)
+
+		w.applyCancellations(response.CancelledInstructions)
+		w.applyApprovals(response.Approvals)
+		if response.Instruction != nil {
+			w.auditReceived(response.Instruction)
+			w.enqueueInstruction(ctx, response.Instruction)
+		}
+	}
+}
+
+// handleInstructionEvent handles a single event received from the event stream
+func (w *OrchestratorWorkflow) handleInstructionEvent(ctx context.Context, event *api.InstructionEvent) {
+	switch event.Type {
+	case api.InstructionEventInstruction:
+		if event.Instruction != nil {
+			w.auditReceived(event.Instruction)
+			w.enqueueInstruction(ctx, event.Instruction)
+		}
+	case api.InstructionEventCancel:
+		reason := event.Reason
+		if reason == "" {
+			reason = "cancelled by orchestrator"
+		}
+		w.cancelInstruction(event.InstructionID, reason)
+	case api.InstructionEventApprove:
+		if event.Approval != nil {
+			w.approveInstruction(event.Approval)
+		}
+	}
+}
+
+// enqueueInstruction hands a pushed instruction to the main loop for
+// processing. While the instruction queue is full it blocks the push
+// transport, so no further instructions are received, until there is space
+// or ctx is cancelled on shutdown.
+func (w *OrchestratorWorkflow) enqueueInstruction(ctx context.Context, instruction *api.Instruction) {
+	w.logger.Debug("Instruction received over push transport",
+		zap.String("instruction_id", instruction.ID),
+		zap.String("transport", w.transport))
+
+	select {
+	case w.instructionChan <- instruction:
+		w.recordQueueDepth()
+		return
+	default:
+	}
+
+	w.logger.Warn("Instruction queue full, pausing push transport",
+		zap.String("instruction_id", instruction.ID))
+	select {
+	case w.instructionChan <- instruction:
+		w.recordQueueDepth()
+	case <-ctx.Done():
+		w.logger.Warn("Shutting down with a pushed instruction not processed",
+			zap.String("instruction_id", instruction.ID))
+	}
+}
+
+// applyCancellations cancels every instruction listed by the orchestrator
+func (w *OrchestratorWorkflow) applyCancellations(instructionIDs []string) {
+	for _, id := range instructionIDs {
+		w.cancelInstruction(id, "cancelled by orchestrator")
+	}
+}
+
+// cancelInstruction records a cancellation and interrupts the instruction if it is running
+func (w *OrchestratorWorkflow) cancelInstruction(instructionID, reason string) {
+	if instructionID == "" {
+		return
+	}
+
+	w.mu.Lock()
+	now := time.Now()
+	for id, c := range w.cancellations {
+		if now.Sub(c.requestedAt) > cancellationRetention {
+			delete(w.cancellations, id)
+		}
+	}
+	w.cancellations[instructionID] = &instructionCancellation{reason: reason, requestedAt: now}
+
+	running := w.currentInstruction != nil && w.currentInstruction.ID == instructionID
+	if running && w.cancelCurrent != nil {
+		w.cancelCurrent()
+	}
+	w.mu.Unlock()
+
+	w.logger.Info("Instruction cancellation received",
+		zap.String("instruction_id", instructionID),
+		zap.String("reason", reason),
+		zap.Bool("running", running))
+}
+
+// takeCancellation returns and clears a pending cancellation for an instruction
+func (w *OrchestratorWorkflow) takeCancellation(instructionID string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	c, ok := w.cancellations[instructionID]
+	if !ok {
+		return "", false
+	}
+	delete(w.cancellations, instructionID)
+	return c.reason, true
+}
+
+// applyApprovals applies every approval sent by the orchestrator
+func (w *OrchestratorWorkflow) applyApprovals(approvals []*api.InstructionApproval) {
+	for _, approval := range approvals {
+		w.approveInstruction(approval)
+	}
+}
+
+// approveInstruction verifies an approval and releases the instruction it
+// approves, or keeps it until the instruction arrives
+func (w *OrchestratorWorkflow) approveInstruction(approval *api.InstructionApproval) {
+	if w.approvalGate == nil {
+		w.logger.Debug("Ignoring approval, approval is not required",
+			zap.String("instruction_id", approval.InstructionID))
+		return
+	}
+	if err := w.approvalGate.Verify(approval); err != nil {
+		w.logger.Warn("Rejected instruction approval",
+			zap.String("instruction_id", approval.InstructionID),
+			zap.Error(err))
+		w.audit(&audit.Record{
+			Actor:         audit.ActorOrchestrator,
+			Event:         audit.EventInstructionApproved,
+			InstructionID: approval.InstructionID,
+			Outcome:       audit.OutcomeDenied,
+			Data:          map[string]interface{}{"approved_by": approval.ApprovedBy, "error": err.Error()},
+		})
+		return
+	}
+	w.audit(&audit.Record{
+		Actor:         approval.ApprovedBy,
+		Event:         audit.EventInstructionApproved,
+		InstructionID: approval.InstructionID,
+		Outcome:       audit.OutcomeSuccess,
+		Data:          map[string]interface{}{"expires_at": approval.ExpiresAt},
+	})
+
+	w.mu.Lock()
+	w.approvals[approval.InstructionID] = approval
+	waiting, ok := w.awaiting[approval.InstructionID]
+	delete(w.awaiting, approval.InstructionID)
+	w.mu.Unlock()
+
+	w.logger.Info("Instruction approval received",
+		zap.String("instruction_id", approval.InstructionID),
+		zap.String("approved_by", approval.ApprovedBy),
+		zap.Bool("awaiting", ok))
+
+	if ok {
+		w.releaseApproved(waiting)
+	}
+}
+
+// releaseApproved hands an approved instruction to the main loop. While the
+// instruction queue is full it stays awaiting approval, and is released again
+// by checkAwaitingApproval.
+func (w *OrchestratorWorkflow) releaseApproved(waiting *awaitingInstruction) {
+	select {
+	case w.instructionChan <- waiting.instruction:
+		w.recordQueueDepth()
+	default:
+		w.mu.Lock()
+		w.awaiting[waiting.instruction.ID] = waiting
+		w.mu.Unlock()
+		w.logger.Warn("Instruction queue full, holding approved instruction",
+			zap.String("instruction_id", waiting.instruction.ID))
+	}
+}
+
+// takeApproval returns and clears a valid approval for an instruction
+func (w *OrchestratorWorkflow) takeApproval(instruction *api.Instruction) (*api.InstructionApproval, bool) {
+	w.mu.Lock()
+	approval, ok := w.approvals[instruction.ID]
+	delete(w.approvals, instruction.ID)
+	w.mu.Unlock()
+
+	// The approval may have expired while the instruction was queued
+	if !ok || w.approvalGate.Verify(approval) != nil {
+		return nil, false
+	}
+
+	// An approval only covers the plugin, configuration and input it was given for
+	if !approval.Approves(instruction) {
+		w.logger.Warn("Instruction does not match its approval",
+			zap.String("instruction_id", instruction.ID),
+			zap.String("approved_by", approval.ApprovedBy))
+		w.audit(&audit.Record{
+			Actor:         approval.ApprovedBy,
+			Event:         audit.EventInstructionApproved,
+			InstructionID: instruction.ID,
+			Outcome:       audit.OutcomeDenied,
+			Data:          map[string]interface{}{"error": "instruction does not match the approved digest"},
+		})
+		return nil, false
+	}
+	return approval, true
+}
+
+// awaitApproval holds an instruction until it is approved, and reports it
+// as awaiting approval to the orchestrator
+func (w *OrchestratorWorkflow) awaitApproval(ctx context.Context, instruction *api.Instruction, reason string) {
+	w.mu.Lock()
+	_, already := w.awaiting[instruction.ID]
+	if !already {
+		w.awaiting[instruction.ID] = &awaitingInstruction{
+			instruction: instruction,
+			reason:      reason,
+			deadline:    w.approvalGate.now().Add(w.approvalGate.Timeout()),
 		}
 	}
+	w.mu.Unlock()
+
+	if already {
+		return
+	}
+	w.recordQueueDepth()
+
+	w.logger.Info("Instruction awaiting approval",
+		zap.String("instruction_id", instruction.ID),
+		zap.String("plugin_id", instruction.PluginID),
+		zap.String("reason", reason),
+		zap.Duration("timeout", w.approvalGate.Timeout()))
+
+	w.updateInstructionStatus(ctx, instruction.ID, "awaiting_approval", []string{
+		fmt.Sprintf("Awaiting approval: %s", reason),
+	})
+}
+
+// checkAwaitingApproval ends the wait of instructions that were cancelled
+// or were not approved in time, releases approved instructions held while the
+// instruction queue was full, and forgets expired approvals
+func (w *OrchestratorWorkflow) checkAwaitingApproval(ctx context.Context) {
+	now := w.approvalGate.now()
+	cancelled := make(map[string]string)
+	var expired []string
+	var approved []*awaitingInstruction
+
+	w.mu.Lock()
+	for id, waiting := range w.awaiting {
+		if c, ok := w.cancellations[id]; ok {
+			cancelled[id] = c.reason
+			delete(w.cancellations, id)
+			delete(w.awaiting, id)
+			w.recordInstruction(waiting.instruction, "cancelled")
+		} else if approval, ok := w.approvals[id]; ok && now.Before(approval.ExpiresAt) {
+			approved = append(approved, waiting)
+			delete(w.awaiting, id)
+		} else if !now.Before(waiting.deadline) {
+			expired = append(expired, id)
+			delete(w.awaiting, id)
+			w.recordInstruction(waiting.instruction, "timeout")
+		}
+	}
+	for id, approval := range w.approvals {
+		if !now.Before(approval.ExpiresAt) {
+			delete(w.approvals, id)
+		}
+	}
+	w.mu.Unlock()
+	w.recordQueueDepth()
+
+	for _, waiting := range approved {
+		w.releaseApproved(waiting)
+	}
+	for id, reason := range cancelled {
+		w.resetExecutionLog()
+		w.submitCancelledResult(ctx, id, reason)
+	}
+	for _, id := range expired {
+		w.logger.Warn("Instruction approval timed out", zap.String("instruction_id", id))
+		w.resetExecutionLog()
+		w.submitApprovalExpiredResult(ctx, id)
+	}
+}
+
+// logOrchestratorError logs a failed orchestrator call. While the circuit
+// breaker is open calls fail fast, so those are only logged at debug level to
+// avoid flooding the log on every tick.
+func (w *OrchestratorWorkflow) logOrchestratorError(msg string, err error) {
+	if errors.Is(err, api.ErrCircuitOpen) {
+		w.logger.Debug(msg, zap.Error(err))
+		return
+	}
+	w.logger.Error(msg, zap.Error(err))
+}
+
+// setPushConnected records whether the push transport is currently connected
+func (w *OrchestratorWorkflow) setPushConnected(connected bool) {
+	w.mu.Lock()
+	changed := w.pushConnected != connected
+	w.pushConnected = connected
+	w.mu.Unlock()
+
+	if connected {
+		w.recordContact(nil)
+	}
+	if changed && connected {
+		w.logger.Info("Instruction push channel connected, pausing interval polling",
+			zap.String("transport", w.transport))
+	}
+}
+
+// recordContact records the outcome of a request to the orchestrator.
+// Requests cancelled by the agent say nothing about the orchestrator.
+func (w *OrchestratorWorkflow) recordContact(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.contacted = true
+	w.contactErr = err
+}
+
+// CheckReachable is a readiness check returning an error until the
+// orchestrator has answered a request, and while the last request to it
+// failed
+func (w *OrchestratorWorkflow) CheckReachable(ctx context.Context) error {
+	if breaker := w.orchestratorClient.CircuitBreakerStats(); breaker.State == api.CircuitOpen {
+		return fmt.Errorf("orchestrator unreachable: circuit breaker open")
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.contacted {
+		return fmt.Errorf("orchestrator not contacted yet")
+	}
+	if w.contactErr != nil {
+		return fmt.Errorf("orchestrator unreachable: %w", w.contactErr)
+	}
+	return nil
+}
+
+// isPushConnected returns whether instructions are currently being pushed
+func (w *OrchestratorWorkflow) isPushConnected() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pushConnected
 }
 
 // sendHeartbeat sends a heartbeat to the orchestrator
 func (w *OrchestratorWorkflow) sendHeartbeat(ctx context.Context) {
 	w.logger.Debug("Sending heartbeat")
 
-	if err := w.orchestratorClient.SendHeartbeat(ctx, "online"); err != nil {
-		w.logger.Error("Failed to send heartbeat", zap.Error(err))
+	err := w.orchestratorClient.SendHeartbeatWithPlugins(ctx, "online", w.pluginStatusReports())
+	w.recordContact(err)
+	if err != nil {
+		w.logOrchestratorError("Failed to send heartbeat", err)
 		return
 	}
 
 	w.logger.Debug("Heartbeat sent successfully")
 }
 
+// HandleEvent tracks plugin lifecycle events for status reporting. Changes
+// the orchestrator should learn about promptly trigger an early heartbeat.
+func (w *OrchestratorWorkflow) HandleEvent(ctx context.Context, event *plugin.PluginEvent) error {
+	if event.PluginID == "" {
+		return nil
+	}
+
+	w.mu.Lock()
+	report, ok := w.pluginReports[event.PluginID]
+	if !ok {
+		report = &api.PluginStatusReport{ID: event.PluginID}
+		w.pluginReports[event.PluginID] = report
+	}
+
+	notify := true
+	switch event.Type {
+	case PluginEventUnloaded, PluginEventRemoved:
+		delete(w.pluginReports, event.PluginID)
+	case PluginEventLoaded, PluginEventInstalled:
+		report.Status = "loaded"
+	case PluginEventStarted:
+		report.Status = "running"
+		report.StartTime = event.Timestamp
+	case PluginEventStopped:
+		report.Status = "stopped"
+	case PluginEventCrashed, PluginEventInvalid:
+		report.Status = "error"
+		report.ErrorCount++
+		if msg, ok := event.Data["error"].(string); ok {
+			report.LastError = msg
+		}
+	case PluginEventExited:
+		report.Status = "stopped"
+		if msg, ok := event.Data["error"].(string); ok && msg != "" {
+			report.Status = "error"
+			report.ErrorCount++
+			report.LastError = msg
+		}
+	case PluginEventRestarting:
+		report.Status = "restarting"
+	case PluginEventRestarted:
+		report.Status = "running"
+		report.StartTime = event.Timestamp
+	case PluginEventQuarantined:
+		report.Status = "quarantined"
+		if msg, ok := event.Data["error"].(string); ok && msg != "" {
+			report.LastError = msg
+		}
+	case PluginEventReleased:
+		report.Status = "stopped"
+	case PluginEventHealthChanged:
+		if health, ok := event.Data["health"].(string); ok {
+			report.Health = health
+		}
+		if msg, ok := event.Data["error"].(string); ok && msg != "" {
+			report.LastError = msg
+		}
+	default:
+		// Instruction and execution events do not describe a plugin's state
+		notify = false
+		if !ok {
+			delete(w.pluginReports, event.PluginID)
+		}
+	}
+	if version, ok := event.Data["version"].(string); ok && version != "" {
+		report.Version = version
+	}
+	if restarts, ok := event.Data["restarts"].(int); ok {
+		report.Restarts = restarts
+	}
+	w.mu.Unlock()
+
+	if notify {
+		select {
+		case w.statusChanged <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// pluginStatusReports returns the reported plugin status ordered by plugin ID
+func (w *OrchestratorWorkflow) pluginStatusReports() []*api.PluginStatusReport {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	reports := make([]*api.PluginStatusReport, 0, len(w.pluginReports))
+	for _, report := range w.pluginReports {
+		copied := *report
+		reports = append(reports, &copied)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+	return reports
+}
+
 // pollAndProcessInstructions polls for instructions and processes them
 func (w *OrchestratorWorkflow) pollAndProcessInstructions(ctx context.Context) {
 	w.logger.Debug("Polling for instructions")
@@ -202,9 +929,15 @@ func (w *OrchestratorWorkflow) pollAndProcessInstructions(ctx context.Context) {
 	}
 
 	// Poll for instructions
-	response, err := w.orchestratorClient.PollInstructions(ctx)
+	start := time.Now()
+	pollCtx, span := w.tracer.Start(ctx, "instruction.poll", attribute.String("transport", "poll"))
+	response, err := w.orchestratorClient.PollInstructions(pollCtx)
+	w.observePoll(start)
+	tracing.RecordError(span, err)
+	span.End()
+	w.recordContact(err)
 	if err != nil {
-		w.logger.Error("Failed to poll for instructions", zap.Error(err))
+		w.logOrchestratorError("Failed to poll for instructions", err)
 		return
 	}
 
@@ -212,6 +945,9 @@ func (w *OrchestratorWorkflow) pollAndProcessInstructions(ctx context.Context) {
 		zap.String("status", response.Status),
 		zap.Int("next_poll_interval", response.NextPollInterval))
 
+	w.applyCancellations(response.CancelledInstructions)
+	w.applyApprovals(response.Approvals)
+
 	// Update poll interval based on server response
 	if response.NextPollInterval > 0 {
 		newInterval := time.Duration(response.NextPollInterval) * time.Second
@@ -221,12 +957,16 @@ func (w *OrchestratorWorkflow) pollAndProcessInstructions(ctx context.Context) {
 
 	// Process instruction if available
 	if response.Instruction != nil {
+		w.auditReceived(response.Instruction)
 		w.processInstruction(ctx, response.Instruction)
 	}
 }
 
 // processInstruction processes a single instruction
 func (w *OrchestratorWorkflow) processInstruction(ctx context.Context, instruction *api.Instruction) {
+	ctx, span := w.startInstructionSpan(ctx, instruction)
+	defer span.End()
+
 	w.mu.Lock()
 	w.currentInstruction = instruction
 	w.executionLog = []string{"Instruction received"}
@@ -236,14 +976,47 @@ func (w *OrchestratorWorkflow) processInstruction(ctx context.Context, instructi
 		zap.String("instruction_id", instruction.ID),
 		zap.String("plugin_id", instruction.PluginID))
 
-	// Create a context with timeout for the instruction
-	instructionCtx := ctx
+	defer func() {
+		// Clear current instruction
+		w.mu.Lock()
+		w.currentInstruction = nil
+		w.executionLog = nil
+		w.cancelCurrent = nil
+		w.mu.Unlock()
+	}()
+
+	// The orchestrator may have cancelled the instruction before it started
+	if reason, cancelled := w.takeCancellation(instruction.ID); cancelled {
+		w.recordInstruction(instruction, "cancelled")
+		w.submitCancelledResult(ctx, instruction.ID, reason)
+		return
+	}
+
+	// High-risk instructions wait until an approval arrives
+	if w.approvalGate != nil {
+		if required, reason := w.approvalGate.Requires(instruction); required {
+			approval, approved := w.takeApproval(instruction)
+			if !approved {
+				w.awaitApproval(ctx, instruction, reason)
+				return
+			}
+			w.appendExecutionLog(fmt.Sprintf("Approved by %s", approval.ApprovedBy))
+		}
+	}
+
+	// Create a cancellable context, with timeout if requested, for the instruction
+	instructionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	if instruction.TimeoutSeconds > 0 {
-		var cancel context.CancelFunc
-		instructionCtx, cancel = context.WithTimeout(ctx, time.Duration(instruction.TimeoutSeconds)*time.Second)
-		defer cancel()
+		var cancelTimeout context.CancelFunc
+		instructionCtx, cancelTimeout = context.WithTimeout(instructionCtx, time.Duration(instruction.TimeoutSeconds)*time.Second)
+		defer cancelTimeout()
 	}
 
+	w.mu.Lock()
+	w.cancelCurrent = cancel
+	w.mu.Unlock()
+
 	// Update instruction status to executing
 	w.updateInstructionStatus(ctx, instruction.ID, "executing", []string{"Started plugin execution"})
 
@@ -251,17 +1024,40 @@ func (w *OrchestratorWorkflow) processInstruction(ctx context.Context, instructi
 	result, err := w.pluginExecutor(instructionCtx, instruction)
 
 	// Submit final result
-	if err != nil {
+	if reason, cancelled := w.takeCancellation(instruction.ID); cancelled {
+		w.recordInstruction(instruction, "cancelled")
+		w.submitCancelledResult(ctx, instruction.ID, reason)
+	} else if err != nil {
+		tracing.RecordError(span, err)
+		w.recordInstruction(instruction, "failed")
 		w.submitFailedResult(ctx, instruction.ID, err)
 	} else {
+		w.recordInstruction(instruction, "completed")
 		w.submitSuccessResult(ctx, instruction.ID, result)
 	}
+}
 
-	// Clear current instruction
-	w.mu.Lock()
-	w.currentInstruction = nil
-	w.executionLog = nil
-	w.mu.Unlock()
+// startInstructionSpan starts the span covering an instruction's processing,
+// as a child of the orchestrator's span when the instruction carries a valid
+// traceparent
+func (w *OrchestratorWorkflow) startInstructionSpan(ctx context.Context, instruction *api.Instruction) (context.Context, trace.Span) {
+	if instruction.Traceparent != "" {
+		remote := tracing.Extract(ctx, instruction.Traceparent)
+		if trace.SpanContextFromContext(remote).IsRemote() {
+			ctx = remote
+		} else {
+			w.logger.Debug("Ignoring invalid instruction traceparent",
+				zap.String("instruction_id", instruction.ID),
+				zap.String("traceparent", instruction.Traceparent))
+		}
+	}
+
+	return w.tracer.Start(ctx, "instruction.process",
+		attribute.String("instruction.id", instruction.ID),
+		attribute.String("instruction.type", instruction.InstructionType),
+		attribute.String("plugin.id", instruction.PluginID),
+		attribute.String("correlation_id", instruction.CorrelationID),
+		attribute.String("workflow_execution_id", instruction.WorkflowExecutionID))
 }
 
 // updateInstructionStatus updates the instruction status during execution
@@ -297,7 +1093,7 @@ func (w *OrchestratorWorkflow) submitSuccessResult(ctx context.Context, instruct
 		ExecutionLog: w.getExecutionLog(),
 	}
 
-	response, err := w.orchestratorClient.SubmitInstructionResult(ctx, instructionID, resultRequest)
+	response, err := w.submitResult(ctx, instructionID, resultRequest)
 	if err != nil {
 		w.logger.Error("Failed to submit success result",
 			zap.String("instruction_id", instructionID),
@@ -324,7 +1120,7 @@ func (w *OrchestratorWorkflow) submitFailedResult(ctx context.Context, instructi
 		ExecutionLog: w.getExecutionLog(),
 	}
 
-	response, err := w.orchestratorClient.SubmitInstructionResult(ctx, instructionID, resultRequest)
+	response, err := w.submitResult(ctx, instructionID, resultRequest)
 	if err != nil {
 		w.logger.Error("Failed to submit failed result",
 			zap.String("instruction_id", instructionID),
@@ -338,6 +1134,70 @@ func (w *OrchestratorWorkflow) submitFailedResult(ctx context.Context, instructi
 		zap.String("error", execErr.Error()))
 }
 
+// submitCancelledResult submits the result of an instruction cancelled by the orchestrator
+func (w *OrchestratorWorkflow) submitCancelledResult(ctx context.Context, instructionID, reason string) {
+	w.appendExecutionLog(fmt.Sprintf("Instruction cancelled: %s", reason))
+
+	resultRequest := &api.InstructionResultRequest{
+		Status:       "cancelled",
+		ErrorMessage: reason,
+		ExecutionLog: w.getExecutionLog(),
+	}
+
+	response, err := w.submitResult(ctx, instructionID, resultRequest)
+	if err != nil {
+		w.logger.Error("Failed to submit cancelled result",
+			zap.String("instruction_id", instructionID),
+			zap.Error(err))
+		return
+	}
+
+	w.logger.Info("Cancelled result submitted",
+		zap.String("instruction_id", instructionID),
+		zap.Bool("acknowledged", response.Acknowledged),
+		zap.String("reason", reason))
+}
+
+// submitApprovalExpiredResult submits the result of an instruction that was
+// not approved in time
+func (w *OrchestratorWorkflow) submitApprovalExpiredResult(ctx context.Context, instructionID string) {
+	message := fmt.Sprintf("instruction was not approved within %s", w.approvalGate.Timeout())
+	w.appendExecutionLog(fmt.Sprintf("Approval expired: %s", message))
+
+	resultRequest := &api.InstructionResultRequest{
+		Status:       "timeout",
+		ErrorMessage: message,
+		ErrorDetails: map[string]interface{}{
+			"error_type": "approval_expired",
+			"timestamp":  time.Now().UTC().Format(time.RFC3339),
+		},
+		ExecutionLog: w.getExecutionLog(),
+	}
+
+	if _, err := w.submitResult(ctx, instructionID, resultRequest); err != nil {
+		w.logger.Error("Failed to submit approval expired result",
+			zap.String("instruction_id", instructionID),
+			zap.Error(err))
+	}
+}
+
+// submitResult submits an instruction's final result
+func (w *OrchestratorWorkflow) submitResult(ctx context.Context, instructionID string, result *api.InstructionResultRequest) (*api.InstructionResultResponse, error) {
+	ctx, span := tracing.Start(ctx, "instruction.submit_result", attribute.String("instruction.status", result.Status))
+	defer span.End()
+
+	response, err := w.orchestratorClient.SubmitInstructionResult(ctx, instructionID, result)
+	tracing.RecordError(span, err)
+	return response, err
+}
+
+// resetExecutionLog clears the execution log
+func (w *OrchestratorWorkflow) resetExecutionLog() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.executionLog = nil
+}
+
 // appendExecutionLog appends entries to the execution log
 func (w *OrchestratorWorkflow) appendExecutionLog(entries ...string) {
 	w.mu.Lock()
@@ -372,6 +1232,15 @@ func (w *OrchestratorWorkflow) GetStatus() map[string]interface{} {
 		"running":    w.running,
 		"start_time": w.startTime,
 		"uptime":     time.Since(w.startTime),
+		"transport":  w.transport,
+	}
+
+	if w.transport != api.TransportPoll {
+		status["push_connected"] = w.pushConnected
+	}
+
+	if w.approvalGate != nil {
+		status["awaiting_approval"] = len(w.awaiting)
 	}
 
 	if w.currentInstruction != nil {
@@ -396,9 +1265,82 @@ func (w *OrchestratorWorkflow) GetHealth() map[string]interface{} {
 		"uptime":    time.Since(w.startTime),
 	}
 
-	if !w.running {
+	breaker := w.orchestratorClient.CircuitBreakerStats()
+	health["circuit_breaker"] = breaker
+
+	certificate := w.orchestratorClient.CertificateStatus()
+	if certificate != nil {
+		health["client_certificate"] = certificate
+	}
+
+	switch {
+	case !w.running:
 		health["status"] = "unhealthy"
 		health["message"] = "Orchestrator workflow is not running"
+	case certificate != nil && certificate.Expired:
+		health["status"] = "unhealthy"
+		health["message"] = fmt.Sprintf("Client certificate expired at %s", certificate.NotAfter.Format(time.RFC3339))
+	case breaker.State != api.CircuitClosed:
+		health["status"] = "degraded"
+		health["message"] = fmt.Sprintf("Orchestrator circuit breaker is %s", breaker.State)
+	case certificate != nil && certificate.Expiring:
+		health["status"] = "degraded"
+		health["message"] = fmt.Sprintf("Client certificate expires at %s", certificate.NotAfter.Format(time.RFC3339))
+	}
+
+	return health
+}
+
+// CircuitBreakerStats returns the state of the circuit breaker guarding orchestrator calls
+func (w *OrchestratorWorkflow) CircuitBreakerStats() api.CircuitBreakerStats {
+	return w.orchestratorClient.CircuitBreakerStats()
+}
+
+// Client returns the orchestrator client, so agents can make additional
+// calls that share its retry, rate limiting and circuit breaker state
+func (w *OrchestratorWorkflow) Client() *api.OrchestratorClient {
+	return w.orchestratorClient
+}
+
+// CertificateStatus returns the status of the client certificate used to
+// authenticate with the orchestrator, or nil if none is configured
+func (w *OrchestratorWorkflow) CertificateStatus() *api.CertificateStatus {
+	return w.orchestratorClient.CertificateStatus()
+}
+
+// GetComponentHealth reports the connection to the orchestrator, including
+// circuit breaker state and client certificate expiry, as a health component
+func (w *OrchestratorWorkflow) GetComponentHealth() *ComponentHealth {
+	breaker := w.orchestratorClient.CircuitBreakerStats()
+
+	health := &ComponentHealth{
+		Status:     HealthStatusHealthy,
+		LastCheck:  time.Now(),
+		ErrorCount: breaker.ConsecutiveFailures,
+	}
+
+	switch breaker.State {
+	case api.CircuitOpen:
+		health.Status = HealthStatusUnhealthy
+		health.Message = "Lost connection to orchestrator: circuit breaker open"
+		if breaker.LastError != "" {
+			health.Message += ": " + breaker.LastError
+		}
+	case api.CircuitHalfOpen:
+		health.Status = HealthStatusDegraded
+		health.Message = "Probing orchestrator connection: circuit breaker half-open"
+	}
+
+	// Certificate expiry takes precedence; an expired certificate will lock the agent out
+	if certificate := w.orchestratorClient.CertificateStatus(); certificate != nil {
+		switch {
+		case certificate.Expired:
+			health.Status = HealthStatusUnhealthy
+			health.Message = fmt.Sprintf("Client certificate expired at %s", certificate.NotAfter.Format(time.RFC3339))
+		case certificate.Expiring && health.Status == HealthStatusHealthy:
+			health.Status = HealthStatusDegraded
+			health.Message = fmt.Sprintf("Client certificate expires in %s", certificate.ExpiresIn.Round(time.Hour))
+		}
 	}
 
 	return health
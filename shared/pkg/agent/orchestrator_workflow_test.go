@@ -0,0 +1,375 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/api"
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
+)
+
+func TestOrchestratorWorkflowTracing(t *testing.T) {
+	var mu sync.Mutex
+	traceparents := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents[r.URL.Path] = r.Header.Get(tracing.TraceparentHeader)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"no_instructions","acknowledged":true}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:      server.URL,
+			Timeout:      5 * time.Second,
+			RateLimitRPS: 100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer key"},
+		},
+	}
+	var executed trace.SpanContext
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		executed = trace.SpanContextFromContext(ctx)
+		return map[string]interface{}{"ok": true}, nil
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	tracer, err := tracing.NewTracer(config.TracingConfig{Exporter: tracing.ExporterFile, File: path, SampleRatio: 1}, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	workflow.SetTracer(tracer)
+
+	remote := trace.SpanContextFromContext(tracing.Extract(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	require.True(t, remote.IsValid())
+
+	ctx := context.Background()
+	workflow.pollAndProcessInstructions(ctx)
+	workflow.processInstruction(ctx, &api.Instruction{
+		ID:              "inst-1",
+		PluginID:        "cleanup",
+		InstructionType: "execute",
+		Traceparent:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	require.NoError(t, tracer.Shutdown(ctx))
+
+	// The plugin runs within the orchestrator's trace, and requests made on
+	// its behalf carry it
+	assert.Equal(t, remote.TraceID(), executed.TraceID())
+	mu.Lock()
+	for requestPath, traceparent := range traceparents {
+		require.NotEmpty(t, traceparent, requestPath)
+		if strings.Contains(requestPath, "inst-1") {
+			assert.Contains(t, traceparent, remote.TraceID().String(), requestPath)
+		} else {
+			assert.NotContains(t, traceparent, remote.TraceID().String(), requestPath)
+		}
+	}
+	mu.Unlock()
+
+	type exportedSpan struct {
+		Name        string
+		SpanContext struct {
+			TraceID string
+			SpanID  string
+		}
+		Parent struct {
+			SpanID string
+		}
+	}
+	spans := make(map[string]exportedSpan)
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var span exportedSpan
+		require.NoError(t, decoder.Decode(&span))
+		spans[span.Name] = span
+	}
+
+	require.Contains(t, spans, "instruction.poll")
+	require.Contains(t, spans, "instruction.process")
+	require.Contains(t, spans, "instruction.submit_result")
+	process := spans["instruction.process"]
+	assert.Equal(t, remote.TraceID().String(), process.SpanContext.TraceID)
+	assert.Equal(t, remote.SpanID().String(), process.Parent.SpanID)
+	assert.Equal(t, executed.SpanID().String(), process.SpanContext.SpanID)
+	assert.Equal(t, process.SpanContext.SpanID, spans["instruction.submit_result"].Parent.SpanID)
+	assert.Equal(t, "0000000000000000", spans["instruction.poll"].Parent.SpanID)
+}
+
+func TestOrchestratorWorkflowCheckReachable(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API:   config.APIConfig{BaseURL: "http://127.0.0.1:1", Timeout: time.Second},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer key"},
+		},
+	}
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.EqualError(t, workflow.CheckReachable(ctx), "orchestrator not contacted yet")
+
+	workflow.recordContact(nil)
+	assert.NoError(t, workflow.CheckReachable(ctx))
+
+	workflow.recordContact(errors.New("connection refused"))
+	assert.EqualError(t, workflow.CheckReachable(ctx), "orchestrator unreachable: connection refused")
+
+	// Requests cancelled on shutdown leave the last outcome in place
+	workflow.recordContact(context.Canceled)
+	assert.Error(t, workflow.CheckReachable(ctx))
+	workflow.setPushConnected(true)
+	assert.NoError(t, workflow.CheckReachable(ctx))
+}
+
+// pushOrchestrator serves the instruction endpoints for the push transport
+// tests. Interval polls are counted separately from long polls and streams.
+type pushOrchestrator struct {
+	mu        sync.Mutex
+	polls     int
+	longPolls int
+	streams   int
+	// serve handles long polls and streams; it is replaced by the tests
+	serve func(w http.ResponseWriter, r *http.Request, attempt int)
+}
+
+func (o *pushOrchestrator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	serve := o.serve
+	var attempt int
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/instructions/stream"):
+		o.streams++
+		attempt = o.streams
+	case strings.HasSuffix(r.URL.Path, "/instructions") && r.URL.Query().Get("wait") != "":
+		o.longPolls++
+		attempt = o.longPolls
+	case strings.HasSuffix(r.URL.Path, "/instructions"):
+		o.polls++
+		serve = nil
+	default:
+		serve = nil
+	}
+	o.mu.Unlock()
+
+	if serve != nil {
+		serve(w, r, attempt)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"no_instructions","acknowledged":true,"success":true}`))
+}
+
+func (o *pushOrchestrator) pollCount() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.polls
+}
+
+func newPushWorkflow(t *testing.T, baseURL, transport string) (*OrchestratorWorkflow, func() []string) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			ID:           "agent-1",
+			Heartbeat:    time.Hour,
+			PollInterval: 20 * time.Millisecond,
+		},
+		API: config.APIConfig{
+			BaseURL:              baseURL,
+			Timeout:              5 * time.Second,
+			RetryAttempts:        1,
+			RetryDelay:           time.Millisecond,
+			RateLimitRPS:         1000,
+			Transport:            transport,
+			LongPollTimeout:      time.Second,
+			StreamReconnectDelay: 50 * time.Millisecond,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer key"},
+		},
+	}
+
+	var mu sync.Mutex
+	var executed []string
+	workflow, err := NewOrchestratorWorkflow(cfg, zaptest.NewLogger(t), func(ctx context.Context, instruction *api.Instruction) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		executed = append(executed, instruction.ID)
+		return map[string]interface{}{"ok": true}, nil
+	})
+	require.NoError(t, err)
+
+	return workflow, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), executed...)
+	}
+}
+
+func TestOrchestratorWorkflowEventStream(t *testing.T) {
+	closeStream := make(chan struct{})
+	orchestrator := &pushOrchestrator{}
+	orchestrator.serve = func(w http.ResponseWriter, r *http.Request, attempt int) {
+		// The first connection attempt fails
+		if attempt == 1 {
+			http.Error(w, "streaming unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		if attempt == 2 {
+			w.Write([]byte(": connected\n\n" +
+				"event: instruction\ndata: {\"id\":\"streamed-1\",\"plugin_id\":\"cleanup\"}\n\n" +
+				": keep-alive\n\n" +
+				"event: cancel\ndata: {\"instruction_id\":\"queued-1\",\"reason\":\"superseded\"}\n\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-closeStream:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}
+	server := httptest.NewServer(orchestrator)
+	defer server.Close()
+
+	workflow, executed := newPushWorkflow(t, server.URL, api.TransportSSE)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, workflow.Start(ctx))
+	defer workflow.Stop(context.Background())
+
+	// While the stream cannot be opened, instructions are polled for
+	require.Eventually(t, func() bool { return orchestrator.pollCount() > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// Once connected, streamed instructions run and cancellations are recorded
+	require.Eventually(t, func() bool {
+		return len(executed()) == 1 && workflow.isPushConnected()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"streamed-1"}, executed())
+	require.Eventually(t, func() bool {
+		reason, ok := workflow.takeCancellation("queued-1")
+		return ok && reason == "superseded"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Interval polling pauses while the stream is connected
+	polls := orchestrator.pollCount()
+	time.Sleep(100 * time.Millisecond)
+	assert.LessOrEqual(t, orchestrator.pollCount(), polls+1)
+
+	// When the server closes the stream, polling resumes until it reconnects
+	polls = orchestrator.pollCount()
+	close(closeStream)
+	require.Eventually(t, func() bool { return orchestrator.pollCount() > polls }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, workflow.isPushConnected, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestOrchestratorWorkflowLongPoll(t *testing.T) {
+	orchestrator := &pushOrchestrator{}
+	orchestrator.serve = func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"instruction_available","instruction":{"id":"long-1"},"cancelled_instructions":["queued-1"]}`))
+			return
+		}
+		// The hold timeout elapses with nothing to deliver
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	server := httptest.NewServer(orchestrator)
+	defer server.Close()
+
+	workflow, executed := newPushWorkflow(t, server.URL, api.TransportLongPoll)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, workflow.Start(ctx))
+	defer workflow.Stop(context.Background())
+
+	require.Eventually(t, func() bool { return len(executed()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"long-1"}, executed())
+	reason, ok := workflow.takeCancellation("queued-1")
+	assert.True(t, ok)
+	assert.Equal(t, "cancelled by orchestrator", reason)
+
+	// Empty long polls keep the channel connected without interval polling
+	require.Eventually(t, workflow.isPushConnected, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, orchestrator.pollCount())
+
+	// A failing long poll falls back to interval polling
+	orchestrator.mu.Lock()
+	orchestrator.serve = func(w http.ResponseWriter, r *http.Request, attempt int) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}
+	orchestrator.mu.Unlock()
+	require.Eventually(t, func() bool { return orchestrator.pollCount() > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestOrchestratorWorkflowPushWaitsForQueueSpace(t *testing.T) {
+	workflow, _ := newPushWorkflow(t, "http://127.0.0.1:1", api.TransportSSE)
+	for len(workflow.instructionChan) < cap(workflow.instructionChan) {
+		workflow.instructionChan <- &api.Instruction{ID: "filler"}
+	}
+
+	// A pushed instruction waits for space instead of being dropped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	enqueued := make(chan struct{})
+	go func() {
+		workflow.handleInstructionEvent(ctx, &api.InstructionEvent{
+			Type:        api.InstructionEventInstruction,
+			Instruction: &api.Instruction{ID: "pushed-1"},
+		})
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("push should wait while the instruction queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-workflow.instructionChan
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatal("push did not resume after space was freed")
+	}
+
+	var last *api.Instruction
+	for len(workflow.instructionChan) > 0 {
+		last = <-workflow.instructionChan
+	}
+	assert.Equal(t, "pushed-1", last.ID)
+
+	// Shutdown releases a waiting push
+	for len(workflow.instructionChan) < cap(workflow.instructionChan) {
+		workflow.instructionChan <- &api.Instruction{ID: "filler"}
+	}
+	cancel()
+	workflow.enqueueInstruction(ctx, &api.Instruction{ID: "pushed-2"})
+}
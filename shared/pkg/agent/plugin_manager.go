@@ -3,6 +3,8 @@ package agent
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -17,6 +19,31 @@ type PluginManager struct {
 	logger  *zap.Logger
 	plugins map[string]plugin.Plugin
 	mu      sync.RWMutex
+
+	// baseDir is the directory installed plugins are loaded from
+	baseDir  string
+	installs map[string]*installedPlugin
+
+	// events receives plugin lifecycle events, nil when none is set;
+	// observed holds the status and health last seen for each plugin
+	events   *EventBus
+	observed map[string]*observedPlugin
+
+	// supervised leaves restarting exited trigger plugins to the caller
+	supervised bool
+}
+
+// observedPlugin is the status and health last seen for a plugin
+type observedPlugin struct {
+	status plugin.Status
+	health plugin.HealthStatus
+}
+
+// installedPlugin records where a plugin loaded from disk lives and the
+// configuration it was given, so it can be reloaded
+type installedPlugin struct {
+	dir    string
+	config map[string]interface{}
 }
 
 // PluginStatus represents the status of plugins
@@ -36,12 +63,110 @@ func NewPluginManager(cfg *config.PluginConfig, logger *zap.Logger) (*PluginMana
 	}
 	
 	return &PluginManager{
-		cfg:     cfg,
-		logger:  logger,
-		plugins: make(map[string]plugin.Plugin),
+		cfg:      cfg,
+		logger:   logger,
+		plugins:  make(map[string]plugin.Plugin),
+		baseDir:  cfg.Directory,
+		installs: make(map[string]*installedPlugin),
+		observed: make(map[string]*observedPlugin),
 	}, nil
 }
 
+// SetEventBus sets the bus plugin lifecycle events are published on
+func (pm *PluginManager) SetEventBus(bus *EventBus) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.events = bus
+}
+
+// SuperviseTriggers makes trigger plugins loaded from now on stay stopped
+// when their process exits, for agents that restart them with their own
+// restart policy
+func (pm *PluginManager) SuperviseTriggers() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.supervised = true
+}
+
+// EventBus returns the bus plugin lifecycle events are published on, or nil
+func (pm *PluginManager) EventBus() *EventBus {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.events
+}
+
+// publish publishes a plugin lifecycle event if an event bus is set
+func (pm *PluginManager) publish(eventType, pluginID string, severity plugin.Severity, data map[string]interface{}) {
+	if bus := pm.EventBus(); bus != nil {
+		bus.Emit(eventType, pluginID, severity, data)
+	}
+}
+
+// observe records the status a plugin was put in by the plugin manager
+func (pm *PluginManager) observe(id string, status plugin.Status) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if state, ok := pm.observed[id]; ok {
+		state.status = status
+	} else {
+		pm.observed[id] = &observedPlugin{status: status}
+	}
+}
+
+// CheckPlugins compares the status and health of each plugin with what was
+// last seen, publishing an event when a running plugin fails or its health
+// changes
+func (pm *PluginManager) CheckPlugins() {
+	for _, p := range pm.ListPlugins() {
+		id := p.GetInfo().ID
+		status := p.GetStatus()
+		health := p.GetHealth()
+
+		var healthStatus plugin.HealthStatus
+		var lastError string
+		if health != nil {
+			healthStatus = health.Status
+			lastError = health.LastError
+		}
+
+		pm.mu.Lock()
+		previous, seen := pm.observed[id]
+		pm.observed[id] = &observedPlugin{status: status, health: healthStatus}
+		pm.mu.Unlock()
+
+		if !seen {
+			continue
+		}
+
+		if previous.status == plugin.StatusRunning && status == plugin.StatusError {
+			pm.logger.Error("Plugin crashed", zap.String("plugin_id", id), zap.String("last_error", lastError))
+			pm.publish(PluginEventCrashed, id, plugin.SeverityHigh, map[string]interface{}{
+				"error": lastError,
+			})
+		}
+
+		if previous.health != "" && previous.health != healthStatus {
+			severity := plugin.SeverityLow
+			switch healthStatus {
+			case plugin.HealthStatusDegraded:
+				severity = plugin.SeverityMedium
+			case plugin.HealthStatusUnhealthy:
+				severity = plugin.SeverityHigh
+			}
+			data := map[string]interface{}{
+				"previous_health": string(previous.health),
+				"health":          string(healthStatus),
+			}
+			if health != nil {
+				data["message"] = health.Message
+				data["error"] = lastError
+			}
+			pm.publish(PluginEventHealthChanged, id, severity, data)
+		}
+	}
+}
+
 // Initialize initializes the plugin manager
 func (pm *PluginManager) Initialize(ctx context.Context) error {
 	pm.logger.Info("Initializing plugin manager")
@@ -53,15 +178,19 @@ func (pm *PluginManager) Initialize(ctx context.Context) error {
 func (pm *PluginManager) Shutdown(ctx context.Context) error {
 	pm.logger.Info("Shutting down plugin manager")
 	
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-	
 	// Stop all plugins
-	for id, p := range pm.plugins {
+	for _, p := range pm.ListPlugins() {
+		id := p.GetInfo().ID
+		wasRunning := p.GetStatus() == plugin.StatusRunning
 		if err := p.Stop(ctx); err != nil {
 			pm.logger.Error("Failed to stop plugin during shutdown",
 				zap.String("plugin_id", id),
 				zap.Error(err))
+			continue
+		}
+		pm.observe(id, plugin.StatusStopped)
+		if wasRunning {
+			pm.publish(PluginEventStopped, id, plugin.SeverityLow, map[string]interface{}{"reason": "shutdown"})
 		}
 	}
 	
@@ -165,17 +294,27 @@ func (pm *PluginManager) UnregisterPlugin(id string) error {
 	}
 	
 	// Stop the plugin if it's running
+	stopped := false
 	if p.GetStatus() == plugin.StatusRunning {
 		if err := p.Stop(context.Background()); err != nil {
 			pm.logger.Warn("Failed to stop plugin during unregistration",
 				zap.String("plugin_id", id),
 				zap.Error(err))
+		} else {
+			stopped = true
 		}
 	}
 	
 	delete(pm.plugins, id)
+	delete(pm.installs, id)
+	delete(pm.observed, id)
+	events := pm.events
 	pm.logger.Info("Plugin unregistered", zap.String("plugin_id", id))
 	
+	if stopped && events != nil {
+		events.Emit(PluginEventStopped, id, plugin.SeverityLow, map[string]interface{}{"reason": "unregistered"})
+	}
+	
 	return nil
 }
 
@@ -215,11 +354,36 @@ func (pm *PluginManager) GetPluginInfo(id string) (*plugin.Info, error) {
 	return p.GetInfo(), nil
 }
 
-// LoadPlugin loads a plugin from path
+// LoadPlugin loads an installed plugin from its directory, given as a path
+// or as a plugin ID under the plugin directory. The plugin is registered and
+// initialized with its default configuration but not started.
 func (pm *PluginManager) LoadPlugin(ctx context.Context, path string) (plugin.Plugin, error) {
-	pm.logger.Info("Loading plugin", zap.String("path", path))
-	// TODO: Implement plugin loading from path
-	return nil, fmt.Errorf("plugin loading not implemented")
+	dir := pm.resolvePluginDir(path)
+	pm.logger.Info("Loading plugin", zap.String("path", dir))
+
+	p, err := pm.loadAdapter(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	id := p.GetInfo().ID
+
+	if _, err := pm.GetPlugin(id); err == nil {
+		return nil, fmt.Errorf("plugin with ID %s already registered", id)
+	}
+
+	if err := p.Initialize(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", id, err)
+	}
+
+	if err := pm.RegisterPlugin(p); err != nil {
+		return nil, err
+	}
+
+	pm.mu.Lock()
+	pm.installs[id] = &installedPlugin{dir: dir}
+	pm.mu.Unlock()
+
+	return p, nil
 }
 
 // UnloadPlugin unloads a plugin
@@ -227,23 +391,151 @@ func (pm *PluginManager) UnloadPlugin(ctx context.Context, p plugin.Plugin) erro
 	return pm.UnregisterPlugin(p.GetInfo().ID)
 }
 
-// ReloadPlugin reloads a plugin
+// ReloadPlugin reloads the files of a plugin loaded from disk and swaps the
+// new version in, started if the old one was running. The plugin keeps its
+// configuration. If the new version cannot be initialized or started the old
+// one stays registered and running.
 func (pm *PluginManager) ReloadPlugin(ctx context.Context, p plugin.Plugin) (plugin.Plugin, error) {
-	info := p.GetInfo()
-	if err := pm.UnloadPlugin(ctx, p); err != nil {
-		return nil, fmt.Errorf("failed to unload plugin: %w", err)
+	id := p.GetInfo().ID
+
+	// ConfigurePlugin replaces the config under the lock
+	pm.mu.RLock()
+	install, ok := pm.installs[id]
+	var config map[string]interface{}
+	if ok {
+		config = install.config
 	}
-	
-	// TODO: Implement plugin reloading
-	pm.logger.Info("Plugin reloaded", zap.String("plugin_id", info.ID))
-	return nil, fmt.Errorf("plugin reloading not implemented")
+	pm.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("plugin %s was not loaded from disk", id)
+	}
+
+	// Prepare the new version before touching the old one
+	reloaded, err := pm.loadAdapter(install.dir, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload plugin %s: %w", id, err)
+	}
+	if err := reloaded.Initialize(ctx, config); err != nil {
+		pm.discardPlugin(ctx, reloaded)
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", id, err)
+	}
+
+	wasRunning := p.GetStatus() == plugin.StatusRunning
+	if err := p.Stop(ctx); err != nil {
+		pm.logger.Warn("Failed to stop plugin for reload",
+			zap.String("plugin_id", id),
+			zap.Error(err))
+	}
+
+	if wasRunning {
+		if err := reloaded.Start(ctx); err != nil {
+			pm.discardPlugin(ctx, reloaded)
+			if restartErr := p.Start(ctx); restartErr != nil {
+				pm.logger.Error("Failed to restart previous version of plugin",
+					zap.String("plugin_id", id),
+					zap.Error(restartErr))
+			}
+			return nil, fmt.Errorf("failed to start plugin %s: %w", id, err)
+		}
+	}
+
+	pm.mu.Lock()
+	if current, exists := pm.plugins[id]; exists && current != p {
+		pm.mu.Unlock()
+		pm.discardPlugin(ctx, reloaded)
+		return nil, fmt.Errorf("plugin %s was replaced during reload", id)
+	}
+	pm.plugins[id] = reloaded
+	pm.installs[id] = install
+	pm.mu.Unlock()
+
+	pm.logger.Info("Plugin reloaded",
+		zap.String("plugin_id", id),
+		zap.String("version", reloaded.GetInfo().Version))
+	return reloaded, nil
 }
 
-// ValidatePlugin validates a plugin before loading
+// discardPlugin stops a plugin instance that is not registered, such as a
+// new version that failed to come up
+func (pm *PluginManager) discardPlugin(ctx context.Context, p plugin.Plugin) {
+	if err := p.Stop(ctx); err != nil {
+		pm.logger.Warn("Failed to stop discarded plugin",
+			zap.String("plugin_id", p.GetInfo().ID),
+			zap.Error(err))
+	}
+}
+
+// ValidatePlugin validates an installed plugin before loading: its manifest,
+// entry point and default configuration
 func (pm *PluginManager) ValidatePlugin(path string) error {
-	pm.logger.Info("Validating plugin", zap.String("path", path))
-	// TODO: Implement plugin validation
-	return nil
+	dir := pm.resolvePluginDir(path)
+	pm.logger.Debug("Validating plugin", zap.String("path", dir))
+
+	_, err := pm.validatePluginDir(dir)
+	return err
+}
+
+// validatePluginDir validates the plugin installed in dir and returns its manifest
+func (pm *PluginManager) validatePluginDir(dir string) (*plugin.Manifest, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("plugin path %s is not a directory", dir)
+	}
+
+	manifest, err := plugin.LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := plugin.SubprocessCommand(manifest, dir); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifest.Runtime.EntryPoint)); err != nil {
+		return nil, fmt.Errorf("plugin %s entry point not found: %w", manifest.ID, err)
+	}
+
+	schema, _ := manifest.Schema()
+	if err := plugin.ValidateConfig(schema, nil, manifest.Defaults()); err != nil {
+		return nil, fmt.Errorf("plugin %s has invalid defaults: %w", manifest.ID, err)
+	}
+
+	return manifest, nil
+}
+
+// loadAdapter validates the plugin installed in dir and builds its runtime
+// adapter. If id is set the manifest must declare that ID.
+func (pm *PluginManager) loadAdapter(dir, id string) (plugin.Plugin, error) {
+	manifest, err := pm.validatePluginDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if id != "" && manifest.ID != id {
+		return nil, fmt.Errorf("plugin manifest in %s declares ID %s, expected %s", dir, manifest.ID, id)
+	}
+
+	pm.mu.RLock()
+	supervised := pm.supervised
+	pm.mu.RUnlock()
+
+	return plugin.NewAdapter(dir, manifest, plugin.AdapterOptions{
+		Timeout:               pm.cfg.Timeout,
+		DisableTriggerRestart: supervised,
+	}, pm.logger)
+}
+
+// resolvePluginDir resolves a plugin path, treating a bare name that does not
+// exist as a plugin ID under the plugin directory
+func (pm *PluginManager) resolvePluginDir(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return filepath.Join(pm.baseDir, path)
 }
 
 // StartPlugin starts a plugin
@@ -253,7 +545,14 @@ func (pm *PluginManager) StartPlugin(ctx context.Context, id string) error {
 		return err
 	}
 	
-	return p.Start(ctx)
+	if err := p.Start(ctx); err != nil {
+		return err
+	}
+	pm.observe(id, plugin.StatusRunning)
+	pm.publish(PluginEventStarted, id, plugin.SeverityLow, map[string]interface{}{
+		"version": p.GetInfo().Version,
+	})
+	return nil
 }
 
 // StopPlugin stops a plugin
@@ -263,21 +562,25 @@ func (pm *PluginManager) StopPlugin(ctx context.Context, id string) error {
 		return err
 	}
 	
-	return p.Stop(ctx)
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+	pm.observe(id, plugin.StatusStopped)
+	pm.publish(PluginEventStopped, id, plugin.SeverityLow, nil)
+	return nil
 }
 
 // RestartPlugin restarts a plugin
 func (pm *PluginManager) RestartPlugin(ctx context.Context, id string) error {
-	p, err := pm.GetPlugin(id)
-	if err != nil {
+	if _, err := pm.GetPlugin(id); err != nil {
 		return err
 	}
 	
-	if err := p.Stop(ctx); err != nil {
+	if err := pm.StopPlugin(ctx, id); err != nil {
 		return fmt.Errorf("failed to stop plugin: %w", err)
 	}
 	
-	return p.Start(ctx)
+	return pm.StartPlugin(ctx, id)
 }
 
 // GetPluginStatus gets the status of a plugin
@@ -300,19 +603,81 @@ func (pm *PluginManager) GetPluginHealth(id string) (*plugin.Health, error) {
 	return p.GetHealth(), nil
 }
 
-// UpdatePlugin updates a plugin to a new version
+// UpdatePlugin switches a loaded plugin to the version installed on disk,
+// which must match version when one is given
 func (pm *PluginManager) UpdatePlugin(ctx context.Context, id string, version string) error {
 	pm.logger.Info("Updating plugin", zap.String("plugin_id", id), zap.String("version", version))
-	// TODO: Implement plugin updates
-	return fmt.Errorf("plugin updates not implemented")
+
+	p, err := pm.GetPlugin(id)
+	if err != nil {
+		return err
+	}
+
+	pm.mu.RLock()
+	install, ok := pm.installs[id]
+	pm.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("plugin %s was not loaded from disk", id)
+	}
+
+	manifest, err := plugin.LoadManifest(install.dir)
+	if err != nil {
+		return err
+	}
+	if version != "" && manifest.Version != version {
+		return fmt.Errorf("plugin %s version %s is not installed, found %s", id, version, manifest.Version)
+	}
+	if p.GetInfo().Version == manifest.Version {
+		pm.logger.Info("Plugin is already at the installed version",
+			zap.String("plugin_id", id),
+			zap.String("version", manifest.Version))
+		return nil
+	}
+
+	previous := p.GetInfo().Version
+	if _, err := pm.ReloadPlugin(ctx, p); err != nil {
+		return err
+	}
+
+	pm.publish(PluginEventUpdated, id, plugin.SeverityLow, map[string]interface{}{
+		"previous_version": previous,
+		"version":          manifest.Version,
+	})
+	return nil
 }
 
-// ConfigurePlugin configures a plugin with new settings
+// ConfigurePlugin validates new settings against the plugin's schema and
+// applies them, including to a running plugin
 func (pm *PluginManager) ConfigurePlugin(ctx context.Context, id string, config map[string]interface{}) error {
 	p, err := pm.GetPlugin(id)
 	if err != nil {
 		return err
 	}
-	
-	return p.Initialize(ctx, config)
-} 
\ No newline at end of file
+
+	schema, required := plugin.ConfigSchema(p)
+	merged := make(map[string]interface{}, len(schema)+len(config))
+	for name, field := range schema {
+		if field != nil && field.Default != nil {
+			merged[name] = field.Default
+		}
+	}
+	for k, v := range config {
+		merged[k] = v
+	}
+	if err := plugin.ValidateConfig(schema, required, merged); err != nil {
+		return err
+	}
+
+	if err := p.Initialize(ctx, config); err != nil {
+		return fmt.Errorf("failed to configure plugin %s: %w", id, err)
+	}
+
+	pm.mu.Lock()
+	if install, ok := pm.installs[id]; ok {
+		install.config = config
+	}
+	pm.mu.Unlock()
+
+	pm.logger.Info("Plugin configured", zap.String("plugin_id", id))
+	return nil
+}
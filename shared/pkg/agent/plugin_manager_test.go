@@ -12,6 +12,7 @@ import (
 	"go.uber.org/zap/zaptest"
 
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
 )
 
 func TestNewPluginManager(t *testing.T) {
@@ -181,4 +182,176 @@ func TestPluginManager_EmptyDirectory(t *testing.T) {
 	// Shutdown should succeed
 	err = manager.Shutdown(ctx)
 	assert.NoError(t, err)
-} 
\ No newline at end of file
+} 
+func writeTestTriggerPlugin(t *testing.T, dir, version string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	manifest := `
+plugin:
+  id: "test-trigger"
+  version: "` + version + `"
+  type: "trigger"
+  runtime:
+    type: "bash"
+    entry_point: "run.sh"
+  configuration:
+    threshold:
+      type: "number"
+      default: 80
+      maximum: 100
+`
+	script := `echo "{\"type\":\"tick\",\"data\":{\"config\":$STAVILY_PLUGIN_CONFIG}}"
+sleep 30
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(manifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte(script), 0755))
+}
+
+func nextThreshold(t *testing.T, p plugin.Plugin) interface{} {
+	events, err := p.(plugin.TriggerPlugin).DetectTriggers(context.Background())
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		return event.Data["config"].(map[string]interface{})["threshold"]
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received from plugin")
+		return nil
+	}
+}
+
+func TestPluginManager_LoadReloadConfigure(t *testing.T) {
+	tmpDir := t.TempDir()
+	pluginDir := filepath.Join(tmpDir, "test-trigger")
+	writeTestTriggerPlugin(t, pluginDir, "1.0.0")
+
+	manager, err := NewPluginManager(&config.PluginConfig{Directory: tmpDir, Timeout: 2 * time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	defer manager.Shutdown(ctx)
+
+	assert.Error(t, manager.ValidatePlugin("missing"))
+	assert.NoError(t, manager.ValidatePlugin(pluginDir))
+
+	// Plugins can be loaded by ID from the plugin directory
+	p, err := manager.LoadPlugin(ctx, "test-trigger")
+	require.NoError(t, err)
+	_, err = manager.LoadPlugin(ctx, pluginDir)
+	assert.Error(t, err, "plugin is already loaded")
+
+	require.NoError(t, manager.StartPlugin(ctx, "test-trigger"))
+	assert.Equal(t, float64(80), nextThreshold(t, p))
+
+	// New settings are validated and pushed to the running process
+	assert.Error(t, manager.ConfigurePlugin(ctx, "test-trigger", map[string]interface{}{"threshold": 150}))
+	assert.Error(t, manager.ConfigurePlugin(ctx, "test-trigger", map[string]interface{}{"threshold": "high"}))
+	require.NoError(t, manager.ConfigurePlugin(ctx, "test-trigger", map[string]interface{}{"threshold": 95}))
+	assert.Equal(t, float64(95), nextThreshold(t, p))
+
+	// Updating picks up the new files and keeps the configuration
+	writeTestTriggerPlugin(t, pluginDir, "1.1.0")
+	assert.Error(t, manager.UpdatePlugin(ctx, "test-trigger", "2.0.0"))
+	require.NoError(t, manager.UpdatePlugin(ctx, "test-trigger", "1.1.0"))
+
+	reloaded, err := manager.GetPlugin("test-trigger")
+	require.NoError(t, err)
+	assert.NotSame(t, p, reloaded)
+	assert.Equal(t, "1.1.0", reloaded.GetInfo().Version)
+	assert.Equal(t, float64(95), nextThreshold(t, reloaded))
+	assert.Equal(t, plugin.StatusRunning, reloaded.GetStatus())
+}
+
+func TestPluginManager_FailedReloadKeepsPlugin(t *testing.T) {
+	tmpDir := t.TempDir()
+	pluginDir := filepath.Join(tmpDir, "test-trigger")
+	writeTestTriggerPlugin(t, pluginDir, "1.0.0")
+
+	manager, err := NewPluginManager(&config.PluginConfig{Directory: tmpDir, Timeout: 2 * time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	defer manager.Shutdown(ctx)
+
+	p, err := manager.LoadPlugin(ctx, "test-trigger")
+	require.NoError(t, err)
+	require.NoError(t, manager.StartPlugin(ctx, "test-trigger"))
+	assert.Equal(t, float64(80), nextThreshold(t, p))
+
+	// The new version is an RPC plugin whose process exits before the
+	// handshake, so it fails to initialize
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(`
+plugin:
+  id: "test-trigger"
+  version: "1.1.0"
+  type: "trigger"
+  runtime:
+    type: "bash"
+    entry_point: "broken.sh"
+    protocol: "rpc"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "broken.sh"), []byte("exit 1\n"), 0755))
+
+	_, err = manager.ReloadPlugin(ctx, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initialize plugin test-trigger")
+
+	current, err := manager.GetPlugin("test-trigger")
+	require.NoError(t, err)
+	assert.Same(t, p, current)
+	assert.Equal(t, "1.0.0", current.GetInfo().Version)
+	assert.Equal(t, plugin.StatusRunning, current.GetStatus())
+
+	// A fixed version reloads as usual
+	writeTestTriggerPlugin(t, pluginDir, "1.2.0")
+	reloaded, err := manager.ReloadPlugin(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", reloaded.GetInfo().Version)
+	assert.Equal(t, float64(80), nextThreshold(t, reloaded))
+	assert.Equal(t, plugin.StatusRunning, reloaded.GetStatus())
+}
+
+func TestPluginManager_ConfigureDuringReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestTriggerPlugin(t, filepath.Join(tmpDir, "test-trigger"), "1.0.0")
+
+	manager, err := NewPluginManager(&config.PluginConfig{Directory: tmpDir, Timeout: 2 * time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	defer manager.Shutdown(ctx)
+
+	p, err := manager.LoadPlugin(ctx, "test-trigger")
+	require.NoError(t, err)
+
+	// Keep configuring the plugin while it is reloaded
+	stop := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; ; i++ {
+			if err := manager.ConfigurePlugin(ctx, "test-trigger", map[string]interface{}{"threshold": 90 + i%10}); err != nil {
+				done <- err
+				return
+			}
+			if i == 0 {
+				close(started)
+			}
+			select {
+			case <-stop:
+				done <- nil
+				return
+			default:
+			}
+		}
+	}()
+	<-started
+	for i := 0; i < 20; i++ {
+		p, err = manager.ReloadPlugin(ctx, p)
+		require.NoError(t, err)
+	}
+	close(stop)
+	assert.NoError(t, <-done)
+}
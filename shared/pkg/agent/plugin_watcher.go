@@ -0,0 +1,365 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Plugin lifecycle events emitted by the plugin watcher
+const (
+	PluginEventLoaded   = "plugin_loaded"
+	PluginEventReloaded = "plugin_reloaded"
+	PluginEventUnloaded = "plugin_unloaded"
+	PluginEventInvalid  = "plugin_invalid"
+)
+
+// PluginWatcherOptions controls which plugins the watcher manages
+type PluginWatcherOptions struct {
+	// AutoLoad loads the plugins already in the directory on start
+	AutoLoad bool
+	// WatchChanges loads, reloads and unloads plugins as their files change
+	WatchChanges bool
+	// Quiet period after the last change before plugins are reloaded
+	Debounce time.Duration
+	// Plugin types to manage, all types when empty
+	Types []plugin.PluginType
+}
+
+// PluginWatcher keeps the plugins in a directory loaded in a PluginManager,
+// one plugin per subdirectory. Changes are debounced so that a burst of
+// writes, such as a checkout or an editor save, causes a single reload.
+type PluginWatcher struct {
+	dir     string
+	manager *PluginManager
+	opts    PluginWatcherOptions
+	logger  *zap.Logger
+
+	handlersMu sync.RWMutex
+	handlers   []plugin.EventHandler
+
+	// loaded maps plugin subdirectory names to the plugin IDs loaded from them
+	loaded map[string]string
+}
+
+// NewPluginWatcher creates a watcher for the plugin directory dir
+func NewPluginWatcher(dir string, manager *PluginManager, opts PluginWatcherOptions, logger *zap.Logger) (*PluginWatcher, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("plugin directory is required")
+	}
+	if manager == nil {
+		return nil, fmt.Errorf("plugin manager is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+
+	return &PluginWatcher{
+		dir:     dir,
+		manager: manager,
+		opts:    opts,
+		logger:  logger.With(zap.String("plugin_dir", dir)),
+		loaded:  make(map[string]string),
+	}, nil
+}
+
+// AddHandler registers a handler for plugin lifecycle events
+func (w *PluginWatcher) AddHandler(handler plugin.EventHandler) {
+	w.handlersMu.Lock()
+	defer w.handlersMu.Unlock()
+	w.handlers = append(w.handlers, handler)
+}
+
+// Run loads the plugins in the directory if AutoLoad is set and, if
+// WatchChanges is set, keeps them in sync with the directory until ctx is
+// cancelled
+func (w *PluginWatcher) Run(ctx context.Context) error {
+	if w.opts.AutoLoad {
+		w.scan(ctx)
+	}
+	if !w.opts.WatchChanges {
+		return nil
+	}
+
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := w.watchTree(watcher, w.dir); err != nil {
+		return fmt.Errorf("failed to watch plugin directory: %w", err)
+	}
+
+	w.logger.Info("Watching plugin directory for changes")
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.opts.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name, ok := w.pluginName(event.Name)
+			if !ok {
+				continue
+			}
+
+			// New directories have to be watched themselves
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.watchTree(watcher, event.Name); err != nil {
+						w.logger.Warn("Failed to watch plugin directory",
+							zap.String("path", event.Name),
+							zap.Error(err))
+					}
+				}
+			}
+
+			pending[name] = true
+			timer.Reset(w.opts.Debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Warn("Plugin watcher error", zap.Error(err))
+
+		case <-timer.C:
+			for name := range pending {
+				w.sync(ctx, name)
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// scan syncs every plugin subdirectory
+func (w *PluginWatcher) scan(ctx context.Context) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			w.logger.Warn("Failed to read plugin directory", zap.Error(err))
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && !ignoredPluginPath(entry.Name()) {
+			w.sync(ctx, entry.Name())
+		}
+	}
+}
+
+// sync loads, reloads or unloads the plugin in a subdirectory to match its
+// files. A plugin whose manifest becomes invalid keeps running as it was.
+func (w *PluginWatcher) sync(ctx context.Context, name string) {
+	dir := filepath.Join(w.dir, name)
+	loadedID, isLoaded := w.loaded[name]
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if isLoaded {
+			w.unload(ctx, name, loadedID)
+		}
+		return
+	}
+
+	manifest, err := plugin.LoadManifest(dir)
+	if err != nil {
+		w.logger.Warn("Ignoring invalid plugin", zap.String("path", dir), zap.Error(err))
+		w.emit(ctx, PluginEventInvalid, loadedID, map[string]interface{}{
+			"directory": dir,
+			"error":     err.Error(),
+		})
+		return
+	}
+	if !w.manages(manifest.Type) {
+		if isLoaded {
+			w.unload(ctx, name, loadedID)
+		}
+		return
+	}
+
+	if isLoaded && loadedID != manifest.ID {
+		w.unload(ctx, name, loadedID)
+		isLoaded = false
+	}
+
+	if isLoaded {
+		p, err := w.manager.GetPlugin(loadedID)
+		if err != nil {
+			// Unregistered behind our back, load it afresh
+			delete(w.loaded, name)
+			w.sync(ctx, name)
+			return
+		}
+
+		reloaded, err := w.manager.ReloadPlugin(ctx, p)
+		if err != nil {
+			w.logger.Error("Failed to reload plugin", zap.String("plugin_id", loadedID), zap.Error(err))
+			w.emit(ctx, PluginEventInvalid, loadedID, map[string]interface{}{
+				"directory": dir,
+				"error":     err.Error(),
+			})
+			return
+		}
+
+		w.emit(ctx, PluginEventReloaded, loadedID, map[string]interface{}{
+			"directory": dir,
+			"version":   reloaded.GetInfo().Version,
+		})
+		return
+	}
+
+	if _, err := w.manager.GetPlugin(manifest.ID); err == nil {
+		w.logger.Debug("Plugin is already loaded from elsewhere, skipping",
+			zap.String("plugin_id", manifest.ID),
+			zap.String("path", dir))
+		return
+	}
+
+	p, err := w.manager.LoadPlugin(ctx, dir)
+	if err != nil {
+		w.logger.Error("Failed to load plugin", zap.String("plugin_id", manifest.ID), zap.Error(err))
+		w.emit(ctx, PluginEventInvalid, manifest.ID, map[string]interface{}{
+			"directory": dir,
+			"error":     err.Error(),
+		})
+		return
+	}
+
+	w.loaded[name] = manifest.ID
+	w.emit(ctx, PluginEventLoaded, manifest.ID, map[string]interface{}{
+		"directory": dir,
+		"version":   p.GetInfo().Version,
+	})
+}
+
+// unload unregisters a plugin loaded from a subdirectory
+func (w *PluginWatcher) unload(ctx context.Context, name, id string) {
+	delete(w.loaded, name)
+
+	if err := w.manager.UnregisterPlugin(id); err != nil {
+		w.logger.Warn("Failed to unload plugin", zap.String("plugin_id", id), zap.Error(err))
+		return
+	}
+
+	w.emit(ctx, PluginEventUnloaded, id, map[string]interface{}{
+		"directory": filepath.Join(w.dir, name),
+	})
+}
+
+// emit notifies the handlers of a lifecycle event
+func (w *PluginWatcher) emit(ctx context.Context, eventType, pluginID string, data map[string]interface{}) {
+	event := &plugin.PluginEvent{
+		PluginID:  pluginID,
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+		Severity:  plugin.SeverityLow,
+	}
+	if eventType == PluginEventInvalid {
+		event.Severity = plugin.SeverityMedium
+	}
+
+	w.logger.Info("Plugin changed",
+		zap.String("event", eventType),
+		zap.String("plugin_id", pluginID))
+
+	// Subscribers on the event bus hear about it asynchronously
+	if bus := w.manager.EventBus(); bus != nil {
+		bus.Publish(event)
+	}
+
+	w.handlersMu.RLock()
+	handlers := append([]plugin.EventHandler(nil), w.handlers...)
+	w.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler.HandleEvent(ctx, event); err != nil {
+			w.logger.Warn("Plugin event handler failed",
+				zap.String("event", eventType),
+				zap.String("plugin_id", pluginID),
+				zap.Error(err))
+		}
+	}
+}
+
+// manages reports whether the watcher handles plugins of a type
+func (w *PluginWatcher) manages(pluginType plugin.PluginType) bool {
+	if len(w.opts.Types) == 0 {
+		return true
+	}
+	for _, t := range w.opts.Types {
+		if t == pluginType {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginName returns the plugin subdirectory a changed path belongs to
+func (w *PluginWatcher) pluginName(path string) (string, bool) {
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts {
+		if ignoredPluginPath(part) {
+			return "", false
+		}
+	}
+	return parts[0], true
+}
+
+// watchTree adds a directory and its subdirectories to the watcher
+func (w *PluginWatcher) watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && ignoredPluginPath(d.Name()) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// ignoredPluginPath reports whether changes under a file or directory name
+// are ignored: hidden files, editor backups and dependency or bytecode caches
+// that plugins write to while running
+func ignoredPluginPath(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "~") ||
+		name == "__pycache__" ||
+		name == "node_modules"
+}
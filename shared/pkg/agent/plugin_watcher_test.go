@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+func writeTestActionPlugin(t *testing.T, dir, id, version string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	manifest := "plugin:\n  id: " + id + "\n  version: " + version + "\n  type: action\n  runtime:\n    type: bash\n    entry_point: run.sh\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo '{}'\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(manifest), 0644))
+}
+
+func TestPluginWatcher(t *testing.T) {
+	pluginDir := t.TempDir()
+	writeTestActionPlugin(t, filepath.Join(pluginDir, "one"), "plugin-one", "1.0.0")
+	writeTestTriggerPlugin(t, filepath.Join(pluginDir, "trigger"), "1.0.0")
+
+	manager, err := NewPluginManager(&config.PluginConfig{Directory: pluginDir, Timeout: time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	watcher, err := NewPluginWatcher(pluginDir, manager, PluginWatcherOptions{
+		AutoLoad:     true,
+		WatchChanges: true,
+		Debounce:     50 * time.Millisecond,
+		Types:        []plugin.PluginType{plugin.PluginTypeAction},
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	events := make(chan *plugin.PluginEvent, 10)
+	watcher.AddHandler(plugin.EventHandlerFunc(func(ctx context.Context, event *plugin.PluginEvent) error {
+		events <- event
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx)
+
+	next := func(eventType, pluginID string) *plugin.PluginEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			require.Equal(t, eventType, event.Type)
+			require.Equal(t, pluginID, event.PluginID)
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event for %s", eventType, pluginID)
+			return nil
+		}
+	}
+
+	// Existing plugins of the managed types are loaded on start
+	next(PluginEventLoaded, "plugin-one")
+	_, err = manager.GetPlugin("test-trigger")
+	assert.Error(t, err, "trigger plugins are not managed by this watcher")
+
+	// Give the watcher time to register its watches
+	time.Sleep(100 * time.Millisecond)
+
+	writeTestActionPlugin(t, filepath.Join(pluginDir, "one"), "plugin-one", "1.1.0")
+	event := next(PluginEventReloaded, "plugin-one")
+	assert.Equal(t, "1.1.0", event.Data["version"])
+
+	writeTestActionPlugin(t, filepath.Join(pluginDir, "two"), "plugin-two", "1.0.0")
+	next(PluginEventLoaded, "plugin-two")
+
+	// A broken manifest leaves the loaded plugin in place
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "two", "plugin.yaml"), []byte("plugin:\n  id: plugin-two\n"), 0644))
+	next(PluginEventInvalid, "plugin-two")
+	_, err = manager.GetPlugin("plugin-two")
+	assert.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(pluginDir, "one")))
+	next(PluginEventUnloaded, "plugin-one")
+	_, err = manager.GetPlugin("plugin-one")
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -19,6 +20,9 @@ type AuthManager struct {
 	// API Key management
 	apiKey string
 	mu     sync.RWMutex
+
+	// Access tokens for the client_credentials method
+	tokenSource *clientCredentialsSource
 }
 
 // NewAuthManager creates a new authentication manager
@@ -29,10 +33,20 @@ func NewAuthManager(config config.AuthConfig, logger *zap.Logger) (*AuthManager,
 	}
 
 	switch config.Method {
-	case "api_key":
+	case "api_key", "jwt":
 		if err := manager.initAPIKey(); err != nil {
-			return nil, fmt.Errorf("failed to initialize API key auth: %w", err)
+			return nil, fmt.Errorf("failed to initialize %s auth: %w", config.Method, err)
+		}
+	case "mtls":
+		// Authentication happens in the TLS handshake with the client certificate
+		logger.Debug("Using mutual TLS authentication")
+	case "client_credentials":
+		// Tokens are fetched lazily on the first request
+		source, err := newClientCredentialsSource(config, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize client_credentials auth: %w", err)
 		}
+		manager.tokenSource = source
 	default:
 		return nil, fmt.Errorf("unsupported authentication method: %s", config.Method)
 	}
@@ -40,12 +54,12 @@ func NewAuthManager(config config.AuthConfig, logger *zap.Logger) (*AuthManager,
 	return manager, nil
 }
 
-// initAPIKey initializes API key authentication
+// initAPIKey initializes API key or JWT bearer token authentication
 func (a *AuthManager) initAPIKey() error {
 	// First, check if we have an API key directly in config
 	if a.config.APIKey != "" {
 		a.mu.Lock()
-		a.apiKey = a.config.APIKey
+		a.apiKey = normalizeToken(a.config.APIKey)
 		a.mu.Unlock()
 		a.logger.Debug("Using API key from configuration")
 		return nil
@@ -54,15 +68,9 @@ func (a *AuthManager) initAPIKey() error {
 	// If no direct API key, check if we have a token file to read from
 	if a.config.TokenFile != "" {
 		a.logger.Debug("Loading API key from file", zap.String("file", a.config.TokenFile))
-		token, err := os.ReadFile(a.config.TokenFile)
+		apiKey, err := a.readTokenFile()
 		if err != nil {
-			return fmt.Errorf("failed to read API key file: %w", err)
-		}
-
-		// Trim whitespace and set as API key
-		apiKey := strings.TrimSpace(string(token))
-		if apiKey == "" {
-			return fmt.Errorf("API key file is empty: %s", a.config.TokenFile)
+			return err
 		}
 
 		a.mu.Lock()
@@ -76,11 +84,73 @@ func (a *AuthManager) initAPIKey() error {
 	return fmt.Errorf("no API key provided: either set api_key in config or provide token_file")
 }
 
+// readTokenFile reads and normalizes the token from the configured token file
+func (a *AuthManager) readTokenFile() (string, error) {
+	token, err := os.ReadFile(a.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	apiKey := normalizeToken(string(token))
+	if apiKey == "" {
+		return "", fmt.Errorf("API key file is empty: %s", a.config.TokenFile)
+	}
+
+	return apiKey, nil
+}
+
+// normalizeToken trims whitespace and any "Bearer " prefix from a token
+func normalizeToken(token string) string {
+	return strings.TrimPrefix(strings.TrimSpace(token), "Bearer ")
+}
+
+// Refresh reloads credentials from their source, reporting whether they
+// changed. It is called when the orchestrator rejects the current credentials.
+func (a *AuthManager) Refresh(ctx context.Context) (bool, error) {
+	switch a.config.Method {
+	case "api_key", "jwt":
+		// Only a token file can hold rotated credentials; a configured API
+		// key is used instead of it
+		if a.config.APIKey != "" || a.config.TokenFile == "" {
+			return false, nil
+		}
+
+		apiKey, err := a.readTokenFile()
+		if err != nil {
+			return false, err
+		}
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if apiKey == a.apiKey {
+			return false, nil
+		}
+		a.apiKey = apiKey
+		return true, nil
+	case "mtls":
+		// The client certificate is reloaded on the next handshake when it changes
+		return false, nil
+	case "client_credentials":
+		return a.tokenSource.Invalidate(ctx)
+	default:
+		return false, fmt.Errorf("unsupported authentication method: %s", a.config.Method)
+	}
+}
+
 // AddAuth adds authentication to an HTTP request
 func (a *AuthManager) AddAuth(req *http.Request) error {
 	switch a.config.Method {
-	case "api_key":
+	case "api_key", "jwt":
 		return a.addAPIKeyAuth(req)
+	case "mtls":
+		return nil
+	case "client_credentials":
+		token, err := a.tokenSource.Token(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to obtain access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
 	default:
 		return fmt.Errorf("unsupported authentication method: %s", a.config.Method)
 	}
@@ -126,6 +196,14 @@ func (a *AuthManager) UpdateAPIKey(newAPIKey string) error {
 	return nil
 }
 
+// SetHTTPClient sets the HTTP client used to reach the token endpoint, so
+// token requests share the TLS configuration of API requests
+func (a *AuthManager) SetHTTPClient(client *http.Client) {
+	if a.tokenSource != nil && client != nil {
+		a.tokenSource.httpClient = client
+	}
+}
+
 // Close cleans up the authentication manager
 func (a *AuthManager) Close() error {
 	a.mu.Lock()
@@ -133,6 +211,9 @@ func (a *AuthManager) Close() error {
 
 	// Clear sensitive data
 	a.apiKey = ""
+	if a.tokenSource != nil {
+		a.tokenSource.Clear()
+	}
 
 	return nil
 }
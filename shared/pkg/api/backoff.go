@@ -0,0 +1,60 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decorrelatedJitter returns the next retry delay using the "decorrelated
+// jitter" strategy: a random delay between base and three times the previous
+// delay, capped at maxDelay
+func decorrelatedJitter(base, maxDelay, previous time.Duration) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+	if maxDelay < base {
+		maxDelay = base
+	}
+	if previous < base {
+		previous = base
+	}
+
+	upper := previous * 3
+	if upper > maxDelay || upper <= 0 {
+		upper = maxDelay
+	}
+
+	delay := base
+	if upper > base {
+		delay += time.Duration(rand.Int63n(int64(upper - base)))
+	}
+
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns zero if the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	// Members are sorted by UTF-16 code units, so the emoji, a surrogate
+	// pair, sorts before U+FB33. Only quotes, backslashes and control
+	// characters are escaped.
+	canonical, err := CanonicalJSON(map[string]interface{}{
+		"\u20ac":       "Euro Sign",
+		"\r":           "Carriage Return",
+		"\ufb33":       "Hebrew Letter Dalet With Dagesh",
+		"1":            "One",
+		"\U0001F600":   "Emoji: Grinning Face",
+		"\u0080":       "Control",
+		"\u00f6":       "Latin Small Letter O With Diaeresis",
+		"</script>":    "a \"quoted\" \\ <&>\n\u001f",
+		"nested":       []interface{}{1, 2.5, true, nil, map[string]int{"b": 2, "a": 1}},
+		"empty object": map[string]interface{}{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"</script>\":\"a \\\"quoted\\\" \\\\ <&>\\n\\u001f\","+
+		"\"empty object\":{},\"nested\":[1,2.5,true,null,{\"a\":1,\"b\":2}],"+
+		"\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\","+
+		"\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}", string(canonical))
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	// Number serialization samples of RFC 8785, appendix B
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		got, err := canonicalNumber(math.Float64frombits(tt.bits))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "bits %016x", tt.bits)
+	}
+
+	_, err := canonicalNumber(math.NaN())
+	assert.Error(t, err)
+	_, err = canonicalNumber(math.Inf(1))
+	assert.Error(t, err)
+}
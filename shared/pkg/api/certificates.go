@@ -0,0 +1,218 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CertificateStatus describes the client certificate currently in use
+type CertificateStatus struct {
+	Subject    string        `json:"subject"`
+	Issuer     string        `json:"issuer"`
+	NotBefore  time.Time     `json:"not_before"`
+	NotAfter   time.Time     `json:"not_after"`
+	ExpiresIn  time.Duration `json:"expires_in"`
+	Expiring   bool          `json:"expiring"`
+	Expired    bool          `json:"expired"`
+	LastReload time.Time     `json:"last_reload"`
+	LastError  string        `json:"last_error,omitempty"`
+}
+
+// CertificateReloader serves the client certificate for TLS handshakes and
+// reloads it when the certificate or key file changes on disk, so rotated
+// certificates are picked up without restarting the agent
+type CertificateReloader struct {
+	certFile      string
+	keyFile       string
+	expiryWarning time.Duration
+	logger        *zap.Logger
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	leaf        *x509.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastReload  time.Time
+	lastError   string
+	lastWarning time.Time
+}
+
+// expiryWarningInterval limits how often the expiry warning is logged
+const expiryWarningInterval = time.Hour
+
+// NewCertificateReloader loads the certificate and key and returns a reloader
+func NewCertificateReloader(certFile, keyFile string, expiryWarning time.Duration, logger *zap.Logger) (*CertificateReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("certificate and key files are required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	r := &CertificateReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		expiryWarning: expiryWarning,
+		logger:        logger,
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// maybeReload reloads the certificate if either file has been modified.
+// A failed reload keeps the previous certificate in use.
+func (r *CertificateReloader) maybeReload() {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		r.recordError(fmt.Errorf("failed to stat client certificate: %w", err))
+		return
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		r.recordError(fmt.Errorf("failed to stat client key: %w", err))
+		return
+	}
+
+	r.mu.RLock()
+	changed := !certInfo.ModTime().Equal(r.certModTime) || !keyInfo.ModTime().Equal(r.keyModTime)
+	r.mu.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		r.recordError(err)
+		return
+	}
+
+	r.logger.Info("Client certificate reloaded", zap.String("cert_file", r.certFile))
+}
+
+// reload loads the key pair from disk and replaces the current certificate
+func (r *CertificateReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat client key: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse client certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.leaf = leaf
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	r.lastReload = time.Now()
+	r.lastError = ""
+	r.lastWarning = time.Time{}
+	r.mu.Unlock()
+
+	r.logger.Debug("Client certificate loaded",
+		zap.String("subject", leaf.Subject.String()),
+		zap.Time("not_after", leaf.NotAfter))
+
+	r.checkExpiry()
+	return nil
+}
+
+// recordError records a reload failure
+func (r *CertificateReloader) recordError(err error) {
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.mu.Unlock()
+
+	r.logger.Error("Failed to reload client certificate, keeping current certificate", zap.Error(err))
+}
+
+// checkExpiry logs a warning, at most once per interval, when the
+// certificate is close to or past its expiry
+func (r *CertificateReloader) checkExpiry() {
+	r.mu.Lock()
+	leaf := r.leaf
+	warn := leaf != nil && time.Since(r.lastWarning) >= expiryWarningInterval
+	expiresIn := time.Duration(0)
+	if leaf != nil {
+		expiresIn = time.Until(leaf.NotAfter)
+	}
+	warn = warn && expiresIn <= r.expiryWarning
+	if warn {
+		r.lastWarning = time.Now()
+	}
+	r.mu.Unlock()
+
+	if !warn {
+		return
+	}
+
+	if expiresIn <= 0 {
+		r.logger.Error("Client certificate has expired",
+			zap.String("cert_file", r.certFile),
+			zap.Time("not_after", leaf.NotAfter))
+		return
+	}
+
+	r.logger.Warn("Client certificate is about to expire",
+		zap.String("cert_file", r.certFile),
+		zap.Time("not_after", leaf.NotAfter),
+		zap.Duration("expires_in", expiresIn.Round(time.Minute)))
+}
+
+// Status picks up any pending certificate change, checks expiry and returns
+// the status of the certificate in use
+func (r *CertificateReloader) Status() *CertificateStatus {
+	r.maybeReload()
+	r.checkExpiry()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := &CertificateStatus{
+		LastReload: r.lastReload,
+		LastError:  r.lastError,
+	}
+
+	if r.leaf != nil {
+		status.Subject = r.leaf.Subject.String()
+		status.Issuer = r.leaf.Issuer.String()
+		status.NotBefore = r.leaf.NotBefore
+		status.NotAfter = r.leaf.NotAfter
+		status.ExpiresIn = time.Until(r.leaf.NotAfter)
+		status.Expired = status.ExpiresIn <= 0
+		status.Expiring = status.ExpiresIn <= r.expiryWarning
+	}
+
+	return status
+}
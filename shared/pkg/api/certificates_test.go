@@ -0,0 +1,95 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeTestCertificate writes a self-signed certificate and key valid for the given duration
+func writeTestCertificate(t *testing.T, certFile, keyFile, commonName string, validFor time.Duration) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+func TestCertificateReloaderReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "agent.crt")
+	keyFile := filepath.Join(dir, "agent.key")
+
+	writeTestCertificate(t, certFile, keyFile, "agent-old", 90*24*time.Hour)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, 30*24*time.Hour, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	status := reloader.Status()
+	assert.Equal(t, "CN=agent-old", status.Subject)
+	assert.False(t, status.Expiring)
+	assert.False(t, status.Expired)
+
+	// Rotate the certificate on disk with a short-lived one
+	writeTestCertificate(t, certFile, keyFile, "agent-new", 24*time.Hour)
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	cert, err := reloader.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-new", cert.Leaf.Subject.CommonName)
+
+	status = reloader.Status()
+	assert.Equal(t, "CN=agent-new", status.Subject)
+	assert.True(t, status.Expiring)
+	assert.False(t, status.Expired)
+}
+
+func TestCertificateReloaderKeepsCertificateOnInvalidRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "agent.crt")
+	keyFile := filepath.Join(dir, "agent.key")
+
+	writeTestCertificate(t, certFile, keyFile, "agent", 90*24*time.Hour)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, time.Hour, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0600))
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	cert, err := reloader.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "agent", cert.Leaf.Subject.CommonName)
+	assert.NotEmpty(t, reloader.Status().LastError)
+}
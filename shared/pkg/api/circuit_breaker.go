@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit
+// breaker around the orchestrator is open
+var ErrCircuitOpen = errors.New("orchestrator circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreakerStats is a snapshot of the circuit breaker state
+type CircuitBreakerStats struct {
+	Enabled             bool         `json:"enabled"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Trips               int          `json:"trips"`
+	OpenedAt            time.Time    `json:"opened_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker stops requests to the orchestrator after repeated failures.
+// After OpenTimeout a single probe request is let through (half-open); enough
+// successful probes close the circuit again, a failed probe re-opens it.
+type CircuitBreaker struct {
+	cfg    config.CircuitBreakerConfig
+	logger *zap.Logger
+
+	mu                  sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	probeSuccesses      int
+	probeInFlight       bool
+	trips               int
+	openedAt            time.Time
+	lastError           string
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a new circuit breaker
+func NewCircuitBreaker(cfg config.CircuitBreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = 1
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+
+	return &CircuitBreaker{
+		cfg:    cfg,
+		logger: logger,
+		state:  CircuitClosed,
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether a request may be sent, returning ErrCircuitOpen if not
+func (cb *CircuitBreaker) Allow() error {
+	if !cb.cfg.Enabled {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cfg.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		cb.setState(CircuitHalfOpen)
+		cb.probeSuccesses = 0
+		cb.probeInFlight = true
+		return nil
+	case CircuitHalfOpen:
+		// Only one probe at a time while half-open
+		if cb.probeInFlight {
+			return ErrCircuitOpen
+		}
+		cb.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess records a successful call to the orchestrator
+func (cb *CircuitBreaker) RecordSuccess() {
+	if !cb.cfg.Enabled {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures = 0
+
+	if cb.state == CircuitHalfOpen {
+		cb.probeInFlight = false
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.cfg.SuccessThreshold {
+			cb.setState(CircuitClosed)
+			cb.lastError = ""
+		}
+	}
+}
+
+// RecordNeutral records a call whose outcome says nothing about the
+// orchestrator, such as one cancelled by the caller
+func (cb *CircuitBreaker) RecordNeutral() {
+	if !cb.cfg.Enabled {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitHalfOpen {
+		cb.probeInFlight = false
+	}
+}
+
+// RecordFailure records a failed call to the orchestrator
+func (cb *CircuitBreaker) RecordFailure(err error) {
+	if !cb.cfg.Enabled {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures++
+	if err != nil {
+		cb.lastError = err.Error()
+	}
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		cb.probeInFlight = false
+		cb.trip()
+	case CircuitClosed:
+		if cb.consecutiveFailures >= cb.cfg.FailureThreshold {
+			cb.trip()
+		}
+	}
+}
+
+// trip opens the circuit; the caller must hold the lock
+func (cb *CircuitBreaker) trip() {
+	cb.openedAt = cb.now()
+	cb.trips++
+	cb.setState(CircuitOpen)
+}
+
+// setState transitions the breaker; the caller must hold the lock
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+
+	previous := cb.state
+	cb.state = state
+
+	fields := []zap.Field{
+		zap.String("from", string(previous)),
+		zap.String("to", string(state)),
+		zap.Int("consecutive_failures", cb.consecutiveFailures),
+	}
+
+	switch state {
+	case CircuitOpen:
+		cb.logger.Warn("Orchestrator circuit breaker opened",
+			append(fields, zap.Duration("open_timeout", cb.cfg.OpenTimeout), zap.String("last_error", cb.lastError))...)
+	case CircuitClosed:
+		cb.logger.Info("Orchestrator circuit breaker closed", fields...)
+	default:
+		cb.logger.Info("Orchestrator circuit breaker half-open, probing", fields...)
+	}
+}
+
+// State returns the current circuit state
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Stats returns a snapshot of the circuit breaker state
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return CircuitBreakerStats{
+		Enabled:             cb.cfg.Enabled,
+		State:               cb.state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+		Trips:               cb.trips,
+		OpenedAt:            cb.openedAt,
+		LastError:           cb.lastError,
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker(config.CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		OpenTimeout:      10 * time.Second,
+	}, zaptest.NewLogger(t))
+	cb.now = func() time.Time { return now }
+
+	failure := errors.New("connection refused")
+
+	require.NoError(t, cb.Allow())
+	cb.RecordFailure(failure)
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	require.NoError(t, cb.Allow())
+	cb.RecordFailure(failure)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.ErrorIs(t, cb.Allow(), ErrCircuitOpen)
+
+	// After the open timeout a single probe is allowed
+	now = now.Add(11 * time.Second)
+	require.NoError(t, cb.Allow())
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.ErrorIs(t, cb.Allow(), ErrCircuitOpen)
+
+	// A failed probe re-opens the circuit
+	cb.RecordFailure(failure)
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	now = now.Add(11 * time.Second)
+	require.NoError(t, cb.Allow())
+	cb.RecordSuccess()
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	stats := cb.Stats()
+	assert.Equal(t, 2, stats.Trips)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	cb := NewCircuitBreaker(config.CircuitBreakerConfig{FailureThreshold: 1}, zaptest.NewLogger(t))
+
+	cb.RecordFailure(errors.New("boom"))
+	assert.NoError(t, cb.Allow())
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestDecorrelatedJitterBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxDelay := time.Second
+
+	var delay time.Duration
+	for i := 0; i < 50; i++ {
+		delay = decorrelatedJitter(base, maxDelay, delay)
+		assert.GreaterOrEqual(t, delay, base)
+		assert.LessOrEqual(t, delay, maxDelay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
@@ -7,15 +7,18 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"go.uber.org/zap"
 )
 
@@ -23,21 +26,59 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
-	config     *config.APIConfig
-	auth       *AuthManager
-	logger     *zap.Logger
+	// streamClient shares the transport of httpClient but has no overall
+	// timeout, for requests the orchestrator may hold open
+	streamClient *http.Client
+	config       *config.APIConfig
+	auth         *AuthManager
+	logger       *zap.Logger
 
 	// Rate limiting
 	rateLimiter *RateLimiter
 
+	// Circuit breaker around orchestrator calls
+	breaker *CircuitBreaker
+
+	// Client certificate, when TLS client authentication is configured
+	certReloader *CertificateReloader
+
+	// Called when the agent cannot authenticate with the orchestrator
+	onAuthFailure AuthFailureHandler
+
+	// Called with every failed attempt, for metrics
+	onError ErrorHandler
+
 	// Connection pooling
 	mu sync.RWMutex
 }
 
+// AuthFailureHandler is called with a request the orchestrator rejected as
+// unauthorized or forbidden, or that could not be authenticated at all, in
+// which case statusCode is 0
+type AuthFailureHandler func(method, path string, statusCode int, err error)
+
+// ErrorHandler is called with every failed request attempt. statusCode is
+// the orchestrator's response status, or 0 when no response was received.
+type ErrorHandler func(method, path string, statusCode int, err error)
+
 // NewClient creates a new API client
 func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	// Mutual TLS needs a client certificate to authenticate with
+	if cfg.Security.Auth.Method == "mtls" {
+		if !cfg.Security.TLS.Enabled || cfg.Security.TLS.CertFile == "" || cfg.Security.TLS.KeyFile == "" {
+			return nil, fmt.Errorf("mtls authentication requires TLS to be enabled with cert_file and key_file")
+		}
+	}
+
 	// Create HTTP client with security configuration
-	httpClient, err := createHTTPClient(cfg)
+	httpClient, certReloader, err := createHTTPClient(cfg, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -48,23 +89,32 @@ func NewClient(cfg *config.Config, logger *zap.Logger) (*Client, error) {
 		return nil, fmt.Errorf("failed to create auth manager: %w", err)
 	}
 
+	// Token requests go through the same transport as API requests
+	authManager.SetHTTPClient(&http.Client{
+		Transport: httpClient.Transport,
+		Timeout:   cfg.API.Timeout,
+	})
+
 	// Create rate limiter
 	rateLimiter := NewRateLimiter(cfg.API.RateLimitRPS)
 
 	client := &Client{
-		baseURL:     cfg.API.BaseURL,
-		httpClient:  httpClient,
-		config:      &cfg.API,
-		auth:        authManager,
-		logger:      logger,
-		rateLimiter: rateLimiter,
+		baseURL:      cfg.API.BaseURL,
+		httpClient:   httpClient,
+		streamClient: &http.Client{Transport: httpClient.Transport},
+		config:       &cfg.API,
+		auth:         authManager,
+		logger:       logger,
+		rateLimiter:  rateLimiter,
+		breaker:      NewCircuitBreaker(cfg.API.CircuitBreaker, logger),
+		certReloader: certReloader,
 	}
 
 	return client, nil
 }
 
 // createHTTPClient creates an HTTP client with the specified security configuration
-func createHTTPClient(cfg *config.Config) (*http.Client, error) {
+func createHTTPClient(cfg *config.Config, logger *zap.Logger) (*http.Client, *CertificateReloader, error) {
 	transport := &http.Transport{
 		MaxIdleConns:       cfg.API.MaxIdleConns,
 		IdleConnTimeout:    cfg.API.IdleConnTimeout,
@@ -73,22 +123,26 @@ func createHTTPClient(cfg *config.Config) (*http.Client, error) {
 	}
 
 	// Configure TLS if enabled
+	var certReloader *CertificateReloader
 	if cfg.Security.TLS.Enabled {
-		tlsConfig, err := createTLSConfig(cfg.Security.TLS)
+		tlsConfig, reloader, err := createTLSConfig(cfg.Security.TLS, logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+			return nil, nil, fmt.Errorf("failed to create TLS config: %w", err)
 		}
 		transport.TLSClientConfig = tlsConfig
+		certReloader = reloader
 	}
 
 	return &http.Client{
 		Transport: transport,
 		Timeout:   cfg.API.Timeout,
-	}, nil
+	}, certReloader, nil
 }
 
-// createTLSConfig creates a TLS configuration from the security config
-func createTLSConfig(tlsConfig config.TLSConfig) (*tls.Config, error) {
+// createTLSConfig creates a TLS configuration from the security config.
+// The client certificate, if any, is served through a reloader so that it
+// can be rotated on disk without restarting the agent.
+func createTLSConfig(tlsConfig config.TLSConfig, logger *zap.Logger) (*tls.Config, *CertificateReloader, error) {
 	config := &tls.Config{
 		ServerName:         tlsConfig.ServerName,
 		InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
@@ -105,28 +159,30 @@ func createTLSConfig(tlsConfig config.TLSConfig) (*tls.Config, error) {
 	}
 
 	// Load client certificates if specified
+	var reloader *CertificateReloader
 	if tlsConfig.CertFile != "" && tlsConfig.KeyFile != "" {
-		cert, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		var err error
+		reloader, err = NewCertificateReloader(tlsConfig.CertFile, tlsConfig.KeyFile, tlsConfig.ExpiryWarning, logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			return nil, nil, err
 		}
-		config.Certificates = []tls.Certificate{cert}
+		config.GetClientCertificate = reloader.GetClientCertificate
 	}
 
 	// Load CA certificate if specified
 	if tlsConfig.CAFile != "" {
 		caCert, err := os.ReadFile(tlsConfig.CAFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+			return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
 		}
 		caCertPool := x509.NewCertPool()
 		if !caCertPool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to parse CA certificate")
+			return nil, nil, fmt.Errorf("failed to parse CA certificate")
 		}
 		config.RootCAs = caCertPool
 	}
 
-	return config, nil
+	return config, reloader, nil
 }
 
 // Request represents an API request
@@ -136,6 +192,10 @@ type Request struct {
 	Headers map[string]string
 	Body    interface{}
 	Query   map[string]string
+
+	// Timeout overrides the client timeout for requests the server may
+	// hold open, such as long-polls
+	Timeout time.Duration
 }
 
 // Response represents an API response
@@ -145,54 +205,259 @@ type Response struct {
 	Body       []byte
 }
 
-// Do executes an API request with retry logic and rate limiting
+// Do executes an API request with retry logic, rate limiting and circuit breaking.
+// Retries use decorrelated-jitter backoff and honor Retry-After on 429/503.
 func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
 	// Apply rate limiting
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
+	// Always make at least one attempt
+	attempts := c.config.RetryAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	maxDelay := c.config.MaxRetryDelay
+	if maxDelay <= 0 {
+		maxDelay = 60 * time.Second
+	}
+
 	var lastErr error
+	var delay time.Duration
+	authRefreshed := false
 
 	// Retry logic
-	for attempt := 1; attempt <= c.config.RetryAttempts; attempt++ {
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return nil, err
+		}
+
 		resp, err := c.doRequest(ctx, req)
+		c.recordOutcome(ctx, err)
 		if err == nil {
 			return resp, nil
 		}
 
 		lastErr = err
 
+		// Credentials may have been rotated; reload them once and retry immediately
+		if httpErr, ok := IsHTTPError(err); ok && httpErr.StatusCode == http.StatusUnauthorized && !authRefreshed {
+			authRefreshed = true
+			if refreshed, refreshErr := c.auth.Refresh(ctx); refreshErr != nil {
+				c.logger.Warn("Failed to refresh credentials", zap.Error(refreshErr))
+			} else if refreshed {
+				c.logger.Info("Credentials refreshed after unauthorized response, retrying request",
+					zap.String("method", req.Method),
+					zap.String("path", req.Path))
+				attempt--
+				continue
+			}
+		}
+
 		// Don't retry on certain errors
 		if !isRetryableError(err) {
 			break
 		}
 
 		// Don't retry on the last attempt
-		if attempt == c.config.RetryAttempts {
+		if attempt == attempts {
 			break
 		}
 
-		// Wait before retrying
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-time.After(c.config.RetryDelay * time.Duration(attempt)):
-			// Continue to next attempt
+		// Honor the server's Retry-After, giving up if it asks for more than we would wait
+		delay = decorrelatedJitter(c.config.RetryDelay, maxDelay, delay)
+		if httpErr, ok := IsHTTPError(err); ok && httpErr.RetryAfter > 0 {
+			if httpErr.RetryAfter > maxDelay {
+				c.logger.Debug("Retry-After exceeds maximum retry delay, not retrying",
+					zap.Duration("retry_after", httpErr.RetryAfter),
+					zap.Duration("max_retry_delay", maxDelay))
+				break
+			}
+			delay = httpErr.RetryAfter
 		}
 
 		c.logger.Debug("Retrying API request",
 			zap.Int("attempt", attempt),
 			zap.String("method", req.Method),
 			zap.String("path", req.Path),
+			zap.Duration("delay", delay),
 			zap.Error(err))
+
+		// Wait before retrying
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+			// Continue to next attempt
+		}
 	}
 
-	return nil, fmt.Errorf("API request failed after %d attempts: %w", c.config.RetryAttempts, lastErr)
+	return nil, fmt.Errorf("API request failed after %d attempts: %w", attempts, lastErr)
 }
 
 // doRequest executes a single API request
 func (c *Client) doRequest(ctx context.Context, req *Request) (*Response, error) {
+	httpReq, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := c.httpClient
+	if req.Timeout > 0 {
+		reqCtx, cancel := context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+		httpReq = httpReq.WithContext(reqCtx)
+		httpClient = c.streamClient
+	}
+
+	// Execute request
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		err = fmt.Errorf("HTTP request failed: %w", err)
+		c.reportError(ctx, req, 0, err)
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	response := &Response{
+		StatusCode: httpResp.StatusCode,
+		Headers:    httpResp.Header,
+		Body:       respBody,
+	}
+
+	// Check for HTTP errors
+	if httpResp.StatusCode >= 400 {
+		httpErr := newHTTPError(httpResp, respBody)
+		c.reportAuthFailure(req, httpResp.StatusCode, httpErr)
+		c.reportError(ctx, req, httpResp.StatusCode, httpErr)
+		return response, httpErr
+	}
+
+	return response, nil
+}
+
+// recordOutcome feeds the result of a single attempt into the circuit breaker.
+// Only server-side and transport failures count against the orchestrator;
+// client errors show it is reachable, and caller cancellation says nothing.
+func (c *Client) recordOutcome(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		c.breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		c.breaker.RecordNeutral()
+	case isRetryableError(err):
+		c.breaker.RecordFailure(err)
+	default:
+		c.breaker.RecordSuccess()
+	}
+}
+
+// SetAuthFailureHandler sets the handler called when authenticating with the
+// orchestrator fails. It must be called before the client is used.
+func (c *Client) SetAuthFailureHandler(handler AuthFailureHandler) {
+	c.onAuthFailure = handler
+}
+
+// reportAuthFailure passes a failed request to the auth failure handler if
+// the orchestrator rejected its credentials
+func (c *Client) reportAuthFailure(req *Request, statusCode int, err error) {
+	if c.onAuthFailure == nil {
+		return
+	}
+	if statusCode != 0 && statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden {
+		return
+	}
+	c.onAuthFailure(req.Method, req.Path, statusCode, err)
+}
+
+// SetErrorHandler sets the handler called with every failed request attempt.
+// It must be called before the client is used.
+func (c *Client) SetErrorHandler(handler ErrorHandler) {
+	c.onError = handler
+}
+
+// reportError passes a failed attempt to the error handler, unless it failed
+// because the caller gave up on it
+func (c *Client) reportError(ctx context.Context, req *Request, statusCode int, err error) {
+	if c.onError == nil || ctx.Err() != nil {
+		return
+	}
+	c.onError(req.Method, req.Path, statusCode, err)
+}
+
+// CertificateStatus returns the status of the client certificate, or nil if
+// no client certificate is configured
+func (c *Client) CertificateStatus() *CertificateStatus {
+	if c.certReloader == nil {
+		return nil
+	}
+	return c.certReloader.Status()
+}
+
+// CircuitBreakerStats returns a snapshot of the orchestrator circuit breaker
+func (c *Client) CircuitBreakerStats() CircuitBreakerStats {
+	return c.breaker.Stats()
+}
+
+// DoStream executes a rate limited, authenticated request and returns the
+// open response for the caller to consume. It is used for long-lived
+// responses such as event streams and is not retried. The caller must close
+// the response body.
+func (c *Client) DoStream(ctx context.Context, req *Request) (*http.Response, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit exceeded: %w", err)
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		c.breaker.RecordNeutral()
+		return nil, err
+	}
+
+	httpResp, err := c.streamClient.Do(httpReq)
+	if err != nil {
+		err = fmt.Errorf("HTTP request failed: %w", err)
+		c.reportError(ctx, req, 0, err)
+		c.recordOutcome(ctx, err)
+		return nil, err
+	}
+
+	if httpResp.StatusCode >= 400 {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		if httpResp.StatusCode == http.StatusUnauthorized {
+			if _, refreshErr := c.auth.Refresh(ctx); refreshErr != nil {
+				c.logger.Warn("Failed to refresh credentials", zap.Error(refreshErr))
+			}
+		}
+		httpErr := newHTTPError(httpResp, respBody)
+		c.reportAuthFailure(req, httpResp.StatusCode, httpErr)
+		c.reportError(ctx, req, httpResp.StatusCode, httpErr)
+		c.recordOutcome(ctx, httpErr)
+		return nil, httpErr
+	}
+
+	c.breaker.RecordSuccess()
+	return httpResp, nil
+}
+
+// newHTTPRequest builds an authenticated HTTP request from an API request
+func (c *Client) newHTTPRequest(ctx context.Context, req *Request) (*http.Request, error) {
 	// Build URL
 	fullURL, err := c.buildURL(req.Path, req.Query)
 	if err != nil {
@@ -217,40 +482,15 @@ func (c *Client) doRequest(ctx context.Context, req *Request) (*Response, error)
 
 	// Set headers
 	c.setHeaders(httpReq, req.Headers)
+	tracing.Inject(ctx, httpReq.Header)
 
 	// Add authentication
 	if err := c.auth.AddAuth(httpReq); err != nil {
+		c.reportAuthFailure(req, 0, err)
 		return nil, fmt.Errorf("failed to add authentication: %w", err)
 	}
 
-	// Execute request
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
-	}
-	defer httpResp.Body.Close()
-
-	// Read response body
-	respBody, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	response := &Response{
-		StatusCode: httpResp.StatusCode,
-		Headers:    httpResp.Header,
-		Body:       respBody,
-	}
-
-	// Check for HTTP errors
-	if httpResp.StatusCode >= 400 {
-		return response, &HTTPError{
-			StatusCode: httpResp.StatusCode,
-			Message:    string(respBody),
-		}
-	}
-
-	return response, nil
+	return httpReq, nil
 }
 
 // buildURL constructs the full URL for the request
@@ -260,7 +500,8 @@ func (c *Client) buildURL(path string, query map[string]string) (string, error)
 		return "", fmt.Errorf("invalid base URL: %w", err)
 	}
 
-	u.Path = path
+	// Keep any path prefix configured in the base URL
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
 
 	if len(query) > 0 {
 		values := url.Values{}
@@ -339,6 +580,16 @@ func (c *Client) Close() error {
 		c.httpClient.CloseIdleConnections()
 	}
 
+	if c.rateLimiter != nil {
+		c.rateLimiter.Close()
+	}
+
+	if c.auth != nil {
+		if err := c.auth.Close(); err != nil {
+			return fmt.Errorf("failed to close auth manager: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -346,6 +597,22 @@ func (c *Client) Close() error {
 type HTTPError struct {
 	StatusCode int
 	Message    string
+	// RetryAfter is the delay requested by the server on 429/503 responses
+	RetryAfter time.Duration
+}
+
+// newHTTPError builds an HTTPError from a response and its body
+func newHTTPError(resp *http.Response, body []byte) *HTTPError {
+	httpErr := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		httpErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+
+	return httpErr
 }
 
 func (e *HTTPError) Error() string {
@@ -354,7 +621,8 @@ func (e *HTTPError) Error() string {
 
 // IsHTTPError checks if an error is an HTTP error
 func IsHTTPError(err error) (*HTTPError, bool) {
-	httpErr, ok := err.(*HTTPError)
+	var httpErr *HTTPError
+	ok := errors.As(err, &httpErr)
 	return httpErr, ok
 }
 
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Instruction delivery transports supported by the orchestrator
+const (
+	TransportPoll     = "poll"
+	TransportLongPoll = "long_poll"
+	TransportSSE      = "sse"
+)
+
+// InstructionEventType identifies the kind of message received over a push transport
+type InstructionEventType string
+
+const (
+	InstructionEventInstruction InstructionEventType = "instruction"
+	InstructionEventCancel      InstructionEventType = "cancel"
+	InstructionEventApprove     InstructionEventType = "approve"
+)
+
+// InstructionEvent represents a single message delivered by the orchestrator
+// over a long-poll or event-stream transport
+type InstructionEvent struct {
+	Type          InstructionEventType `json:"type"`
+	Instruction   *Instruction         `json:"instruction,omitempty"`
+	InstructionID string               `json:"instruction_id,omitempty"`
+	Reason        string               `json:"reason,omitempty"`
+	Approval      *InstructionApproval `json:"approval,omitempty"`
+}
+
+// InstructionEventHandler receives events from a push transport
+type InstructionEventHandler func(event *InstructionEvent)
+
+// LongPollInstructions polls for the next pending instruction, asking the
+// orchestrator to hold the request open until an instruction or cancellation
+// is available or the long-poll timeout elapses
+func (c *OrchestratorClient) LongPollInstructions(ctx context.Context) (*InstructionResponse, error) {
+	req := &Request{
+		Method: http.MethodGet,
+		Path:   c.agentPath("/instructions"),
+		Query:  map[string]string{"wait": strconv.Itoa(int(c.longPollTimeout.Seconds()))},
+		// Allow the server hold time plus the regular request timeout
+		Timeout: c.longPollTimeout + c.timeout,
+	}
+
+	resp, err := c.client.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to long-poll instructions: %w", err)
+	}
+
+	// 204 means the hold timeout elapsed without anything to deliver
+	if resp.StatusCode == http.StatusNoContent {
+		return &InstructionResponse{Status: "no_instructions"}, nil
+	}
+
+	var instructionResp InstructionResponse
+	if err := json.Unmarshal(resp.Body, &instructionResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &instructionResp, nil
+}
+
+// StreamInstructions opens a persistent server-sent events channel and
+// delivers instructions, cancellations and approvals to the handler as they
+// arrive. onConnected is invoked once the stream has been established. It
+// blocks until the stream is closed by either side and returns the reason.
+func (c *OrchestratorClient) StreamInstructions(ctx context.Context, onConnected func(), handler InstructionEventHandler) error {
+	req := &Request{
+		Method: http.MethodGet,
+		Path:   c.agentPath("/instructions/stream"),
+		Headers: map[string]string{
+			"Accept":        "text/event-stream",
+			"Cache-Control": "no-cache",
+		},
+	}
+
+	resp, err := c.client.DoStream(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to open instruction stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if onConnected != nil {
+		onConnected()
+	}
+
+	c.logger.Info("Instruction stream connected", zap.String("agent_id", c.agentID))
+
+	return c.readEventStream(resp.Body, handler)
+}
+
+// readEventStream parses a text/event-stream body and dispatches each event
+func (c *OrchestratorClient) readEventStream(body io.Reader, handler InstructionEventHandler) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var eventType string
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// Blank line terminates the event
+			if len(data) > 0 {
+				c.dispatchStreamEvent(eventType, strings.Join(data, "\n"), handler)
+			}
+			eventType = ""
+			data = data[:0]
+		case strings.HasPrefix(line, ":"):
+			// Comment line, used by the server as a keep-alive
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("instruction stream read failed: %w", err)
+	}
+
+	return fmt.Errorf("instruction stream closed by server")
+}
+
+// dispatchStreamEvent decodes a single server-sent event and forwards it to the handler
+func (c *OrchestratorClient) dispatchStreamEvent(eventType, data string, handler InstructionEventHandler) {
+	switch InstructionEventType(eventType) {
+	case InstructionEventInstruction:
+		var instruction Instruction
+		if err := json.Unmarshal([]byte(data), &instruction); err != nil {
+			c.logger.Error("Failed to decode streamed instruction", zap.Error(err))
+			return
+		}
+		handler(&InstructionEvent{Type: InstructionEventInstruction, Instruction: &instruction})
+	case InstructionEventCancel:
+		var event InstructionEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			c.logger.Error("Failed to decode streamed cancellation", zap.Error(err))
+			return
+		}
+		event.Type = InstructionEventCancel
+		handler(&event)
+	case InstructionEventApprove:
+		var approval InstructionApproval
+		if err := json.Unmarshal([]byte(data), &approval); err != nil {
+			c.logger.Error("Failed to decode streamed approval", zap.Error(err))
+			return
+		}
+		handler(&InstructionEvent{Type: InstructionEventApprove, InstructionID: approval.InstructionID, Approval: &approval})
+	default:
+		c.logger.Debug("Ignoring instruction stream event", zap.String("event", eventType))
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReadEventStream(t *testing.T) {
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig("http://127.0.0.1:1"), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	stream := strings.Join([]string{
+		": keep-alive",
+		"",
+		"event: instruction",
+		`data: {"id":"inst-1",`,
+		`data: "plugin_id":"cleanup"}`,
+		"",
+		": keep-alive between events",
+		"event: cancel",
+		`data: {"instruction_id":"inst-0","reason":"superseded"}`,
+		"",
+		"event: approve",
+		`data: {"instruction_id":"inst-2","approved_by":"alice@example.com"}`,
+		"",
+		"event: unknown",
+		`data: {}`,
+		"",
+		"event: instruction",
+		`data: not json`,
+		"",
+		"event: instruction",
+		`data:{"id":"inst-3"}`,
+		"",
+		// An event cut off before its blank line is discarded
+		"event: instruction",
+		`data: {"id":"inst-4"}`,
+	}, "\n")
+
+	var events []*InstructionEvent
+	err = client.readEventStream(strings.NewReader(stream), func(event *InstructionEvent) {
+		events = append(events, event)
+	})
+	assert.ErrorContains(t, err, "closed by server")
+
+	require.Len(t, events, 4)
+	assert.Equal(t, InstructionEventInstruction, events[0].Type)
+	assert.Equal(t, "inst-1", events[0].Instruction.ID)
+	assert.Equal(t, "cleanup", events[0].Instruction.PluginID)
+
+	assert.Equal(t, InstructionEventCancel, events[1].Type)
+	assert.Equal(t, "inst-0", events[1].InstructionID)
+	assert.Equal(t, "superseded", events[1].Reason)
+
+	assert.Equal(t, InstructionEventApprove, events[2].Type)
+	assert.Equal(t, "inst-2", events[2].InstructionID)
+	assert.Equal(t, "alice@example.com", events[2].Approval.ApprovedBy)
+
+	assert.Equal(t, "inst-3", events[3].Instruction.ID)
+}
+
+func TestStreamInstructions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/agents/v1/agent-1/instructions/stream", r.URL.Path)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": connected\n\nevent: instruction\ndata: {\"id\":\"inst-1\"}\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("event: cancel\ndata: {\"instruction_id\":\"inst-1\"}\n\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig(server.URL), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	connected := false
+	var events []*InstructionEvent
+	err = client.StreamInstructions(context.Background(), func() { connected = true }, func(event *InstructionEvent) {
+		events = append(events, event)
+	})
+	assert.ErrorContains(t, err, "closed by server")
+	assert.True(t, connected)
+	require.Len(t, events, 2)
+	assert.Equal(t, "inst-1", events[0].Instruction.ID)
+	assert.Equal(t, InstructionEventCancel, events[1].Type)
+	assert.Equal(t, "inst-1", events[1].InstructionID)
+}
+
+func TestStreamInstructionsFailsToConnect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "streaming unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig(server.URL), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	connected := false
+	err = client.StreamInstructions(context.Background(), func() { connected = true }, func(*InstructionEvent) {
+		t.Error("no events expected")
+	})
+	require.Error(t, err)
+	assert.False(t, connected)
+
+	httpErr, ok := IsHTTPError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+}
+
+func TestLongPollInstructions(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/agents/v1/agent-1/instructions", r.URL.Path)
+		assert.Equal(t, "30", r.URL.Query().Get("wait"))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"instruction_available","instruction":{"id":"inst-1"},"cancelled_instructions":["inst-0"]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig(server.URL), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The hold timeout elapsed with nothing to deliver
+	resp, err := client.LongPollInstructions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "no_instructions", resp.Status)
+	assert.Nil(t, resp.Instruction)
+
+	resp, err = client.LongPollInstructions(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, resp.Instruction)
+	assert.Equal(t, "inst-1", resp.Instruction.ID)
+	assert.Equal(t, []string{"inst-0"}, resp.CancelledInstructions)
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Tokens are refreshed once this fraction of their lifetime has elapsed,
+// but never later than tokenRefreshMinMargin before expiry
+const (
+	tokenRefreshFraction  = 0.8
+	tokenRefreshMinMargin = 10 * time.Second
+)
+
+// clientCredentialsSource fetches and caches OAuth2 access tokens using the
+// client credentials grant. Token material is never logged.
+type clientCredentialsSource struct {
+	config     config.AuthConfig
+	httpClient *http.Client
+	logger     *zap.Logger
+
+	// fetchMu serializes token fetches so concurrent requests share one refresh
+	fetchMu sync.Mutex
+
+	mu        sync.RWMutex
+	token     string
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+// tokenResponse is the token endpoint response defined by RFC 6749
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// tokenErrorResponse is the token endpoint error response defined by RFC 6749
+type tokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// newClientCredentialsSource validates the configuration and creates a token source
+func newClientCredentialsSource(cfg config.AuthConfig, logger *zap.Logger) (*clientCredentialsSource, error) {
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("token_url is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	if cfg.ClientSecret == "" && cfg.ClientSecretFile == "" {
+		return nil, fmt.Errorf("client_secret or client_secret_file is required")
+	}
+
+	return &clientCredentialsSource{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}, nil
+}
+
+// Token returns a valid access token, fetching a new one if the cached token
+// is missing or due for refresh
+func (s *clientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	token, refreshAt := s.token, s.refreshAt
+	s.mu.RUnlock()
+
+	if token != "" && time.Now().Before(refreshAt) {
+		return token, nil
+	}
+
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	// Another request may have refreshed the token while we waited
+	s.mu.RLock()
+	token, refreshAt = s.token, s.refreshAt
+	s.mu.RUnlock()
+	if token != "" && time.Now().Before(refreshAt) {
+		return token, nil
+	}
+
+	return s.fetch(ctx)
+}
+
+// Invalidate forces a fresh token fetch, returning whether a new token was obtained
+func (s *clientCredentialsSource) Invalidate(ctx context.Context) (bool, error) {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	if _, err := s.fetch(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetch requests a new token from the token endpoint; the caller must hold fetchMu
+func (s *clientCredentialsSource) fetch(ctx context.Context) (string, error) {
+	secret, err := s.clientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.Audience != "" {
+		form.Set("audience", s.config.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(secret))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Only the standard error fields are surfaced, never the raw body
+		var tokenErr tokenErrorResponse
+		_ = json.Unmarshal(body, &tokenErr)
+		if tokenErr.Error != "" {
+			return "", fmt.Errorf("token endpoint returned status %d: %s %s", resp.StatusCode, tokenErr.Error, tokenErr.ErrorDescription)
+		}
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp tokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported token type: %s", tokenResp.TokenType)
+	}
+
+	// The configured TTL caps the lifetime reported by the server
+	lifetime := time.Duration(tokenResp.ExpiresIn) * time.Second
+	if s.config.TokenTTL > 0 && (lifetime <= 0 || s.config.TokenTTL < lifetime) {
+		lifetime = s.config.TokenTTL
+	}
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+
+	margin := lifetime - time.Duration(float64(lifetime)*tokenRefreshFraction)
+	if margin < tokenRefreshMinMargin {
+		margin = tokenRefreshMinMargin
+	}
+	if margin > lifetime {
+		margin = lifetime
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.token = tokenResp.AccessToken
+	s.expiresAt = now.Add(lifetime)
+	s.refreshAt = s.expiresAt.Add(-margin)
+	s.mu.Unlock()
+
+	s.logger.Debug("Obtained access token",
+		zap.String("client_id", s.config.ClientID),
+		zap.Duration("lifetime", lifetime))
+
+	return tokenResp.AccessToken, nil
+}
+
+// clientSecret returns the client secret, reading it from file if configured
+// so that rotated secrets are picked up on the next fetch
+func (s *clientCredentialsSource) clientSecret() (string, error) {
+	if s.config.ClientSecretFile != "" {
+		data, err := os.ReadFile(s.config.ClientSecretFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read client secret file: %w", err)
+		}
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("client secret file is empty: %s", s.config.ClientSecretFile)
+		}
+		return secret, nil
+	}
+
+	return s.config.ClientSecret, nil
+}
+
+// Clear drops the cached token
+func (s *clientCredentialsSource) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+	s.refreshAt = time.Time{}
+	s.expiresAt = time.Time{}
+}
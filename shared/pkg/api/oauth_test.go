@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// newTestTokenServer issues sequentially numbered tokens
+func newTestTokenServer(t *testing.T, issued *int32, expiresIn int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "agent-client", clientID)
+		assert.Equal(t, "s3cret", secret)
+
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "agents:read agents:write", r.PostForm.Get("scope"))
+
+		n := atomic.AddInt32(issued, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+}
+
+func newTestClientCredentialsConfig(tokenURL string) config.AuthConfig {
+	return config.AuthConfig{
+		Method:       "client_credentials",
+		TokenURL:     tokenURL,
+		ClientID:     "agent-client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"agents:read", "agents:write"},
+	}
+}
+
+func TestClientCredentialsTokenCaching(t *testing.T) {
+	var issued int32
+	tokenServer := newTestTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+
+	source, err := newClientCredentialsSource(newTestClientCredentialsConfig(tokenServer.URL), zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&issued))
+
+	// Tokens past their refresh point are replaced
+	source.mu.Lock()
+	source.refreshAt = time.Now().Add(-time.Second)
+	source.mu.Unlock()
+
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestClientCredentialsTokenTTLCapsLifetime(t *testing.T) {
+	var issued int32
+	tokenServer := newTestTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+
+	cfg := newTestClientCredentialsConfig(tokenServer.URL)
+	cfg.TokenTTL = 5 * time.Minute
+
+	source, err := newClientCredentialsSource(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	_, err = source.Token(context.Background())
+	require.NoError(t, err)
+
+	source.mu.RLock()
+	defer source.mu.RUnlock()
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), source.expiresAt, 5*time.Second)
+	assert.True(t, source.refreshAt.Before(source.expiresAt))
+}
+
+func TestClientRetriesUnauthorizedWithFreshToken(t *testing.T) {
+	var issued int32
+	tokenServer := newTestTokenServer(t, &issued, 3600)
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first token has been revoked by the orchestrator
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer apiServer.Close()
+
+	cfg := newTestOrchestratorConfig(apiServer.URL)
+	cfg.API.RetryAttempts = 1
+	cfg.Security.Auth = newTestClientCredentialsConfig(tokenServer.URL)
+
+	client, err := NewOrchestratorClient(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.NoError(t, client.SendHeartbeat(context.Background(), "online"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&issued))
+}
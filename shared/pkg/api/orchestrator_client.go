@@ -2,14 +2,11 @@
 package api
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/Stavily/01-Agents/shared/pkg/config"
@@ -17,14 +14,15 @@ import (
 )
 
 // OrchestratorClient handles communication with the Stavily Orchestrator API
-// following the AGENT_USE.md specification
+// following the AGENT_USE.md specification. It is built on Client, so every
+// call shares its retry, rate limiting, TLS and authentication handling.
 type OrchestratorClient struct {
-	baseURL    string
-	agentID    string
-	authToken  string
-	authMethod string
-	httpClient *http.Client
-	logger     *zap.Logger
+	client  *Client
+	agentID string
+	logger  *zap.Logger
+
+	timeout         time.Duration
+	longPollTimeout time.Duration
 }
 
 // NewOrchestratorClient creates a new orchestrator client
@@ -41,86 +39,107 @@ func NewOrchestratorClient(cfg *config.Config, logger *zap.Logger) (*Orchestrato
 		zap.String("agent_id", cfg.Agent.ID),
 		zap.String("auth_method", cfg.Security.Auth.Method))
 
-	// Validate auth method
-	if cfg.Security.Auth.Method != "api_key" && cfg.Security.Auth.Method != "jwt" {
-		return nil, fmt.Errorf("unsupported authentication method: %s (must be 'api_key' or 'jwt')", cfg.Security.Auth.Method)
-	}
-
-	// Initialize auth token based on configuration
-	var authToken string
-
-	// If token file is specified, it takes precedence
-	if cfg.Security.Auth.TokenFile != "" {
-		logger.Debug("Reading token from file", zap.String("token_file", cfg.Security.Auth.TokenFile))
-		tokenBytes, err := os.ReadFile(cfg.Security.Auth.TokenFile)
-		if err != nil {
-			logger.Error("Failed to read token file",
-				zap.String("token_file", cfg.Security.Auth.TokenFile),
-				zap.Error(err))
-			return nil, fmt.Errorf("failed to read token from file: %w", err)
-		}
-		authToken = string(bytes.TrimSpace(tokenBytes))
-		logger.Debug("Token read from file",
-			zap.String("token_file", cfg.Security.Auth.TokenFile),
-			zap.Int("token_length", len(authToken)))
-	} else {
-		// Otherwise use the API key/token from config
-		logger.Debug("Using token from config")
-		authToken = cfg.Security.Auth.APIKey
-	}
-
-	// Validate that we have a token
-	if authToken == "" {
-		logger.Error("No authentication token provided")
-		return nil, fmt.Errorf("no authentication token provided: set api_key/token in config or provide valid token_file")
+	client, err := NewClient(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API client: %w", err)
 	}
 
-	// Clean the token - remove any "Bearer " prefix if present
-	authToken = strings.TrimPrefix(strings.TrimSpace(authToken), "Bearer ")
-	
-	logger.Debug("Token validation",
-		zap.Int("token_length", len(authToken)),
-		zap.String("token_prefix", authToken[:min(len(authToken), 10)]+"..."))
-
-	httpClient := &http.Client{
-		Timeout: cfg.API.Timeout,
+	longPollTimeout := cfg.API.LongPollTimeout
+	if longPollTimeout <= 0 {
+		longPollTimeout = 30 * time.Second
 	}
 
 	return &OrchestratorClient{
-		baseURL:    cfg.API.BaseURL,
-		agentID:    cfg.Agent.ID,
-		authToken:  authToken,
-		authMethod: cfg.Security.Auth.Method,
-		httpClient: httpClient,
-		logger:     logger,
+		client:          client,
+		agentID:         cfg.Agent.ID,
+		logger:          logger,
+		timeout:         cfg.API.Timeout,
+		longPollTimeout: longPollTimeout,
 	}, nil
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// agentPath builds the path of an agent-scoped orchestrator endpoint
+func (c *OrchestratorClient) agentPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("/agents/v1/%s", c.agentID) + fmt.Sprintf(format, args...)
 }
 
 // InstructionResponse represents the response from polling for instructions
 type InstructionResponse struct {
-	Instruction      *Instruction `json:"instruction"`
-	Status           string       `json:"status"`
-	NextPollInterval int          `json:"next_poll_interval"`
+	Instruction           *Instruction           `json:"instruction"`
+	Status                string                 `json:"status"`
+	NextPollInterval      int                    `json:"next_poll_interval"`
+	CancelledInstructions []string               `json:"cancelled_instructions,omitempty"`
+	Approvals             []*InstructionApproval `json:"approvals,omitempty"`
 }
 
 // Instruction represents an instruction from the orchestrator
 type Instruction struct {
-	ID                   string                 `json:"id"`
-	PluginID             string                 `json:"plugin_id"`
-	InstructionType      string                 `json:"instruction_type"`
-	PluginConfiguration  map[string]interface{} `json:"plugin_configuration"`
-	InputData            map[string]interface{} `json:"input_data"`
-	TimeoutSeconds       int                    `json:"timeout_seconds"`
-	MaxRetries           int                    `json:"max_retries"`
-	CorrelationID        string                 `json:"correlation_id,omitempty"`
+	ID                  string                 `json:"id"`
+	PluginID            string                 `json:"plugin_id"`
+	InstructionType     string                 `json:"instruction_type"`
+	PluginConfiguration map[string]interface{} `json:"plugin_configuration"`
+	InputData           map[string]interface{} `json:"input_data"`
+	DryRun              bool                   `json:"dry_run,omitempty"`
+	Priority            string                 `json:"priority,omitempty"`
+	TimeoutSeconds      int                    `json:"timeout_seconds"`
+	MaxRetries          int                    `json:"max_retries"`
+	CorrelationID       string                 `json:"correlation_id,omitempty"`
+	WorkflowExecutionID string                 `json:"workflow_execution_id,omitempty"`
+	// W3C trace context of the orchestrator's work that sent the instruction
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+// ApprovalDigest returns the digest of what an instruction runs, as bound
+// into approvals: the lowercase hex SHA-256 of the RFC 8785 canonical JSON
+// of the object
+//
+//	{"input_data": {...}, "plugin_configuration": {...}, "plugin_id": "..."}
+//
+// where missing input data or plugin configuration is an empty object
+func (i *Instruction) ApprovalDigest() string {
+	content := map[string]interface{}{
+		"plugin_id":            i.PluginID,
+		"plugin_configuration": i.PluginConfiguration,
+		"input_data":           i.InputData,
+	}
+	if i.PluginConfiguration == nil {
+		content["plugin_configuration"] = map[string]interface{}{}
+	}
+	if i.InputData == nil {
+		content["input_data"] = map[string]interface{}{}
+	}
+
+	canonical, err := CanonicalJSON(content)
+	if err != nil {
+		// Content that cannot be encoded cannot match any approval
+		return ""
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// InstructionApproval is an approval token for an instruction awaiting
+// approval, signed by the orchestrator with its Ed25519 key. It approves the
+// instruction only with the content its digest was computed from.
+type InstructionApproval struct {
+	InstructionID     string    `json:"instruction_id"`
+	InstructionDigest string    `json:"instruction_digest"`
+	AgentID           string    `json:"agent_id"`
+	ApprovedBy        string    `json:"approved_by"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	Signature         []byte    `json:"signature"`
+}
+
+// SigningPayload returns the bytes the orchestrator signs for an approval
+func (a *InstructionApproval) SigningPayload() []byte {
+	return []byte(fmt.Sprintf("stavily-approval-v2\n%s\n%s\n%s\n%s\n%d",
+		a.InstructionID, a.InstructionDigest, a.AgentID, a.ApprovedBy, a.ExpiresAt.Unix()))
+}
+
+// Approves reports whether the approval covers the instruction as received
+func (a *InstructionApproval) Approves(instruction *Instruction) bool {
+	digest := instruction.ApprovalDigest()
+	return a.InstructionID == instruction.ID && digest != "" && a.InstructionDigest == digest
 }
 
 // InstructionUpdateRequest represents a request to update an instruction
@@ -132,9 +151,9 @@ type InstructionUpdateRequest struct {
 
 // InstructionUpdateResponse represents the response from updating an instruction
 type InstructionUpdateResponse struct {
-	Success        bool     `json:"success"`
-	InstructionID  string   `json:"instruction_id"`
-	UpdatedFields  []string `json:"updated_fields"`
+	Success       bool     `json:"success"`
+	InstructionID string   `json:"instruction_id"`
+	UpdatedFields []string `json:"updated_fields"`
 }
 
 // InstructionResultRequest represents a request to submit instruction results
@@ -154,28 +173,13 @@ type InstructionResultResponse struct {
 
 // PollInstructions polls for the next pending instruction
 func (c *OrchestratorClient) PollInstructions(ctx context.Context) (*InstructionResponse, error) {
-	url := fmt.Sprintf("%s/agents/v1/%s/instructions", c.baseURL, c.agentID)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	c.setHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.client.Get(ctx, c.agentPath("/instructions"), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("failed to poll instructions: %w", err)
 	}
 
 	var instructionResp InstructionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&instructionResp); err != nil {
+	if err := json.Unmarshal(resp.Body, &instructionResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -184,33 +188,13 @@ func (c *OrchestratorClient) PollInstructions(ctx context.Context) (*Instruction
 
 // UpdateInstruction updates an instruction during execution
 func (c *OrchestratorClient) UpdateInstruction(ctx context.Context, instructionID string, update *InstructionUpdateRequest) (*InstructionUpdateResponse, error) {
-	url := fmt.Sprintf("%s/agents/v1/%s/instructions/%s", c.baseURL, c.agentID, instructionID)
-	
-	bodyBytes, err := json.Marshal(update)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	c.setHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.client.Put(ctx, c.agentPath("/instructions/%s", instructionID), update)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("failed to update instruction: %w", err)
 	}
 
 	var updateResp InstructionUpdateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
+	if err := json.Unmarshal(resp.Body, &updateResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -219,33 +203,13 @@ func (c *OrchestratorClient) UpdateInstruction(ctx context.Context, instructionI
 
 // SubmitInstructionResult submits the final execution result
 func (c *OrchestratorClient) SubmitInstructionResult(ctx context.Context, instructionID string, result *InstructionResultRequest) (*InstructionResultResponse, error) {
-	url := fmt.Sprintf("%s/agents/v1/%s/instructions/%s/result", c.baseURL, c.agentID, instructionID)
-	
-	bodyBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+	resp, err := c.client.Post(ctx, c.agentPath("/instructions/%s/result", instructionID), result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	c.setHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("failed to submit instruction result: %w", err)
 	}
 
 	var resultResp InstructionResultResponse
-	if err := json.NewDecoder(resp.Body).Decode(&resultResp); err != nil {
+	if err := json.Unmarshal(resp.Body, &resultResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -256,67 +220,70 @@ func (c *OrchestratorClient) SubmitInstructionResult(ctx context.Context, instru
 // allows the caller to specify the agent's state (e.g. "online", "offline").
 // If an empty string is provided, the status defaults to "online".
 func (c *OrchestratorClient) SendHeartbeat(ctx context.Context, status string) error {
+	return c.SendHeartbeatWithPlugins(ctx, status, nil)
+}
+
+// SendHeartbeatWithPlugins sends a heartbeat that also reports the status
+// of the agent's plugins
+func (c *OrchestratorClient) SendHeartbeatWithPlugins(ctx context.Context, status string, plugins []*PluginStatusReport) error {
 	if status == "" {
 		status = "online"
 	}
 
-	url := fmt.Sprintf("%s/agents/v1/%s/heartbeat", c.baseURL, c.agentID)
 	heartbeatData := map[string]interface{}{
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"status":    status,
 	}
-
-	bodyBytes, err := json.Marshal(heartbeatData)
-	if err != nil {
-		return fmt.Errorf("failed to marshal heartbeat data: %w", err)
+	if len(plugins) > 0 {
+		heartbeatData["plugins"] = plugins
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if _, err := c.client.Post(ctx, c.agentPath("/heartbeat"), heartbeatData); err != nil {
+		return fmt.Errorf("heartbeat failed: %w", err)
 	}
 
-	c.setHeaders(req)
+	return nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// ReportTriggers reports a batch of detected trigger events. Events the
+// orchestrator could not accept are listed in FailedEvents.
+func (c *OrchestratorClient) ReportTriggers(ctx context.Context, req *ReportTriggerRequest) (*ReportTriggerResponse, error) {
+	resp, err := c.client.Post(ctx, c.agentPath("/triggers"), req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to report triggers: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("heartbeat failed with status %d: %s", resp.StatusCode, string(body))
+	var reportResp ReportTriggerResponse
+	if err := json.Unmarshal(resp.Body, &reportResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return nil
+	return &reportResp, nil
+}
+
+// CircuitBreakerStats returns the state of the circuit breaker guarding orchestrator calls
+func (c *OrchestratorClient) CircuitBreakerStats() CircuitBreakerStats {
+	return c.client.CircuitBreakerStats()
+}
+
+// CertificateStatus returns the status of the client certificate, or nil if
+// no client certificate is configured
+func (c *OrchestratorClient) CertificateStatus() *CertificateStatus {
+	return c.client.CertificateStatus()
+}
+
+// SetAuthFailureHandler sets the handler called when authenticating with the
+// orchestrator fails
+func (c *OrchestratorClient) SetAuthFailureHandler(handler AuthFailureHandler) {
+	c.client.SetAuthFailureHandler(handler)
 }
 
-// setHeaders sets the required headers for API requests
-func (c *OrchestratorClient) setHeaders(req *http.Request) {
-	c.logger.Debug("Setting request headers",
-		zap.String("method", req.Method),
-		zap.String("url", req.URL.String()),
-		zap.String("auth_method", c.authMethod))
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	
-	// Set auth header - always use Bearer for both JWT and API key
-	authHeader := fmt.Sprintf("Bearer %s", c.authToken)
-	req.Header.Set("Authorization", authHeader)
-	
-	c.logger.Debug("Authorization header set",
-		zap.Int("auth_header_length", len(authHeader)),
-		zap.String("auth_header_prefix", authHeader[:min(len(authHeader), 15)]+"..."))
-	
-	req.Header.Set("User-Agent", "Stavily-Agent/1.0.0")
+// SetErrorHandler sets the handler called with every failed request attempt
+func (c *OrchestratorClient) SetErrorHandler(handler ErrorHandler) {
+	c.client.SetErrorHandler(handler)
 }
 
 // Close closes the orchestrator client
 func (c *OrchestratorClient) Close() error {
-	if c.httpClient != nil {
-		c.httpClient.CloseIdleConnections()
-	}
-	return nil
-} 
\ No newline at end of file
+	return c.client.Close()
+}
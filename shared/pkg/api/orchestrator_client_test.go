@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func newTestOrchestratorConfig(baseURL string) *config.Config {
+	return &config.Config{
+		Agent: config.AgentConfig{ID: "agent-1"},
+		API: config.APIConfig{
+			BaseURL:       baseURL,
+			Timeout:       5 * time.Second,
+			RetryAttempts: 3,
+			RetryDelay:    time.Millisecond,
+			RateLimitRPS:  100,
+		},
+		Security: config.SecurityConfig{
+			Auth: config.AuthConfig{Method: "api_key", APIKey: "Bearer test-key"},
+		},
+	}
+}
+
+func TestOrchestratorClientRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/base/agents/v1/agent-1/instructions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(InstructionResponse{Status: "no_instructions", NextPollInterval: 15})
+	}))
+	defer server.Close()
+
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig(server.URL+"/base"), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	resp, err := client.PollInstructions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "no_instructions", resp.Status)
+	assert.Equal(t, 15, resp.NextPollInterval)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestOrchestratorClientReturnsHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown instruction", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewOrchestratorClient(newTestOrchestratorConfig(server.URL), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.UpdateInstruction(context.Background(), "missing", &InstructionUpdateRequest{Status: "executing"})
+	require.Error(t, err)
+
+	httpErr, ok := IsHTTPError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+}
+
+func TestOrchestratorClientReloadsTokenFileOnUnauthorized(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("old-token\n"), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := newTestOrchestratorConfig(server.URL)
+	cfg.API.RetryAttempts = 1
+	cfg.Security.Auth = config.AuthConfig{Method: "jwt", TokenFile: tokenFile}
+
+	client, err := NewOrchestratorClient(cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("new-token\n"), 0600))
+	assert.NoError(t, client.SendHeartbeat(context.Background(), "online"))
+}
+
+func TestAuthManagerPrefersConfiguredAPIKey(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+
+	auth, err := NewAuthManager(config.AuthConfig{Method: "api_key", APIKey: "config-token", TokenFile: tokenFile}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, auth.AddAuth(req))
+	assert.Equal(t, "Bearer config-token", req.Header.Get("Authorization"))
+
+	// The token file is not used on refresh either
+	changed, err := auth.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestInstructionApprovalDigest(t *testing.T) {
+	// Fixed vectors, so the canonical form cannot change unnoticed. The
+	// digest is the SHA-256 of
+	// {"input_data":{"reason":"line1\nline2\t\"quoted\""},"plugin_configuration":{"graceful":true,
+	// "hosts":["web-1","web-2"],"notes":null,"retries":3,"service":"nginx","timeout":1.5,"é":"\u0001",
+	// "€uro":"<&>"},"plugin_id":"restart-service"}
+	instruction := &Instruction{
+		ID:       "inst-1",
+		PluginID: "restart-service",
+		PluginConfiguration: map[string]interface{}{
+			"service":  "nginx",
+			"€uro":     "<&>",
+			"retries":  3,
+			"timeout":  1.5,
+			"graceful": true,
+			"notes":    nil,
+			"hosts":    []string{"web-1", "web-2"},
+			"é":        "\u0001",
+		},
+		InputData: map[string]interface{}{"reason": "line1\nline2\t\"quoted\""},
+	}
+	assert.Equal(t, "9ca60cbaea2562e1cb98ccc34ce092a3238f270b8b359ec4eb00e03eff13182f", instruction.ApprovalDigest())
+
+	// Decoding the instruction, as received, does not change its digest
+	encoded, err := json.Marshal(instruction)
+	require.NoError(t, err)
+	var received Instruction
+	require.NoError(t, json.Unmarshal(encoded, &received))
+	assert.Equal(t, instruction.ApprovalDigest(), received.ApprovalDigest())
+
+	// Missing configuration and input data digest as empty objects
+	assert.Equal(t, "568a19911ab7006abf7bcd760455c845a67b9d8079534698924d8d996013c630",
+		(&Instruction{PluginID: "cleanup"}).ApprovalDigest())
+	assert.Equal(t, (&Instruction{PluginID: "cleanup"}).ApprovalDigest(), (&Instruction{
+		PluginID:            "cleanup",
+		PluginConfiguration: map[string]interface{}{},
+		InputData:           map[string]interface{}{},
+	}).ApprovalDigest())
+}
@@ -92,14 +92,15 @@ func (rl *RateLimiter) refillTokens() {
 			rl.mu.Unlock()
 			return
 		}
-		rl.mu.Unlock()
 
-		// Try to add a token
+		// Try to add a token; the lock keeps Close from closing the
+		// channel during the send
 		select {
 		case rl.tokens <- struct{}{}:
 		default:
 			// Bucket is full, skip
 		}
+		rl.mu.Unlock()
 	}
 }
 
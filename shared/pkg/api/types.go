@@ -3,6 +3,8 @@ package api
 
 import (
 	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/types"
 )
 
 // PollRequest represents a request to poll for pending tasks
@@ -34,6 +36,7 @@ type Task struct {
 	Context     map[string]interface{} `json:"context"`
 	Timeout     time.Duration          `json:"timeout"`
 	Priority    int                    `json:"priority"`
+	DryRun      bool                   `json:"dry_run,omitempty"`
 	RetryCount  int                    `json:"retry_count"`
 	MaxRetries  int                    `json:"max_retries"`
 	CreatedAt   time.Time              `json:"created_at"`
@@ -43,15 +46,17 @@ type Task struct {
 
 // TaskResult represents the result of a task execution
 type TaskResult struct {
-	TaskID      string                 `json:"task_id"`
-	AgentID     string                 `json:"agent_id"`
-	Status      string                 `json:"status"` // "completed", "failed", "timeout"
-	Data        map[string]interface{} `json:"data,omitempty"`
-	Error       string                 `json:"error,omitempty"`
-	StartedAt   time.Time              `json:"started_at"`
-	CompletedAt time.Time              `json:"completed_at"`
-	Duration    time.Duration          `json:"duration"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	TaskID         string                 `json:"task_id"`
+	AgentID        string                 `json:"agent_id"`
+	Status         string                 `json:"status"` // "completed", "failed", "timeout"
+	Data           map[string]interface{} `json:"data,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	StartedAt      time.Time              `json:"started_at"`
+	CompletedAt    time.Time              `json:"completed_at"`
+	Duration       time.Duration          `json:"duration"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	DryRun         bool                   `json:"dry_run,omitempty"`
+	PlannedChanges []types.PlannedChange  `json:"planned_changes,omitempty"`
 }
 
 // AgentConfigUpdate represents configuration updates from the orchestrator
@@ -161,11 +166,12 @@ type PluginStatusReport struct {
 	Name       string                 `json:"name"`
 	Version    string                 `json:"version"`
 	Type       string                 `json:"type"`
-	Status     string                 `json:"status"` // "loaded", "running", "stopped", "error"
+	Status     string                 `json:"status"` // "loaded", "running", "stopped", "error", "restarting", "quarantined"
 	Health     string                 `json:"health"` // "healthy", "degraded", "unhealthy"
 	StartTime  time.Time              `json:"start_time,omitempty"`
 	LastError  string                 `json:"last_error,omitempty"`
 	ErrorCount int                    `json:"error_count"`
+	Restarts   int                    `json:"restarts,omitempty"`
 	Metrics    map[string]interface{} `json:"metrics,omitempty"`
 }
 
@@ -0,0 +1,441 @@
+// Package audit keeps a tamper-evident record of the security-relevant
+// actions of an agent. Records are JSON lines, and each carries the hash of
+// the record before it, so editing, removing or reordering records breaks
+// the chain. Verify checks the chain across the current and rotated files.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// Events recorded directly rather than from the plugin event bus
+const (
+	EventInstructionReceived = "instruction_received"
+	EventInstructionApproved = "instruction_approved"
+	EventConfigLoaded        = "config_loaded"
+	EventConfigChanged       = "config_changed"
+	EventAuthFailed          = "auth_failed"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Actors that cause audited actions
+const (
+	ActorAgent        = "agent"
+	ActorOrchestrator = "orchestrator"
+	ActorLocal        = "local"
+)
+
+// backupTimeFormat names rotated files so that they sort in rotation order
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Record is one audit log entry
+type Record struct {
+	Sequence      uint64                 `json:"seq"`
+	Timestamp     time.Time              `json:"timestamp"`
+	AgentID       string                 `json:"agent_id"`
+	Actor         string                 `json:"actor"`
+	Event         string                 `json:"event"`
+	InstructionID string                 `json:"instruction_id,omitempty"`
+	PluginID      string                 `json:"plugin_id,omitempty"`
+	PluginDigest  string                 `json:"plugin_digest,omitempty"`
+	Severity      string                 `json:"severity,omitempty"`
+	Outcome       string                 `json:"outcome"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	// Hash of the previous record, empty for the first record of the chain
+	PrevHash string `json:"prev_hash"`
+	// SHA-256 of the record's JSON encoding without this field. It must stay
+	// the last field, see encode.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Logger appends hash-chained records to the audit log, rotating it when it
+// exceeds its maximum size
+type Logger struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	agentID    string
+	now        func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	sequence uint64
+	lastHash string
+}
+
+// NewLogger opens the audit log and continues the chain of records already
+// in it, or in its most recent rotated file
+func NewLogger(cfg config.AuditConfig, agentID string) (*Logger, error) {
+	if cfg.LogFile == "" {
+		return nil, fmt.Errorf("audit log file is required")
+	}
+
+	l := &Logger{
+		path:       cfg.LogFile,
+		maxSize:    int64(cfg.MaxSize) * 1024 * 1024,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     time.Duration(cfg.MaxAge) * 24 * time.Hour,
+		compress:   cfg.Compress,
+		agentID:    agentID,
+		now:        time.Now,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	last, err := l.lastRecord()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the end of the audit chain, run audit verify: %w", err)
+	}
+	if last != nil {
+		l.sequence = last.Sequence
+		l.lastHash = last.Hash
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Log appends a record to the chain. The sequence, agent ID and hashes are
+// set by the logger, and the timestamp when it is zero.
+func (l *Logger) Log(record *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	r := *record
+	r.Sequence = l.sequence + 1
+	r.AgentID = l.agentID
+	if r.Timestamp.IsZero() {
+		r.Timestamp = l.now()
+	}
+	r.Timestamp = r.Timestamp.UTC()
+	r.PrevHash = l.lastHash
+	line, hash, err := encode(&r)
+	if err != nil {
+		return err
+	}
+
+	// A failed rotation is reported once the record is written, as long as
+	// there is a file to write it to
+	var rotateErr error
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if rotateErr = l.rotate(); l.file == nil {
+			return rotateErr
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	l.sequence = r.Sequence
+	l.lastHash = hash
+	return rotateErr
+}
+
+// Close closes the audit log
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// encode encodes a record as a JSON line ending in its hash. The hash covers
+// the exact bytes before it, so verifying does not depend on how the record
+// decodes.
+func encode(r *Record) ([]byte, string, error) {
+	r.Hash = ""
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	line := make([]byte, 0, len(body)+len(hash)+12)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+	return line, hash, nil
+}
+
+// decode parses an audit line and checks that it matches its own hash
+func decode(line []byte) (*Record, error) {
+	var r Record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+
+	suffix := []byte(`,"hash":"` + r.Hash + `"}`)
+	if r.Hash == "" || !bytes.HasSuffix(line, suffix) {
+		return nil, fmt.Errorf("record has no trailing hash")
+	}
+	body := append(line[:len(line)-len(suffix):len(line)-len(suffix)], '}')
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != r.Hash {
+		return nil, fmt.Errorf("record hash does not match its content")
+	}
+	return &r, nil
+}
+
+// open opens the current audit log file for appending
+func (l *Logger) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// rotate moves the current file aside, starts a new one and removes the
+// rotated files that are no longer kept
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+
+	backup := l.backupName(l.now())
+	if err := os.Rename(l.path, backup); err != nil {
+		if openErr := l.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	if l.compress {
+		if err := compressFile(backup); err != nil {
+			return fmt.Errorf("failed to compress rotated audit log: %w", err)
+		}
+	}
+	return l.removeExpired()
+}
+
+// backupName returns an unused name for the file rotated at the given time
+func (l *Logger) backupName(rotated time.Time) string {
+	ext := filepath.Ext(l.path)
+	for {
+		name := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(l.path, ext), rotated.UTC().Format(backupTimeFormat), ext)
+		_, err := os.Stat(name)
+		_, gzErr := os.Stat(name + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return name
+		}
+		rotated = rotated.Add(time.Millisecond)
+	}
+}
+
+// removeExpired removes the oldest rotated files beyond the maximum number
+// of backups, and those older than the maximum age
+func (l *Logger) removeExpired() error {
+	backups, err := backupFiles(l.path)
+	if err != nil {
+		return err
+	}
+
+	cutoff := l.now().Add(-l.maxAge)
+	for i, backup := range backups {
+		expired := l.maxBackups > 0 && len(backups)-i > l.maxBackups
+		if l.maxAge > 0 && backup.rotated.Before(cutoff) {
+			expired = true
+		}
+		if !expired {
+			continue
+		}
+		if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove rotated audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// lastRecord returns the last record of the chain: the end of the current
+// file or, if it is empty, of the most recently rotated file
+func (l *Logger) lastRecord() (*Record, error) {
+	files := []string{l.path}
+	backups, err := backupFiles(l.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) > 0 {
+		files = append(files, backups[len(backups)-1].path)
+	}
+
+	for _, file := range files {
+		line, err := lastLine(file)
+		if err != nil {
+			return nil, err
+		}
+		if line != nil {
+			record, err := decode(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// lastLine returns the last line of a possibly compressed file, nil when it
+// is missing or empty
+func lastLine(path string) ([]byte, error) {
+	var last []byte
+	err := scanFile(path, func(line []byte) error {
+		last = append(last[:0], line...)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return last, err
+}
+
+// scanFile calls fn with each non-empty line of a possibly compressed file
+func scanFile(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// backupFile is a rotated audit log file
+type backupFile struct {
+	path    string
+	rotated time.Time
+}
+
+// backupFiles lists the rotated files of an audit log, oldest first
+func backupFiles(path string) ([]backupFile, error) {
+	ext := filepath.Ext(path)
+	prefix := filepath.Base(strings.TrimSuffix(path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list rotated audit logs: %w", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		rotated, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{
+			path:    filepath.Join(filepath.Dir(path), entry.Name()),
+			rotated: rotated,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.Before(backups[j].rotated)
+	})
+	return backups, nil
+}
+
+// compressFile replaces a file with its gzip compressed copy
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+func logRecords(t *testing.T, l *Logger, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, l.Log(&Record{
+			Actor:         ActorOrchestrator,
+			Event:         EventInstructionReceived,
+			InstructionID: "inst-1",
+			PluginID:      "cleanup",
+			Outcome:       OutcomeSuccess,
+			Data:          map[string]interface{}{"attempt": i, "ratio": 0.25},
+		}))
+	}
+}
+
+func readLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return bytes.SplitAfter(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+}
+
+func writeLines(t *testing.T, path string, lines [][]byte) {
+	t.Helper()
+	content := bytes.Join(lines, nil)
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	require.NoError(t, os.WriteFile(path, content, 0600))
+}
+
+func TestLoggerChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	l, err := NewLogger(config.AuditConfig{Enabled: true, LogFile: path}, "agent-1")
+	require.NoError(t, err)
+	logRecords(t, l, 3)
+	require.NoError(t, l.Close())
+	assert.Error(t, l.Log(&Record{Event: EventConfigLoaded}))
+
+	// The chain continues when the log is opened again
+	l, err = NewLogger(config.AuditConfig{Enabled: true, LogFile: path}, "agent-1")
+	require.NoError(t, err)
+	logRecords(t, l, 2)
+	require.NoError(t, l.Close())
+
+	report, err := Verify(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Records)
+	assert.Equal(t, uint64(1), report.First)
+	assert.Equal(t, uint64(5), report.Last)
+	assert.True(t, report.Complete)
+
+	lines := readLines(t, path)
+	first, err := decode(bytes.TrimSpace(lines[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", first.AgentID)
+	assert.Equal(t, "", first.PrevHash)
+	second, err := decode(bytes.TrimSpace(lines[1]))
+	require.NoError(t, err)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, 0.25, second.Data["ratio"])
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewLogger(config.AuditConfig{Enabled: true, LogFile: path}, "agent-1")
+	require.NoError(t, err)
+	logRecords(t, l, 4)
+	require.NoError(t, l.Close())
+	original := readLines(t, path)
+
+	tests := map[string]struct {
+		tamper func(lines [][]byte) [][]byte
+		reason string
+	}{
+		"edited record": {
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte("cleanup"), []byte("backdoor"), 1)
+				return lines
+			},
+			reason: "hash does not match",
+		},
+		"removed record": {
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			reason: "expected record 2, found 3",
+		},
+		"reordered records": {
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			reason: "expected record 2, found 3",
+		},
+		"rewritten chain": {
+			tamper: func(lines [][]byte) [][]byte {
+				// A record re-hashed after editing no longer follows its predecessor
+				r, err := decode(bytes.TrimSpace(lines[2]))
+				require.NoError(t, err)
+				r.Outcome = OutcomeDenied
+				r.PrevHash = strings.Repeat("0", 64)
+				line, _, err := encode(r)
+				require.NoError(t, err)
+				lines[2] = line
+				return lines
+			},
+			reason: "record 3 does not follow record 2",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			lines := make([][]byte, len(original))
+			for i := range original {
+				lines[i] = append([]byte(nil), original[i]...)
+			}
+			writeLines(t, path, tt.tamper(lines))
+
+			_, err := Verify(path)
+			var chainErr *ChainError
+			require.True(t, errors.As(err, &chainErr), "expected a chain error, got %v", err)
+			assert.Contains(t, chainErr.Reason, tt.reason)
+		})
+	}
+
+	// A tampered tail also stops the agent from extending the chain
+	lines := append([][]byte(nil), original...)
+	lines[3] = bytes.Replace(lines[3], []byte("success"), []byte("failure"), 1)
+	writeLines(t, path, lines)
+	_, err = NewLogger(config.AuditConfig{Enabled: true, LogFile: path}, "agent-1")
+	assert.ErrorContains(t, err, "run audit verify")
+}
+
+func TestLoggerRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	l, err := NewLogger(config.AuditConfig{
+		Enabled:    true,
+		LogFile:    path,
+		MaxBackups: 2,
+		MaxAge:     30,
+		Compress:   true,
+	}, "agent-1")
+	require.NoError(t, err)
+
+	clock := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	l.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	// Small enough for every few records to start a new file
+	l.maxSize = 800
+	logRecords(t, l, 20)
+	require.NoError(t, l.Close())
+
+	backups, err := backupFiles(path)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	for _, backup := range backups {
+		assert.True(t, strings.HasSuffix(backup.path, ".gz"), backup.path)
+	}
+
+	// The oldest files were removed, so the chain starts part way through
+	report, err := Verify(path)
+	require.NoError(t, err)
+	assert.Len(t, report.Files, 3)
+	assert.Equal(t, uint64(20), report.Last)
+	assert.False(t, report.Complete)
+	assert.Equal(t, int(report.Last-report.First)+1, report.Records)
+
+	// The chain continues from the last rotated file when the agent stopped
+	// between rotating and writing to the new file
+	clock = clock.Add(time.Second)
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "audit-"+clock.Format(backupTimeFormat)+".log")))
+	l, err = NewLogger(config.AuditConfig{Enabled: true, LogFile: path}, "agent-1")
+	require.NoError(t, err)
+	logRecords(t, l, 1)
+	require.NoError(t, l.Close())
+	report, err = Verify(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21), report.Last)
+
+	// Files older than the maximum age are removed on the next rotation, and
+	// rotations within the same millisecond keep both files
+	l, err = NewLogger(config.AuditConfig{Enabled: true, LogFile: path, MaxAge: 1}, "agent-1")
+	require.NoError(t, err)
+	l.now = func() time.Time { return clock.Add(48 * time.Hour) }
+	l.maxSize = 1
+	logRecords(t, l, 2)
+	require.NoError(t, l.Close())
+	backups, err = backupFiles(path)
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+	report, err = Verify(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(23), report.Last)
+}
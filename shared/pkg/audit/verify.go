@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Report summarizes a verified audit chain
+type Report struct {
+	Files   []string `json:"files"`
+	Records int      `json:"records"`
+	// Sequence numbers of the first and last records
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+	// Whether the chain starts at its first record; otherwise the oldest
+	// rotated files have been removed and the chain starts at First
+	Complete bool `json:"complete"`
+}
+
+// ChainError locates where an audit chain is broken
+type ChainError struct {
+	File   string
+	Line   int
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+}
+
+// Verify checks the hash chain of an audit log across its rotated files and
+// the current file. It returns a *ChainError for the first broken record.
+func Verify(path string) (*Report, error) {
+	backups, err := backupFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil && len(backups) == 0 {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	report := &Report{}
+	for _, backup := range backups {
+		report.Files = append(report.Files, backup.path)
+	}
+	report.Files = append(report.Files, path)
+
+	var last *Record
+	for _, file := range report.Files {
+		line := 0
+		err := scanFile(file, func(content []byte) error {
+			line++
+			record, err := decode(content)
+			if err != nil {
+				return &ChainError{File: file, Line: line, Reason: err.Error()}
+			}
+
+			switch {
+			case last == nil:
+				report.First = record.Sequence
+				report.Complete = record.Sequence == 1 && record.PrevHash == ""
+			case record.Sequence != last.Sequence+1:
+				return &ChainError{File: file, Line: line, Reason: fmt.Sprintf(
+					"expected record %d, found %d", last.Sequence+1, record.Sequence)}
+			case record.PrevHash != last.Hash:
+				return &ChainError{File: file, Line: line, Reason: fmt.Sprintf(
+					"record %d does not follow record %d", record.Sequence, last.Sequence)}
+			}
+
+			last = record
+			report.Records++
+			report.Last = record.Sequence
+			return nil
+		})
+		// Only rotated files are left when the current file was removed
+		if err != nil && !(file == path && errors.Is(err, fs.ErrNotExist)) {
+			return report, err
+		}
+	}
+	return report, nil
+}
@@ -28,11 +28,26 @@ type Config struct {
 	// Metrics configuration
 	Metrics MetricsConfig `mapstructure:"metrics"`
 
+	// Distributed tracing configuration
+	Tracing TracingConfig `mapstructure:"tracing"`
+
 	// Plugin configuration
 	Plugins PluginConfig `mapstructure:"plugins"`
 
 	// Health check configuration
 	Health HealthConfig `mapstructure:"health"`
+
+	// Sensor agent configuration
+	Sensor SensorConfig `mapstructure:"sensor"`
+
+	// Action agent configuration
+	Action ActionConfig `mapstructure:"action"`
+
+	// Routing of execution results and trigger events to outputs
+	Outputs OutputsConfig `mapstructure:"outputs"`
+
+	// File the configuration was loaded from, set by LoadConfig
+	File string `mapstructure:"-"`
 }
 
 // AgentConfig contains agent-specific configuration
@@ -68,6 +83,28 @@ type APIConfig struct {
 	IdleConnTimeout  time.Duration     `mapstructure:"idle_conn_timeout" validate:"min=30s,max=300s"`
 	UserAgent        string            `mapstructure:"user_agent"`
 	Headers          map[string]string `mapstructure:"headers"`
+
+	// Instruction delivery transport: "poll" (fixed interval), "long_poll"
+	// (server holds the request open) or "sse" (persistent event stream).
+	// Push transports fall back to interval polling while disconnected.
+	Transport            string        `mapstructure:"transport" validate:"omitempty,oneof=poll long_poll sse"`
+	LongPollTimeout      time.Duration `mapstructure:"long_poll_timeout" validate:"omitempty,min=1s,max=300s"`
+	StreamReconnectDelay time.Duration `mapstructure:"stream_reconnect_delay" validate:"omitempty,min=1s,max=300s"`
+
+	// Upper bound for the jittered delay between retries
+	MaxRetryDelay  time.Duration        `mapstructure:"max_retry_delay" validate:"omitempty,min=1s,max=600s"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+// CircuitBreakerConfig controls the circuit breaker around orchestrator calls
+type CircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Consecutive failures that open the circuit
+	FailureThreshold int `mapstructure:"failure_threshold" validate:"omitempty,min=1,max=100"`
+	// Consecutive successful probes in half-open state that close the circuit
+	SuccessThreshold int `mapstructure:"success_threshold" validate:"omitempty,min=1,max=10"`
+	// How long the circuit stays open before a probe request is allowed
+	OpenTimeout time.Duration `mapstructure:"open_timeout" validate:"omitempty,min=1s,max=1800s"`
 }
 
 // SecurityConfig contains security-related configuration
@@ -76,6 +113,15 @@ type SecurityConfig struct {
 	Auth    AuthConfig    `mapstructure:"auth"`
 	Sandbox SandboxConfig `mapstructure:"sandbox"`
 	Audit   AuditConfig   `mapstructure:"audit"`
+	Policy  PolicyConfig  `mapstructure:"policy"`
+}
+
+// PolicyConfig points to the local policy deciding which instructions the
+// agent accepts from the orchestrator
+type PolicyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Policy file, relative to the agent config directory
+	File string `mapstructure:"file" validate:"required_if=Enabled true"`
 }
 
 // TLSConfig contains TLS configuration
@@ -87,14 +133,25 @@ type TLSConfig struct {
 	ServerName         string `mapstructure:"server_name"`
 	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
 	MinVersion         string `mapstructure:"min_version" validate:"oneof=1.2 1.3"`
+
+	// How long before the client certificate expires to start warning
+	ExpiryWarning time.Duration `mapstructure:"expiry_warning"`
 }
 
 // AuthConfig contains authentication configuration
 type AuthConfig struct {
-	Method    string        `mapstructure:"method" validate:"required,oneof=api_key"`
+	Method    string        `mapstructure:"method" validate:"required,oneof=api_key jwt mtls client_credentials"`
 	TokenFile string        `mapstructure:"token_file" validate:"omitempty,file_exists"`
 	APIKey    string        `mapstructure:"api_key"`
 	TokenTTL  time.Duration `mapstructure:"token_ttl"`
+
+	// OAuth2 client credentials grant, used by the client_credentials method
+	TokenURL         string   `mapstructure:"token_url" validate:"omitempty,url"`
+	ClientID         string   `mapstructure:"client_id"`
+	ClientSecret     string   `mapstructure:"client_secret"`
+	ClientSecretFile string   `mapstructure:"client_secret_file" validate:"omitempty,file_exists"`
+	Scopes           []string `mapstructure:"scopes"`
+	Audience         string   `mapstructure:"audience"`
 }
 
 // SandboxConfig contains sandbox configuration
@@ -136,6 +193,18 @@ type MetricsConfig struct {
 	Port      int    `mapstructure:"port" validate:"port_range"`
 	Path      string `mapstructure:"path"`
 	Namespace string `mapstructure:"namespace"`
+	Bind      string `mapstructure:"bind"` // All interfaces when empty
+}
+
+// TracingConfig contains distributed tracing configuration
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Exporter    string            `mapstructure:"exporter" validate:"omitempty,oneof=otlp file stdout"`
+	Endpoint    string            `mapstructure:"endpoint"` // OTLP/HTTP traces URL
+	Headers     map[string]string `mapstructure:"headers"`  // Sent with every OTLP export
+	File        string            `mapstructure:"file"`     // JSON spans written by the file exporter
+	SampleRatio float64           `mapstructure:"sample_ratio" validate:"min=0,max=1"`
+	Timeout     time.Duration     `mapstructure:"timeout"`
 }
 
 // PluginConfig contains plugin configuration
@@ -162,10 +231,188 @@ type HealthConfig struct {
 	Enabled  bool          `mapstructure:"enabled"`
 	Port     int           `mapstructure:"port" validate:"port_range"`
 	Path     string        `mapstructure:"path"`
+	Bind     string        `mapstructure:"bind"` // All interfaces when empty
 	Interval time.Duration `mapstructure:"interval" validate:"min=10s,max=300s"`
 	Timeout  time.Duration `mapstructure:"timeout" validate:"min=1s,max=60s"`
 }
 
+// SensorConfig contains sensor agent specific configuration
+type SensorConfig struct {
+	Queue      EventQueueConfig        `mapstructure:"queue"`
+	Rules      EventRulesConfig        `mapstructure:"rules"`
+	Processing EventProcessingConfig   `mapstructure:"processing"`
+	Reporting  TriggerReportingConfig  `mapstructure:"reporting"`
+	Supervisor TriggerSupervisorConfig `mapstructure:"supervisor"`
+	// Built-in trigger plugins registered at startup
+	Triggers []BuiltinTriggerConfig `mapstructure:"triggers" validate:"dive"`
+}
+
+// BuiltinTriggerConfig configures an instance of a built-in trigger plugin
+type BuiltinTriggerConfig struct {
+	// Plugin ID, defaults to "builtin-<type>"; needed to run several
+	// instances of the same type
+	ID   string `mapstructure:"id"`
+	Type string `mapstructure:"type" validate:"required,oneof=cpu memory disk load file_watch log_tail tcp_probe http_probe systemd"`
+	// Settings for the trigger, checked against its configuration schema
+	Config map[string]interface{} `mapstructure:"config"`
+}
+
+// ActionConfig contains action agent specific configuration
+type ActionConfig struct {
+	// Built-in action plugins registered at startup
+	Actions []BuiltinActionConfig `mapstructure:"actions" validate:"dive"`
+
+	// Plugins and environments whose actions only ever run as a dry run
+	DryRun DryRunPolicyConfig `mapstructure:"dry_run"`
+
+	// Approval required before high-risk instructions execute
+	Approval ApprovalConfig `mapstructure:"approval"`
+}
+
+// ApprovalConfig holds high-risk instructions until the orchestrator sends
+// an approval token signed with its Ed25519 key
+type ApprovalConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Base64 encoded Ed25519 public key of the orchestrator, or a file
+	// holding it in base64 or PEM
+	PublicKey     string `mapstructure:"public_key"`
+	PublicKeyFile string `mapstructure:"public_key_file"`
+	// How long an instruction waits for approval before it expires
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=0"`
+	// Plugin IDs, or globs, that always need approval, besides plugins
+	// whose manifest declares risk: high
+	Plugins []string `mapstructure:"plugins"`
+	// Instruction priorities that need approval
+	Priorities []string `mapstructure:"priorities" validate:"dive,oneof=low normal high urgent"`
+}
+
+// DryRunPolicyConfig forces dry runs regardless of what a request asks for
+type DryRunPolicyConfig struct {
+	// Force dry runs for every plugin
+	All bool `mapstructure:"all"`
+	// Plugin IDs, or globs such as "builtin-*"
+	Plugins []string `mapstructure:"plugins"`
+	// Agent environments, such as prod
+	Environments []string `mapstructure:"environments" validate:"dive,oneof=dev staging prod"`
+}
+
+// BuiltinActionConfig configures an instance of a built-in action plugin
+type BuiltinActionConfig struct {
+	// Plugin ID, defaults to "builtin-<type>"; needed to run several
+	// instances of the same type
+	ID   string `mapstructure:"id"`
+	Type string `mapstructure:"type" validate:"required,oneof=command service file http_request cleanup"`
+	// Settings for the action, checked against its configuration schema
+	Config map[string]interface{} `mapstructure:"config"`
+}
+
+// EventRulesConfig controls the local rules used to filter and enrich
+// trigger events before they are queued
+type EventRulesConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Rules file, relative paths are resolved against the config directory
+	File string `mapstructure:"file"`
+	// Evaluate rules and log what they would have done without applying them
+	DryRun bool `mapstructure:"dry_run"`
+	// How often the rules file is checked for changes, 0 disables hot reload
+	ReloadInterval time.Duration `mapstructure:"reload_interval" validate:"omitempty,min=1s,max=3600s"`
+}
+
+// EventQueueConfig controls the queue between trigger plugins and event
+// processing. Events are dequeued by severity, highest first.
+type EventQueueConfig struct {
+	Capacity int `mapstructure:"capacity" validate:"omitempty,min=1"`
+	// What happens when the queue is full: "drop_oldest_low" drops the oldest
+	// event of the lowest severity, "spill" moves events to disk and "block"
+	// makes the producing plugin wait
+	OverflowPolicy string `mapstructure:"overflow_policy" validate:"omitempty,oneof=drop_oldest_low spill block"`
+	// Directory for spilled events, defaults to the agent state directory
+	SpillDir string `mapstructure:"spill_dir"`
+	// Maximum number of events kept on disk; beyond this events are dropped
+	SpillMaxEvents int `mapstructure:"spill_max_events" validate:"omitempty,min=1"`
+}
+
+// EventProcessingConfig controls deduplication, throttling and aggregation
+// of trigger events before they are reported
+type EventProcessingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Repeats of a firing event are folded into it for this long before an
+	// aggregated update is emitted
+	DedupeWindow time.Duration `mapstructure:"dedupe_window" validate:"omitempty,min=1s,max=86400s"`
+	// A firing event is resolved when it has not repeated for this long
+	ResolveAfter time.Duration `mapstructure:"resolve_after" validate:"omitempty,min=1s,max=86400s"`
+	// Event data fields included in the fingerprint alongside type and source
+	FingerprintFields []string `mapstructure:"fingerprint_fields"`
+	// Maximum events per minute reported for each plugin, 0 for no limit
+	PluginRateLimit int `mapstructure:"plugin_rate_limit" validate:"omitempty,min=1"`
+	// Maximum number of distinct fingerprints tracked at once
+	MaxTracked int `mapstructure:"max_tracked" validate:"omitempty,min=1"`
+}
+
+// TriggerReportingConfig controls how trigger events are batched and
+// delivered to the orchestrator
+type TriggerReportingConfig struct {
+	// A batch is sent when it reaches BatchSize events or FlushInterval elapses
+	BatchSize     int           `mapstructure:"batch_size" validate:"omitempty,min=1,max=1000"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" validate:"omitempty,min=100ms,max=300s"`
+	// Events waiting for delivery; the oldest are dropped beyond this
+	MaxPending int `mapstructure:"max_pending" validate:"omitempty,min=1"`
+	// Delivery attempts per event before it is dropped
+	MaxAttempts int `mapstructure:"max_attempts" validate:"omitempty,min=1,max=100"`
+}
+
+// TriggerSupervisorConfig controls how trigger plugins that crash or stop
+// are restarted
+type TriggerSupervisorConfig struct {
+	// "always" restarts plugins whenever they stop, "on-failure" only when
+	// they fail and "never" leaves them stopped
+	RestartPolicy string `mapstructure:"restart_policy" validate:"omitempty,oneof=always on-failure never"`
+	// Restart policies for individual plugins by plugin ID
+	Policies map[string]string `mapstructure:"policies" validate:"omitempty,dive,oneof=always on-failure never"`
+	// Delay before the first restart, doubled for each further restart
+	// within the restart window
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"omitempty,min=10ms,max=3600s"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" validate:"omitempty,min=10ms,max=86400s"`
+	// A plugin restarted MaxRestarts times within RestartWindow is
+	// quarantined instead of being restarted again
+	MaxRestarts   int           `mapstructure:"max_restarts" validate:"omitempty,min=1,max=1000"`
+	RestartWindow time.Duration `mapstructure:"restart_window" validate:"omitempty,min=1s,max=86400s"`
+	// How long a quarantined plugin stays stopped, 0 until it is reloaded
+	QuarantineDuration time.Duration `mapstructure:"quarantine_duration" validate:"omitempty,min=1s,max=604800s"`
+}
+
+// OutputsConfig controls where execution results and trigger events are
+// sent in addition to the orchestrator
+type OutputsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Items waiting for each destination; new items are dropped beyond this
+	QueueSize    int                       `mapstructure:"queue_size" validate:"omitempty,min=1"`
+	Destinations []OutputDestinationConfig `mapstructure:"destinations" validate:"dive"`
+}
+
+// OutputDestinationConfig configures one output destination
+type OutputDestinationConfig struct {
+	Name string `mapstructure:"name" validate:"required"`
+	// Built-in output ("file", "webhook", "syslog") or "plugin" for an
+	// installed output plugin
+	Type string `mapstructure:"type" validate:"required,oneof=file webhook syslog plugin"`
+	// Output plugin ID when Type is "plugin"
+	Plugin string `mapstructure:"plugin" validate:"required_if=Type plugin"`
+	// What is sent here: "execution_results", "trigger_events"; both when empty
+	Sources []string `mapstructure:"sources" validate:"dive,oneof=execution_results trigger_events"`
+	// Format the output is converted to; must be one the output supports,
+	// defaults to the first one it lists
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json text yaml"`
+	// Delivery attempts per item and the delay before the first retry,
+	// doubled on each further attempt
+	MaxAttempts int           `mapstructure:"max_attempts" validate:"omitempty,min=1,max=100"`
+	RetryDelay  time.Duration `mapstructure:"retry_delay" validate:"omitempty,min=10ms,max=600s"`
+	// Time allowed for a single delivery attempt
+	Timeout time.Duration `mapstructure:"timeout" validate:"omitempty,min=100ms,max=300s"`
+	// Settings for the output, checked against its configuration schema
+	Config map[string]interface{} `mapstructure:"config"`
+}
+
 var validate *validator.Validate
 
 func init() {
@@ -189,6 +436,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.File = viper.ConfigFileUsed()
 
 	// Expand base folder paths
 	if err := cfg.expandBaseFolderPaths(); err != nil {
@@ -229,11 +477,33 @@ func (c *Config) expandBaseFolderPaths() error {
 		c.Security.Audit.LogFile = filepath.Join(c.Agent.BaseFolder, "logs", "audit", c.Security.Audit.LogFile)
 	}
 
+	// Expand policy file path
+	if c.Security.Policy.File != "" && !filepath.IsAbs(c.Security.Policy.File) {
+		c.Security.Policy.File = filepath.Join(c.Agent.BaseFolder, "config", c.Security.Policy.File)
+	}
+
+	// Expand TLS certificate paths
+	for _, path := range []*string{&c.Security.TLS.CertFile, &c.Security.TLS.KeyFile, &c.Security.TLS.CAFile} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(c.Agent.BaseFolder, "config", "certificates", *path)
+		}
+	}
+
+	// Expand event rules file path
+	if c.Sensor.Rules.File != "" && !filepath.IsAbs(c.Sensor.Rules.File) {
+		c.Sensor.Rules.File = filepath.Join(c.Agent.BaseFolder, "config", c.Sensor.Rules.File)
+	}
+
 	// Expand auth token file path
 	if c.Security.Auth.TokenFile != "" && !filepath.IsAbs(c.Security.Auth.TokenFile) {
 		c.Security.Auth.TokenFile = filepath.Join(c.Agent.BaseFolder, "config", "certificates", c.Security.Auth.TokenFile)
 	}
 
+	// Expand client secret file path
+	if c.Security.Auth.ClientSecretFile != "" && !filepath.IsAbs(c.Security.Auth.ClientSecretFile) {
+		c.Security.Auth.ClientSecretFile = filepath.Join(c.Agent.BaseFolder, "config", "certificates", c.Security.Auth.ClientSecretFile)
+	}
+
 	return nil
 }
 
@@ -286,10 +556,19 @@ func setDefaults() {
 	viper.SetDefault("api.max_idle_conns", 10)
 	viper.SetDefault("api.idle_conn_timeout", "90s")
 	viper.SetDefault("api.user_agent", "Stavily-Agent/1.0.0")
+	viper.SetDefault("api.transport", "poll")
+	viper.SetDefault("api.long_poll_timeout", "30s")
+	viper.SetDefault("api.stream_reconnect_delay", "5s")
+	viper.SetDefault("api.max_retry_delay", "60s")
+	viper.SetDefault("api.circuit_breaker.enabled", true)
+	viper.SetDefault("api.circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("api.circuit_breaker.success_threshold", 1)
+	viper.SetDefault("api.circuit_breaker.open_timeout", "30s")
 
 	// Security defaults
 	viper.SetDefault("security.tls.enabled", false)
 	viper.SetDefault("security.tls.min_version", "1.3")
+	viper.SetDefault("security.tls.expiry_warning", "720h")
 	viper.SetDefault("security.auth.method", "api_key")
 	viper.SetDefault("security.auth.token_ttl", "1h")
 	viper.SetDefault("security.sandbox.enabled", true)
@@ -303,6 +582,8 @@ func setDefaults() {
 	viper.SetDefault("security.audit.max_backups", 10)
 	viper.SetDefault("security.audit.max_age", 30)
 	viper.SetDefault("security.audit.compress", true)
+	viper.SetDefault("security.policy.enabled", false)
+	viper.SetDefault("security.policy.file", "policy.yaml")
 
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
@@ -319,6 +600,13 @@ func setDefaults() {
 	viper.SetDefault("metrics.path", "/metrics")
 	viper.SetDefault("metrics.namespace", "stavily")
 
+	// Tracing defaults
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.exporter", "otlp")
+	viper.SetDefault("tracing.endpoint", "http://localhost:4318/v1/traces")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.timeout", "10s")
+
 	// Plugin defaults
 	viper.SetDefault("plugins.directory", "data/plugins")
 	viper.SetDefault("plugins.auto_load", true)
@@ -334,6 +622,36 @@ func setDefaults() {
 	viper.SetDefault("health.path", "/health")
 	viper.SetDefault("health.interval", "30s")
 	viper.SetDefault("health.timeout", "10s")
+
+	// Sensor defaults
+	viper.SetDefault("sensor.queue.capacity", 1000)
+	viper.SetDefault("sensor.queue.overflow_policy", "drop_oldest_low")
+	viper.SetDefault("sensor.queue.spill_max_events", 100000)
+	viper.SetDefault("sensor.rules.enabled", false)
+	viper.SetDefault("sensor.rules.file", "rules.yaml")
+	viper.SetDefault("sensor.rules.reload_interval", "30s")
+	viper.SetDefault("sensor.processing.enabled", true)
+	viper.SetDefault("sensor.processing.dedupe_window", "5m")
+	viper.SetDefault("sensor.processing.resolve_after", "2m")
+	viper.SetDefault("sensor.processing.max_tracked", 10000)
+	viper.SetDefault("sensor.reporting.batch_size", 50)
+	viper.SetDefault("sensor.reporting.flush_interval", "5s")
+	viper.SetDefault("sensor.reporting.max_pending", 10000)
+	viper.SetDefault("sensor.reporting.max_attempts", 5)
+	viper.SetDefault("sensor.supervisor.restart_policy", "on-failure")
+	viper.SetDefault("sensor.supervisor.initial_backoff", "1s")
+	viper.SetDefault("sensor.supervisor.max_backoff", "5m")
+	viper.SetDefault("sensor.supervisor.max_restarts", 5)
+	viper.SetDefault("sensor.supervisor.restart_window", "10m")
+
+	// Action defaults
+	viper.SetDefault("action.approval.enabled", false)
+	viper.SetDefault("action.approval.timeout", "1h")
+	viper.SetDefault("action.approval.priorities", []string{"high", "urgent"})
+
+	// Output defaults
+	viper.SetDefault("outputs.enabled", false)
+	viper.SetDefault("outputs.queue_size", 1000)
 }
 
 // Validate validates the configuration
@@ -366,8 +684,12 @@ func (c *Config) GetLogDir() string {
 	return filepath.Join(c.Agent.BaseFolder, "logs")
 }
 
-// GetPluginDir returns the plugin directory path
+// GetPluginDir returns the plugin directory path, where plugins are
+// installed, watched and loaded from
 func (c *Config) GetPluginDir() string {
+	if c.Plugins.Directory == "" {
+		return filepath.Join(c.Agent.BaseFolder, "data", "plugins")
+	}
 	return c.Plugins.Directory
 }
 
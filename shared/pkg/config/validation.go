@@ -189,6 +189,20 @@ func ValidateConfigPaths(config *Config) error {
 		}
 	}
 
+	// Validate client secret file
+	if config.Security.Auth.ClientSecretFile != "" {
+		if err := validateFilePath(config.Security.Auth.ClientSecretFile, "client secret"); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	// Validate event rules file
+	if config.Sensor.Rules.Enabled && config.Sensor.Rules.File != "" {
+		if err := validateFilePath(config.Sensor.Rules.File, "event rules"); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
 
 
 	// Validate plugin directory
@@ -301,6 +315,26 @@ func ValidateAgentConfig(config *Config) error {
 		errors = append(errors, fmt.Sprintf("invalid agent type: %s", config.Agent.Type))
 	}
 
+	// Mutual TLS authenticates with the client certificate
+	if config.Security.Auth.Method == "mtls" {
+		if !config.Security.TLS.Enabled {
+			errors = append(errors, "TLS must be enabled for mtls authentication")
+		}
+		if config.Security.TLS.CertFile == "" || config.Security.TLS.KeyFile == "" {
+			errors = append(errors, "mtls authentication requires tls cert_file and key_file")
+		}
+	}
+
+	// Client credentials need a token endpoint and client identity
+	if config.Security.Auth.Method == "client_credentials" {
+		if config.Security.Auth.TokenURL == "" || config.Security.Auth.ClientID == "" {
+			errors = append(errors, "client_credentials authentication requires token_url and client_id")
+		}
+		if config.Security.Auth.ClientSecret == "" && config.Security.Auth.ClientSecretFile == "" {
+			errors = append(errors, "client_credentials authentication requires client_secret or client_secret_file")
+		}
+	}
+
 	// Validate tenant and agent ID combination
 	if config.Agent.TenantID == config.Agent.ID {
 		errors = append(errors, "agent ID cannot be the same as tenant ID")
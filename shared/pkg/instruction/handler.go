@@ -3,11 +3,15 @@ package instruction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/policy"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -17,8 +21,23 @@ type Handler struct {
 	factory    *plugin.Factory
 	downloader *plugin.PluginDownloader
 	executor   *plugin.PluginExecutor
+
+	// Local policy instructions must satisfy, nil when every valid
+	// instruction is accepted; every decision is passed to onDecision
+	policy     *policy.Engine
+	onDecision DecisionHandler
+
+	// Looks up action plugins registered without being installed, such as
+	// built-in actions, nil when there are none
+	actions ActionLookup
 }
 
+// DecisionHandler receives every policy decision, for auditing
+type DecisionHandler func(inst *types.Instruction, decision *policy.Decision)
+
+// ActionLookup returns the registered action plugin with an ID, if any
+type ActionLookup func(pluginID string) (plugin.ActionPlugin, bool)
+
 // HandlerConfig contains configuration for the instruction handler
 type HandlerConfig struct {
 	PluginBaseDir string
@@ -63,16 +82,29 @@ func (h *Handler) ProcessPollResponse(ctx context.Context, response *types.PollR
 
 	startTime := time.Now()
 	
+	var handle func(context.Context, *types.Instruction, time.Time) (*types.InstructionResult, error)
+	var spanName string
 	switch instruction.Type {
 	case types.InstructionTypePluginInstall:
-		return h.handlePluginInstall(ctx, instruction, startTime)
+		handle, spanName = h.handlePluginInstall, "plugin.install"
 	case types.InstructionTypePluginUpdate:
-		return h.handlePluginUpdate(ctx, instruction, startTime)
+		handle, spanName = h.handlePluginUpdate, "plugin.update"
 	case types.InstructionTypeExecute:
-		return h.handlePluginExecute(ctx, instruction, startTime)
+		handle, spanName = h.handlePluginExecute, "plugin.execute"
 	default:
 		return h.createErrorResult(instruction, startTime, fmt.Sprintf("unsupported instruction type: %s", instruction.Type))
 	}
+
+	ctx, span := tracing.Start(ctx, spanName, attribute.String("plugin.id", instruction.PluginID))
+	defer span.End()
+
+	result, err := handle(ctx, instruction, startTime)
+	if err != nil {
+		tracing.RecordError(span, err)
+	} else if result != nil && !result.Success {
+		tracing.RecordError(span, errors.New(result.Error))
+	}
+	return result, err
 }
 
 // handlePluginInstall handles plugin installation instructions
@@ -81,6 +113,10 @@ func (h *Handler) handlePluginInstall(ctx context.Context, inst *types.Instructi
 		zap.String("instruction_id", inst.ID),
 		zap.String("plugin_id", inst.PluginID))
 
+	if inst.DryRun {
+		return h.planInstall(inst, startTime)
+	}
+
 	// Check if plugin is already installed
 	if h.downloader.IsPluginInstalled(inst.PluginID) {
 		h.logger.Warn("Plugin already installed, skipping",
@@ -149,6 +185,10 @@ func (h *Handler) handlePluginUpdate(ctx context.Context, inst *types.Instructio
 		zap.String("instruction_id", inst.ID),
 		zap.String("plugin_id", inst.PluginID))
 
+	if inst.DryRun {
+		return h.planInstall(inst, startTime)
+	}
+
 	var processingLogs []string
 	processingLogs = append(processingLogs, "Starting plugin update")
 
@@ -220,7 +260,8 @@ func (h *Handler) handlePluginExecute(ctx context.Context, inst *types.Instructi
 		zap.String("plugin_id", inst.PluginID))
 
 	// Check if plugin is installed
-	if !h.downloader.IsPluginInstalled(inst.PluginID) {
+	action, registered := h.registeredAction(inst.PluginID)
+	if !registered && !h.downloader.IsPluginInstalled(inst.PluginID) {
 		err := fmt.Errorf("plugin not installed: %s", inst.PluginID)
 		h.logger.Error("Cannot execute plugin - not installed",
 			zap.String("instruction_id", inst.ID),
@@ -229,8 +270,14 @@ func (h *Handler) handlePluginExecute(ctx context.Context, inst *types.Instructi
 		return h.createErrorResult(inst, startTime, err.Error())
 	}
 
-	// Execute the plugin
-	execResult, err := h.executor.ExecutePlugin(ctx, inst)
+	// Execute the plugin; registered action plugins run in process
+	var execResult *types.ExecutionResult
+	var err error
+	if registered {
+		execResult, err = h.executeAction(ctx, inst, action)
+	} else {
+		execResult, err = h.executor.ExecutePlugin(ctx, inst)
+	}
 	if err != nil {
 		h.logger.Error("Plugin execution failed",
 			zap.String("instruction_id", inst.ID),
@@ -273,6 +320,94 @@ func (h *Handler) handlePluginExecute(ctx context.Context, inst *types.Instructi
 	return result, nil
 }
 
+// registeredAction returns the action plugin registered under an ID, unless
+// a plugin with that ID is installed, which takes precedence
+func (h *Handler) registeredAction(pluginID string) (plugin.ActionPlugin, bool) {
+	if h.actions == nil || h.downloader.IsPluginInstalled(pluginID) {
+		return nil, false
+	}
+	return h.actions(pluginID)
+}
+
+// executeAction runs an execute instruction on a registered action plugin,
+// its input data being the action parameters
+func (h *Handler) executeAction(ctx context.Context, inst *types.Instruction, action plugin.ActionPlugin) (*types.ExecutionResult, error) {
+	result, err := action.ExecuteAction(ctx, &plugin.ActionRequest{
+		ID:          inst.ID,
+		Type:        inst.PluginID,
+		Parameters:  inst.InputData,
+		Context:     inst.Context,
+		Timeout:     time.Duration(inst.TimeoutSeconds) * time.Second,
+		DryRun:      inst.DryRun,
+		Metadata:    inst.Metadata,
+		RequestedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun, _ := result.Data["dry_run"].(bool)
+	return &types.ExecutionResult{
+		PluginID:       inst.PluginID,
+		Success:        result.Status == plugin.ActionStatusCompleted,
+		Error:          result.Error,
+		OutputData:     result.Data,
+		Duration:       result.Duration.Seconds(),
+		Timestamp:      result.CompletedAt,
+		DryRun:         inst.DryRun || dryRun,
+		PlannedChanges: result.PlannedChanges,
+	}, nil
+}
+
+// planInstall answers a dry-run install or update instruction with the
+// changes it would make. Nothing is cloned, replaced or removed.
+func (h *Handler) planInstall(inst *types.Instruction, startTime time.Time) (*types.InstructionResult, error) {
+	installedPath := h.downloader.GetInstalledPluginPath(inst.PluginID)
+	installed := h.downloader.IsPluginInstalled(inst.PluginID)
+
+	var changes []types.PlannedChange
+	if installed && inst.Type == types.InstructionTypePluginInstall {
+		changes = append(changes, types.PlannedChange{Action: "skip", Target: installedPath, Detail: "plugin already installed"})
+	} else {
+		if installed {
+			changes = append(changes, types.PlannedChange{Action: "remove", Target: installedPath, Detail: "existing installation"})
+		}
+		planned, err := h.downloader.PlanDownload(inst)
+		if err != nil {
+			return h.createErrorResult(inst, startTime, fmt.Sprintf("failed to plan plugin installation: %v", err))
+		}
+		changes = append(changes, planned...)
+	}
+
+	processingLogs := []string{"Dry run, no changes made"}
+	for _, change := range changes {
+		processingLogs = append(processingLogs, "Would "+change.String())
+	}
+
+	h.logger.Info("Planned plugin installation",
+		zap.String("instruction_id", inst.ID),
+		zap.String("plugin_id", inst.PluginID),
+		zap.Int("planned_changes", len(changes)))
+
+	return &types.InstructionResult{
+		InstructionID: inst.ID,
+		Type:          inst.Type,
+		Success:       true,
+		InstallResult: &types.InstallationResult{
+			PluginID:       inst.PluginID,
+			Success:        true,
+			InstalledPath:  installedPath,
+			Timestamp:      time.Now(),
+			DryRun:         true,
+			PlannedChanges: changes,
+		},
+		ProcessingLogs: processingLogs,
+		StartTime:      startTime,
+		EndTime:        time.Now(),
+		Duration:       time.Since(startTime).Seconds(),
+	}, nil
+}
+
 // createErrorResult creates an error result for failed instructions
 func (h *Handler) createErrorResult(inst *types.Instruction, startTime time.Time, errorMsg string) (*types.InstructionResult, error) {
 	return &types.InstructionResult{
@@ -284,7 +419,20 @@ func (h *Handler) createErrorResult(inst *types.Instruction, startTime time.Time
 		StartTime:      startTime,
 		EndTime:        time.Now(),
 		Duration:       time.Since(startTime).Seconds(),
-	}, fmt.Errorf(errorMsg)
+	}, errors.New(errorMsg)
+}
+
+// SetActionLookup makes execute instructions for registered action plugins,
+// such as built-in actions, run on them, validated and authorized like
+// instructions for installed plugins
+func (h *Handler) SetActionLookup(lookup ActionLookup) {
+	h.actions = lookup
+}
+
+// SetPolicy makes ValidateInstruction reject instructions the policy denies
+func (h *Handler) SetPolicy(engine *policy.Engine, onDecision DecisionHandler) {
+	h.policy = engine
+	h.onDecision = onDecision
 }
 
 // ValidateInstruction validates an instruction based on its type
@@ -305,16 +453,47 @@ func (h *Handler) ValidateInstruction(inst *types.Instruction) error {
 	}
 
 	// Validate based on instruction type
+	var err error
 	switch inst.Type {
 	case types.InstructionTypePluginInstall:
-		return h.validatePluginInstallInstruction(inst)
+		err = h.validatePluginInstallInstruction(inst)
 	case types.InstructionTypePluginUpdate:
-		return h.validatePluginUpdateInstruction(inst)
+		err = h.validatePluginUpdateInstruction(inst)
 	case types.InstructionTypeExecute:
-		return h.validatePluginExecuteInstruction(inst)
+		err = h.validatePluginExecuteInstruction(inst)
 	default:
 		return fmt.Errorf("unsupported instruction type: %s", inst.Type)
 	}
+	if err != nil {
+		return err
+	}
+
+	return h.authorize(inst)
+}
+
+// authorize checks a valid instruction against the local policy
+func (h *Handler) authorize(inst *types.Instruction) error {
+	if h.policy == nil {
+		return nil
+	}
+
+	decision := h.policy.Evaluate(inst)
+	if h.onDecision != nil {
+		h.onDecision(inst, decision)
+	}
+	if !decision.Allowed {
+		h.logger.Warn("Instruction denied by policy",
+			zap.String("instruction_id", inst.ID),
+			zap.String("plugin_id", inst.PluginID),
+			zap.String("rule", decision.Rule),
+			zap.String("reason", decision.Reason))
+		return fmt.Errorf("instruction denied by policy: %s", decision.Reason)
+	}
+
+	h.logger.Debug("Instruction allowed by policy",
+		zap.String("instruction_id", inst.ID),
+		zap.String("rule", decision.Rule))
+	return nil
 }
 
 // validatePluginInstallInstruction validates a plugin install instruction
@@ -344,6 +523,11 @@ func (h *Handler) validatePluginUpdateInstruction(inst *types.Instruction) error
 
 // validatePluginExecuteInstruction validates a plugin execute instruction
 func (h *Handler) validatePluginExecuteInstruction(inst *types.Instruction) error {
+	// Registered action plugins have no entrypoint
+	if _, ok := h.registeredAction(inst.PluginID); ok {
+		return nil
+	}
+
 	// Check for entrypoint in configuration
 	if entrypoint, ok := inst.PluginConfiguration["entrypoint"].(string); !ok || entrypoint == "" {
 		return fmt.Errorf("entrypoint is required for plugin execution")
@@ -0,0 +1,321 @@
+// Package metrics provides typed counters, gauges and histograms, and
+// exposes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metric types, as written in the TYPE line of the exposition format
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets are histogram buckets, in seconds, suited to request
+// latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	namePattern  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Registry holds the metrics an agent exposes. Metric names are prefixed
+// with the registry's namespace.
+type Registry struct {
+	namespace string
+
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry. An empty namespace leaves metric
+// names unprefixed.
+func NewRegistry(namespace string) *Registry {
+	return &Registry{
+		namespace: namespace,
+		families:  make(map[string]*family),
+	}
+}
+
+// family is a metric name with its help text, type and one child per
+// combination of label values
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+
+	mu       sync.RWMutex
+	children map[string]*child
+}
+
+// child is a single series of a family
+type child struct {
+	values []string
+
+	// Counters and gauges
+	value atomicFloat
+
+	// Histograms, with one count per bucket and a final count for +Inf
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+}
+
+// register adds a family to the registry. Registering the same name twice
+// returns the existing family when the definitions match, and panics
+// otherwise, since metrics are defined by the agent's own code.
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *family {
+	if r.namespace != "" {
+		name = r.namespace + "_" + name
+	}
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !labelPattern.MatchString(label) || strings.HasPrefix(label, "__") || (typ == TypeHistogram && label == "le") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", label, name))
+		}
+	}
+	if typ == TypeHistogram {
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		if !sort.Float64sAreSorted(buckets) {
+			panic(fmt.Sprintf("metrics: buckets for %s are not sorted", name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name]; ok {
+		if existing.typ != typ || strings.Join(existing.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: %s is already registered with a different definition", name))
+		}
+		return existing
+	}
+
+	f := &family{
+		name:     name,
+		help:     help,
+		typ:      typ,
+		labels:   append([]string(nil), labels...),
+		buckets:  append([]float64(nil), buckets...),
+		children: make(map[string]*child),
+	}
+	r.families[name] = f
+	return f
+}
+
+// with returns the child for a combination of label values, creating it on
+// first use
+func (f *family) with(values []string) *child {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	c, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.children[key]; ok {
+		return c
+	}
+	c = &child{values: append([]string(nil), values...)}
+	if f.typ == TypeHistogram {
+		c.counts = make([]uint64, len(f.buckets)+1)
+	}
+	f.children[key] = c
+	return c
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	c *child
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.c.value.add(1)
+}
+
+// Add adds a non-negative value to the counter. Negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.c.value.add(v)
+}
+
+// Value returns the counter's current value
+func (c *Counter) Value() float64 {
+	return c.c.value.load()
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	f *family
+}
+
+// NewCounterVec registers a counter with the given labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{f: r.register(name, help, TypeCounter, nil, labels)}
+}
+
+// NewCounter registers a counter without labels
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// With returns the counter for the given label values, in label order
+func (v *CounterVec) With(values ...string) *Counter {
+	return &Counter{c: v.f.with(values)}
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	c *child
+}
+
+// Set sets the gauge to a value
+func (g *Gauge) Set(v float64) {
+	g.c.value.store(v)
+}
+
+// Add adds a value, which may be negative, to the gauge
+func (g *Gauge) Add(v float64) {
+	g.c.value.add(v)
+}
+
+// Inc adds one to the gauge
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec subtracts one from the gauge
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the gauge's current value
+func (g *Gauge) Value() float64 {
+	return g.c.value.load()
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	f *family
+}
+
+// NewGaugeVec registers a gauge with the given labels
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{f: r.register(name, help, TypeGauge, nil, labels)}
+}
+
+// NewGauge registers a gauge without labels
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+// With returns the gauge for the given label values, in label order
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return &Gauge{c: v.f.with(values)}
+}
+
+// Histogram counts observations in configurable buckets
+type Histogram struct {
+	c       *child
+	buckets []float64
+}
+
+// Observe records a single observation
+func (h *Histogram) Observe(v float64) {
+	// Buckets are cumulative when written; here each observation is counted
+	// in the first bucket it fits
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.c.mu.Lock()
+	h.c.counts[i]++
+	h.c.sum += v
+	h.c.mu.Unlock()
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.c.mu.Lock()
+	defer h.c.mu.Unlock()
+
+	var count uint64
+	for _, n := range h.c.counts {
+		count += n
+	}
+	return count
+}
+
+// Sum returns the sum of all observations
+func (h *Histogram) Sum() float64 {
+	h.c.mu.Lock()
+	defer h.c.mu.Unlock()
+	return h.c.sum
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	f *family
+}
+
+// NewHistogramVec registers a histogram with the given buckets and labels.
+// Nil buckets use DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{f: r.register(name, help, TypeHistogram, buckets, labels)}
+}
+
+// NewHistogram registers a histogram without labels
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+// With returns the histogram for the given label values, in label order
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return &Histogram{c: v.f.with(values), buckets: v.f.buckets}
+}
+
+// atomicFloat is a float64 updated without locks
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+func (f *atomicFloat) store(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&f.bits, old, updated) {
+			return
+		}
+	}
+}
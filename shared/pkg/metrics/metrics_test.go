@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry("stavily")
+	instructions := r.NewCounterVec("instructions_total", "Instructions processed.", "type", "status")
+	instructions.With("execute", "completed").Inc()
+	instructions.With("execute", "completed").Add(2)
+	instructions.With("execute", "failed").Inc()
+	instructions.With("execute", "failed").Add(-1)
+
+	depth := r.NewGaugeVec("queue_depth", "Items waiting in a queue.", "queue")
+	depth.With("instructions").Set(4)
+	depth.With("instructions").Dec()
+
+	polls := r.NewHistogram("poll_duration_seconds", "Poll latency.", []float64{0.1, 1})
+	polls.Observe(0.05)
+	polls.Observe(0.1)
+	polls.Observe(0.5)
+	polls.Observe(3)
+
+	// Families without series are not written
+	r.NewCounterVec("unused_total", "Never incremented.", "reason")
+
+	errors := r.NewCounterVec("errors_total", "Help with a \\ and\na new line.", "message")
+	errors.With(`say "hi"` + "\n").Inc()
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	assert.Equal(t, `# HELP stavily_errors_total Help with a \\ and\na new line.
+# TYPE stavily_errors_total counter
+stavily_errors_total{message="say \"hi\"\n"} 1
+# HELP stavily_instructions_total Instructions processed.
+# TYPE stavily_instructions_total counter
+stavily_instructions_total{type="execute",status="completed"} 3
+stavily_instructions_total{type="execute",status="failed"} 1
+# HELP stavily_poll_duration_seconds Poll latency.
+# TYPE stavily_poll_duration_seconds histogram
+stavily_poll_duration_seconds_bucket{le="0.1"} 2
+stavily_poll_duration_seconds_bucket{le="1"} 3
+stavily_poll_duration_seconds_bucket{le="+Inf"} 4
+stavily_poll_duration_seconds_sum 3.65
+stavily_poll_duration_seconds_count 4
+# HELP stavily_queue_depth Items waiting in a queue.
+# TYPE stavily_queue_depth gauge
+stavily_queue_depth{queue="instructions"} 3
+`, buf.String())
+
+	snapshot := r.Snapshot()
+	assert.Equal(t, float64(3), snapshot[`stavily_instructions_total{type="execute",status="completed"}`])
+	assert.Equal(t, map[string]interface{}{"count": uint64(4), "sum": 3.65}, snapshot["stavily_poll_duration_seconds"])
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry("")
+
+	// Registering the same definition again returns the same metric
+	r.NewCounter("events_total", "Events.").Inc()
+	assert.Equal(t, float64(1), r.NewCounter("events_total", "Events.").Value())
+
+	assert.Panics(t, func() { r.NewGauge("events_total", "Events.") })
+	assert.Panics(t, func() { r.NewCounter("events-total", "Events.") })
+	assert.Panics(t, func() { r.NewCounterVec("drops_total", "Drops.", "le-bound") })
+	assert.Panics(t, func() { r.NewHistogramVec("latency_seconds", "Latency.", nil, "le") })
+	assert.Panics(t, func() { r.NewHistogram("size_bytes", "Size.", []float64{10, 1}) })
+	assert.Panics(t, func() { r.NewCounterVec("drops_total", "Drops.", "reason").With() })
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	r := NewRegistry("")
+	counter := r.NewCounterVec("events_total", "Events.", "type")
+	histogram := r.NewHistogram("latency_seconds", "Latency.", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				counter.With("poll").Inc()
+				histogram.Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, float64(8000), counter.With("poll").Value())
+	assert.Equal(t, uint64(8000), histogram.Count())
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry("agent")
+	r.NewGauge("up", "Whether the agent is up.").Set(1)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "agent_up 1\n")
+
+	rec = httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText writes every metric in the Prometheus text format, with
+// families sorted by name and series sorted by label values
+func (r *Registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.sortedFamilies() {
+		children := f.sortedChildren()
+		if len(children) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+
+		for _, c := range children {
+			if f.typ != TypeHistogram {
+				writeSample(bw, f.name, f.labels, c.values, "", "", c.value.load())
+				continue
+			}
+
+			c.mu.Lock()
+			counts := append([]uint64(nil), c.counts...)
+			sum := c.sum
+			c.mu.Unlock()
+
+			var cumulative uint64
+			for i, upper := range f.buckets {
+				cumulative += counts[i]
+				writeSample(bw, f.name+"_bucket", f.labels, c.values, "le", formatFloat(upper), float64(cumulative))
+			}
+			cumulative += counts[len(f.buckets)]
+			writeSample(bw, f.name+"_bucket", f.labels, c.values, "le", "+Inf", float64(cumulative))
+			writeSample(bw, f.name+"_sum", f.labels, c.values, "", "", sum)
+			writeSample(bw, f.name+"_count", f.labels, c.values, "", "", float64(cumulative))
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler serving the registry in the Prometheus
+// text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		if req.Method == http.MethodHead {
+			return
+		}
+		// A write error means the client went away, and the response has
+		// already started
+		_ = r.WriteText(w)
+	})
+}
+
+// Snapshot returns the current value of every series, keyed by the series
+// name with its labels as written in the text format. Histograms are
+// reported by their count and sum.
+func (r *Registry) Snapshot() map[string]interface{} {
+	snapshot := make(map[string]interface{})
+	for _, f := range r.sortedFamilies() {
+		for _, c := range f.sortedChildren() {
+			key := f.name + formatLabels(f.labels, c.values, "", "")
+			if f.typ != TypeHistogram {
+				snapshot[key] = c.value.load()
+				continue
+			}
+
+			c.mu.Lock()
+			var count uint64
+			for _, n := range c.counts {
+				count += n
+			}
+			snapshot[key] = map[string]interface{}{"count": count, "sum": c.sum}
+			c.mu.Unlock()
+		}
+	}
+	return snapshot
+}
+
+func (r *Registry) sortedFamilies() []*family {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+	return families
+}
+
+func (f *family) sortedChildren() []*child {
+	f.mu.RLock()
+	children := make([]*child, 0, len(f.children))
+	for _, c := range f.children {
+		children = append(children, c)
+	}
+	f.mu.RUnlock()
+
+	sort.Slice(children, func(i, j int) bool {
+		return strings.Join(children[i].values, "\xff") < strings.Join(children[j].values, "\xff")
+	})
+	return children
+}
+
+// writeSample writes one sample line, with an optional extra label such as
+// a histogram bucket's upper bound
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	w.WriteString(formatLabels(labels, values, extraLabel, extraValue))
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatLabels(labels, values []string, extraLabel, extraValue string) string {
+	if len(labels) == 0 && extraLabel == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)+1)
+	for i, label := range labels {
+		pairs = append(pairs, label+`="`+escapeLabelValue(values[i])+`"`)
+	}
+	if extraLabel != "" {
+		pairs = append(pairs, extraLabel+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// Built-in output types
+const (
+	TypeFile    = "file"
+	TypeWebhook = "webhook"
+	TypeSyslog  = "syslog"
+	TypePlugin  = "plugin"
+)
+
+// NewBuiltin creates a built-in output by type
+func NewBuiltin(outputType string, logger *zap.Logger) (plugin.OutputPlugin, error) {
+	switch outputType {
+	case TypeFile:
+		return NewFileOutput(logger)
+	case TypeWebhook:
+		return NewWebhookOutput(logger)
+	case TypeSyslog:
+		return NewSyslogOutput(logger)
+	}
+	return nil, fmt.Errorf("unknown output type %q", outputType)
+}
+
+// builtin holds the lifecycle and delivery state shared by built-in outputs
+type builtin struct {
+	info   *plugin.Info
+	config *plugin.OutputConfig
+
+	mu        sync.Mutex
+	status    plugin.Status
+	sent      int
+	failures  int
+	lastError string
+	lastSent  time.Time
+}
+
+func newBuiltin(id, name, description string, config *plugin.OutputConfig) builtin {
+	config.Description = description
+	return builtin{
+		info: &plugin.Info{
+			ID:          id,
+			Name:        name,
+			Description: description,
+			Version:     "1.0.0",
+			Author:      "Stavily",
+			Type:        plugin.PluginTypeOutput,
+		},
+		config: config,
+		status: plugin.StatusStopped,
+	}
+}
+
+// GetInfo returns plugin metadata
+func (b *builtin) GetInfo() *plugin.Info {
+	return b.info
+}
+
+// GetOutputConfig returns the configuration schema and supported formats
+func (b *builtin) GetOutputConfig() *plugin.OutputConfig {
+	return b.config
+}
+
+// Start marks the output ready to send
+func (b *builtin) Start(ctx context.Context) error {
+	b.setStatus(plugin.StatusRunning)
+	return nil
+}
+
+// GetStatus returns the current status
+func (b *builtin) GetStatus() plugin.Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
+
+// GetHealth reports the outcome of recent deliveries
+func (b *builtin) GetHealth() *plugin.Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := &plugin.Health{
+		Status:     plugin.HealthStatusHealthy,
+		Message:    "output is ready",
+		LastCheck:  time.Now(),
+		ErrorCount: b.failures,
+		LastError:  b.lastError,
+		Metrics: map[string]interface{}{
+			"sent":     b.sent,
+			"failures": b.failures,
+		},
+	}
+	if !b.lastSent.IsZero() {
+		health.Metrics["last_sent"] = b.lastSent
+	}
+	if b.status != plugin.StatusRunning {
+		health.Status = plugin.HealthStatusUnknown
+		health.Message = "output is not running"
+	}
+	return health
+}
+
+// validate checks an output configuration against the schema
+func (b *builtin) validate(config map[string]interface{}) error {
+	return plugin.ValidateConfig(b.config.Schema, b.config.Required, config)
+}
+
+// ready returns an error unless the output has been started
+func (b *builtin) ready() error {
+	if b.GetStatus() != plugin.StatusRunning {
+		return fmt.Errorf("output %s is not running", b.info.ID)
+	}
+	return nil
+}
+
+// record records the outcome of a delivery
+func (b *builtin) record(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.failures++
+		b.lastError = err.Error()
+		return err
+	}
+	b.sent++
+	b.lastSent = time.Now()
+	return nil
+}
+
+func (b *builtin) setStatus(status plugin.Status) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status = status
+}
+
+// payload returns the bytes to send for an output, rendering structured
+// content in the output's format
+func payload(data *plugin.OutputData) ([]byte, error) {
+	switch content := data.Content.(type) {
+	case string:
+		return []byte(content), nil
+	case []byte:
+		return content, nil
+	}
+	return Render(data.Content, data.Format)
+}
+
+// stringSetting returns a string setting or its default
+func stringSetting(config map[string]interface{}, name, def string) string {
+	if value, ok := config[name].(string); ok && value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// FileOutput appends each output to a file, one per line
+type FileOutput struct {
+	builtin
+	logger *zap.Logger
+
+	path string
+	file *os.File
+}
+
+// NewFileOutput creates a file output
+func NewFileOutput(logger *zap.Logger) (*FileOutput, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	return &FileOutput{
+		builtin: newBuiltin(TypeFile, "File output", "Appends outputs to a file, one per line", &plugin.OutputConfig{
+			Schema: map[string]*plugin.ConfigField{
+				"path": {Type: "string", Description: "File to append to", Required: true},
+			},
+			Required: []string{"path"},
+			Examples: []map[string]interface{}{{"path": "/var/log/stavily/results.log"}},
+			Formats:  []string{FormatJSON, FormatText, FormatYAML},
+		}),
+		logger: logger,
+	}, nil
+}
+
+// Initialize sets the file to write to
+func (o *FileOutput) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := o.validate(config); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file != nil {
+		o.file.Close()
+		o.file = nil
+	}
+	o.path = stringSetting(config, "path", "")
+	return nil
+}
+
+// Start opens the file for appending
+func (o *FileOutput) Start(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.path == "" {
+		return fmt.Errorf("file output is not configured")
+	}
+	if o.file == nil {
+		if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		file, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		o.file = file
+	}
+	o.status = plugin.StatusRunning
+	return nil
+}
+
+// Stop closes the file
+func (o *FileOutput) Stop(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.status = plugin.StatusStopped
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	o.file = nil
+	return err
+}
+
+// SendOutput appends an output to the file
+func (o *FileOutput) SendOutput(ctx context.Context, data *plugin.OutputData) error {
+	if err := o.ready(); err != nil {
+		return err
+	}
+	line, err := payload(data)
+	if err != nil {
+		return o.record(err)
+	}
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line = append(line, '\n')
+	}
+
+	o.mu.Lock()
+	file := o.file
+	if file == nil {
+		o.mu.Unlock()
+		return o.record(fmt.Errorf("output file is closed"))
+	}
+	_, err = file.Write(line)
+	o.mu.Unlock()
+
+	if err != nil {
+		return o.record(fmt.Errorf("failed to write output file: %w", err))
+	}
+	return o.record(nil)
+}
@@ -0,0 +1,112 @@
+// Package output sends execution results and trigger events to output
+// destinations: built-in file, webhook and syslog outputs and installed
+// output plugins
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+	FormatYAML = "yaml"
+)
+
+// Render converts content to a format
+func Render(content interface{}, format string) ([]byte, error) {
+	// Round trip through JSON so structs render with their JSON field names
+	var generic interface{}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	switch format {
+	case FormatJSON, "":
+		return raw, nil
+	case FormatYAML:
+		out, err := yaml.Marshal(generic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode output as yaml: %w", err)
+		}
+		return out, nil
+	case FormatText:
+		var b strings.Builder
+		writeText(&b, "", generic)
+		return []byte(strings.TrimSuffix(b.String(), " ")), nil
+	}
+	return nil, fmt.Errorf("unsupported output format %q", format)
+}
+
+// ChooseFormat picks the format to send to an output: the requested format
+// if the output supports it, otherwise the first format the output lists.
+// Outputs that list no formats accept JSON.
+func ChooseFormat(requested string, supported []string) (string, error) {
+	if len(supported) == 0 {
+		if requested == "" || requested == FormatJSON {
+			return FormatJSON, nil
+		}
+		return "", fmt.Errorf("output only supports %s, not %s", FormatJSON, requested)
+	}
+	if requested == "" {
+		return supported[0], nil
+	}
+	for _, format := range supported {
+		if format == requested {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("output does not support %s, supported formats are %s", requested, strings.Join(supported, ", "))
+}
+
+// writeText writes a value as space separated key=value pairs, flattening
+// nested objects into dotted keys
+func writeText(b *strings.Builder, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			writeText(b, key, v[k])
+		}
+	case nil:
+		if prefix != "" {
+			fmt.Fprintf(b, "%s= ", prefix)
+		}
+	default:
+		text := fmt.Sprint(v)
+		switch v := v.(type) {
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case []interface{}:
+			raw, _ := json.Marshal(v)
+			text = string(raw)
+		}
+		if strings.ContainsAny(text, " \t\n\"=") {
+			text = fmt.Sprintf("%q", text)
+		}
+		if prefix == "" {
+			fmt.Fprintf(b, "%s ", text)
+			return
+		}
+		fmt.Fprintf(b, "%s=%s ", prefix, text)
+	}
+}
@@ -0,0 +1,477 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+// Sources of the items routed to outputs
+const (
+	SourceExecutionResults = "execution_results"
+	SourceTriggerEvents    = "trigger_events"
+)
+
+// MetadataRoutes is the item metadata key naming the only destinations an
+// item is sent to, such as the routes sensor rules set on trigger events
+const MetadataRoutes = "routes"
+
+// PluginResolver looks up an installed output plugin by ID
+type PluginResolver func(id string) (plugin.OutputPlugin, error)
+
+// ManagerResolver resolves output plugins from a plugin registry
+func ManagerResolver(manager plugin.PluginRegistry) PluginResolver {
+	return func(id string) (plugin.OutputPlugin, error) {
+		p, err := manager.GetPlugin(id)
+		if err != nil {
+			return nil, err
+		}
+		output, ok := p.(plugin.OutputPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %s is not an output plugin", id)
+		}
+		return output, nil
+	}
+}
+
+// Router sends execution results and trigger events to the configured
+// destinations. Each destination has its own bounded queue and worker, so a
+// slow or failing destination never delays the agent or other destinations.
+type Router struct {
+	logger       *zap.Logger
+	resolve      PluginResolver
+	destinations []*destination
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// destination is an output and the items waiting to be sent to it
+type destination struct {
+	cfg     config.OutputDestinationConfig
+	sources map[string]bool
+	queue   chan *plugin.OutputData
+	logger  *zap.Logger
+
+	// Built-in output and its format; output plugins are looked up for
+	// every item as they may be loaded or reloaded at any time
+	output  plugin.OutputPlugin
+	format  string
+	builtin bool
+
+	sent    uint64
+	failed  uint64
+	dropped uint64
+	retries uint64
+
+	mu        sync.Mutex
+	lastError string
+}
+
+// DestinationStats reports delivery to a destination
+type DestinationStats struct {
+	Type      string `json:"type"`
+	Format    string `json:"format,omitempty"`
+	Queued    int    `json:"queued"`
+	Sent      uint64 `json:"sent"`
+	Failed    uint64 `json:"failed"`
+	Dropped   uint64 `json:"dropped"`
+	Retries   uint64 `json:"retries"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// NewRouter creates a router for the configured destinations. Built-in
+// outputs are configured here; output plugins are looked up with resolve
+// when items are sent to them.
+func NewRouter(cfg config.OutputsConfig, resolve PluginResolver, logger *zap.Logger) (*Router, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
+	r := &Router{logger: logger, resolve: resolve}
+	names := make(map[string]bool)
+
+	for _, destCfg := range cfg.Destinations {
+		if destCfg.Name == "" {
+			return nil, fmt.Errorf("output destination name is required")
+		}
+		if names[destCfg.Name] {
+			return nil, fmt.Errorf("duplicate output destination %s", destCfg.Name)
+		}
+		names[destCfg.Name] = true
+
+		if destCfg.MaxAttempts <= 0 {
+			destCfg.MaxAttempts = 3
+		}
+		if destCfg.RetryDelay <= 0 {
+			destCfg.RetryDelay = time.Second
+		}
+		if destCfg.Timeout <= 0 {
+			destCfg.Timeout = 10 * time.Second
+		}
+
+		dest := &destination{
+			cfg:    destCfg,
+			queue:  make(chan *plugin.OutputData, queueSize),
+			logger: logger.With(zap.String("output", destCfg.Name)),
+		}
+		if len(destCfg.Sources) > 0 {
+			dest.sources = make(map[string]bool, len(destCfg.Sources))
+			for _, source := range destCfg.Sources {
+				if source != SourceExecutionResults && source != SourceTriggerEvents {
+					return nil, fmt.Errorf("output destination %s has unknown source %q", destCfg.Name, source)
+				}
+				dest.sources[source] = true
+			}
+		}
+
+		if destCfg.Type == TypePlugin {
+			if destCfg.Plugin == "" {
+				return nil, fmt.Errorf("output destination %s requires a plugin ID", destCfg.Name)
+			}
+			if resolve == nil {
+				return nil, fmt.Errorf("output destination %s uses a plugin but no plugins are available", destCfg.Name)
+			}
+		} else {
+			output, err := NewBuiltin(destCfg.Type, dest.logger)
+			if err != nil {
+				return nil, fmt.Errorf("output destination %s: %w", destCfg.Name, err)
+			}
+			if err := output.Initialize(context.Background(), destCfg.Config); err != nil {
+				return nil, fmt.Errorf("output destination %s: %w", destCfg.Name, err)
+			}
+			format, err := ChooseFormat(destCfg.Format, output.GetOutputConfig().Formats)
+			if err != nil {
+				return nil, fmt.Errorf("output destination %s: %w", destCfg.Name, err)
+			}
+			dest.output = output
+			dest.format = format
+			dest.builtin = true
+		}
+
+		r.destinations = append(r.destinations, dest)
+	}
+
+	return r, nil
+}
+
+// Start starts the built-in outputs and the delivery workers
+func (r *Router) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return fmt.Errorf("output router is already started")
+	}
+
+	for _, dest := range r.destinations {
+		if dest.builtin {
+			if err := dest.output.Start(ctx); err != nil {
+				return fmt.Errorf("failed to start output %s: %w", dest.cfg.Name, err)
+			}
+		}
+	}
+
+	workerCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	for _, dest := range r.destinations {
+		r.wg.Add(1)
+		go r.deliver(workerCtx, dest)
+	}
+
+	r.started = true
+	r.logger.Info("Output router started", zap.Int("destinations", len(r.destinations)))
+	return nil
+}
+
+// Stop stops accepting items and waits until queued items are sent or ctx
+// is done, then stops the built-in outputs
+func (r *Router) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.started || r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	for _, dest := range r.destinations {
+		close(dest.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("outputs did not drain: %w", ctx.Err())
+	}
+	r.cancel()
+
+	for _, dest := range r.destinations {
+		if dest.builtin {
+			if stopErr := dest.output.Stop(ctx); stopErr != nil {
+				dest.logger.Warn("Failed to stop output", zap.Error(stopErr))
+			}
+		}
+	}
+
+	r.logger.Info("Output router stopped")
+	return err
+}
+
+// Route queues an item from a source for every destination that takes it,
+// without blocking. An item whose metadata lists routes only goes to the
+// destinations named there. Destinations whose queue is full miss the item.
+func (r *Router) Route(source string, data *plugin.OutputData) {
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+	routes := itemRoutes(data)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	for _, dest := range r.destinations {
+		if dest.sources != nil && !dest.sources[source] {
+			continue
+		}
+		if routes != nil && !routes[dest.cfg.Name] {
+			continue
+		}
+
+		select {
+		case dest.queue <- data:
+		default:
+			if dropped := atomic.AddUint64(&dest.dropped, 1); dropped == 1 || dropped%100 == 0 {
+				dest.logger.Warn("Output queue full, dropping items",
+					zap.String("item_id", data.ID),
+					zap.Uint64("dropped", dropped))
+			}
+		}
+	}
+}
+
+// RouteExecutionResult queues the result of a plugin execution
+func (r *Router) RouteExecutionResult(instructionID string, result *types.ExecutionResult) {
+	if result == nil {
+		return
+	}
+	r.Route(SourceExecutionResults, &plugin.OutputData{
+		ID:      instructionID,
+		Type:    "execution_result",
+		Content: result,
+		Metadata: map[string]interface{}{
+			"plugin_id": result.PluginID,
+			"success":   result.Success,
+		},
+		Timestamp: result.Timestamp,
+	})
+}
+
+// RouteActionResult queues the result of an action task
+func (r *Router) RouteActionResult(pluginID string, result *plugin.ActionResult) {
+	if result == nil {
+		return
+	}
+	r.Route(SourceExecutionResults, &plugin.OutputData{
+		ID:      result.ID,
+		Type:    "action_result",
+		Content: result,
+		Metadata: map[string]interface{}{
+			"plugin_id": pluginID,
+			"status":    string(result.Status),
+		},
+		Timestamp: result.CompletedAt,
+	})
+}
+
+// RouteTriggerEvent queues a trigger event
+func (r *Router) RouteTriggerEvent(event *plugin.TriggerEvent) {
+	if event == nil {
+		return
+	}
+	metadata := map[string]interface{}{
+		"event_type": event.Type,
+		"source":     event.Source,
+		"severity":   string(event.Severity),
+	}
+	if routes, ok := event.Metadata[MetadataRoutes]; ok {
+		metadata[MetadataRoutes] = routes
+	}
+	r.Route(SourceTriggerEvents, &plugin.OutputData{
+		ID:        event.ID,
+		Type:      "trigger_event",
+		Content:   event,
+		Metadata:  metadata,
+		Timestamp: event.Timestamp,
+	})
+}
+
+// itemRoutes returns the destinations an item is restricted to, or nil when
+// it goes to every destination
+func itemRoutes(data *plugin.OutputData) map[string]bool {
+	var names []string
+	switch routes := data.Metadata[MetadataRoutes].(type) {
+	case []string:
+		names = routes
+	case []interface{}:
+		for _, route := range routes {
+			if name, ok := route.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case string:
+		names = []string{routes}
+	default:
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[name] = true
+	}
+	return allowed
+}
+
+// GetStats returns delivery statistics per destination
+func (r *Router) GetStats() map[string]*DestinationStats {
+	stats := make(map[string]*DestinationStats, len(r.destinations))
+	for _, dest := range r.destinations {
+		dest.mu.Lock()
+		stats[dest.cfg.Name] = &DestinationStats{
+			Type:      dest.cfg.Type,
+			Format:    dest.format,
+			Queued:    len(dest.queue),
+			Sent:      atomic.LoadUint64(&dest.sent),
+			Failed:    atomic.LoadUint64(&dest.failed),
+			Dropped:   atomic.LoadUint64(&dest.dropped),
+			Retries:   atomic.LoadUint64(&dest.retries),
+			LastError: dest.lastError,
+		}
+		dest.mu.Unlock()
+	}
+	return stats
+}
+
+// deliver sends a destination its items in order until its queue is closed
+func (r *Router) deliver(ctx context.Context, dest *destination) {
+	defer r.wg.Done()
+
+	for data := range dest.queue {
+		if err := r.send(ctx, dest, data); err != nil {
+			atomic.AddUint64(&dest.failed, 1)
+			dest.mu.Lock()
+			dest.lastError = err.Error()
+			dest.mu.Unlock()
+			dest.logger.Error("Failed to send output",
+				zap.String("item_id", data.ID),
+				zap.String("item_type", data.Type),
+				zap.Error(err))
+			continue
+		}
+		atomic.AddUint64(&dest.sent, 1)
+	}
+}
+
+// send delivers one item, retrying with a doubling delay
+func (r *Router) send(ctx context.Context, dest *destination, data *plugin.OutputData) error {
+	delay := dest.cfg.RetryDelay
+	var err error
+
+	for attempt := 1; attempt <= dest.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			atomic.AddUint64(&dest.retries, 1)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("gave up after %d attempts: %w", attempt-1, err)
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > time.Minute {
+				delay = time.Minute
+			}
+		}
+
+		if err = r.attempt(ctx, dest, data); err == nil {
+			return nil
+		}
+		dest.logger.Debug("Output attempt failed",
+			zap.String("item_id", data.ID),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w", dest.cfg.MaxAttempts, err)
+}
+
+// attempt renders an item in the destination's format and sends it once
+func (r *Router) attempt(ctx context.Context, dest *destination, data *plugin.OutputData) error {
+	output, format, err := r.output(dest)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := Render(data.Content, format)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dest.cfg.Timeout)
+	defer cancel()
+
+	return output.SendOutput(ctx, &plugin.OutputData{
+		ID:          data.ID,
+		Type:        data.Type,
+		Content:     string(rendered),
+		Format:      format,
+		Destination: dest.cfg.Name,
+		Metadata:    data.Metadata,
+		Timestamp:   data.Timestamp,
+	})
+}
+
+// output returns a destination's output and the format to send it
+func (r *Router) output(dest *destination) (plugin.OutputPlugin, string, error) {
+	if dest.builtin {
+		return dest.output, dest.format, nil
+	}
+
+	output, err := r.resolve(dest.cfg.Plugin)
+	if err != nil {
+		return nil, "", err
+	}
+	var formats []string
+	if outputCfg := output.GetOutputConfig(); outputCfg != nil {
+		formats = outputCfg.Formats
+	}
+	format, err := ChooseFormat(dest.cfg.Format, formats)
+	if err != nil {
+		return nil, "", err
+	}
+	return output, format, nil
+}
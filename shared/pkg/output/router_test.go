@@ -0,0 +1,237 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+// recordingOutput is an output plugin that only accepts text
+type recordingOutput struct {
+	mu      sync.Mutex
+	outputs []*plugin.OutputData
+}
+
+func (o *recordingOutput) GetInfo() *plugin.Info {
+	return &plugin.Info{ID: "recorder", Type: plugin.PluginTypeOutput}
+}
+func (o *recordingOutput) Initialize(ctx context.Context, config map[string]interface{}) error {
+	return nil
+}
+func (o *recordingOutput) Start(ctx context.Context) error { return nil }
+func (o *recordingOutput) Stop(ctx context.Context) error  { return nil }
+func (o *recordingOutput) GetStatus() plugin.Status        { return plugin.StatusRunning }
+func (o *recordingOutput) GetHealth() *plugin.Health {
+	return &plugin.Health{Status: plugin.HealthStatusHealthy}
+}
+func (o *recordingOutput) GetOutputConfig() *plugin.OutputConfig {
+	return &plugin.OutputConfig{Formats: []string{FormatText}}
+}
+func (o *recordingOutput) SendOutput(ctx context.Context, data *plugin.OutputData) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.outputs = append(o.outputs, data)
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	content := map[string]interface{}{
+		"id":   "e1",
+		"data": map[string]interface{}{"cpu": 93.5, "host": "web 1"},
+	}
+
+	out, err := Render(content, FormatText)
+	require.NoError(t, err)
+	assert.Equal(t, `data.cpu=93.5 data.host="web 1" id=e1`, string(out))
+
+	out, err = Render(content, FormatYAML)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "cpu: 93.5")
+
+	_, err = Render(content, "xml")
+	assert.Error(t, err)
+
+	format, err := ChooseFormat("", []string{FormatText, FormatJSON})
+	require.NoError(t, err)
+	assert.Equal(t, FormatText, format)
+	_, err = ChooseFormat(FormatYAML, []string{FormatText})
+	assert.Error(t, err)
+}
+
+func TestRouter(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "out", "results.log")
+
+	// The webhook fails once before accepting
+	var calls int32
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Header.Get("Content-Type")+" "+r.Header.Get("X-Token")+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	syslogConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer syslogConn.Close()
+
+	recorder := &recordingOutput{}
+	router, err := NewRouter(config.OutputsConfig{
+		Destinations: []config.OutputDestinationConfig{
+			{
+				Name:    "file",
+				Type:    TypeFile,
+				Sources: []string{SourceExecutionResults},
+				Config:  map[string]interface{}{"path": outFile},
+			},
+			{
+				Name:       "webhook",
+				Type:       TypeWebhook,
+				Sources:    []string{SourceTriggerEvents},
+				RetryDelay: 10 * time.Millisecond,
+				Config: map[string]interface{}{
+					"url":     server.URL,
+					"headers": map[string]interface{}{"X-Token": "secret"},
+				},
+			},
+			{
+				Name:    "syslog",
+				Type:    TypeSyslog,
+				Sources: []string{SourceTriggerEvents},
+				Config:  map[string]interface{}{"address": syslogConn.LocalAddr().String(), "tag": "sensor"},
+			},
+			{
+				Name:   "plugin",
+				Type:   TypePlugin,
+				Plugin: "recorder",
+			},
+		},
+	}, func(id string) (plugin.OutputPlugin, error) {
+		if id != "recorder" {
+			return nil, fmt.Errorf("plugin %s not found", id)
+		}
+		return recorder, nil
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, router.Start(ctx))
+
+	router.RouteExecutionResult("inst-1", &types.ExecutionResult{PluginID: "backup", Success: true, ExitCode: 0})
+	router.RouteTriggerEvent(&plugin.TriggerEvent{ID: "evt-1", Type: "cpu_high", Severity: plugin.SeverityHigh, Data: map[string]interface{}{"cpu": 93}})
+
+	require.NoError(t, syslogConn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 4096)
+	n, _, err := syslogConn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg) // local0.err
+	assert.Contains(t, msg, " sensor ")
+	assert.Contains(t, msg, " trigger_event - data.cpu=93 ")
+
+	require.NoError(t, router.Stop(ctx))
+
+	data, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	var result types.ExecutionResult
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, "backup", result.PluginID)
+
+	require.Len(t, bodies, 1)
+	assert.True(t, strings.HasPrefix(bodies[0], `application/json secret {"id":"evt-1"`), bodies[0])
+
+	// The plugin takes both sources, converted to the text format it supports
+	require.Len(t, recorder.outputs, 2)
+	assert.Equal(t, FormatText, recorder.outputs[0].Format)
+	assert.Equal(t, "plugin", recorder.outputs[0].Destination)
+	assert.Contains(t, recorder.outputs[1].Content, "id=evt-1")
+
+	stats := router.GetStats()
+	assert.Equal(t, uint64(1), stats["webhook"].Sent)
+	assert.Equal(t, uint64(1), stats["webhook"].Retries)
+	assert.Equal(t, uint64(2), stats["plugin"].Sent)
+	assert.Equal(t, FormatJSON, stats["file"].Format)
+
+	// Routing after stop is ignored
+	router.RouteTriggerEvent(&plugin.TriggerEvent{ID: "evt-2"})
+}
+
+func TestRouterRejectsInvalidDestinations(t *testing.T) {
+	tests := []struct {
+		name string
+		dest config.OutputDestinationConfig
+	}{
+		{"missing file path", config.OutputDestinationConfig{Name: "f", Type: TypeFile}},
+		{"unsupported format", config.OutputDestinationConfig{Name: "s", Type: TypeSyslog, Format: FormatYAML}},
+		{"unknown type", config.OutputDestinationConfig{Name: "x", Type: "kafka"}},
+		{"plugin without resolver", config.OutputDestinationConfig{Name: "p", Type: TypePlugin, Plugin: "out"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRouter(config.OutputsConfig{Destinations: []config.OutputDestinationConfig{tt.dest}}, nil, zaptest.NewLogger(t))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRouterHonoursRoutes(t *testing.T) {
+	recorders := map[string]*recordingOutput{"slack": {}, "pagerduty": {}}
+	router, err := NewRouter(config.OutputsConfig{
+		Destinations: []config.OutputDestinationConfig{
+			{Name: "slack", Type: TypePlugin, Plugin: "slack"},
+			{Name: "pagerduty", Type: TypePlugin, Plugin: "pagerduty"},
+		},
+	}, func(id string) (plugin.OutputPlugin, error) {
+		return recorders[id], nil
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, router.Start(ctx))
+
+	router.RouteTriggerEvent(&plugin.TriggerEvent{ID: "evt-1", Metadata: map[string]interface{}{MetadataRoutes: []string{"pagerduty"}}})
+	// Routes decoded from JSON
+	router.RouteTriggerEvent(&plugin.TriggerEvent{ID: "evt-2", Metadata: map[string]interface{}{MetadataRoutes: []interface{}{"slack", "email"}}})
+	router.RouteTriggerEvent(&plugin.TriggerEvent{ID: "evt-3"})
+	require.NoError(t, router.Stop(ctx))
+
+	ids := func(o *recordingOutput) []string {
+		var ids []string
+		for _, data := range o.outputs {
+			ids = append(ids, data.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"evt-2", "evt-3"}, ids(recorders["slack"]))
+	assert.Equal(t, []string{"evt-1", "evt-3"}, ids(recorders["pagerduty"]))
+	assert.Equal(t, []interface{}{"slack", "email"}, recorders["slack"].outputs[0].Metadata[MetadataRoutes])
+}
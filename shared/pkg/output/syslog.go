@@ -0,0 +1,188 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// syslogFacilities maps facility names to RFC 5424 facility codes
+var syslogFacilities = map[string]int{
+	"user": 1, "daemon": 3, "auth": 4, "syslog": 5,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogOutput sends each output as an RFC 5424 syslog message. It speaks
+// the network protocol itself so that it works the same on every platform.
+type SyslogOutput struct {
+	builtin
+	logger *zap.Logger
+
+	network  string
+	address  string
+	facility int
+	tag      string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogOutput creates a syslog output
+func NewSyslogOutput(logger *zap.Logger) (*SyslogOutput, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	facilities := make([]string, 0, len(syslogFacilities))
+	for name := range syslogFacilities {
+		facilities = append(facilities, name)
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogOutput{
+		builtin: newBuiltin(TypeSyslog, "Syslog output", "Sends outputs to a syslog server", &plugin.OutputConfig{
+			Schema: map[string]*plugin.ConfigField{
+				"network":  {Type: "string", Description: "Transport to the syslog server", Default: "udp", Enum: []string{"udp", "tcp", "unix", "unixgram"}},
+				"address":  {Type: "string", Description: "Syslog server address or socket path", Default: "localhost:514"},
+				"facility": {Type: "string", Description: "Syslog facility", Default: "local0", Enum: facilities},
+				"tag":      {Type: "string", Description: "Application name in messages", Default: "stavily"},
+			},
+			Examples: []map[string]interface{}{{"network": "tcp", "address": "logs.example.com:514"}},
+			Formats:  []string{FormatText, FormatJSON},
+		}),
+		logger:   logger,
+		network:  "udp",
+		address:  "localhost:514",
+		facility: syslogFacilities["local0"],
+		tag:      "stavily",
+		hostname: hostname,
+	}, nil
+}
+
+// Initialize sets the syslog server and message settings
+func (o *SyslogOutput) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := o.validate(config); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closeConn()
+	o.network = stringSetting(config, "network", "udp")
+	o.address = stringSetting(config, "address", "localhost:514")
+	o.facility = syslogFacilities[stringSetting(config, "facility", "local0")]
+	o.tag = stringSetting(config, "tag", "stavily")
+	return nil
+}
+
+// Stop closes the connection to the syslog server
+func (o *SyslogOutput) Stop(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.status = plugin.StatusStopped
+	o.closeConn()
+	return nil
+}
+
+// SendOutput sends an output as a syslog message. Multi-line content is
+// sent as a single message.
+func (o *SyslogOutput) SendOutput(ctx context.Context, data *plugin.OutputData) error {
+	if err := o.ready(); err != nil {
+		return err
+	}
+	body, err := payload(data)
+	if err != nil {
+		return o.record(err)
+	}
+
+	o.mu.Lock()
+	msg := o.format(data, strings.TrimRight(string(body), "\n"))
+	err = o.write(ctx, msg)
+	if err != nil {
+		// The server may have dropped the connection; retry once on a new one
+		o.closeConn()
+		if err = o.write(ctx, msg); err != nil {
+			o.closeConn()
+		}
+	}
+	o.mu.Unlock()
+
+	if err != nil {
+		return o.record(fmt.Errorf("failed to send syslog message: %w", err))
+	}
+	return o.record(nil)
+}
+
+// format builds an RFC 5424 message
+func (o *SyslogOutput) format(data *plugin.OutputData, body string) string {
+	priority := o.facility*8 + syslogSeverity(data.Metadata)
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	msgID := data.Type
+	if msgID == "" {
+		msgID = "-"
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		priority, timestamp.UTC().Format(time.RFC3339Nano), o.hostname, o.tag, os.Getpid(), msgID, body)
+}
+
+// write sends a message, connecting first if needed. Stream transports use
+// octet counting framing (RFC 6587).
+func (o *SyslogOutput) write(ctx context.Context, msg string) error {
+	if o.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, o.network, o.address)
+		if err != nil {
+			return err
+		}
+		o.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		o.conn.SetWriteDeadline(deadline)
+	} else {
+		o.conn.SetWriteDeadline(time.Time{})
+	}
+
+	if o.network == "tcp" || o.network == "unix" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	_, err := o.conn.Write([]byte(msg))
+	return err
+}
+
+func (o *SyslogOutput) closeConn() {
+	if o.conn != nil {
+		o.conn.Close()
+		o.conn = nil
+	}
+}
+
+// syslogSeverity maps the severity in output metadata to a syslog severity
+func syslogSeverity(metadata map[string]interface{}) int {
+	severity, _ := metadata["severity"].(string)
+	switch plugin.Severity(severity) {
+	case plugin.SeverityCritical:
+		return 2
+	case plugin.SeverityHigh:
+		return 3
+	case plugin.SeverityMedium:
+		return 4
+	case plugin.SeverityLow:
+		return 5
+	}
+	return 6
+}
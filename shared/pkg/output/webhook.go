@@ -0,0 +1,123 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/plugin"
+)
+
+// WebhookOutput sends each output in the body of an HTTP request
+type WebhookOutput struct {
+	builtin
+	logger *zap.Logger
+	client *http.Client
+
+	url     string
+	method  string
+	headers map[string]string
+}
+
+// contentTypes maps output formats to HTTP content types
+var contentTypes = map[string]string{
+	FormatJSON: "application/json",
+	FormatYAML: "application/yaml",
+	FormatText: "text/plain; charset=utf-8",
+}
+
+// NewWebhookOutput creates a webhook output
+func NewWebhookOutput(logger *zap.Logger) (*WebhookOutput, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	return &WebhookOutput{
+		builtin: newBuiltin(TypeWebhook, "Webhook output", "Sends outputs to an HTTP endpoint", &plugin.OutputConfig{
+			Schema: map[string]*plugin.ConfigField{
+				"url":     {Type: "string", Description: "Endpoint outputs are sent to", Required: true, Pattern: "^https?://"},
+				"method":  {Type: "string", Description: "HTTP method", Default: http.MethodPost, Enum: []string{http.MethodPost, http.MethodPut}},
+				"headers": {Type: "object", Description: "Extra request headers"},
+			},
+			Required: []string{"url"},
+			Examples: []map[string]interface{}{{"url": "https://hooks.example.com/stavily"}},
+			Formats:  []string{FormatJSON, FormatYAML, FormatText},
+		}),
+		logger: logger,
+		client: &http.Client{Timeout: 30 * time.Second},
+		method: http.MethodPost,
+	}, nil
+}
+
+// Initialize sets the endpoint and request settings
+func (o *WebhookOutput) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := o.validate(config); err != nil {
+		return err
+	}
+
+	headers := make(map[string]string)
+	if raw, ok := config["headers"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.url = stringSetting(config, "url", "")
+	o.method = stringSetting(config, "method", http.MethodPost)
+	o.headers = headers
+	return nil
+}
+
+// Stop marks the output stopped
+func (o *WebhookOutput) Stop(ctx context.Context) error {
+	o.setStatus(plugin.StatusStopped)
+	o.client.CloseIdleConnections()
+	return nil
+}
+
+// SendOutput sends an output to the endpoint; any non-2xx response is an error
+func (o *WebhookOutput) SendOutput(ctx context.Context, data *plugin.OutputData) error {
+	if err := o.ready(); err != nil {
+		return err
+	}
+	body, err := payload(data)
+	if err != nil {
+		return o.record(err)
+	}
+
+	o.mu.Lock()
+	url, method, headers := o.url, o.method, o.headers
+	o.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return o.record(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+	if contentType, ok := contentTypes[data.Format]; ok {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Stavily-Output-Type", data.Type)
+	req.Header.Set("X-Stavily-Output-ID", data.ID)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return o.record(fmt.Errorf("webhook request failed: %w", err))
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return o.record(fmt.Errorf("webhook returned status %d", resp.StatusCode))
+	}
+	return o.record(nil)
+}
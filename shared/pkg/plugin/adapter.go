@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AdapterOptions configures the runtime adapter built for an installed plugin
+type AdapterOptions struct {
+	// Timeout bounds plugin calls, actions and process shutdown
+	Timeout time.Duration
+	// Extra environment variables for the plugin process
+	Environment map[string]string
+	// DisableTriggerRestart leaves restarting exited trigger processes to
+	// the caller
+	DisableTriggerRestart bool
+}
+
+// NewAdapter builds the runtime adapter for the plugin installed in dir: an
+// RPC proxy for plugins speaking the rpc protocol, a long-lived process for
+// other trigger plugins and a process per action for other action plugins
+func NewAdapter(dir string, manifest *Manifest, opts AdapterOptions, logger *zap.Logger) (Plugin, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("plugin manifest is required")
+	}
+
+	var (
+		p   Plugin
+		err error
+	)
+	switch {
+	case manifest.Runtime.Protocol == ProtocolRPC:
+		p, err = NewRPCProxy(dir, manifest, RPCProxyOptions{
+			CallTimeout: opts.Timeout,
+			StopTimeout: opts.Timeout,
+			Environment: opts.Environment,
+		}, logger)
+	case manifest.Type == PluginTypeTrigger:
+		p, err = NewSubprocessTriggerPlugin(dir, manifest, SubprocessTriggerOptions{
+			StopTimeout:    opts.Timeout,
+			Environment:    opts.Environment,
+			DisableRestart: opts.DisableTriggerRestart,
+		}, logger)
+	case manifest.Type == PluginTypeAction:
+		p, err = NewSubprocessActionPlugin(dir, manifest, SubprocessActionOptions{
+			Timeout:     opts.Timeout,
+			Environment: opts.Environment,
+		}, logger)
+	default:
+		err = fmt.Errorf("%s plugin %s must use the rpc protocol", manifest.Type, manifest.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConfigSchema returns the configuration schema a plugin declares for its type
+func ConfigSchema(p Plugin) (map[string]*ConfigField, []string) {
+	info := p.GetInfo()
+	if info == nil {
+		return nil, nil
+	}
+
+	switch info.Type {
+	case PluginTypeTrigger:
+		if tp, ok := p.(TriggerPlugin); ok {
+			if cfg := tp.GetTriggerConfig(); cfg != nil {
+				return cfg.Schema, cfg.Required
+			}
+		}
+	case PluginTypeAction:
+		if ap, ok := p.(ActionPlugin); ok {
+			if cfg := ap.GetActionConfig(); cfg != nil {
+				return cfg.Schema, cfg.Required
+			}
+		}
+	case PluginTypeOutput:
+		if op, ok := p.(OutputPlugin); ok {
+			if cfg := op.GetOutputConfig(); cfg != nil {
+				return cfg.Schema, cfg.Required
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ValidateConfig checks a plugin configuration against its schema. Fields the
+// schema does not describe are allowed.
+func ValidateConfig(schema map[string]*ConfigField, required []string, config map[string]interface{}) error {
+	var problems []string
+
+	missing := make(map[string]bool)
+	for _, name := range required {
+		missing[name] = true
+	}
+	for name, field := range schema {
+		if field != nil && field.Required {
+			missing[name] = true
+		}
+	}
+	for name := range missing {
+		if value, ok := config[name]; !ok || value == nil {
+			problems = append(problems, fmt.Sprintf("%s: is required", name))
+		}
+	}
+
+	for name, value := range config {
+		field, ok := schema[name]
+		if !ok || field == nil || value == nil {
+			continue
+		}
+		if err := validateField(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid plugin configuration: %s", strings.Join(problems, "; "))
+}
+
+// validateField checks a single value against its field schema
+func validateField(field *ConfigField, value interface{}) error {
+	switch field.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		length := utf8.RuneCountInString(s)
+		if field.MinLength != nil && length < *field.MinLength {
+			return fmt.Errorf("must be at least %d characters", *field.MinLength)
+		}
+		if field.MaxLength != nil && length > *field.MaxLength {
+			return fmt.Errorf("must be at most %d characters", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			re, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return fmt.Errorf("schema pattern is invalid: %w", err)
+			}
+			if !re.MatchString(s) {
+				return fmt.Errorf("must match %s", field.Pattern)
+			}
+		}
+
+	case "number", "integer":
+		n, ok := toFloat(value)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		if field.Type == "integer" && n != float64(int64(n)) {
+			return fmt.Errorf("must be an integer")
+		}
+		if field.Minimum != nil && n < *field.Minimum {
+			return fmt.Errorf("must be at least %v", *field.Minimum)
+		}
+		if field.Maximum != nil && n > *field.Maximum {
+			return fmt.Errorf("must be at most %v", *field.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+
+	case "array":
+		switch value.(type) {
+		case []interface{}, []string:
+		default:
+			return fmt.Errorf("must be an array")
+		}
+
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("must be an object")
+		}
+	}
+
+	if len(field.Enum) > 0 {
+		s := fmt.Sprint(value)
+		for _, allowed := range field.Enum {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(field.Enum, ", "))
+	}
+
+	return nil
+}
+
+// toFloat converts the numeric types produced by JSON and YAML decoding
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -10,7 +10,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -107,6 +109,11 @@ func (pd *PluginDownloader) DownloadPlugin(ctx context.Context, inst *types.Inst
 		Duration:      time.Since(startTime).Seconds(),
 		Timestamp:     time.Now(),
 	}
+	if digest, err := Digest(pluginDir); err != nil {
+		pd.logger.Warn("Failed to compute plugin digest", zap.String("plugin_id", inst.PluginID), zap.Error(err))
+	} else {
+		result.Digest = digest
+	}
 
 	pd.logger.Info("Plugin download completed successfully",
 		zap.String("instruction_id", inst.ID),
@@ -117,6 +124,33 @@ func (pd *PluginDownloader) DownloadPlugin(ctx context.Context, inst *types.Inst
 	return result, nil
 }
 
+// PlanDownload lists what DownloadPlugin would do for the instruction,
+// without touching the network or the plugin directory
+func (pd *PluginDownloader) PlanDownload(inst *types.Instruction) ([]types.PlannedChange, error) {
+	config, err := pd.extractDownloadConfig(inst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract download config: %w", err)
+	}
+
+	ref := "branch " + config.Branch
+	if config.Tag != "" {
+		ref = "tag " + config.Tag
+	}
+	if config.CommitHash != "" {
+		ref += ", commit " + config.CommitHash
+	}
+
+	install := "install plugin"
+	if config.Version != "" {
+		install += " version " + config.Version
+	}
+
+	return []types.PlannedChange{
+		{Action: "clone", Target: config.RepositoryURL, Detail: ref},
+		{Action: "install", Target: pd.GetInstalledPluginPath(inst.PluginID), Detail: install},
+	}, nil
+}
+
 // extractDownloadConfig extracts download configuration from instruction
 func (pd *PluginDownloader) extractDownloadConfig(inst *types.Instruction) (*DownloadConfig, error) {
 	config := &DownloadConfig{}
@@ -166,8 +200,15 @@ func (pd *PluginDownloader) extractDownloadConfig(inst *types.Instruction) (*Dow
 }
 
 // gitClone performs git clone operation with proper error handling
-func (pd *PluginDownloader) gitClone(ctx context.Context, config *DownloadConfig, targetDir string) ([]string, error) {
-	var logs []string
+func (pd *PluginDownloader) gitClone(ctx context.Context, config *DownloadConfig, targetDir string) (logs []string, err error) {
+	ctx, span := tracing.Start(ctx, "git.clone",
+		attribute.String("git.branch", config.Branch),
+		attribute.String("git.tag", config.Tag),
+		attribute.String("git.commit", config.CommitHash))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
 	
 	// Create context with timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, pd.gitTimeout)
@@ -2,6 +2,7 @@
 package plugin
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,7 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
 	"github.com/Stavily/01-Agents/shared/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -32,6 +35,7 @@ type ExecutionConfig struct {
 	InputData         map[string]interface{} `json:"input_data"`
 	Context           map[string]interface{} `json:"context"`
 	Variables         map[string]interface{} `json:"variables"`
+	DryRun            bool                   `json:"dry_run"`
 }
 
 // Runtime represents different plugin runtime environments
@@ -80,6 +84,24 @@ func (pe *PluginExecutor) ExecutePlugin(ctx context.Context, inst *types.Instruc
 		}, fmt.Errorf("plugin not installed: %s", inst.PluginID)
 	}
 
+	// Only plugins that declare support for dry runs may run in one
+	if inst.DryRun {
+		manifest, _ := LoadManifest(pluginDir)
+		if err := checkDryRun(inst.PluginID, manifest); err != nil {
+			pe.logger.Warn("Refusing dry run",
+				zap.String("instruction_id", inst.ID),
+				zap.String("plugin_id", inst.PluginID))
+			return &types.ExecutionResult{
+				Success:   false,
+				PluginID:  inst.PluginID,
+				Error:     err.Error(),
+				Duration:  time.Since(startTime).Seconds(),
+				Timestamp: time.Now(),
+				DryRun:    true,
+			}, err
+		}
+	}
+
 	// Extract execution configuration
 	config, err := pe.extractExecutionConfig(inst, pluginDir)
 	if err != nil {
@@ -120,12 +142,15 @@ func (pe *PluginExecutor) ExecutePlugin(ctx context.Context, inst *types.Instruc
 			Duration:  time.Since(startTime).Seconds(),
 			ExitCode:  result.ExitCode,
 			Timestamp: time.Now(),
+			DryRun:    inst.DryRun,
 		}, err
 	}
 
 	result.PluginID = inst.PluginID
 	result.Duration = time.Since(startTime).Seconds()
 	result.Timestamp = time.Now()
+	result.DryRun = inst.DryRun
+	result.PlannedChanges = plannedChanges(result.OutputData)
 
 	pe.logger.Info("Plugin execution completed",
 		zap.String("instruction_id", inst.ID),
@@ -144,6 +169,7 @@ func (pe *PluginExecutor) extractExecutionConfig(inst *types.Instruction, plugin
 		InputData:        inst.InputData,
 		Context:          inst.Context,
 		Variables:        inst.Variables,
+		DryRun:           inst.DryRun,
 	}
 
 	// Extract entrypoint from plugin configuration
@@ -174,6 +200,11 @@ func (pe *PluginExecutor) extractExecutionConfig(inst *types.Instruction, plugin
 		config.Timeout = time.Duration(timeoutSec) * time.Second
 	}
 
+	// Set last so the plugin configuration cannot turn a dry run off
+	if config.DryRun {
+		config.Environment[EnvDryRun] = "true"
+	}
+
 	return config, nil
 }
 
@@ -281,7 +312,7 @@ func (pe *PluginExecutor) executePython(ctx context.Context, config *ExecutionCo
 		zap.Strings("args", args),
 		zap.String("working_dir", cmd.Dir))
 
-	output, err := cmd.CombinedOutput()
+	output, err := pe.runCommand(ctx, cmd)
 	logs = append(logs, string(output))
 
 	result := &types.ExecutionResult{
@@ -334,7 +365,7 @@ func (pe *PluginExecutor) executeNode(ctx context.Context, config *ExecutionConf
 	cmd.Dir = config.WorkingDirectory
 	cmd.Env = pe.buildEnvironment(config.Environment)
 
-	output, err := cmd.CombinedOutput()
+	output, err := pe.runCommand(ctx, cmd)
 	logs = append(logs, string(output))
 
 	result := &types.ExecutionResult{
@@ -372,7 +403,7 @@ func (pe *PluginExecutor) executeBash(ctx context.Context, config *ExecutionConf
 	cmd.Dir = config.WorkingDirectory
 	cmd.Env = pe.buildEnvironment(config.Environment)
 
-	output, err := cmd.CombinedOutput()
+	output, err := pe.runCommand(ctx, cmd)
 	logs = append(logs, string(output))
 
 	result := &types.ExecutionResult{
@@ -401,7 +432,7 @@ func (pe *PluginExecutor) executeExecutable(ctx context.Context, config *Executi
 	cmd.Dir = config.WorkingDirectory
 	cmd.Env = pe.buildEnvironment(config.Environment)
 
-	output, err := cmd.CombinedOutput()
+	output, err := pe.runCommand(ctx, cmd)
 	logs = append(logs, string(output))
 
 	result := &types.ExecutionResult{
@@ -449,11 +480,17 @@ func (pe *PluginExecutor) executeDocker(ctx context.Context, config *ExecutionCo
 		runArgs = append(runArgs, "-e", fmt.Sprintf("%s=%s", k, v))
 	}
 	
+	// Pass the trace context from the docker client's environment
+	for _, env := range tracing.Environ(ctx) {
+		name, _, _ := strings.Cut(env, "=")
+		runArgs = append(runArgs, "-e", name)
+	}
+	
 	runArgs = append(runArgs, imageName)
 	runArgs = append(runArgs, config.Arguments...)
 
 	runCmd := exec.CommandContext(ctx, "docker", runArgs...)
-	output, err := runCmd.CombinedOutput()
+	output, err := pe.runCommand(ctx, runCmd)
 	logs = append(logs, string(output))
 
 	result := &types.ExecutionResult{
@@ -471,6 +508,41 @@ func (pe *PluginExecutor) executeDocker(ctx context.Context, config *ExecutionCo
 	return result, err
 }
 
+// runCommand runs a plugin process and returns its combined output, like
+// CombinedOutput. Starting the process and waiting for it are traced
+// separately, and the process receives the trace context in TRACEPARENT.
+func (pe *PluginExecutor) runCommand(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	if env := tracing.Environ(ctx); env != nil {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, env...)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	_, spawn := tracing.Start(ctx, "process.spawn", attribute.String("process.executable.path", cmd.Path))
+	err := cmd.Start()
+	if err == nil {
+		spawn.SetAttributes(attribute.Int("process.pid", cmd.Process.Pid))
+	}
+	tracing.RecordError(spawn, err)
+	spawn.End()
+	if err != nil {
+		return output.Bytes(), err
+	}
+
+	_, wait := tracing.Start(ctx, "process.wait")
+	err = cmd.Wait()
+	wait.SetAttributes(attribute.Int("process.exit.code", cmd.ProcessState.ExitCode()))
+	tracing.RecordError(wait, err)
+	wait.End()
+
+	return output.Bytes(), err
+}
+
 // executeGeneric executes a plugin using generic approach
 func (pe *PluginExecutor) executeGeneric(ctx context.Context, config *ExecutionConfig, pluginDir string) (*types.ExecutionResult, error) {
 	return pe.executeExecutable(ctx, config, pluginDir)
@@ -478,7 +550,7 @@ func (pe *PluginExecutor) executeGeneric(ctx context.Context, config *ExecutionC
 
 // prepareInputFile creates a temporary JSON file with input data
 func (pe *PluginExecutor) prepareInputFile(config *ExecutionConfig, pluginDir string) (string, error) {
-	if len(config.InputData) == 0 && len(config.Context) == 0 && len(config.Variables) == 0 {
+	if len(config.InputData) == 0 && len(config.Context) == 0 && len(config.Variables) == 0 && !config.DryRun {
 		return "", nil
 	}
 
@@ -486,6 +558,7 @@ func (pe *PluginExecutor) prepareInputFile(config *ExecutionConfig, pluginDir st
 		"input_data": config.InputData,
 		"context":    config.Context,
 		"variables":  config.Variables,
+		"dry_run":    config.DryRun,
 	}
 
 	data, err := json.Marshal(inputData)
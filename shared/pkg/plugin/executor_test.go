@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/tracing"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+func TestPluginExecutorDryRun(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not available")
+	}
+
+	baseDir := t.TempDir()
+	pluginDir := filepath.Join(baseDir, "restart-service")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(`
+plugin:
+  id: "restart-service"
+  version: "1.0.0"
+  type: "action"
+  supports_dry_run: true
+  runtime:
+    type: "python"
+    entry_point: "main.py"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "main.py"), []byte(`
+import json, os, sys
+with open(sys.argv[2]) as f:
+    request = json.load(f)
+dry_run = os.environ.get("STAVILY_DRY_RUN") == "true"
+if dry_run and request["dry_run"]:
+    print(json.dumps({"planned_changes": [{"action": "restart", "target": "nginx.service", "detail": "currently active"}]}))
+else:
+    print(json.dumps({"restarted": True}))
+`), 0644))
+
+	executor := NewPluginExecutor(zaptest.NewLogger(t), baseDir)
+	inst := &types.Instruction{
+		ID:                  "inst-1",
+		PluginID:            "restart-service",
+		PluginConfiguration: map[string]interface{}{"entrypoint": "main.py"},
+		DryRun:              true,
+	}
+
+	result, err := executor.ExecutePlugin(context.Background(), inst)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []types.PlannedChange{
+		{Action: "restart", Target: "nginx.service", Detail: "currently active"},
+	}, result.PlannedChanges)
+	assert.Equal(t, "restart nginx.service (currently active)", result.PlannedChanges[0].String())
+
+	inst.DryRun = false
+	inst.InputData = map[string]interface{}{"unit": "nginx.service"}
+	result, err = executor.ExecutePlugin(context.Background(), inst)
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	assert.Empty(t, result.PlannedChanges)
+	assert.Equal(t, true, result.OutputData["restarted"])
+}
+
+func TestPluginExecutorRefusesUnsupportedDryRun(t *testing.T) {
+	baseDir := t.TempDir()
+	pluginDir := filepath.Join(baseDir, "restart-service")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "main.sh"), []byte("touch ran\n"), 0755))
+
+	executor := NewPluginExecutor(zaptest.NewLogger(t), baseDir)
+	result, err := executor.ExecutePlugin(context.Background(), &types.Instruction{
+		ID:                  "inst-1",
+		PluginID:            "restart-service",
+		PluginConfiguration: map[string]interface{}{"entrypoint": "main.sh"},
+		DryRun:              true,
+	})
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, "plugin restart-service does not support dry runs", result.Error)
+	assert.NoFileExists(t, filepath.Join(pluginDir, "ran"))
+}
+
+func TestPluginExecutorTraceparent(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not available")
+	}
+
+	baseDir := t.TempDir()
+	pluginDir := filepath.Join(baseDir, "show-trace")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "main.py"), []byte(`
+import json, os
+print(json.dumps({"traceparent": os.environ.get("TRACEPARENT", "")}))
+`), 0644))
+
+	executor := NewPluginExecutor(zaptest.NewLogger(t), baseDir)
+	inst := &types.Instruction{
+		ID:                  "inst-1",
+		PluginID:            "show-trace",
+		PluginConfiguration: map[string]interface{}{"entrypoint": "main.py"},
+	}
+
+	// Without a span there is no trace context to pass on
+	result, err := executor.ExecutePlugin(context.Background(), inst)
+	require.NoError(t, err)
+	assert.Equal(t, "", result.OutputData["traceparent"])
+
+	tracer, err := tracing.NewTracer(config.TracingConfig{
+		Exporter:    tracing.ExporterFile,
+		File:        filepath.Join(t.TempDir(), "spans.jsonl"),
+		SampleRatio: 1,
+	}, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer tracer.Shutdown(context.Background())
+
+	ctx, span := tracer.Start(context.Background(), "plugin.execute")
+	defer span.End()
+	result, err = executor.ExecutePlugin(ctx, inst)
+	require.NoError(t, err)
+	assert.Equal(t, tracing.Traceparent(ctx), result.OutputData["traceparent"])
+}
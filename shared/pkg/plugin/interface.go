@@ -4,6 +4,8 @@ package plugin
 import (
 	"context"
 	"time"
+
+	"github.com/Stavily/01-Agents/shared/pkg/types"
 )
 
 // Plugin represents the base interface that all plugins must implement
@@ -139,27 +141,33 @@ type TriggerConfig struct {
 	Description string                   `json:"description"`
 }
 
-// ActionRequest represents an action execution request
+// ActionRequest represents an action execution request. A dry run must
+// not change anything, only report the changes the action would make.
 type ActionRequest struct {
 	ID          string                 `json:"id"`
 	Type        string                 `json:"type"`
 	Parameters  map[string]interface{} `json:"parameters"`
 	Context     map[string]interface{} `json:"context"`
 	Timeout     time.Duration          `json:"timeout"`
+	DryRun      bool                   `json:"dry_run,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata"`
 	RequestedAt time.Time              `json:"requested_at"`
 }
 
+// PlannedChange describes a change an action would make
+type PlannedChange = types.PlannedChange
+
 // ActionResult represents the result of an action execution
 type ActionResult struct {
-	ID          string                 `json:"id"`
-	Status      ActionStatus           `json:"status"`
-	Data        map[string]interface{} `json:"data"`
-	Error       string                 `json:"error,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata"`
-	StartedAt   time.Time              `json:"started_at"`
-	CompletedAt time.Time              `json:"completed_at"`
-	Duration    time.Duration          `json:"duration"`
+	ID             string                 `json:"id"`
+	Status         ActionStatus           `json:"status"`
+	Data           map[string]interface{} `json:"data"`
+	Error          string                 `json:"error,omitempty"`
+	PlannedChanges []PlannedChange        `json:"planned_changes,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	StartedAt      time.Time              `json:"started_at"`
+	CompletedAt    time.Time              `json:"completed_at"`
+	Duration       time.Duration          `json:"duration"`
 }
 
 // ActionConfig defines the configuration schema for action plugins
@@ -320,6 +328,14 @@ type EventHandler interface {
 	HandleEvent(ctx context.Context, event *PluginEvent) error
 }
 
+// EventHandlerFunc adapts a function to the EventHandler interface
+type EventHandlerFunc func(ctx context.Context, event *PluginEvent) error
+
+// HandleEvent calls f(ctx, event)
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, event *PluginEvent) error {
+	return f(ctx, event)
+}
+
 // SecurityContext provides security constraints for plugin execution
 type SecurityContext struct {
 	MaxMemory      int64         `json:"max_memory"`
@@ -0,0 +1,257 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFiles are the file names a plugin manifest is looked up under
+var ManifestFiles = []string{"plugin.yaml", "plugin.yml"}
+
+// Manifest describes an installed plugin, as read from its plugin.yaml
+type Manifest struct {
+	ID            string                    `yaml:"id"`
+	Name          string                    `yaml:"name"`
+	Description   string                    `yaml:"description"`
+	Version       string                    `yaml:"version"`
+	Author        string                    `yaml:"author"`
+	License       string                    `yaml:"license"`
+	Homepage      string                    `yaml:"homepage"`
+	Repository    string                    `yaml:"repository"`
+	Tags          []string                  `yaml:"tags"`
+	Categories    []string                  `yaml:"categories"`
+	Type          PluginType                `yaml:"type"`
+	Runtime       ManifestRuntime           `yaml:"runtime"`
+	Configuration map[string]*ManifestField `yaml:"configuration"`
+	Limits        ManifestLimits            `yaml:"limits"`
+	// Risk of running the plugin; agents may hold high-risk actions until
+	// they are approved
+	Risk string `yaml:"risk"`
+	// SupportsDryRun declares that the plugin honours STAVILY_DRY_RUN and
+	// only reports the changes it would make; dry runs of other plugins
+	// are refused
+	SupportsDryRun bool `yaml:"supports_dry_run"`
+}
+
+// Risk levels a plugin manifest can declare
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// Protocols a long-lived plugin process can speak
+const (
+	// ProtocolStream plugins write newline-delimited JSON trigger events to stdout
+	ProtocolStream = "stream"
+	// ProtocolRPC plugins implement the plugin interfaces over JSON-RPC
+	ProtocolRPC = "rpc"
+)
+
+// Transports for RPC plugins
+const (
+	TransportStdio = "stdio"
+	TransportUnix  = "unix"
+)
+
+// ManifestRuntime describes how a plugin is executed
+type ManifestRuntime struct {
+	Type         Runtime  `yaml:"type"`
+	Version      string   `yaml:"version"`
+	EntryPoint   string   `yaml:"entry_point"`
+	Arguments    []string `yaml:"arguments"`
+	Requirements string   `yaml:"requirements"`
+	// Protocol spoken by a long-lived plugin process; trigger plugins
+	// default to "stream"
+	Protocol string `yaml:"protocol"`
+	// Transport for RPC plugins, "stdio" when empty
+	Transport string `yaml:"transport"`
+}
+
+// ManifestField is a configuration field in a plugin manifest
+type ManifestField struct {
+	Type        string      `yaml:"type"`
+	Description string      `yaml:"description"`
+	Default     interface{} `yaml:"default"`
+	Required    bool        `yaml:"required"`
+	Enum        []string    `yaml:"enum"`
+	Pattern     string      `yaml:"pattern"`
+	Minimum     *float64    `yaml:"minimum"`
+	Maximum     *float64    `yaml:"maximum"`
+	MinLength   *int        `yaml:"min_length"`
+	MaxLength   *int        `yaml:"max_length"`
+}
+
+// ManifestLimits are the resource limits declared by a plugin
+type ManifestLimits struct {
+	Memory        string `yaml:"memory"`
+	CPU           string `yaml:"cpu"`
+	ExecutionTime string `yaml:"execution_time"`
+}
+
+// manifestFile is the top-level layout of plugin.yaml
+type manifestFile struct {
+	Plugin *Manifest `yaml:"plugin"`
+}
+
+// FindManifest returns the path of the manifest in a plugin directory
+func FindManifest(dir string) (string, error) {
+	for _, name := range ManifestFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no plugin manifest found in %s", dir)
+}
+
+// LoadManifest reads and validates the manifest in a plugin directory
+func LoadManifest(dir string) (*Manifest, error) {
+	path, err := FindManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
+
+	return ParseManifest(content)
+}
+
+// ParseManifest parses and validates a plugin manifest
+func ParseManifest(content []byte) (*Manifest, error) {
+	var file manifestFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin manifest: %w", err)
+	}
+	if file.Plugin == nil {
+		return nil, fmt.Errorf("plugin manifest has no plugin section")
+	}
+
+	manifest := file.Plugin
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Validate checks the manifest for required fields
+func (m *Manifest) Validate() error {
+	if m.ID == "" {
+		return fmt.Errorf("plugin manifest is missing id")
+	}
+	if m.Version == "" {
+		return fmt.Errorf("plugin manifest %s is missing version", m.ID)
+	}
+
+	switch m.Type {
+	case PluginTypeTrigger, PluginTypeAction, PluginTypeOutput:
+	default:
+		return fmt.Errorf("plugin manifest %s has unsupported type %q", m.ID, m.Type)
+	}
+
+	if m.Runtime.EntryPoint == "" {
+		return fmt.Errorf("plugin manifest %s is missing runtime.entry_point", m.ID)
+	}
+
+	switch m.Runtime.Protocol {
+	case "", ProtocolStream:
+		if m.Type != PluginTypeTrigger && m.Runtime.Protocol == ProtocolStream {
+			return fmt.Errorf("plugin manifest %s: the stream protocol is only supported for trigger plugins", m.ID)
+		}
+	case ProtocolRPC:
+	default:
+		return fmt.Errorf("plugin manifest %s has unsupported protocol %q", m.ID, m.Runtime.Protocol)
+	}
+
+	switch m.Runtime.Transport {
+	case "", TransportStdio, TransportUnix:
+	default:
+		return fmt.Errorf("plugin manifest %s has unsupported transport %q", m.ID, m.Runtime.Transport)
+	}
+
+	switch m.Risk {
+	case "", RiskLow, RiskMedium, RiskHigh:
+	default:
+		return fmt.Errorf("plugin manifest %s has unsupported risk %q", m.ID, m.Risk)
+	}
+
+	return nil
+}
+
+// Info returns the plugin metadata described by the manifest
+func (m *Manifest) Info() *Info {
+	name := m.Name
+	if name == "" {
+		name = m.ID
+	}
+
+	metadata := map[string]string{"runtime": string(m.Runtime.Type)}
+	if m.Risk != "" {
+		metadata["risk"] = m.Risk
+	}
+	if m.SupportsDryRun {
+		metadata["supports_dry_run"] = "true"
+	}
+
+	return &Info{
+		ID:          m.ID,
+		Name:        name,
+		Description: m.Description,
+		Version:     m.Version,
+		Author:      m.Author,
+		License:     m.License,
+		Homepage:    m.Homepage,
+		Repository:  m.Repository,
+		Tags:        m.Tags,
+		Categories:  m.Categories,
+		Type:        m.Type,
+		Metadata:    metadata,
+	}
+}
+
+// Schema returns the configuration schema described by the manifest
+func (m *Manifest) Schema() (map[string]*ConfigField, []string) {
+	schema := make(map[string]*ConfigField, len(m.Configuration))
+	var required []string
+
+	for name, field := range m.Configuration {
+		if field == nil {
+			continue
+		}
+		schema[name] = &ConfigField{
+			Type:        field.Type,
+			Description: field.Description,
+			Default:     field.Default,
+			Required:    field.Required,
+			Enum:        field.Enum,
+			Pattern:     field.Pattern,
+			Minimum:     field.Minimum,
+			Maximum:     field.Maximum,
+			MinLength:   field.MinLength,
+			MaxLength:   field.MaxLength,
+		}
+		if field.Required {
+			required = append(required, name)
+		}
+	}
+
+	return schema, required
+}
+
+// Defaults returns the default configuration described by the manifest
+func (m *Manifest) Defaults() map[string]interface{} {
+	defaults := make(map[string]interface{}, len(m.Configuration))
+	for name, field := range m.Configuration {
+		if field != nil && field.Default != nil {
+			defaults[name] = field.Default
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package plugin
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess stops the plugin process; Windows has no SIGTERM
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcess kills the plugin process
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
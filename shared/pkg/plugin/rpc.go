@@ -0,0 +1,379 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Out-of-process plugins talk to the agent using JSON-RPC 2.0, one message
+// per line, over the plugin's stdin/stdout or a unix socket. Either side may
+// send requests. The agent opens with a handshake in which both sides state
+// the protocol versions they support.
+const (
+	RPCProtocolVersion    = 1
+	RPCMinProtocolVersion = 1
+)
+
+// Methods served by the plugin
+const (
+	RPCMethodHandshake     = "handshake"
+	RPCMethodInitialize    = "initialize"
+	RPCMethodStart         = "start"
+	RPCMethodStop          = "stop"
+	RPCMethodStatus        = "status"
+	RPCMethodHealth        = "health"
+	RPCMethodExecuteAction = "execute_action"
+	RPCMethodSendOutput    = "send_output"
+	RPCMethodShutdown      = "shutdown"
+)
+
+// Methods served by the agent
+const (
+	RPCMethodTriggerEvent = "trigger_event"
+	RPCMethodLog          = "log"
+	RPCMethodPing         = "ping"
+)
+
+// JSON-RPC error codes
+const (
+	RPCErrParse          = -32700
+	RPCErrInvalidRequest = -32600
+	RPCErrMethodNotFound = -32601
+	RPCErrInvalidParams  = -32602
+	RPCErrInternal       = -32603
+	RPCErrIncompatible   = -32000
+)
+
+// ErrRPCClosed is returned for calls on a closed connection
+var ErrRPCClosed = errors.New("plugin connection closed")
+
+// RPCError is a JSON-RPC error
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// RPCHandshakeRequest is sent by the agent to open a session
+type RPCHandshakeRequest struct {
+	ProtocolVersion    int    `json:"protocol_version"`
+	MinProtocolVersion int    `json:"min_protocol_version"`
+	PluginID           string `json:"plugin_id"`
+}
+
+// RPCHandshakeResponse describes the plugin and the protocol version it speaks
+type RPCHandshakeResponse struct {
+	ProtocolVersion int            `json:"protocol_version"`
+	Info            *Info          `json:"info"`
+	TriggerConfig   *TriggerConfig `json:"trigger_config,omitempty"`
+	ActionConfig    *ActionConfig  `json:"action_config,omitempty"`
+	OutputConfig    *OutputConfig  `json:"output_config,omitempty"`
+}
+
+// RPCInitializeParams carries the plugin configuration
+type RPCInitializeParams struct {
+	Config map[string]interface{} `json:"config"`
+}
+
+// RPCStatusResult carries the plugin status
+type RPCStatusResult struct {
+	Status Status `json:"status"`
+}
+
+// RPCLogParams is a log line sent by the plugin
+type RPCLogParams struct {
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// CompatibleRPCVersion reports whether a peer's protocol version can be used
+func CompatibleRPCVersion(version int) bool {
+	return version >= RPCMinProtocolVersion && version <= RPCProtocolVersion
+}
+
+// RPCHandler serves requests and notifications received from the peer. The
+// result is ignored for notifications.
+type RPCHandler func(ctx context.Context, method string, params json.RawMessage) (interface{}, error)
+
+// rpcMessage is a JSON-RPC request, notification or response
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCConn is a bidirectional JSON-RPC connection. Notifications are handled
+// in the order they arrive; requests are handled concurrently.
+type RPCConn struct {
+	reader  *bufio.Reader
+	writer  io.Writer
+	closer  io.Closer
+	handler RPCHandler
+	logger  *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *rpcMessage
+	done    chan struct{}
+	err     error
+}
+
+// NewRPCConn starts serving a connection. closer, if set, is closed along
+// with the connection.
+func NewRPCConn(r io.Reader, w io.Writer, closer io.Closer, handler RPCHandler, logger *zap.Logger) *RPCConn {
+	c := newRPCConn(r, w, closer, handler, logger)
+	go c.readLoop()
+	return c
+}
+
+// newRPCConn creates a connection that has not started reading yet
+func newRPCConn(r io.Reader, w io.Writer, closer io.Closer, handler RPCHandler, logger *zap.Logger) *RPCConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RPCConn{
+		reader:  bufio.NewReaderSize(r, 64*1024),
+		writer:  w,
+		closer:  closer,
+		handler: handler,
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[int64]chan *rpcMessage),
+		done:    make(chan struct{}),
+	}
+}
+
+// Call sends a request and decodes the result into result, if not nil
+func (c *RPCConn) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *rpcMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(json.RawMessage(strconv.FormatInt(id, 10)), method, params); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.Err()
+	}
+}
+
+// Notify sends a notification, which has no response
+func (c *RPCConn) Notify(method string, params interface{}) error {
+	return c.send(nil, method, params)
+}
+
+// Done is closed when the connection is closed
+func (c *RPCConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection was closed
+func (c *RPCConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close closes the connection
+func (c *RPCConn) Close() error {
+	c.shutdown(ErrRPCClosed)
+	return nil
+}
+
+// send writes a request or notification
+func (c *RPCConn) send(id json.RawMessage, method string, params interface{}) error {
+	msg := &rpcMessage{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		msg.Params = data
+	}
+	return c.write(msg)
+}
+
+// write writes a message as a single line
+func (c *RPCConn) write(msg *rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.Err()
+	default:
+	}
+
+	if _, err := c.writer.Write(append(data, '\n')); err != nil {
+		c.shutdown(fmt.Errorf("failed to write message: %w", err))
+		return c.Err()
+	}
+	return nil
+}
+
+// readLoop reads messages until the connection fails
+func (c *RPCConn) readLoop() {
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = ErrRPCClosed
+			}
+			c.shutdown(err)
+			return
+		}
+	}
+}
+
+// dispatch routes a received message
+func (c *RPCConn) dispatch(line []byte) {
+	var msg rpcMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.logger.Warn("Ignoring malformed plugin message", zap.Error(err))
+		return
+	}
+
+	// Responses to our requests
+	if msg.Method == "" {
+		id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+		if err != nil {
+			c.logger.Warn("Ignoring response with unknown id", zap.String("id", string(msg.ID)))
+			return
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+		return
+	}
+
+	if len(msg.ID) == 0 {
+		c.handle(&msg)
+		return
+	}
+	go c.handle(&msg)
+}
+
+// handle serves a request or notification from the peer
+func (c *RPCConn) handle(msg *rpcMessage) {
+	var result interface{}
+	var err error
+	if c.handler == nil {
+		err = &RPCError{Code: RPCErrMethodNotFound, Message: "method not found: " + msg.Method}
+	} else {
+		result, err = c.handler(c.ctx, msg.Method, msg.Params)
+	}
+
+	// Notifications get no response
+	if len(msg.ID) == 0 {
+		if err != nil {
+			c.logger.Debug("Failed to handle notification", zap.String("method", msg.Method), zap.Error(err))
+		}
+		return
+	}
+
+	resp := &rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: RPCErrInternal, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			resp.Error = &RPCError{Code: RPCErrInternal, Message: marshalErr.Error()}
+		} else {
+			resp.Result = data
+		}
+	}
+
+	if err := c.write(resp); err != nil {
+		c.logger.Debug("Failed to send response", zap.String("method", msg.Method), zap.Error(err))
+	}
+}
+
+// shutdown closes the connection once, recording the reason
+func (c *RPCConn) shutdown(reason error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = reason
+	close(c.done)
+	c.mu.Unlock()
+
+	c.cancel()
+	if c.closer != nil {
+		c.closer.Close()
+	}
+}
+
+// decodeParams decodes request parameters, reporting invalid params to the peer
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &RPCError{Code: RPCErrInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,709 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EnvPluginSocket carries the unix socket path to RPC plugins using the unix transport
+const EnvPluginSocket = "STAVILY_PLUGIN_SOCKET"
+
+// RPCProxyOptions controls how an RPC plugin process is hosted
+type RPCProxyOptions struct {
+	// Timeout for the handshake and lifecycle calls
+	CallTimeout time.Duration
+	// Timeout for status and health queries
+	QueryTimeout time.Duration
+	// Time a process is given to exit after shutdown before it is killed
+	StopTimeout time.Duration
+	// Directory unix sockets are created in, the system temp directory when empty
+	SocketDir string
+	// Events buffered between the process and the consumer
+	EventBuffer int
+	// Extra environment variables for the process
+	Environment map[string]string
+}
+
+// RPCProxy hosts an installed plugin that speaks the JSON-RPC plugin protocol
+// and exposes it through the Plugin, TriggerPlugin, ActionPlugin and
+// OutputPlugin interfaces. The process is launched on first use and
+// configured again whenever it has to be relaunched.
+type RPCProxy struct {
+	manifest *Manifest
+	dir      string
+	opts     RPCProxyOptions
+	logger   *zap.Logger
+
+	// events stays open across process restarts so consumers keep receiving
+	events chan *TriggerEvent
+
+	// command builds the plugin process, replaced in tests
+	command func() (*exec.Cmd, error)
+
+	// connectMu serializes launching and stopping the process, so mu is
+	// only held briefly and status queries never wait on a launch
+	connectMu sync.Mutex
+
+	mu            sync.Mutex
+	config        map[string]interface{}
+	status        Status
+	session       *rpcSession
+	handshake     *RPCHandshakeResponse
+	lastError     string
+	restarts      int
+	droppedEvents int
+}
+
+// rpcSession is a running plugin process and its connection
+type rpcSession struct {
+	cmd       *exec.Cmd
+	conn      *RPCConn
+	exited    chan struct{}
+	startedAt time.Time
+	stopping  bool
+}
+
+// NewRPCProxy creates a proxy for the RPC plugin installed in dir
+func NewRPCProxy(dir string, manifest *Manifest, opts RPCProxyOptions, logger *zap.Logger) (*RPCProxy, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("plugin manifest is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if manifest.Runtime.Protocol != ProtocolRPC {
+		return nil, fmt.Errorf("plugin %s does not use the rpc protocol", manifest.ID)
+	}
+	if _, _, err := SubprocessCommand(manifest, dir); err != nil {
+		return nil, err
+	}
+
+	if opts.CallTimeout <= 0 {
+		opts.CallTimeout = 30 * time.Second
+	}
+	if opts.QueryTimeout <= 0 {
+		opts.QueryTimeout = 5 * time.Second
+	}
+	if opts.StopTimeout <= 0 {
+		opts.StopTimeout = 10 * time.Second
+	}
+	if opts.SocketDir == "" {
+		opts.SocketDir = os.TempDir()
+	}
+	if opts.EventBuffer <= 0 {
+		opts.EventBuffer = 100
+	}
+
+	p := &RPCProxy{
+		manifest: manifest,
+		dir:      dir,
+		opts:     opts,
+		logger:   logger.With(zap.String("plugin_id", manifest.ID)),
+		events:   make(chan *TriggerEvent, opts.EventBuffer),
+		config:   manifest.Defaults(),
+		status:   StatusStopped,
+	}
+	p.command = p.defaultCommand
+
+	return p, nil
+}
+
+// GetInfo returns plugin metadata, as reported by the plugin once connected
+func (p *RPCProxy) GetInfo() *Info {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.handshake != nil && p.handshake.Info != nil {
+		return p.handshake.Info
+	}
+	return p.manifest.Info()
+}
+
+// Initialize configures the plugin on top of the manifest defaults, launching
+// the process if needed
+func (p *RPCProxy) Initialize(ctx context.Context, config map[string]interface{}) error {
+	merged := p.manifest.Defaults()
+	for k, v := range config {
+		merged[k] = v
+	}
+
+	p.mu.Lock()
+	p.config = merged
+	p.mu.Unlock()
+
+	session, fresh, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		// connect already sent the configuration
+		return nil
+	}
+
+	return p.call(ctx, session, RPCMethodInitialize, &RPCInitializeParams{Config: merged}, nil)
+}
+
+// Start starts the plugin, launching the process if needed
+func (p *RPCProxy) Start(ctx context.Context) error {
+	session, _, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.status = StatusStarting
+	p.mu.Unlock()
+
+	if err := p.call(ctx, session, RPCMethodStart, nil, nil); err != nil {
+		p.setError(err)
+		return fmt.Errorf("failed to start plugin %s: %w", p.manifest.ID, err)
+	}
+
+	p.mu.Lock()
+	p.status = StatusRunning
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Stop stops the plugin and shuts its process down
+func (p *RPCProxy) Stop(ctx context.Context) error {
+	p.connectMu.Lock()
+	defer p.connectMu.Unlock()
+
+	p.mu.Lock()
+	session := p.session
+	if session == nil {
+		p.status = StatusStopped
+		p.mu.Unlock()
+		return nil
+	}
+	session.stopping = true
+	p.status = StatusStopping
+	p.mu.Unlock()
+
+	var stopErr error
+	if err := p.call(ctx, session, RPCMethodStop, nil, nil); err != nil && err != ErrRPCClosed {
+		stopErr = fmt.Errorf("failed to stop plugin %s: %w", p.manifest.ID, err)
+	}
+	p.shutdown(ctx, session)
+
+	p.mu.Lock()
+	if p.session == session {
+		p.session = nil
+	}
+	p.status = StatusStopped
+	p.mu.Unlock()
+
+	return stopErr
+}
+
+// GetStatus returns the plugin status as reported by the process
+func (p *RPCProxy) GetStatus() Status {
+	p.mu.Lock()
+	session := p.session
+	status := p.status
+	p.mu.Unlock()
+
+	if session == nil || status != StatusRunning {
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.QueryTimeout)
+	defer cancel()
+
+	var result RPCStatusResult
+	if err := session.conn.Call(ctx, RPCMethodStatus, nil, &result); err != nil {
+		p.logger.Debug("Failed to query plugin status", zap.Error(err))
+		return StatusError
+	}
+	return result.Status
+}
+
+// GetHealth returns the plugin health as reported by the process
+func (p *RPCProxy) GetHealth() *Health {
+	p.mu.Lock()
+	session := p.session
+	status := p.status
+	lastError := p.lastError
+	restarts := p.restarts
+	dropped := p.droppedEvents
+	p.mu.Unlock()
+
+	metrics := map[string]interface{}{
+		"restarts":       restarts,
+		"dropped_events": dropped,
+	}
+
+	if session == nil {
+		health := &Health{
+			Status:     HealthStatusUnknown,
+			Message:    "plugin is not running",
+			LastCheck:  time.Now(),
+			LastError:  lastError,
+			ErrorCount: restarts,
+			Metrics:    metrics,
+		}
+		if status == StatusError {
+			health.Status = HealthStatusUnhealthy
+			health.Message = "plugin process exited"
+		}
+		return health
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.QueryTimeout)
+	defer cancel()
+
+	var health Health
+	if err := session.conn.Call(ctx, RPCMethodHealth, nil, &health); err != nil {
+		return &Health{
+			Status:     HealthStatusUnhealthy,
+			Message:    "plugin did not answer health check",
+			LastCheck:  time.Now(),
+			LastError:  err.Error(),
+			ErrorCount: restarts,
+			Metrics:    metrics,
+		}
+	}
+
+	if health.Metrics == nil {
+		health.Metrics = make(map[string]interface{})
+	}
+	health.Metrics["pid"] = session.cmd.Process.Pid
+	health.Metrics["restarts"] = restarts
+	health.Metrics["dropped_events"] = dropped
+	health.Uptime = time.Since(session.startedAt)
+	if health.LastCheck.IsZero() {
+		health.LastCheck = time.Now()
+	}
+
+	return &health
+}
+
+// DetectTriggers returns the channel of events sent by the plugin
+func (p *RPCProxy) DetectTriggers(ctx context.Context) (<-chan *TriggerEvent, error) {
+	if p.manifest.Type != PluginTypeTrigger {
+		return nil, fmt.Errorf("plugin %s is not a trigger plugin", p.manifest.ID)
+	}
+	return p.events, nil
+}
+
+// GetTriggerConfig returns the trigger configuration reported by the plugin,
+// falling back to the manifest schema
+func (p *RPCProxy) GetTriggerConfig() *TriggerConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.handshake != nil && p.handshake.TriggerConfig != nil {
+		return p.handshake.TriggerConfig
+	}
+	schema, required := p.manifest.Schema()
+	return &TriggerConfig{Schema: schema, Required: required, Description: p.manifest.Description}
+}
+
+// ExecuteAction runs an action in the plugin process
+func (p *RPCProxy) ExecuteAction(ctx context.Context, request *ActionRequest) (*ActionResult, error) {
+	if request == nil {
+		return nil, fmt.Errorf("action request is required")
+	}
+	if refused := refuseDryRun(p.manifest, request); refused != nil {
+		p.logger.Warn("Refusing dry run of plugin that does not support dry runs",
+			zap.String("action_id", request.ID))
+		return refused, nil
+	}
+
+	session, _, err := p.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+		defer cancel()
+	}
+
+	var result ActionResult
+	if err := session.conn.Call(ctx, RPCMethodExecuteAction, request, &result); err != nil {
+		return nil, fmt.Errorf("failed to execute action in plugin %s: %w", p.manifest.ID, err)
+	}
+	return &result, nil
+}
+
+// GetActionConfig returns the action configuration reported by the plugin,
+// falling back to the manifest schema
+func (p *RPCProxy) GetActionConfig() *ActionConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.handshake != nil && p.handshake.ActionConfig != nil {
+		return p.handshake.ActionConfig
+	}
+	schema, required := p.manifest.Schema()
+	return &ActionConfig{Schema: schema, Required: required, Description: p.manifest.Description}
+}
+
+// SendOutput sends output data through the plugin process
+func (p *RPCProxy) SendOutput(ctx context.Context, data *OutputData) error {
+	if data == nil {
+		return fmt.Errorf("output data is required")
+	}
+
+	session, _, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := session.conn.Call(ctx, RPCMethodSendOutput, data, nil); err != nil {
+		return fmt.Errorf("failed to send output through plugin %s: %w", p.manifest.ID, err)
+	}
+	return nil
+}
+
+// GetOutputConfig returns the output configuration reported by the plugin,
+// falling back to the manifest schema
+func (p *RPCProxy) GetOutputConfig() *OutputConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.handshake != nil && p.handshake.OutputConfig != nil {
+		return p.handshake.OutputConfig
+	}
+	schema, required := p.manifest.Schema()
+	return &OutputConfig{Schema: schema, Required: required, Description: p.manifest.Description}
+}
+
+// connect returns the running session, launching and configuring a new
+// process when there is none. fresh reports whether a process was launched.
+func (p *RPCProxy) connect(ctx context.Context) (*rpcSession, bool, error) {
+	p.connectMu.Lock()
+	defer p.connectMu.Unlock()
+
+	p.mu.Lock()
+	current := p.session
+	config := p.config
+	p.mu.Unlock()
+
+	if current != nil {
+		select {
+		case <-current.exited:
+		default:
+			return current, false, nil
+		}
+	}
+
+	session, err := p.launch(ctx)
+	if err != nil {
+		p.setError(err)
+		return nil, false, err
+	}
+
+	p.mu.Lock()
+	if p.handshake != nil {
+		p.restarts++
+	}
+	p.mu.Unlock()
+
+	var handshake RPCHandshakeResponse
+	err = p.call(ctx, session, RPCMethodHandshake, &RPCHandshakeRequest{
+		ProtocolVersion:    RPCProtocolVersion,
+		MinProtocolVersion: RPCMinProtocolVersion,
+		PluginID:           p.manifest.ID,
+	}, &handshake)
+	if err == nil {
+		err = p.checkHandshake(&handshake)
+	}
+	if err == nil {
+		err = p.call(ctx, session, RPCMethodInitialize, &RPCInitializeParams{Config: config}, nil)
+	}
+	if err != nil {
+		p.mu.Lock()
+		session.stopping = true
+		p.mu.Unlock()
+		p.shutdown(ctx, session)
+		p.setError(err)
+		return nil, false, fmt.Errorf("failed to connect to plugin %s: %w", p.manifest.ID, err)
+	}
+
+	p.mu.Lock()
+	p.session = session
+	p.handshake = &handshake
+	p.status = StatusStopped
+	p.mu.Unlock()
+
+	p.logger.Info("RPC plugin connected",
+		zap.Int("pid", session.cmd.Process.Pid),
+		zap.Int("protocol_version", handshake.ProtocolVersion))
+
+	return session, true, nil
+}
+
+// checkHandshake verifies the plugin speaks a supported protocol and is the
+// plugin the manifest describes
+func (p *RPCProxy) checkHandshake(resp *RPCHandshakeResponse) error {
+	if !CompatibleRPCVersion(resp.ProtocolVersion) {
+		return fmt.Errorf("plugin protocol version %d not supported, agent supports %d-%d",
+			resp.ProtocolVersion, RPCMinProtocolVersion, RPCProtocolVersion)
+	}
+	if resp.Info == nil {
+		return fmt.Errorf("plugin did not report its info")
+	}
+	if resp.Info.ID != p.manifest.ID {
+		return fmt.Errorf("plugin reported id %q, manifest declares %q", resp.Info.ID, p.manifest.ID)
+	}
+	return nil
+}
+
+// launch starts the plugin process and opens its connection
+func (p *RPCProxy) launch(ctx context.Context) (*rpcSession, error) {
+	cmd, err := p.command()
+	if err != nil {
+		return nil, err
+	}
+
+	setProcessGroup(cmd)
+	cmd.Env = append(cmd.Env,
+		EnvPluginID+"="+p.manifest.ID,
+		EnvPluginType+"="+string(p.manifest.Type),
+		EnvPluginMode+"="+ProtocolRPC)
+	for k, v := range p.opts.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stderr: %w", err)
+	}
+
+	session := &rpcSession{cmd: cmd, exited: make(chan struct{})}
+
+	switch p.manifest.Runtime.Transport {
+	case TransportUnix:
+		err = p.launchUnix(ctx, session)
+	default:
+		err = p.launchStdio(session)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		logPluginOutput(stderr, p.logger)
+	}()
+	go p.wait(session, stderrDone)
+
+	return session, nil
+}
+
+// launchStdio starts the process speaking the protocol over stdin and stdout
+func (p *RPCProxy) launchStdio(session *rpcSession) error {
+	cmd := session.cmd
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin process: %w", err)
+	}
+	session.startedAt = time.Now()
+
+	// Closing stdin tells the plugin the agent has gone away
+	session.conn = NewRPCConn(stdout, stdin, stdin, p.handle, p.logger)
+	return nil
+}
+
+// launchUnix starts the process and waits for it to connect to a unix socket
+func (p *RPCProxy) launchUnix(ctx context.Context, session *rpcSession) error {
+	cmd := session.cmd
+
+	path := filepath.Join(p.opts.SocketDir, fmt.Sprintf("stavily-%s-%d.sock", p.manifest.ID, time.Now().UnixNano()))
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on plugin socket: %w", err)
+	}
+	defer os.Remove(path)
+	defer listener.Close()
+
+	cmd.Env = append(cmd.Env, EnvPluginSocket+"="+path)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin process: %w", err)
+	}
+	session.startedAt = time.Now()
+
+	deadline := time.Now().Add(p.opts.CallTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	listener.(*net.UnixListener).SetDeadline(deadline)
+
+	conn, err := listener.Accept()
+	if err != nil {
+		_ = killProcess(cmd)
+		_ = cmd.Wait()
+		return fmt.Errorf("plugin did not connect to %s: %w", path, err)
+	}
+
+	session.conn = NewRPCConn(conn, conn, conn, p.handle, p.logger)
+	return nil
+}
+
+// wait reaps the process and records unexpected exits
+func (p *RPCProxy) wait(session *rpcSession, stderrDone <-chan struct{}) {
+	// Wait closes the pipes, so let the readers drain them first
+	<-stderrDone
+	<-session.conn.Done()
+	err := session.cmd.Wait()
+	close(session.exited)
+	session.conn.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if session.stopping {
+		return
+	}
+
+	if err == nil {
+		err = fmt.Errorf("process exited with code %d", session.cmd.ProcessState.ExitCode())
+	}
+	p.status = StatusError
+	p.lastError = err.Error()
+	if p.session == session {
+		p.session = nil
+	}
+
+	p.logger.Warn("RPC plugin process exited unexpectedly", zap.Error(err))
+}
+
+// shutdown asks the process to exit and kills it if it does not
+func (p *RPCProxy) shutdown(ctx context.Context, session *rpcSession) {
+	callCtx, cancel := context.WithTimeout(ctx, p.opts.QueryTimeout)
+	_ = session.conn.Call(callCtx, RPCMethodShutdown, nil, nil)
+	cancel()
+	session.conn.Close()
+
+	select {
+	case <-session.exited:
+		return
+	case <-time.After(p.opts.StopTimeout):
+	case <-ctx.Done():
+	}
+
+	p.logger.Warn("RPC plugin process did not exit, killing it")
+	_ = terminateProcess(session.cmd)
+	select {
+	case <-session.exited:
+	case <-time.After(time.Second):
+		_ = killProcess(session.cmd)
+	}
+}
+
+// call makes a lifecycle call bounded by CallTimeout
+func (p *RPCProxy) call(ctx context.Context, session *rpcSession, method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.CallTimeout)
+	defer cancel()
+	return session.conn.Call(ctx, method, params, result)
+}
+
+// setError records a failed call
+func (p *RPCProxy) setError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = StatusError
+	p.lastError = err.Error()
+}
+
+// handle serves requests the plugin sends to the agent
+func (p *RPCProxy) handle(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case RPCMethodTriggerEvent:
+		var event TriggerEvent
+		if err := decodeParams(params, &event); err != nil {
+			return nil, err
+		}
+		normalizeTriggerEvent(&event, p.manifest.ID)
+
+		// Events arrive on the connection's read loop, so waiting for a slow
+		// or departed consumer would hold up responses to lifecycle calls
+		select {
+		case p.events <- &event:
+		default:
+			p.mu.Lock()
+			p.droppedEvents++
+			dropped := p.droppedEvents
+			p.mu.Unlock()
+			p.logger.Debug("Event buffer full, dropped trigger event",
+				zap.String("event_id", event.ID),
+				zap.Int("dropped_events", dropped))
+		}
+		return nil, nil
+
+	case RPCMethodLog:
+		var entry RPCLogParams
+		if err := decodeParams(params, &entry); err != nil {
+			return nil, err
+		}
+		fields := make([]zap.Field, 0, len(entry.Fields))
+		for k, v := range entry.Fields {
+			fields = append(fields, zap.Any(k, v))
+		}
+		switch entry.Level {
+		case "debug":
+			p.logger.Debug(entry.Message, fields...)
+		case "warn", "warning":
+			p.logger.Warn(entry.Message, fields...)
+		case "error":
+			p.logger.Error(entry.Message, fields...)
+		default:
+			p.logger.Info(entry.Message, fields...)
+		}
+		return nil, nil
+
+	case RPCMethodPing:
+		return "pong", nil
+	}
+
+	return nil, &RPCError{Code: RPCErrMethodNotFound, Message: "method not found: " + method}
+}
+
+// defaultCommand builds the plugin process from its manifest
+func (p *RPCProxy) defaultCommand() (*exec.Cmd, error) {
+	name, args, err := SubprocessCommand(p.manifest, p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = p.dir
+	cmd.Env = os.Environ()
+	return cmd, nil
+}
+
+// logPluginOutput forwards a plugin's stderr to the agent log
+func logPluginOutput(stderr io.Reader, logger *zap.Logger) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		logger.Info("Plugin output", zap.String("stderr", scanner.Text()))
+	}
+	io.Copy(io.Discard, stderr)
+}
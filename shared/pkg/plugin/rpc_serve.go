@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ServeRPC serves a plugin implementation to the agent over r and w, usually
+// the process's stdin and stdout. It is the plugin side of the protocol and
+// returns when the agent sends shutdown, closes the connection or ctx is
+// cancelled. Trigger plugins have their events forwarded to the agent while
+// they are running.
+func ServeRPC(ctx context.Context, p Plugin, r io.Reader, w io.Writer, logger *zap.Logger) error {
+	if p == nil {
+		return fmt.Errorf("plugin is required")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	s := &rpcServer{plugin: p, logger: logger, shutdown: make(chan struct{})}
+	// The handlers use s.conn, so it is set before any request is read
+	s.conn = newRPCConn(r, w, nil, s.handle, logger)
+	defer s.conn.Close()
+	go s.conn.readLoop()
+
+	select {
+	case <-ctx.Done():
+		s.stopForwarding()
+		return ctx.Err()
+	case <-s.shutdown:
+		s.stopForwarding()
+		// Give the response time to reach the agent, which closes the
+		// connection once it has it
+		select {
+		case <-s.conn.Done():
+		case <-time.After(time.Second):
+		}
+		return nil
+	case <-s.conn.Done():
+		s.stopForwarding()
+		if err := s.conn.Err(); err != ErrRPCClosed {
+			return err
+		}
+		return nil
+	}
+}
+
+// rpcServer dispatches agent requests to a plugin implementation
+type rpcServer struct {
+	plugin Plugin
+	conn   *RPCConn
+	logger *zap.Logger
+
+	mu           sync.Mutex
+	stopForward  context.CancelFunc
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func (s *rpcServer) handle(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case RPCMethodHandshake:
+		var req RPCHandshakeRequest
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		if req.ProtocolVersion < RPCMinProtocolVersion || req.MinProtocolVersion > RPCProtocolVersion {
+			return nil, &RPCError{
+				Code:    RPCErrIncompatible,
+				Message: fmt.Sprintf("agent protocol versions %d-%d not supported, plugin supports %d-%d", req.MinProtocolVersion, req.ProtocolVersion, RPCMinProtocolVersion, RPCProtocolVersion),
+			}
+		}
+
+		version := RPCProtocolVersion
+		if req.ProtocolVersion < version {
+			version = req.ProtocolVersion
+		}
+
+		resp := &RPCHandshakeResponse{ProtocolVersion: version, Info: s.plugin.GetInfo()}
+		if tp, ok := s.plugin.(TriggerPlugin); ok {
+			resp.TriggerConfig = tp.GetTriggerConfig()
+		}
+		if ap, ok := s.plugin.(ActionPlugin); ok {
+			resp.ActionConfig = ap.GetActionConfig()
+		}
+		if op, ok := s.plugin.(OutputPlugin); ok {
+			resp.OutputConfig = op.GetOutputConfig()
+		}
+		return resp, nil
+
+	case RPCMethodInitialize:
+		var req RPCInitializeParams
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		return nil, s.plugin.Initialize(ctx, req.Config)
+
+	case RPCMethodStart:
+		if err := s.plugin.Start(ctx); err != nil {
+			return nil, err
+		}
+		if tp, ok := s.plugin.(TriggerPlugin); ok {
+			return nil, s.forwardTriggers(tp)
+		}
+		return nil, nil
+
+	case RPCMethodStop:
+		s.stopForwarding()
+		return nil, s.plugin.Stop(ctx)
+
+	case RPCMethodStatus:
+		return &RPCStatusResult{Status: s.plugin.GetStatus()}, nil
+
+	case RPCMethodHealth:
+		return s.plugin.GetHealth(), nil
+
+	case RPCMethodExecuteAction:
+		ap, ok := s.plugin.(ActionPlugin)
+		if !ok {
+			return nil, &RPCError{Code: RPCErrMethodNotFound, Message: "plugin does not execute actions"}
+		}
+		var req ActionRequest
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		return ap.ExecuteAction(ctx, &req)
+
+	case RPCMethodSendOutput:
+		op, ok := s.plugin.(OutputPlugin)
+		if !ok {
+			return nil, &RPCError{Code: RPCErrMethodNotFound, Message: "plugin does not send outputs"}
+		}
+		var req OutputData
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		return nil, op.SendOutput(ctx, &req)
+
+	case RPCMethodShutdown:
+		s.shutdownOnce.Do(func() { close(s.shutdown) })
+		return nil, nil
+
+	case RPCMethodPing:
+		return "pong", nil
+	}
+
+	return nil, &RPCError{Code: RPCErrMethodNotFound, Message: "method not found: " + method}
+}
+
+// forwardTriggers sends the plugin's trigger events to the agent until stopped
+func (s *rpcServer) forwardTriggers(tp TriggerPlugin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopForward != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := tp.DetectTriggers(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to detect triggers: %w", err)
+	}
+	s.stopForward = cancel
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := s.conn.Notify(RPCMethodTriggerEvent, event); err != nil {
+					s.logger.Warn("Failed to forward trigger event", zap.Error(err))
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stopForwarding stops forwarding trigger events
+func (s *rpcServer) stopForwarding() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopForward != nil {
+		s.stopForward()
+		s.stopForward = nil
+	}
+}
@@ -0,0 +1,279 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeRPCPlugin is a trigger and action plugin served over RPC in tests
+type fakeRPCPlugin struct {
+	mu     sync.Mutex
+	config map[string]interface{}
+	status Status
+	events chan *TriggerEvent
+
+	// Extra events sent after starting
+	burst int
+}
+
+func newFakeRPCPlugin() *fakeRPCPlugin {
+	return &fakeRPCPlugin{status: StatusStopped, events: make(chan *TriggerEvent, 10)}
+}
+
+func (f *fakeRPCPlugin) GetInfo() *Info {
+	return &Info{ID: "rpc-test", Name: "RPC Test", Version: "1.0.0", Type: PluginTypeTrigger}
+}
+
+func (f *fakeRPCPlugin) Initialize(ctx context.Context, config map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+	return nil
+}
+
+func (f *fakeRPCPlugin) Start(ctx context.Context) error {
+	f.mu.Lock()
+	f.status = StatusRunning
+	threshold := f.config["threshold"]
+	f.mu.Unlock()
+
+	f.events <- &TriggerEvent{Type: "cpu_high", Data: map[string]interface{}{"threshold": threshold}}
+	go func() {
+		for i := 0; i < f.burst; i++ {
+			f.events <- &TriggerEvent{Type: "cpu_high"}
+		}
+	}()
+	return nil
+}
+
+func (f *fakeRPCPlugin) Stop(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = StatusStopped
+	return nil
+}
+
+func (f *fakeRPCPlugin) GetStatus() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.status
+}
+
+func (f *fakeRPCPlugin) GetHealth() *Health {
+	return &Health{Status: HealthStatusHealthy, Message: "ok"}
+}
+
+func (f *fakeRPCPlugin) DetectTriggers(ctx context.Context) (<-chan *TriggerEvent, error) {
+	return f.events, nil
+}
+
+func (f *fakeRPCPlugin) GetTriggerConfig() *TriggerConfig {
+	return &TriggerConfig{Description: "from plugin"}
+}
+
+func (f *fakeRPCPlugin) ExecuteAction(ctx context.Context, action *ActionRequest) (*ActionResult, error) {
+	return &ActionResult{
+		ID:     action.ID,
+		Status: ActionStatusCompleted,
+		Data:   map[string]interface{}{"echo": action.Parameters["message"]},
+	}, nil
+}
+
+func (f *fakeRPCPlugin) GetActionConfig() *ActionConfig {
+	return &ActionConfig{}
+}
+
+// TestRPCHelperPlugin is not a real test: it serves the fake plugin when run
+// as a plugin process by the proxy tests
+func TestRPCHelperPlugin(t *testing.T) {
+	if os.Getenv("STAVILY_TEST_RPC_PLUGIN") != "1" {
+		return
+	}
+	fake := newFakeRPCPlugin()
+	fake.burst, _ = strconv.Atoi(os.Getenv("STAVILY_TEST_RPC_BURST"))
+	_ = ServeRPC(context.Background(), fake, os.Stdin, os.Stdout, zap.NewNop())
+	os.Exit(0)
+}
+
+func TestRPCConnServesPlugin(t *testing.T) {
+	agentR, pluginW := io.Pipe()
+	pluginR, agentW := io.Pipe()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeRPC(context.Background(), newFakeRPCPlugin(), pluginR, pluginW, zaptest.NewLogger(t))
+	}()
+
+	events := make(chan *TriggerEvent, 1)
+	conn := NewRPCConn(agentR, agentW, agentW, func(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+		var event TriggerEvent
+		if err := decodeParams(params, &event); err != nil {
+			return nil, err
+		}
+		events <- &event
+		return nil, nil
+	}, zaptest.NewLogger(t))
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var handshake RPCHandshakeResponse
+	require.NoError(t, conn.Call(ctx, RPCMethodHandshake, &RPCHandshakeRequest{ProtocolVersion: 1, MinProtocolVersion: 1}, &handshake))
+	assert.Equal(t, 1, handshake.ProtocolVersion)
+	assert.Equal(t, "rpc-test", handshake.Info.ID)
+	assert.Equal(t, "from plugin", handshake.TriggerConfig.Description)
+	assert.NotNil(t, handshake.ActionConfig)
+	assert.Nil(t, handshake.OutputConfig)
+
+	// A newer agent settles on the version the plugin speaks
+	require.NoError(t, conn.Call(ctx, RPCMethodHandshake, &RPCHandshakeRequest{ProtocolVersion: 5, MinProtocolVersion: 1}, &handshake))
+	assert.Equal(t, RPCProtocolVersion, handshake.ProtocolVersion)
+
+	err := conn.Call(ctx, RPCMethodHandshake, &RPCHandshakeRequest{ProtocolVersion: 7, MinProtocolVersion: 5}, nil)
+	var rpcErr *RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, RPCErrIncompatible, rpcErr.Code)
+
+	require.NoError(t, conn.Call(ctx, RPCMethodInitialize, &RPCInitializeParams{Config: map[string]interface{}{"threshold": 90}}, nil))
+	require.NoError(t, conn.Call(ctx, RPCMethodStart, nil, nil))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "cpu_high", event.Type)
+		assert.Equal(t, float64(90), event.Data["threshold"])
+	case <-ctx.Done():
+		t.Fatal("trigger event was not forwarded")
+	}
+
+	var result ActionResult
+	require.NoError(t, conn.Call(ctx, RPCMethodExecuteAction, &ActionRequest{ID: "a1", Parameters: map[string]interface{}{"message": "hi"}}, &result))
+	assert.Equal(t, ActionStatusCompleted, result.Status)
+	assert.Equal(t, "hi", result.Data["echo"])
+
+	err = conn.Call(ctx, RPCMethodSendOutput, &OutputData{}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, RPCErrMethodNotFound, rpcErr.Code)
+
+	err = conn.Call(ctx, "bogus", nil, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, RPCErrMethodNotFound, rpcErr.Code)
+
+	require.NoError(t, conn.Call(ctx, RPCMethodShutdown, nil, nil))
+	conn.Close()
+
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("plugin did not shut down")
+	}
+}
+
+// newTestRPCProxy creates a proxy running the fake plugin in a helper process
+func newTestRPCProxy(t *testing.T, opts RPCProxyOptions, env ...string) *RPCProxy {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(`
+plugin:
+  id: "rpc-test"
+  version: "1.0.0"
+  type: "trigger"
+  runtime:
+    type: "executable"
+    entry_point: "plugin"
+    protocol: "rpc"
+  configuration:
+    threshold:
+      type: "number"
+      default: 80
+`), 0644))
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+
+	p, err := NewRPCProxy(dir, manifest, opts, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	p.command = func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRPCHelperPlugin$")
+		cmd.Env = append(append(os.Environ(), "STAVILY_TEST_RPC_PLUGIN=1"), env...)
+		return cmd, nil
+	}
+	return p
+}
+
+func TestRPCProxyHostsPluginProcess(t *testing.T) {
+	p := newTestRPCProxy(t, RPCProxyOptions{StopTimeout: 2 * time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, p.Initialize(ctx, map[string]interface{}{"threshold": 95}))
+	assert.Equal(t, "RPC Test", p.GetInfo().Name)
+	assert.Equal(t, "from plugin", p.GetTriggerConfig().Description)
+
+	events, err := p.DetectTriggers(ctx)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(ctx))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "cpu_high", event.Type)
+		assert.Equal(t, "rpc-test", event.Source)
+		assert.Equal(t, float64(95), event.Data["threshold"])
+	case <-ctx.Done():
+		t.Fatal("no event received from plugin process")
+	}
+
+	assert.Equal(t, StatusRunning, p.GetStatus())
+	health := p.GetHealth()
+	assert.Equal(t, HealthStatusHealthy, health.Status)
+	assert.NotZero(t, health.Metrics["pid"])
+
+	result, err := p.ExecuteAction(ctx, &ActionRequest{ID: "a1", Parameters: map[string]interface{}{"message": "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", result.Data["echo"])
+
+	// The manifest does not declare dry run support
+	result, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a2", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusFailed, result.Status)
+	assert.Equal(t, "plugin rpc-test does not support dry runs", result.Error)
+
+	require.NoError(t, p.Stop(ctx))
+	assert.Equal(t, StatusStopped, p.GetStatus())
+	assert.Equal(t, HealthStatusUnknown, p.GetHealth().Status)
+}
+
+func TestRPCProxyDropsEventsForSlowConsumer(t *testing.T) {
+	p := newTestRPCProxy(t, RPCProxyOptions{EventBuffer: 1, QueryTimeout: 2 * time.Second, StopTimeout: 2 * time.Second},
+		"STAVILY_TEST_RPC_BURST=50")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, p.Initialize(ctx, nil))
+	require.NoError(t, p.Start(ctx))
+
+	// Nobody reads the events, yet the plugin keeps answering calls
+	require.Eventually(t, func() bool {
+		health := p.GetHealth()
+		return health.Status == HealthStatusHealthy && health.Metrics["dropped_events"].(int) >= 50
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, StatusRunning, p.GetStatus())
+
+	start := time.Now()
+	require.NoError(t, p.Stop(ctx))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Environment variables passed to subprocess plugins. EnvDryRun is set to
+// "true" when the plugin must only report the changes it would make.
+const (
+	EnvPluginID     = "STAVILY_PLUGIN_ID"
+	EnvPluginType   = "STAVILY_PLUGIN_TYPE"
+	EnvPluginConfig = "STAVILY_PLUGIN_CONFIG"
+	EnvPluginMode   = "STAVILY_PLUGIN_MODE"
+	EnvDryRun       = "STAVILY_DRY_RUN"
+)
+
+// checkDryRun returns an error when the plugin's manifest, nil if it has
+// none, does not declare support for dry runs, since running the plugin in
+// one would make real changes
+func checkDryRun(pluginID string, manifest *Manifest) error {
+	if manifest == nil || !manifest.SupportsDryRun {
+		return fmt.Errorf("plugin %s does not support dry runs", pluginID)
+	}
+	return nil
+}
+
+// refuseDryRun returns a failed result for a dry run the plugin does not
+// support, or nil when the action may run
+func refuseDryRun(manifest *Manifest, action *ActionRequest) *ActionResult {
+	if !action.DryRun {
+		return nil
+	}
+	err := checkDryRun(manifest.ID, manifest)
+	if err == nil {
+		return nil
+	}
+	now := time.Now()
+	return &ActionResult{
+		ID:          action.ID,
+		Status:      ActionStatusFailed,
+		Error:       err.Error(),
+		StartedAt:   now,
+		CompletedAt: now,
+		Metadata:    map[string]interface{}{"plugin_id": manifest.ID, "dry_run": true},
+	}
+}
+
+// SubprocessCommand returns the program and arguments that run the plugin
+// described by a manifest, installed in dir
+func SubprocessCommand(manifest *Manifest, dir string) (string, []string, error) {
+	entryPoint := manifest.Runtime.EntryPoint
+	clean := filepath.ToSlash(filepath.Clean(entryPoint))
+	if filepath.IsAbs(entryPoint) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", nil, fmt.Errorf("plugin entry point must be inside the plugin directory: %s", entryPoint)
+	}
+
+	runtime := manifest.Runtime.Type
+	if runtime == "" {
+		switch strings.ToLower(filepath.Ext(entryPoint)) {
+		case ".py":
+			runtime = RuntimePython
+		case ".js", ".mjs":
+			runtime = RuntimeNode
+		case ".sh":
+			runtime = RuntimeBash
+		default:
+			runtime = RuntimeExecutable
+		}
+	}
+
+	args := manifest.Runtime.Arguments
+	switch runtime {
+	case RuntimePython:
+		return "python3", append([]string{"-u", entryPoint}, args...), nil
+	case RuntimeNode:
+		return "node", append([]string{entryPoint}, args...), nil
+	case RuntimeBash:
+		return "bash", append([]string{entryPoint}, args...), nil
+	case RuntimeExecutable, RuntimeGo:
+		return filepath.Join(dir, entryPoint), append([]string(nil), args...), nil
+	default:
+		return "", nil, fmt.Errorf("runtime %s cannot run as a subprocess plugin", runtime)
+	}
+}
+
+// plannedChanges reads the planned_changes a plugin reported in its output.
+// Entries may be objects with action, target and detail, or plain strings.
+func plannedChanges(data map[string]interface{}) []PlannedChange {
+	entries, ok := data["planned_changes"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	changes := make([]PlannedChange, 0, len(entries))
+	for _, entry := range entries {
+		if text, ok := entry.(string); ok {
+			changes = append(changes, PlannedChange{Action: text})
+			continue
+		}
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		var change PlannedChange
+		if json.Unmarshal(raw, &change) == nil && change.Action != "" {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,285 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SubprocessActionOptions controls how a subprocess action plugin is run
+type SubprocessActionOptions struct {
+	// Timeout for actions that do not set their own; the manifest's
+	// execution_time limit takes precedence when set
+	Timeout time.Duration
+	// Extra environment variables for the process
+	Environment map[string]string
+}
+
+// SubprocessActionPlugin runs an installed action plugin once per action and
+// adapts it to the ActionPlugin interface. The process receives its
+// configuration as JSON in STAVILY_PLUGIN_CONFIG and the ActionRequest as JSON
+// on stdin. A JSON object written to stdout becomes the result data; a
+// non-zero exit fails the action.
+type SubprocessActionPlugin struct {
+	manifest *Manifest
+	dir      string
+	opts     SubprocessActionOptions
+	logger   *zap.Logger
+
+	mu         sync.Mutex
+	config     map[string]interface{}
+	status     Status
+	executions int
+	failures   int
+	lastError  string
+	lastRun    time.Time
+}
+
+// NewSubprocessActionPlugin creates an action plugin for the plugin installed in dir
+func NewSubprocessActionPlugin(dir string, manifest *Manifest, opts SubprocessActionOptions, logger *zap.Logger) (*SubprocessActionPlugin, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("plugin manifest is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if manifest.Type != PluginTypeAction {
+		return nil, fmt.Errorf("plugin %s is not an action plugin", manifest.ID)
+	}
+	if _, _, err := SubprocessCommand(manifest, dir); err != nil {
+		return nil, err
+	}
+
+	if manifest.Limits.ExecutionTime != "" {
+		timeout, err := time.ParseDuration(manifest.Limits.ExecutionTime)
+		if err != nil {
+			return nil, fmt.Errorf("plugin manifest %s has invalid execution_time: %w", manifest.ID, err)
+		}
+		opts.Timeout = timeout
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+
+	return &SubprocessActionPlugin{
+		manifest: manifest,
+		dir:      dir,
+		opts:     opts,
+		logger:   logger.With(zap.String("plugin_id", manifest.ID)),
+		config:   manifest.Defaults(),
+		status:   StatusStopped,
+	}, nil
+}
+
+// GetInfo returns plugin metadata
+func (p *SubprocessActionPlugin) GetInfo() *Info {
+	return p.manifest.Info()
+}
+
+// Initialize sets the plugin configuration on top of the manifest defaults,
+// used by the next action
+func (p *SubprocessActionPlugin) Initialize(ctx context.Context, config map[string]interface{}) error {
+	merged := p.manifest.Defaults()
+	for k, v := range config {
+		merged[k] = v
+	}
+
+	if _, err := json.Marshal(merged); err != nil {
+		return fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	p.mu.Lock()
+	p.config = merged
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Start marks the plugin ready to execute actions
+func (p *SubprocessActionPlugin) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = StatusRunning
+	return nil
+}
+
+// Stop marks the plugin stopped; actions already running are left to finish
+func (p *SubprocessActionPlugin) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = StatusStopped
+	return nil
+}
+
+// GetStatus returns the current plugin status
+func (p *SubprocessActionPlugin) GetStatus() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// GetHealth reports the outcome of recent actions
+func (p *SubprocessActionPlugin) GetHealth() *Health {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health := &Health{
+		Status:     HealthStatusHealthy,
+		Message:    "plugin is ready",
+		LastCheck:  time.Now(),
+		ErrorCount: p.failures,
+		LastError:  p.lastError,
+		Metrics: map[string]interface{}{
+			"executions": p.executions,
+			"failures":   p.failures,
+		},
+	}
+	if !p.lastRun.IsZero() {
+		health.Metrics["last_run"] = p.lastRun
+	}
+	if p.status != StatusRunning {
+		health.Status = HealthStatusUnknown
+		health.Message = "plugin is not running"
+	}
+
+	return health
+}
+
+// ExecuteAction runs the plugin process for one action
+func (p *SubprocessActionPlugin) ExecuteAction(ctx context.Context, action *ActionRequest) (*ActionResult, error) {
+	if action == nil {
+		return nil, fmt.Errorf("action request is required")
+	}
+
+	p.mu.Lock()
+	status := p.status
+	config, err := json.Marshal(p.config)
+	p.mu.Unlock()
+	if status != StatusRunning {
+		return nil, fmt.Errorf("plugin %s is not running", p.manifest.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+	if refused := refuseDryRun(p.manifest, action); refused != nil {
+		p.logger.Warn("Refusing dry run of plugin that does not support dry runs",
+			zap.String("action_id", action.ID))
+		return refused, nil
+	}
+
+	input, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode action request: %w", err)
+	}
+
+	timeout := p.opts.Timeout
+	if action.Timeout > 0 {
+		timeout = action.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name, args, err := SubprocessCommand(p.manifest, p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = p.dir
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcess(cmd) }
+	cmd.Env = append(os.Environ(),
+		EnvPluginID+"="+p.manifest.ID,
+		EnvPluginType+"="+string(PluginTypeAction),
+		EnvPluginMode+"=action",
+		EnvPluginConfig+"="+string(config))
+	for k, v := range p.opts.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	if action.DryRun {
+		cmd.Env = append(cmd.Env, EnvDryRun+"=true")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := &ActionResult{
+		ID:        action.ID,
+		StartedAt: time.Now(),
+		Metadata:  map[string]interface{}{"plugin_id": p.manifest.ID},
+	}
+
+	runErr := cmd.Run()
+	result.CompletedAt = time.Now()
+	result.Duration = result.CompletedAt.Sub(result.StartedAt)
+	result.Data = parseActionOutput(stdout.Bytes())
+	result.PlannedChanges = plannedChanges(result.Data)
+
+	if stderr.Len() > 0 {
+		p.logger.Info("Plugin output", zap.String("stderr", truncate(stderr.String(), 4096)))
+	}
+
+	switch {
+	case runErr == nil:
+		result.Status = ActionStatusCompleted
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Status = ActionStatusTimeout
+		result.Error = fmt.Sprintf("action timed out after %s", timeout)
+	case ctx.Err() != nil:
+		result.Status = ActionStatusCancelled
+		result.Error = "action was cancelled"
+	default:
+		result.Status = ActionStatusFailed
+		result.Error = strings.TrimSpace(truncate(stderr.String(), 1024))
+		if result.Error == "" {
+			result.Error = runErr.Error()
+		}
+	}
+	result.Metadata["exit_code"] = cmd.ProcessState.ExitCode()
+
+	p.mu.Lock()
+	p.executions++
+	p.lastRun = result.CompletedAt
+	if result.Status != ActionStatusCompleted {
+		p.failures++
+		p.lastError = result.Error
+	}
+	p.mu.Unlock()
+
+	return result, nil
+}
+
+// GetActionConfig returns the configuration schema from the manifest
+func (p *SubprocessActionPlugin) GetActionConfig() *ActionConfig {
+	schema, required := p.manifest.Schema()
+	return &ActionConfig{
+		Schema:      schema,
+		Required:    required,
+		Description: p.manifest.Description,
+	}
+}
+
+// parseActionOutput turns the process output into result data, keeping
+// output that is not a JSON object as text
+func parseActionOutput(output []byte) map[string]interface{} {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return map[string]interface{}{}
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(output, &data); err == nil {
+		return data
+	}
+	return map[string]interface{}{"output": truncate(string(output), 64*1024)}
+}
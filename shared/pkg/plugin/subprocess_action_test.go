@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubprocessActionPluginExecutesAction(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(`
+plugin:
+  id: "test-action"
+  version: "1.0.0"
+  type: "action"
+  supports_dry_run: true
+  runtime:
+    type: "bash"
+    entry_point: "run.sh"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte(`
+request=$(cat)
+if [ "$STAVILY_DRY_RUN" = "true" ]; then
+  echo '{"planned_changes":[{"action":"restart","target":"nginx.service"},"flush cache"]}'
+  exit 0
+fi
+case "$request" in
+  *fail*) echo "boom" >&2; exit 2 ;;
+  *slow*) sleep 30 ;;
+esac
+echo "{\"request\":$request}"
+`), 0755))
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	p, err := NewSubprocessActionPlugin(dir, manifest, SubprocessActionOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a0"})
+	assert.Error(t, err, "plugin is not started")
+	require.NoError(t, p.Start(ctx))
+
+	result, err := p.ExecuteAction(ctx, &ActionRequest{ID: "a1", Type: "restart"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusCompleted, result.Status)
+	assert.Equal(t, "restart", result.Data["request"].(map[string]interface{})["type"])
+
+	result, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a2", Type: "fail"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusFailed, result.Status)
+	assert.Equal(t, "boom", result.Error)
+	assert.Equal(t, 2, result.Metadata["exit_code"])
+
+	result, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a3", Type: "slow", Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusTimeout, result.Status)
+	assert.Less(t, result.Duration, 10*time.Second)
+
+	result, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a4", Type: "restart", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusCompleted, result.Status)
+	assert.Equal(t, []PlannedChange{
+		{Action: "restart", Target: "nginx.service"},
+		{Action: "flush cache"},
+	}, result.PlannedChanges)
+
+	health := p.GetHealth()
+	assert.Equal(t, 4, health.Metrics["executions"])
+	assert.Equal(t, 2, health.Metrics["failures"])
+}
+
+func TestSubprocessActionPluginRefusesUnsupportedDryRun(t *testing.T) {
+	dir := t.TempDir()
+	ran := filepath.Join(dir, "ran")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(`
+plugin:
+  id: "test-action"
+  version: "1.0.0"
+  type: "action"
+  runtime:
+    type: "bash"
+    entry_point: "run.sh"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte("touch ran\necho '{}'\n"), 0755))
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	p, err := NewSubprocessActionPlugin(dir, manifest, SubprocessActionOptions{}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, p.Start(ctx))
+
+	result, err := p.ExecuteAction(ctx, &ActionRequest{ID: "a1", Type: "restart", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusFailed, result.Status)
+	assert.Equal(t, "plugin test-action does not support dry runs", result.Error)
+	assert.NoFileExists(t, ran)
+
+	result, err = p.ExecuteAction(ctx, &ActionRequest{ID: "a2", Type: "restart"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionStatusCompleted, result.Status)
+	assert.FileExists(t, ran)
+}
+
+func TestValidateConfig(t *testing.T) {
+	minimum, maxLength := 1.0, 5
+	schema := map[string]*ConfigField{
+		"interval": {Type: "integer", Minimum: &minimum, Required: true},
+		"mode":     {Type: "string", Enum: []string{"fast", "slow"}},
+		"name":     {Type: "string", MaxLength: &maxLength, Pattern: "^[a-z]+$"},
+		"enabled":  {Type: "boolean"},
+	}
+
+	assert.NoError(t, ValidateConfig(schema, nil, map[string]interface{}{
+		"interval": 10, "mode": "fast", "name": "cpu", "enabled": true, "extra": "kept",
+	}))
+
+	err := ValidateConfig(schema, []string{"name"}, map[string]interface{}{
+		"interval": 0.5, "mode": "medium", "enabled": "yes",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "interval: must be an integer")
+	assert.Contains(t, err.Error(), "mode: must be one of fast, slow")
+	assert.Contains(t, err.Error(), "enabled: must be a boolean")
+	assert.Contains(t, err.Error(), "name: is required")
+
+	err = ValidateConfig(schema, nil, map[string]interface{}{"interval": 0, "name": "Toolong"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "interval: must be at least 1")
+	assert.Contains(t, err.Error(), "name: must be at most 5 characters")
+}
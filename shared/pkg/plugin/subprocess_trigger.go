@@ -0,0 +1,571 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SubprocessTriggerOptions controls how a subprocess trigger plugin is supervised
+type SubprocessTriggerOptions struct {
+	// Delay before restarting an exited process, doubled after each
+	// consecutive failure up to MaxRestartDelay
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+	// A process that ran at least this long resets the restart delay
+	StableAfter time.Duration
+	// Time a process is given to exit after SIGTERM before it is killed
+	StopTimeout time.Duration
+	// Consecutive failures after which the plugin reports itself unhealthy
+	UnhealthyAfter int
+	// DisableRestart leaves an exited process stopped with StatusError, for
+	// callers that supervise and restart the plugin themselves
+	DisableRestart bool
+	// Events buffered between the process and the consumer
+	EventBuffer int
+	// Extra environment variables for the process
+	Environment map[string]string
+}
+
+// SubprocessTriggerPlugin runs an installed plugin as a long-lived process and
+// adapts it to the TriggerPlugin interface. The process receives its
+// configuration as JSON in STAVILY_PLUGIN_CONFIG and writes one JSON
+// TriggerEvent per line to stdout; stderr is logged. When the process exits
+// it is restarted with exponential backoff until the plugin is stopped,
+// unless restarts are disabled.
+type SubprocessTriggerPlugin struct {
+	manifest *Manifest
+	dir      string
+	opts     SubprocessTriggerOptions
+	logger   *zap.Logger
+
+	// events stays open across restarts so consumers keep receiving
+	events chan *TriggerEvent
+
+	mu      sync.Mutex
+	config  map[string]interface{}
+	status  Status
+	cancel  context.CancelFunc
+	done    chan struct{}
+	cmd     *exec.Cmd
+	process subprocessState
+	// reconfigure restarts the process without counting it as a failure
+	reconfigure bool
+}
+
+// subprocessState tracks the supervised process
+type subprocessState struct {
+	pid                 int
+	startedAt           time.Time
+	restarts            int
+	consecutiveFailures int
+	lastExitCode        int
+	lastExit            time.Time
+	lastError           string
+	nextRestart         time.Time
+	eventsEmitted       int
+	invalidLines        int
+	lastEvent           time.Time
+}
+
+// NewSubprocessTriggerPlugin creates a trigger plugin for the plugin installed in dir
+func NewSubprocessTriggerPlugin(dir string, manifest *Manifest, opts SubprocessTriggerOptions, logger *zap.Logger) (*SubprocessTriggerPlugin, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("plugin manifest is required")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if manifest.Type != PluginTypeTrigger {
+		return nil, fmt.Errorf("plugin %s is not a trigger plugin", manifest.ID)
+	}
+	if _, _, err := SubprocessCommand(manifest, dir); err != nil {
+		return nil, err
+	}
+
+	if opts.RestartDelay <= 0 {
+		opts.RestartDelay = time.Second
+	}
+	if opts.MaxRestartDelay <= 0 {
+		opts.MaxRestartDelay = time.Minute
+	}
+	if opts.StableAfter <= 0 {
+		opts.StableAfter = time.Minute
+	}
+	if opts.StopTimeout <= 0 {
+		opts.StopTimeout = 10 * time.Second
+	}
+	if opts.UnhealthyAfter <= 0 {
+		opts.UnhealthyAfter = 5
+	}
+	if opts.EventBuffer <= 0 {
+		opts.EventBuffer = 100
+	}
+
+	return &SubprocessTriggerPlugin{
+		manifest: manifest,
+		dir:      dir,
+		opts:     opts,
+		logger:   logger.With(zap.String("plugin_id", manifest.ID)),
+		events:   make(chan *TriggerEvent, opts.EventBuffer),
+		config:   manifest.Defaults(),
+		status:   StatusStopped,
+	}, nil
+}
+
+// GetInfo returns plugin metadata
+func (p *SubprocessTriggerPlugin) GetInfo() *Info {
+	return p.manifest.Info()
+}
+
+// Initialize sets the plugin configuration on top of the manifest defaults.
+// A running process is restarted to pick it up.
+func (p *SubprocessTriggerPlugin) Initialize(ctx context.Context, config map[string]interface{}) error {
+	merged := p.manifest.Defaults()
+	for k, v := range config {
+		merged[k] = v
+	}
+
+	if _, err := json.Marshal(merged); err != nil {
+		return fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	p.mu.Lock()
+	p.config = merged
+	cmd := p.cmd
+	if cmd != nil {
+		p.reconfigure = true
+	}
+	p.mu.Unlock()
+
+	if cmd != nil {
+		p.logger.Info("Restarting trigger plugin process to apply new configuration")
+		_ = terminateProcess(cmd)
+	}
+
+	return nil
+}
+
+// Start starts the plugin process and keeps it running until Stop is called
+// or the context is cancelled
+func (p *SubprocessTriggerPlugin) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		select {
+		case <-p.done:
+			// Stopped by cancellation of the context it was started with
+		default:
+			return fmt.Errorf("plugin %s is already started", p.manifest.ID)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	p.status = StatusStarting
+	p.process.consecutiveFailures = 0
+
+	go p.supervise(runCtx, p.done)
+
+	return nil
+}
+
+// Stop stops the plugin process
+func (p *SubprocessTriggerPlugin) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if p.cancel == nil {
+		p.mu.Unlock()
+		return nil
+	}
+	p.status = StatusStopping
+	p.cancel()
+	done := p.done
+	p.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for plugin %s to stop: %w", p.manifest.ID, ctx.Err())
+	}
+
+	p.mu.Lock()
+	p.cancel = nil
+	p.status = StatusStopped
+	p.mu.Unlock()
+
+	return nil
+}
+
+// GetStatus returns the current plugin status. A plugin whose process has
+// exited, and is waiting to be restarted unless restarts are disabled,
+// reports StatusError.
+func (p *SubprocessTriggerPlugin) GetStatus() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// GetHealth reports the liveness of the plugin process
+func (p *SubprocessTriggerPlugin) GetHealth() *Health {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.process
+	health := &Health{
+		LastCheck:  time.Now(),
+		ErrorCount: state.restarts,
+		LastError:  state.lastError,
+		Metrics: map[string]interface{}{
+			"restarts":             state.restarts,
+			"consecutive_failures": state.consecutiveFailures,
+			"events_emitted":       state.eventsEmitted,
+			"invalid_lines":        state.invalidLines,
+		},
+	}
+	if !state.lastExit.IsZero() {
+		health.Metrics["last_exit_code"] = state.lastExitCode
+		health.Metrics["last_exit"] = state.lastExit
+	}
+	if !state.lastEvent.IsZero() {
+		health.Metrics["last_event"] = state.lastEvent
+	}
+
+	switch p.status {
+	case StatusRunning:
+		health.Status = HealthStatusHealthy
+		health.Message = fmt.Sprintf("process running (pid %d)", state.pid)
+		health.Uptime = time.Since(state.startedAt)
+		health.Metrics["pid"] = state.pid
+	case StatusError:
+		health.Status = HealthStatusDegraded
+		if state.consecutiveFailures >= p.opts.UnhealthyAfter {
+			health.Status = HealthStatusUnhealthy
+		}
+		health.Message = fmt.Sprintf("process exited with code %d", state.lastExitCode)
+		if !p.opts.DisableRestart {
+			health.Message += fmt.Sprintf(", restarting in %s", time.Until(state.nextRestart).Round(time.Second))
+		}
+	case StatusStarting:
+		health.Status = HealthStatusUnknown
+		health.Message = "process starting"
+	default:
+		health.Status = HealthStatusUnknown
+		health.Message = "plugin is not running"
+	}
+
+	return health
+}
+
+// DetectTriggers returns the channel of events emitted by the plugin process
+func (p *SubprocessTriggerPlugin) DetectTriggers(ctx context.Context) (<-chan *TriggerEvent, error) {
+	return p.events, nil
+}
+
+// GetTriggerConfig returns the configuration schema from the manifest
+func (p *SubprocessTriggerPlugin) GetTriggerConfig() *TriggerConfig {
+	schema, required := p.manifest.Schema()
+	return &TriggerConfig{
+		Schema:      schema,
+		Required:    required,
+		Description: p.manifest.Description,
+	}
+}
+
+// supervise runs the process and restarts it with backoff until ctx is
+// cancelled. With restarts disabled it returns once the process exits,
+// leaving the plugin in StatusError.
+func (p *SubprocessTriggerPlugin) supervise(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	defer func() {
+		p.mu.Lock()
+		if ctx.Err() != nil || p.status != StatusError {
+			p.status = StatusStopped
+		}
+		p.process.pid = 0
+		p.mu.Unlock()
+	}()
+
+	delay := p.opts.RestartDelay
+	for {
+		started := time.Now()
+		exitCode, err := p.runProcess(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.mu.Lock()
+		reconfigured := p.reconfigure
+		p.reconfigure = false
+		p.mu.Unlock()
+		if reconfigured {
+			continue
+		}
+
+		if time.Since(started) >= p.opts.StableAfter {
+			delay = p.opts.RestartDelay
+			p.mu.Lock()
+			p.process.consecutiveFailures = 0
+			p.mu.Unlock()
+		}
+
+		p.mu.Lock()
+		p.status = StatusError
+		p.process.pid = 0
+		p.process.restarts++
+		p.process.consecutiveFailures++
+		p.process.lastExitCode = exitCode
+		p.process.lastExit = time.Now()
+		p.process.nextRestart = time.Now().Add(delay)
+		if err != nil {
+			p.process.lastError = err.Error()
+		} else {
+			p.process.lastError = fmt.Sprintf("process exited with code %d", exitCode)
+		}
+		failures := p.process.consecutiveFailures
+		if p.opts.DisableRestart {
+			p.process.nextRestart = time.Time{}
+		}
+		p.mu.Unlock()
+
+		if p.opts.DisableRestart {
+			p.logger.Warn("Trigger plugin process exited",
+				zap.Int("exit_code", exitCode),
+				zap.Error(err))
+			return
+		}
+
+		p.logger.Warn("Trigger plugin process exited, restarting",
+			zap.Int("exit_code", exitCode),
+			zap.Int("consecutive_failures", failures),
+			zap.Duration("restart_in", delay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > p.opts.MaxRestartDelay {
+			delay = p.opts.MaxRestartDelay
+		}
+	}
+}
+
+// runProcess runs the plugin process once and returns its exit code
+func (p *SubprocessTriggerPlugin) runProcess(ctx context.Context) (int, error) {
+	name, args, err := SubprocessCommand(p.manifest, p.dir)
+	if err != nil {
+		return -1, err
+	}
+
+	p.mu.Lock()
+	config, err := json.Marshal(p.config)
+	p.mu.Unlock()
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = p.dir
+	setProcessGroup(cmd)
+	cmd.Env = append(os.Environ(),
+		EnvPluginID+"="+p.manifest.ID,
+		EnvPluginType+"="+string(PluginTypeTrigger),
+		EnvPluginMode+"=stream",
+		EnvPluginConfig+"="+string(config))
+	for k, v := range p.opts.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return -1, fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return -1, fmt.Errorf("failed to open plugin stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("failed to start plugin process: %w", err)
+	}
+
+	p.mu.Lock()
+	p.status = StatusRunning
+	p.cmd = cmd
+	p.process.pid = cmd.Process.Pid
+	p.process.startedAt = time.Now()
+	p.mu.Unlock()
+
+	p.logger.Info("Trigger plugin process started",
+		zap.Int("pid", cmd.Process.Pid),
+		zap.String("command", name))
+
+	// Terminate the process when the plugin is stopped
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = terminateProcess(cmd)
+			select {
+			case <-exited:
+			case <-time.After(p.opts.StopTimeout):
+				p.logger.Warn("Trigger plugin process did not exit, killing it")
+				_ = killProcess(cmd)
+			}
+		case <-exited:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logPluginOutput(stderr, p.logger)
+	}()
+
+	p.readEvents(ctx, stdout)
+	wg.Wait()
+
+	err = cmd.Wait()
+
+	p.mu.Lock()
+	p.cmd = nil
+	p.mu.Unlock()
+
+	return cmd.ProcessState.ExitCode(), err
+}
+
+// maxTriggerEventSize is the longest line a trigger plugin may write as one
+// event; longer lines are skipped
+const maxTriggerEventSize = 1024 * 1024
+
+// readEvents decodes newline-delimited JSON events from the process output
+func (p *SubprocessTriggerPlugin) readEvents(ctx context.Context, stdout io.Reader) {
+	// Keep draining so the process never blocks on a full pipe
+	defer io.Copy(io.Discard, stdout)
+
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	var line []byte
+	for {
+		var tooLong bool
+		var err error
+		line, tooLong, err = readLine(reader, line[:0], maxTriggerEventSize)
+		switch {
+		case tooLong:
+			p.mu.Lock()
+			p.process.invalidLines++
+			p.mu.Unlock()
+			p.logger.Warn("Ignoring oversized trigger event from plugin",
+				zap.Int("max_bytes", maxTriggerEventSize))
+		case len(line) > 0:
+			if !p.emitEvent(ctx, line) {
+				return
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				p.logger.Warn("Failed to read plugin output", zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+// emitEvent decodes one line of output and sends the event, returning false
+// once the context is done
+func (p *SubprocessTriggerPlugin) emitEvent(ctx context.Context, line []byte) bool {
+	var event TriggerEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		p.mu.Lock()
+		p.process.invalidLines++
+		p.mu.Unlock()
+		p.logger.Warn("Ignoring invalid trigger event from plugin",
+			zap.String("line", truncate(string(line), 256)),
+			zap.Error(err))
+		return true
+	}
+
+	normalizeTriggerEvent(&event, p.manifest.ID)
+
+	select {
+	case p.events <- &event:
+	case <-ctx.Done():
+		return false
+	}
+
+	p.mu.Lock()
+	p.process.eventsEmitted++
+	p.process.lastEvent = time.Now()
+	p.mu.Unlock()
+	return true
+}
+
+// readLine appends the next line to buf without its line ending. A line
+// longer than max is read to its end but not kept, and reported as too long.
+func readLine(r *bufio.Reader, buf []byte, max int) ([]byte, bool, error) {
+	line, tooLong := buf, false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if err == nil {
+			chunk = chunk[:len(chunk)-1]
+		}
+		if !tooLong {
+			// Leave room for a carriage return before the newline
+			if len(line)+len(chunk) > max+1 {
+				tooLong, line = true, buf[:0]
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if len(line) > max {
+			tooLong, line = true, buf[:0]
+		}
+		return line, tooLong, err
+	}
+}
+
+// normalizeTriggerEvent fills in fields a plugin process left empty
+func normalizeTriggerEvent(event *TriggerEvent, pluginID string) {
+	now := time.Now()
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("%s-%d", pluginID, now.UnixNano())
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = now
+	}
+	if event.Source == "" {
+		event.Source = pluginID
+	}
+	if event.Severity == "" {
+		event.Severity = SeverityMedium
+	}
+}
+
+// truncate shortens a string for logging
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
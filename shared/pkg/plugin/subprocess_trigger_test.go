@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const testManifest = `
+plugin:
+  id: "test-trigger"
+  name: "Test Trigger"
+  version: "1.0.0"
+  type: "trigger"
+  runtime:
+    type: "bash"
+    entry_point: "run.sh"
+  configuration:
+    threshold:
+      type: "number"
+      default: 80
+      required: true
+`
+
+func installTestPlugin(t *testing.T, script string) (string, *Manifest) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(testManifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte(script), 0755))
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	return dir, manifest
+}
+
+func TestParseManifest(t *testing.T) {
+	_, manifest := installTestPlugin(t, "")
+
+	assert.Equal(t, "test-trigger", manifest.Info().ID)
+	assert.Equal(t, PluginTypeTrigger, manifest.Info().Type)
+	assert.Equal(t, map[string]interface{}{"threshold": 80}, manifest.Defaults())
+
+	schema, required := manifest.Schema()
+	assert.Equal(t, "number", schema["threshold"].Type)
+	assert.Equal(t, []string{"threshold"}, required)
+
+	_, err := ParseManifest([]byte("plugin:\n  id: x\n  version: 1.0.0\n  type: sensor\n"))
+	assert.Error(t, err)
+	_, err = ParseManifest([]byte("name: x\n"))
+	assert.Error(t, err)
+	_, err = ParseManifest([]byte("plugin:\n  id: x\n  version: 1.0.0\n  type: action\n  risk: extreme\n  runtime:\n    entry_point: run.sh\n"))
+	assert.ErrorContains(t, err, "unsupported risk")
+
+	manifest.Risk = RiskHigh
+	assert.Equal(t, RiskHigh, manifest.Info().Metadata["risk"])
+
+	manifest.Runtime.EntryPoint = "../escape.sh"
+	_, _, err = SubprocessCommand(manifest, "/plugins/test-trigger")
+	assert.Error(t, err)
+}
+
+func TestSubprocessTriggerPluginEmitsEvents(t *testing.T) {
+	dir, manifest := installTestPlugin(t, `
+echo "starting" >&2
+echo "{\"type\":\"cpu_high\",\"data\":{\"threshold\":$(echo $STAVILY_PLUGIN_CONFIG | sed 's/.*"threshold":\([0-9]*\).*/\1/')},\"severity\":\"high\"}"
+echo "not json"
+sleep 30
+`)
+
+	p, err := NewSubprocessTriggerPlugin(dir, manifest, SubprocessTriggerOptions{StopTimeout: time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, p.Initialize(context.Background(), map[string]interface{}{"threshold": 95}))
+
+	events, err := p.DetectTriggers(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "cpu_high", event.Type)
+		assert.Equal(t, "test-trigger", event.Source)
+		assert.Equal(t, SeverityHigh, event.Severity)
+		assert.Equal(t, float64(95), event.Data["threshold"])
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.Timestamp.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received from plugin process")
+	}
+
+	assert.Eventually(t, func() bool {
+		return p.GetHealth().Metrics["invalid_lines"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, StatusRunning, p.GetStatus())
+	assert.Equal(t, HealthStatusHealthy, p.GetHealth().Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Stop(ctx))
+	assert.Equal(t, StatusStopped, p.GetStatus())
+}
+
+func TestSubprocessTriggerPluginSkipsOversizedLines(t *testing.T) {
+	dir, manifest := installTestPlugin(t, `
+head -c 2000000 /dev/zero | tr '\0' 'x'
+echo
+echo '{"type":"after_oversized"}'
+sleep 30
+`)
+
+	p, err := NewSubprocessTriggerPlugin(dir, manifest, SubprocessTriggerOptions{StopTimeout: time.Second}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, p.Initialize(context.Background(), map[string]interface{}{"threshold": 95}))
+
+	events, err := p.DetectTriggers(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "after_oversized", event.Type)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received after an oversized line")
+	}
+	assert.Equal(t, 1, p.GetHealth().Metrics["invalid_lines"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Stop(ctx))
+}
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("short\r\n"+strings.Repeat("x", 40)+"\n12345678\r\nlast"), 16)
+
+	var lines []string
+	var oversized int
+	for {
+		line, tooLong, err := readLine(r, nil, 8)
+		if tooLong {
+			oversized++
+		} else {
+			lines = append(lines, string(line))
+		}
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+	}
+	assert.Equal(t, []string{"short", "12345678", "last"}, lines)
+	assert.Equal(t, 1, oversized)
+}
+
+func TestSubprocessTriggerPluginRestartsOnExit(t *testing.T) {
+	dir, manifest := installTestPlugin(t, `
+echo '{"type":"tick"}'
+exit 3
+`)
+
+	p, err := NewSubprocessTriggerPlugin(dir, manifest, SubprocessTriggerOptions{
+		RestartDelay:    10 * time.Millisecond,
+		MaxRestartDelay: 20 * time.Millisecond,
+		UnhealthyAfter:  2,
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	events, _ := p.DetectTriggers(context.Background())
+	require.NoError(t, p.Start(context.Background()))
+
+	// Each restart emits another event on the same channel
+	for i := 0; i < 3; i++ {
+		select {
+		case <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event after %d restarts", i)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		health := p.GetHealth()
+		return health.Status == HealthStatusUnhealthy && health.Metrics["last_exit_code"] == 3
+	}, 5*time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Stop(ctx))
+}
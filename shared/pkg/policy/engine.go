@@ -0,0 +1,166 @@
+// Package policy decides on the agent which instructions from the
+// orchestrator may run, using a declarative local policy file. A compromised
+// orchestrator can then only make the agent do what its policy allows.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+// Request is what a policy decides on, taken from an instruction
+type Request struct {
+	InstructionID   string   `json:"instruction_id"`
+	InstructionType string   `json:"instruction_type"`
+	PluginID        string   `json:"plugin_id"`
+	Source          string   `json:"source,omitempty"`
+	Entrypoint      string   `json:"entrypoint,omitempty"`
+	Arguments       []string `json:"arguments,omitempty"`
+}
+
+// NewRequest takes the attributes a policy decides on from an instruction
+func NewRequest(inst *types.Instruction) *Request {
+	req := &Request{
+		InstructionID:   inst.ID,
+		InstructionType: string(inst.Type),
+		PluginID:        inst.PluginID,
+	}
+
+	for _, source := range []interface{}{
+		inst.PluginConfiguration["plugin_url"],
+		inst.PluginConfiguration["repository_url"],
+		inst.Metadata["repository_url"],
+	} {
+		if s, ok := source.(string); ok && s != "" {
+			req.Source = s
+			break
+		}
+	}
+	if entrypoint, ok := inst.PluginConfiguration["entrypoint"].(string); ok {
+		req.Entrypoint = entrypoint
+	}
+	if args, ok := inst.PluginConfiguration["arguments"].([]interface{}); ok {
+		for _, arg := range args {
+			req.Arguments = append(req.Arguments, fmt.Sprint(arg))
+		}
+	}
+	return req
+}
+
+// Decision is the outcome of evaluating the policy for a request
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// Rule that decided, empty when the default applied
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Engine evaluates the policy file. The file is reloaded when it changes;
+// if a new version is invalid the previous policy stays in effect.
+type Engine struct {
+	file   string
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	policy  *policy
+	modTime time.Time
+}
+
+// NewEngine creates a policy engine and loads the policy file
+func NewEngine(cfg config.PolicyConfig, logger *zap.Logger) (*Engine, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if cfg.File == "" {
+		return nil, fmt.Errorf("policy file is required")
+	}
+
+	e := &Engine{file: cfg.File, logger: logger, now: time.Now}
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload loads the policy file if it changed since the last load and
+// reports whether a new policy was loaded
+func (e *Engine) Reload() (bool, error) {
+	info, err := os.Stat(e.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat policy file: %w", err)
+	}
+
+	e.mu.RLock()
+	unchanged := e.policy != nil && info.ModTime().Equal(e.modTime)
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(e.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	p, err := parse(content)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.policy = p
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+
+	e.logger.Info("Policy loaded",
+		zap.String("file", e.file),
+		zap.Int("rules", len(p.rules)),
+		zap.Bool("allow_by_default", p.allowByDefault))
+	return true, nil
+}
+
+// Evaluate decides whether an instruction may run
+func (e *Engine) Evaluate(inst *types.Instruction) *Decision {
+	if _, err := e.Reload(); err != nil {
+		e.logger.Error("Failed to reload policy, keeping the previous policy", zap.Error(err))
+	}
+	return e.Decide(NewRequest(inst))
+}
+
+// Decide evaluates the loaded policy for a request
+func (e *Engine) Decide(req *Request) *Decision {
+	e.mu.RLock()
+	p := e.policy
+	e.mu.RUnlock()
+
+	now := e.now()
+	for _, rule := range p.rules {
+		if !rule.matches(req, now) {
+			continue
+		}
+		return &Decision{
+			Allowed: rule.Effect == EffectAllow,
+			Rule:    rule.Name,
+			Reason:  fmt.Sprintf("%s by rule %s", verb(rule.Effect == EffectAllow), rule.Name),
+		}
+	}
+
+	return &Decision{
+		Allowed: p.allowByDefault,
+		Reason:  fmt.Sprintf("%s by default, no rule matched", verb(p.allowByDefault)),
+	}
+}
+
+func verb(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+	"github.com/Stavily/01-Agents/shared/pkg/types"
+)
+
+const testPolicy = `
+rules:
+  - name: no-shell
+    effect: deny
+    entrypoints: ["*.sh", "bash*"]
+  - name: trusted-sources
+    effect: allow
+    instruction_types: [plugin_install, plugin_update]
+    sources: https://github.com/stavily/*
+  - name: cleanup-safe-args
+    effect: allow
+    instruction_types: execute
+    plugin_ids: cleanup-?
+    arguments: ['--dry-run', '--older-than=[0-9]+d']
+  - name: restarts-in-business-hours
+    effect: allow
+    plugin_ids: restart-*
+    time_windows:
+      - days: [mon, tue, wed, thu, fri]
+        start: "09:00"
+        end: "17:00"
+        timezone: UTC
+  - name: night-maintenance
+    effect: allow
+    plugin_ids: maintenance
+    time_windows:
+      - days: sat
+        start: "22:00"
+        end: "04:00"
+        timezone: UTC
+`
+
+func writePolicy(t *testing.T, file, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+}
+
+func execute(pluginID, entrypoint string, args ...interface{}) *types.Instruction {
+	return &types.Instruction{
+		ID:       "inst-1",
+		PluginID: pluginID,
+		Type:     types.InstructionTypeExecute,
+		PluginConfiguration: map[string]interface{}{
+			"entrypoint": entrypoint,
+			"arguments":  args,
+		},
+	}
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy(t, file, testPolicy)
+
+	engine, err := NewEngine(config.PolicyConfig{Enabled: true, File: file}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	// Wednesday 10:00 UTC
+	engine.now = func() time.Time { return time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC) }
+
+	install := func(url string) *types.Instruction {
+		return &types.Instruction{
+			ID:                  "inst-1",
+			PluginID:            "disk-check",
+			Type:                types.InstructionTypePluginInstall,
+			PluginConfiguration: map[string]interface{}{"plugin_url": url},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		inst    *types.Instruction
+		allowed bool
+		rule    string
+	}{
+		{"trusted source", install("https://github.com/stavily/disk-check.git"), true, "trusted-sources"},
+		{"untrusted source", install("https://evil.example.com/disk-check.git"), false, ""},
+		{"shell entrypoint", execute("cleanup-1", "run.sh", "--dry-run"), false, "no-shell"},
+		{"allowed arguments", execute("cleanup-1", "main.py", "--dry-run", "--older-than=30d"), true, "cleanup-safe-args"},
+		{"disallowed argument", execute("cleanup-1", "main.py", "--older-than=30d; rm -rf /"), false, ""},
+		{"glob matches one character", execute("cleanup-10", "main.py"), false, ""},
+		{"inside time window", execute("restart-web", "main.py"), true, "restarts-in-business-hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.inst)
+			assert.Equal(t, tt.allowed, decision.Allowed, decision.Reason)
+			assert.Equal(t, tt.rule, decision.Rule)
+		})
+	}
+
+	decision := engine.Evaluate(install("https://evil.example.com/disk-check.git"))
+	assert.Equal(t, "denied by default, no rule matched", decision.Reason)
+
+	// Outside business hours
+	engine.now = func() time.Time { return time.Date(2024, 5, 15, 18, 0, 0, 0, time.UTC) }
+	assert.False(t, engine.Evaluate(execute("restart-web", "main.py")).Allowed)
+
+	// A window spanning midnight belongs to the day it started in
+	for at, allowed := range map[time.Time]bool{
+		time.Date(2024, 5, 18, 23, 0, 0, 0, time.UTC): true,  // Saturday night
+		time.Date(2024, 5, 19, 3, 0, 0, 0, time.UTC):  true,  // early Sunday
+		time.Date(2024, 5, 19, 23, 0, 0, 0, time.UTC): false, // Sunday night
+		time.Date(2024, 5, 18, 3, 0, 0, 0, time.UTC):  false, // early Saturday
+	} {
+		engine.now = func() time.Time { return at }
+		assert.Equal(t, allowed, engine.Evaluate(execute("maintenance", "main.py")).Allowed, at.String())
+	}
+}
+
+func TestEngineReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy(t, file, "default: allow\n")
+
+	engine, err := NewEngine(config.PolicyConfig{Enabled: true, File: file}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.True(t, engine.Evaluate(execute("cleanup", "main.py")).Allowed)
+
+	reloaded, err := engine.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// An invalid policy keeps the previous one in effect
+	writePolicy(t, file, "default: allow\nrules:\n  - name: broken\n    effect: maybe\n")
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)))
+	_, err = engine.Reload()
+	assert.ErrorContains(t, err, "effect must be allow or deny")
+	assert.True(t, engine.Evaluate(execute("cleanup", "main.py")).Allowed)
+
+	writePolicy(t, file, "rules:\n  - name: cleanup\n    effect: allow\n    plugin_ids: cleanup\n")
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(2*time.Minute)))
+	assert.True(t, engine.Evaluate(execute("cleanup", "main.py")).Allowed)
+	assert.False(t, engine.Evaluate(execute("restart", "main.py")).Allowed)
+}
+
+func TestParseErrors(t *testing.T) {
+	rule := "rules:\n  - name: a\n    effect: allow\n"
+	window := rule + "    time_windows:\n      - start: '09:00'\n        end: '17:00'\n"
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"default: maybe\n", "invalid default effect"},
+		{"rules:\n  - effect: allow\n", "rule 1 has no name"},
+		{rule + "  - name: a\n    effect: deny\n", "duplicate rule name"},
+		{rule + "    plugin_id: cleanup\n", "field plugin_id not found"},
+		{rule + "    arguments: '['\n", "invalid argument pattern"},
+		{rule + "    time_windows:\n      - start: '9'\n        end: '17:00'\n", "invalid window start"},
+		{window + "        days: caturday\n", "unknown day"},
+		{window + "        timezone: Mars/Olympus\n", "invalid window timezone"},
+	}
+	for _, tt := range tests {
+		_, err := parse([]byte(tt.content))
+		assert.ErrorContains(t, err, tt.err, tt.content)
+	}
+}
@@ -0,0 +1,300 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effects a rule or the policy default can have
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// File is the layout of a policy file
+type File struct {
+	// Effect when no rule matches, deny when empty
+	Default string `yaml:"default"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Rule allows or denies the instructions it matches. Rules are evaluated in
+// file order and the first matching rule decides. A rule matches when every
+// condition it sets holds; a condition on something an instruction does not
+// have, such as the source of an execute instruction, does not hold.
+type Rule struct {
+	Name   string `yaml:"name"`
+	Effect string `yaml:"effect"`
+	// Instruction types, such as execute or plugin_install
+	InstructionTypes StringList `yaml:"instruction_types"`
+	// Globs matched against the plugin ID, where * matches any text
+	PluginIDs StringList `yaml:"plugin_ids"`
+	// Globs matched against the plugin_url or repository_url to install from
+	Sources StringList `yaml:"sources"`
+	// Globs matched against the entrypoint to execute
+	Entrypoints StringList `yaml:"entrypoints"`
+	// Regular expressions every argument must fully match one of
+	Arguments StringList `yaml:"arguments"`
+	// Times of day the rule applies in; any of them
+	TimeWindows []TimeWindow `yaml:"time_windows"`
+}
+
+// TimeWindow is a daily time range, such as business hours. An end before
+// the start spans midnight.
+type TimeWindow struct {
+	// Days the window applies on, such as mon or sat; every day when empty
+	Days StringList `yaml:"days"`
+	// Start and end as HH:MM
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+	// IANA time zone, the agent's local time zone when empty
+	Timezone string `yaml:"timezone"`
+}
+
+// StringList accepts either a single string or a list of strings
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+// policy is a validated policy file
+type policy struct {
+	allowByDefault bool
+	rules          []*compiledRule
+}
+
+// compiledRule is a validated rule with its patterns compiled
+type compiledRule struct {
+	Rule
+	pluginIDs   []*regexp.Regexp
+	sources     []*regexp.Regexp
+	entrypoints []*regexp.Regexp
+	arguments   []*regexp.Regexp
+	windows     []*window
+}
+
+// window is a validated time window
+type window struct {
+	days       map[time.Weekday]bool
+	start, end int // minutes after midnight
+	location   *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parse parses and validates a policy file. Unknown fields are rejected, so
+// a misspelt condition cannot silently widen a rule.
+func parse(content []byte) (*policy, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	p := &policy{}
+	switch file.Default {
+	case "", EffectDeny:
+	case EffectAllow:
+		p.allowByDefault = true
+	default:
+		return nil, fmt.Errorf("invalid default effect %q", file.Default)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	for i, rule := range file.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		c, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", rule.Name, err)
+		}
+		p.rules = append(p.rules, c)
+	}
+
+	return p, nil
+}
+
+// compile validates a rule and compiles its patterns
+func compile(rule Rule) (*compiledRule, error) {
+	if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+		return nil, fmt.Errorf("effect must be %s or %s", EffectAllow, EffectDeny)
+	}
+
+	c := &compiledRule{Rule: rule}
+	var err error
+	if c.pluginIDs, err = compileGlobs(rule.PluginIDs); err != nil {
+		return nil, err
+	}
+	if c.sources, err = compileGlobs(rule.Sources); err != nil {
+		return nil, err
+	}
+	if c.entrypoints, err = compileGlobs(rule.Entrypoints); err != nil {
+		return nil, err
+	}
+	for _, pattern := range rule.Arguments {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument pattern %q: %w", pattern, err)
+		}
+		c.arguments = append(c.arguments, re)
+	}
+	for _, tw := range rule.TimeWindows {
+		w, err := compileWindow(tw)
+		if err != nil {
+			return nil, err
+		}
+		c.windows = append(c.windows, w)
+	}
+
+	return c, nil
+}
+
+// compileGlobs turns globs, where * matches any text and ? one character,
+// into regular expressions
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		if glob == "" {
+			return nil, fmt.Errorf("empty pattern")
+		}
+		pattern := regexp.QuoteMeta(glob)
+		pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+		pattern = strings.ReplaceAll(pattern, `\?`, ".")
+		compiled = append(compiled, regexp.MustCompile("^"+pattern+"$"))
+	}
+	return compiled, nil
+}
+
+// compileWindow validates a time window
+func compileWindow(tw TimeWindow) (*window, error) {
+	w := &window{location: time.Local}
+	var err error
+	if w.start, err = parseClock(tw.Start); err != nil {
+		return nil, fmt.Errorf("invalid window start: %w", err)
+	}
+	if w.end, err = parseClock(tw.End); err != nil {
+		return nil, fmt.Errorf("invalid window end: %w", err)
+	}
+	if tw.Timezone != "" {
+		if w.location, err = time.LoadLocation(tw.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid window timezone: %w", err)
+		}
+	}
+	if len(tw.Days) > 0 {
+		w.days = make(map[time.Weekday]bool, len(tw.Days))
+		for _, day := range tw.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q", day)
+			}
+			w.days[weekday] = true
+		}
+	}
+	return w, nil
+}
+
+// parseClock parses HH:MM into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether the window includes a point in time. The day of
+// a window spanning midnight is the day it started.
+func (w *window) contains(now time.Time) bool {
+	now = now.In(w.location)
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+
+	switch {
+	case w.start <= w.end:
+		if minute < w.start || minute >= w.end {
+			return false
+		}
+	case minute >= w.start:
+	case minute < w.end:
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return w.days == nil || w.days[day]
+}
+
+// matches reports whether the rule selects the request
+func (r *compiledRule) matches(req *Request, now time.Time) bool {
+	if len(r.InstructionTypes) > 0 && !containsString(r.InstructionTypes, req.InstructionType) {
+		return false
+	}
+	if len(r.pluginIDs) > 0 && !matchAny(r.pluginIDs, req.PluginID) {
+		return false
+	}
+	if len(r.sources) > 0 && (req.Source == "" || !matchAny(r.sources, req.Source)) {
+		return false
+	}
+	if len(r.entrypoints) > 0 && (req.Entrypoint == "" || !matchAny(r.entrypoints, req.Entrypoint)) {
+		return false
+	}
+	for _, argument := range req.Arguments {
+		if len(r.arguments) > 0 && !matchAny(r.arguments, argument) {
+			return false
+		}
+	}
+
+	if len(r.windows) > 0 {
+		for _, w := range r.windows {
+			if w.contains(now) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+// Package tracing sets up OpenTelemetry tracing for the agents. Spans are
+// exported with OTLP/HTTP or written as JSON to a file or stdout, and trace
+// context is propagated in the W3C traceparent format, in HTTP headers and
+// in the environment of plugin processes.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+// TraceparentHeader is the HTTP header carrying the trace context
+const TraceparentHeader = "traceparent"
+
+// TraceparentEnv is the environment variable carrying the trace context to
+// plugin processes
+const TraceparentEnv = "TRACEPARENT"
+
+// DefaultOTLPEndpoint is the OTLP/HTTP traces endpoint of a local collector
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// Exporters a tracer can send spans to
+const (
+	ExporterOTLP   = "otlp"
+	ExporterFile   = "file"
+	ExporterStdout = "stdout"
+)
+
+// instrumentationName names the tracer the agents create spans with
+const instrumentationName = "github.com/Stavily/01-Agents"
+
+// propagator reads and writes W3C trace context
+var propagator = propagation.TraceContext{}
+
+// Tracer creates spans and exports the sampled ones
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer creates a tracer exporting to the configured exporter. The
+// resource attributes describe the agent, such as its service.name. New
+// traces are sampled at the configured ratio; traces continued from another
+// process follow its sampling decision.
+func NewTracer(cfg config.TracingConfig, attrs []attribute.KeyValue, logger *zap.Logger) (*Tracer, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	logger.Info("Tracing enabled",
+		zap.String("exporter", cfg.Exporter),
+		zap.Float64("sample_ratio", cfg.SampleRatio))
+
+	return &Tracer{provider: provider, tracer: provider.Tracer(instrumentationName)}, nil
+}
+
+// newExporter creates the configured span exporter
+func newExporter(cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP, "":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = DefaultOTLPEndpoint
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("trace file is required")
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create trace directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &closingExporter{SpanExporter: exporter, closer: file}, nil
+
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", cfg.Exporter)
+	}
+}
+
+// closingExporter closes the file spans are written to once the exporter is
+// shut down
+type closingExporter struct {
+	sdktrace.SpanExporter
+	closer io.Closer
+}
+
+func (e *closingExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.closer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Start starts a span, a child of the span in ctx or of a remote parent
+// extracted into ctx, or the root of a new trace. A nil tracer returns ctx
+// unchanged and a span that does nothing.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noopSpan()
+	}
+	return t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Shutdown exports queued spans and stops the exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// Start starts a child of the span in ctx. Without a recording span in ctx
+// it returns ctx unchanged and a span that does nothing, so only work done
+// on behalf of a traced operation is traced.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, noopSpan()
+	}
+	return parent.TracerProvider().Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// noopSpan returns a span that records nothing
+func noopSpan() trace.Span {
+	return trace.SpanFromContext(context.Background())
+}
+
+// RecordError marks a span as failed with the error. A nil error is ignored.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Extract returns a context continuing the trace of another process from a
+// W3C traceparent value. Invalid values leave ctx unchanged.
+func Extract(ctx context.Context, traceparent string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier{TraceparentHeader: traceparent})
+}
+
+// Traceparent formats the span context in ctx as a W3C traceparent value, or
+// returns an empty string when there is none
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get(TraceparentHeader)
+}
+
+// Inject sets the trace context headers for the span in ctx
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Environ returns the environment variables passing the span in ctx to a
+// child process, TRACEPARENT and TRACESTATE, or nil when there is no span
+func Environ(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+
+	env := make([]string, 0, len(carrier))
+	for key, value := range carrier {
+		env = append(env, strings.ToUpper(key)+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
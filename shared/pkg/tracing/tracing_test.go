@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Stavily/01-Agents/shared/pkg/config"
+)
+
+const remoteTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// exportedSpan is the part of a span written by the file exporter the tests
+// look at
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		SpanID string
+	}
+	Status struct {
+		Code string
+	}
+}
+
+// readSpans reads the spans written to a trace file
+func readSpans(t *testing.T, path string) []exportedSpan {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var spans []exportedSpan
+	decoder := json.NewDecoder(file)
+	for {
+		var span exportedSpan
+		if err := decoder.Decode(&span); err == io.EOF {
+			return spans
+		} else {
+			require.NoError(t, err)
+		}
+		spans = append(spans, span)
+	}
+}
+
+func newFileTracer(t *testing.T, ratio float64) (*Tracer, string) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.json")
+	tracer, err := NewTracer(config.TracingConfig{
+		Enabled:     true,
+		Exporter:    ExporterFile,
+		File:        path,
+		SampleRatio: ratio,
+	}, []attribute.KeyValue{attribute.String("service.name", "test")}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return tracer, path
+}
+
+func TestTracerSpans(t *testing.T) {
+	tracer, path := newFileTracer(t, 1)
+
+	ctx, root := tracer.Start(context.Background(), "root", attribute.String("key", "value"))
+	_, child := Start(ctx, "child")
+	RecordError(child, errors.New("boom"))
+	RecordError(child, nil)
+	child.End()
+	root.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 2)
+	byName := map[string]exportedSpan{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	assert.Equal(t, root.SpanContext().TraceID().String(), byName["child"].SpanContext.TraceID)
+	assert.Equal(t, root.SpanContext().SpanID().String(), byName["child"].Parent.SpanID)
+	assert.Equal(t, "0000000000000000", byName["root"].Parent.SpanID)
+	assert.Equal(t, "Error", byName["child"].Status.Code)
+	assert.Equal(t, "Unset", byName["root"].Status.Code)
+}
+
+func TestTracerContinuesRemoteTrace(t *testing.T) {
+	tracer, path := newFileTracer(t, 1)
+
+	ctx := Extract(context.Background(), remoteTraceparent)
+	_, span := tracer.Start(ctx, "instruction")
+	span.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID)
+}
+
+func TestTracerSampling(t *testing.T) {
+	tracer, path := newFileTracer(t, 0)
+
+	_, unsampled := tracer.Start(context.Background(), "unsampled")
+	assert.False(t, unsampled.IsRecording())
+	unsampled.End()
+
+	// A sampled remote parent decides for the trace
+	_, continued := tracer.Start(Extract(context.Background(), remoteTraceparent), "continued")
+	assert.True(t, continued.IsRecording())
+	continued.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 1)
+	assert.Equal(t, "continued", spans[0].Name)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "nothing")
+	assert.False(t, span.IsRecording())
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+	span.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	// Without a span to continue there is nothing to trace
+	_, span = Start(context.Background(), "orphan")
+	assert.False(t, span.IsRecording())
+	assert.Nil(t, Environ(context.Background()))
+	assert.Empty(t, Traceparent(context.Background()))
+}
+
+func TestPropagation(t *testing.T) {
+	ctx := Extract(context.Background(), remoteTraceparent)
+	assert.True(t, trace.SpanContextFromContext(ctx).IsRemote())
+	assert.Equal(t, remoteTraceparent, Traceparent(ctx))
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Equal(t, remoteTraceparent, header.Get(TraceparentHeader))
+	assert.Equal(t, []string{TraceparentEnv + "=" + remoteTraceparent}, Environ(ctx))
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		ctx := Extract(context.Background(), value)
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid(), value)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		names   []string
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var request coltracepb.ExportTraceServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &request))
+		mu.Lock()
+		defer mu.Unlock()
+		headers = r.Header.Clone()
+		for _, rs := range request.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					names = append(names, span.Name)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	tracer, err := NewTracer(config.TracingConfig{
+		Enabled:     true,
+		Exporter:    ExporterOTLP,
+		Endpoint:    server.URL + "/v1/traces",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		SampleRatio: 1,
+		Timeout:     5 * time.Second,
+	}, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "exported")
+	span.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"exported"}, names)
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+}
+
+func TestNewTracerRejectsInvalidConfig(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	for name, cfg := range map[string]config.TracingConfig{
+		"unknown exporter": {Exporter: "zipkin", SampleRatio: 1},
+		"missing file":     {Exporter: ExporterFile, SampleRatio: 1},
+		"bad endpoint":     {Exporter: ExporterOTLP, Endpoint: "localhost:4318", SampleRatio: 1},
+		"bad ratio":        {Exporter: ExporterOTLP, SampleRatio: 2},
+	} {
+		_, err := NewTracer(cfg, nil, logger)
+		assert.Error(t, err, name)
+	}
+}
@@ -65,6 +65,7 @@ type Instruction struct {
 	InputData           map[string]interface{} `json:"input_data"`
 	Context             map[string]interface{} `json:"context"`
 	Variables           map[string]interface{} `json:"variables"`
+	DryRun              bool                   `json:"dry_run,omitempty"`
 	TimeoutSeconds      int                    `json:"timeout_seconds"`
 	MaxRetries          int                    `json:"max_retries"`
 	RetryCount          int                    `json:"retry_count"`
@@ -84,28 +85,56 @@ type PollResponse struct {
 	NextPollInterval int          `json:"next_poll_interval"`
 }
 
-// InstallationResult represents the result of a plugin installation
+// InstallationResult represents the result of a plugin installation. A dry
+// run touches no files and lists what it would have done in PlannedChanges.
 type InstallationResult struct {
-	PluginID      string    `json:"plugin_id"`
-	Success       bool      `json:"success"`
-	Error         string    `json:"error,omitempty"`
-	InstalledPath string    `json:"installed_path"`
-	Version       string    `json:"version"`
-	Logs          []string  `json:"logs"`
-	Duration      float64   `json:"duration_seconds"`
-	Timestamp     time.Time `json:"timestamp"`
+	PluginID       string          `json:"plugin_id"`
+	Success        bool            `json:"success"`
+	Error          string          `json:"error,omitempty"`
+	InstalledPath  string          `json:"installed_path"`
+	Digest         string          `json:"digest,omitempty"`
+	Version        string          `json:"version"`
+	Logs           []string        `json:"logs"`
+	Duration       float64         `json:"duration_seconds"`
+	Timestamp      time.Time       `json:"timestamp"`
+	DryRun         bool            `json:"dry_run,omitempty"`
+	PlannedChanges []PlannedChange `json:"planned_changes,omitempty"`
 }
 
-// ExecutionResult represents the result of a plugin execution
+// PlannedChange describes a change a plugin would make, as reported by a
+// dry run
+type PlannedChange struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// String returns the change as a short sentence such as
+// "restart nginx.service (currently active)"
+func (c PlannedChange) String() string {
+	s := c.Action
+	if c.Target != "" {
+		s += " " + c.Target
+	}
+	if c.Detail != "" {
+		s += " (" + c.Detail + ")"
+	}
+	return s
+}
+
+// ExecutionResult represents the result of a plugin execution. A dry run
+// changes nothing and lists what it would have done in PlannedChanges.
 type ExecutionResult struct {
-	PluginID   string                 `json:"plugin_id"`
-	Success    bool                   `json:"success"`
-	Error      string                 `json:"error,omitempty"`
-	OutputData map[string]interface{} `json:"output_data"`
-	Logs       []string               `json:"logs"`
-	Duration   float64                `json:"duration_seconds"`
-	Timestamp  time.Time              `json:"timestamp"`
-	ExitCode   int                    `json:"exit_code"`
+	PluginID       string                 `json:"plugin_id"`
+	Success        bool                   `json:"success"`
+	Error          string                 `json:"error,omitempty"`
+	OutputData     map[string]interface{} `json:"output_data"`
+	Logs           []string               `json:"logs"`
+	Duration       float64                `json:"duration_seconds"`
+	Timestamp      time.Time              `json:"timestamp"`
+	ExitCode       int                    `json:"exit_code"`
+	DryRun         bool                   `json:"dry_run,omitempty"`
+	PlannedChanges []PlannedChange        `json:"planned_changes,omitempty"`
 }
 
 // InstructionResult represents the result of processing an instruction